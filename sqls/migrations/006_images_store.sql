@@ -0,0 +1,15 @@
+-- +migrate Up
+
+-- Images are no longer kept in the database. Instead they are stored on disk
+-- in a content-addressed directory and only their hashes are kept here. The
+-- blob columns are emptied by the library once their contents are exported.
+alter table `albums_artworks` add column `artwork_cover_hash` text default null;
+alter table `albums_artworks` add column `artwork_cover_small_hash` text default null;
+alter table `artists_images` add column `image_hash` text default null;
+alter table `artists_images` add column `image_small_hash` text default null;
+
+-- +migrate Down
+alter table `albums_artworks` drop column `artwork_cover_hash`;
+alter table `albums_artworks` drop column `artwork_cover_small_hash`;
+alter table `artists_images` drop column `image_hash`;
+alter table `artists_images` drop column `image_small_hash`;
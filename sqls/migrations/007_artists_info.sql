@@ -0,0 +1,23 @@
+-- +migrate Up
+
+-- MusicBrainz IDs of artists and albums. They are stored whenever they are
+-- found while searching for images or artist information.
+alter table `artists` add column `mbid` text default null;
+alter table `albums` add column `mbid` text default null;
+
+-- Cached information about artists. The info column is JSON encoded. When it is
+-- NULL then no information was found at updated_at.
+create table `artists_info` (
+    `id` integer not null primary key,
+    `artist_id` integer unique,
+    `info` text default null,
+    `updated_at` integer
+);
+
+create index artists_info_artist_ids on `artists_info` (`artist_id`);
+
+-- +migrate Down
+
+drop table `artists_info`;
+alter table `artists` drop column `mbid`;
+alter table `albums` drop column `mbid`;
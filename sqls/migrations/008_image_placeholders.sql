@@ -0,0 +1,15 @@
+-- +migrate Up
+
+-- Placeholders which clients show while the artwork is loading. The dominant
+-- colour is in the "#rrggbb" format. Both are empty strings when they could not
+-- be computed for the stored image.
+alter table `albums_artworks` add column `dominant_color` text default null;
+alter table `albums_artworks` add column `blurhash` text default null;
+alter table `artists_images` add column `dominant_color` text default null;
+alter table `artists_images` add column `blurhash` text default null;
+
+-- +migrate Down
+alter table `albums_artworks` drop column `dominant_color`;
+alter table `albums_artworks` drop column `blurhash`;
+alter table `artists_images` drop column `dominant_color`;
+alter table `artists_images` drop column `blurhash`;
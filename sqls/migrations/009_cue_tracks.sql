@@ -0,0 +1,19 @@
+-- +migrate Up
+
+-- Tracks described by CUE sheets are parts of a single audio file. They share
+-- their `fs_path` and are distinguished by where they start in it. Offsets are
+-- in milliseconds. An end of NULL means the track lasts until the end of the file.
+alter table `tracks` add column `cue_sheet` text default null;
+alter table `tracks` add column `cue_start` integer not null default 0;
+alter table `tracks` add column `cue_end` integer default null;
+
+drop index if exists unique_tracks;
+create unique index if not exists unique_tracks on `tracks` (`fs_path`, `cue_start`);
+
+-- +migrate Down
+delete from `tracks` where `cue_start` != 0;
+drop index if exists unique_tracks;
+create unique index if not exists unique_tracks on `tracks` ('fs_path');
+alter table `tracks` drop column `cue_sheet`;
+alter table `tracks` drop column `cue_start`;
+alter table `tracks` drop column `cue_end`;
@@ -0,0 +1,19 @@
+-- +migrate Up
+
+-- Lyrics of tracks. They come from the tags of the media files, from .lrc files
+-- next to them or from an online source. The source column is one of "tags",
+-- "lrc" and "online". When lyrics is NULL then nothing was found online at
+-- updated_at.
+create table `tracks_lyrics` (
+    `id` integer not null primary key,
+    `track_id` integer unique,
+    `lyrics` text default null,
+    `source` text not null,
+    `updated_at` integer
+);
+
+create index tracks_lyrics_track_ids on `tracks_lyrics` (`track_id`);
+
+-- +migrate Down
+
+drop table `tracks_lyrics`;
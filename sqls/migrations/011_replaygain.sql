@@ -0,0 +1,18 @@
+-- +migrate Up
+
+-- ReplayGain information for tracks. Gains are in dB and peaks are linear where
+-- 1.0 is full scale. The gain_source column is "tags" when the information was
+-- read from the media file and "analysis" when its loudness was measured. It is
+-- "failed" when the analysis was not possible and NULL when it was not tried.
+alter table `tracks` add column `track_gain` real default null;
+alter table `tracks` add column `track_peak` real default null;
+alter table `tracks` add column `album_gain` real default null;
+alter table `tracks` add column `album_peak` real default null;
+alter table `tracks` add column `gain_source` text default null;
+
+-- +migrate Down
+alter table `tracks` drop column `track_gain`;
+alter table `tracks` drop column `track_peak`;
+alter table `tracks` drop column `album_gain`;
+alter table `tracks` drop column `album_peak`;
+alter table `tracks` drop column `gain_source`;
@@ -0,0 +1,46 @@
+-- +migrate Up
+
+-- The year and genre of tracks as found in their tags.
+alter table `tracks` add column `year` integer default null;
+alter table `tracks` add column `genre` text default null;
+
+-- Metadata which is used instead of the tags of a track. Tracks are identified
+-- by their file and start in it so that the overrides survive removing and adding
+-- the file again. NULL columns are not overridden.
+create table `tracks_overrides` (
+    `id` integer not null primary key,
+    `fs_path` text not null,
+    `cue_start` integer not null default 0,
+    `artist` text default null,
+    `album` text default null,
+    `title` text default null,
+    `number` integer default null,
+    `year` integer default null,
+    `genre` text default null,
+    `updated_at` integer
+);
+
+create unique index if not exists unique_tracks_overrides on `tracks_overrides`
+    (`fs_path`, `cue_start`);
+
+-- Metadata which is used instead of the tags for all tracks of an album. Albums
+-- are identified by their directory and their name in the tags.
+create table `albums_overrides` (
+    `id` integer not null primary key,
+    `fs_path` text not null,
+    `name` text not null,
+    `artist` text default null,
+    `album` text default null,
+    `year` integer default null,
+    `genre` text default null,
+    `updated_at` integer
+);
+
+create unique index if not exists unique_albums_overrides on `albums_overrides`
+    (`fs_path`, `name`);
+
+-- +migrate Down
+drop table `tracks_overrides`;
+drop table `albums_overrides`;
+alter table `tracks` drop column `year`;
+alter table `tracks` drop column `genre`;
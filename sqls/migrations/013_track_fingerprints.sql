@@ -0,0 +1,11 @@
+-- +migrate Up
+
+-- A fingerprint of the audio in the file of every track. It is used for finding
+-- tracks which were moved or renamed so that they could keep their IDs.
+alter table `tracks` add column `fingerprint` text default null;
+
+create index if not exists tracks_fingerprints on `tracks` (`fingerprint`);
+
+-- +migrate Down
+drop index if exists tracks_fingerprints;
+alter table `tracks` drop column `fingerprint`;
@@ -0,0 +1,8 @@
+-- +migrate Up
+
+-- The disc of a multi-disc album on which a track is. NULL for tracks of albums
+-- with a single disc or when it is not known.
+alter table `tracks` add column `disc` integer default null;
+
+-- +migrate Down
+alter table `tracks` drop column `disc`;
@@ -0,0 +1,13 @@
+-- +migrate Up
+
+-- Albums which are in the library more than once, for example as FLAC and as MP3
+-- files in different directories, are editions of the same album. For all but
+-- one of them edition_of is the ID of the main edition. It is NULL for the main
+-- edition and for albums without other editions.
+alter table `albums` add column `edition_of` integer default null;
+
+create index if not exists albums_editions on `albums` (`edition_of`);
+
+-- +migrate Down
+drop index if exists albums_editions;
+alter table `albums` drop column `edition_of`;
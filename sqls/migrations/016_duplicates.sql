@@ -0,0 +1,20 @@
+-- +migrate Up
+
+-- The bit rate of tracks in kbps as reported by taglib.
+alter table `tracks` add column `bitrate` integer default null;
+
+-- Acoustic fingerprints of tracks calculated with fpcalc from Chromaprint. They
+-- are used for finding duplicate tracks. The audio_fingerprint is the value of
+-- tracks.fingerprint at the time of the calculation so that the acoustic one is
+-- calculated again when the audio changes. When chromaprint is NULL then it could
+-- not be calculated.
+create table `tracks_chromaprints` (
+    `track_id` integer not null primary key,
+    `chromaprint` text default null,
+    `audio_fingerprint` text default null
+);
+
+-- +migrate Down
+
+drop table `tracks_chromaprints`;
+alter table `tracks` drop column `bitrate`;
@@ -0,0 +1,16 @@
+-- +migrate Up
+
+-- The directories of the library. Directories from the configuration have
+-- from_config set and are added again on every start. The rest are added with the
+-- API. Disabled directories are not scanned and their tracks are left as they are.
+create table `libraries` (
+    `id` integer not null primary key,
+    `path` text not null unique,
+    `name` text not null,
+    `disabled` integer not null default 0,
+    `from_config` integer not null default 0
+);
+
+-- +migrate Down
+
+drop table `libraries`;
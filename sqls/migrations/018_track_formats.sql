@@ -0,0 +1,9 @@
+-- +migrate Up
+
+-- The format of the media file of a track as found out from its contents, e.g.
+-- "flac" or "alac". NULL for tracks which were added before it was stored. Their
+-- format is guessed by the extension of their file.
+alter table `tracks` add column `format` text default null;
+
+-- +migrate Down
+alter table `tracks` drop column `format`;
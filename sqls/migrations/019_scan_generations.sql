@@ -0,0 +1,15 @@
+-- +migrate Up
+
+-- Every scan of a library directory is a new generation. scan_generation of
+-- libraries is the last started one and completed_generation is the last one
+-- which walked the whole directory without errors. Tracks store the generation
+-- of the last scan which saw their files. Tracks with older generations are the
+-- ones whose files were not found by the last completed scan.
+alter table `libraries` add column `scan_generation` integer not null default 0;
+alter table `libraries` add column `completed_generation` integer default null;
+alter table `tracks` add column `scan_generation` integer default null;
+
+-- +migrate Down
+alter table `tracks` drop column `scan_generation`;
+alter table `libraries` drop column `completed_generation`;
+alter table `libraries` drop column `scan_generation`;
@@ -0,0 +1,24 @@
+-- +migrate Up
+
+-- The directories with tracks in them at some depth together with the library
+-- directories. They are identified by random opaque IDs so that their paths are
+-- never revealed. parent is the path of the parent directory.
+create table `folders` (
+    `id` text not null primary key,
+    `path` text not null unique,
+    `parent` text not null,
+    `name` text not null
+);
+
+create index if not exists folders_parents on `folders` (`parent`);
+
+-- The folder of the file of every track. NULL for tracks which were added before
+-- folders were stored. They get their folders with the next scan.
+alter table `tracks` add column `folder_id` text default null;
+
+create index if not exists tracks_folders on `tracks` (`folder_id`);
+
+-- +migrate Down
+drop index if exists tracks_folders;
+alter table `tracks` drop column `folder_id`;
+drop table `folders`;
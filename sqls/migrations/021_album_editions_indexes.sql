@@ -0,0 +1,10 @@
+-- +migrate Up
+
+-- The editions of changed albums are found by their names and the tracks of
+-- these albums by their album IDs.
+create index if not exists albums_edition_names on `albums` (lower(trim(`name`)));
+create index if not exists tracks_albums on `tracks` (`album_id`);
+
+-- +migrate Down
+drop index if exists tracks_albums;
+drop index if exists albums_edition_names;
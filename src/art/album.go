@@ -16,15 +16,23 @@ const (
 	musicBrainzReleaseQueryValue = "release:%s AND artist:%s"
 )
 
-// GetFrontImage returns the front image for particular `album` from `artist`.
+// GetFrontImage returns the front image for particular `album` from `artist`
+// together with the MusicBrainz release ID for which it was found. When
+// `releaseID` is known, for example from the tags of the media files, only it is
+// tried and there is no search in MusicBrainz.
 func (c *Client) GetFrontImage(
 	ctx context.Context,
 	artist,
-	album string,
-) ([]byte, error) {
-	mbIDs, err := c.getMusicBrainzReleaseID(ctx, artist, album)
-	if err != nil {
-		return nil, err
+	album,
+	releaseID string,
+) ([]byte, string, error) {
+	mbIDs := []string{releaseID}
+	if releaseID == "" {
+		var err error
+		mbIDs, err = c.getMusicBrainzReleaseID(ctx, artist, album)
+		if err != nil {
+			return nil, "", err
+		}
 	}
 
 	for _, mbidStr := range mbIDs {
@@ -37,17 +45,17 @@ func (c *Client) GetFrontImage(
 				album,
 				mbidStr,
 			)
-			return img.Data, nil
+			return img.Data, mbidStr, nil
 		}
 
 		httpErr, ok := err.(cca.HTTPError)
 		if ok && httpErr.StatusCode == http.StatusNotFound {
 			continue
 		}
-		return img.Data, err
+		return img.Data, "", err
 	}
 
-	return nil, ErrImageNotFound
+	return nil, "", ErrImageNotFound
 }
 
 // getMusicBrainzReleaseID uses the MusicBrainz API to retrieve a list of matching
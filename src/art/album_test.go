@@ -107,7 +107,7 @@ func TestClientGetFrontImage(t *testing.T) {
 	c.SetDiscogsAPIURL(mbrainz.URL)
 
 	ctx := context.Background()
-	img, err := c.GetFrontImage(ctx, artistName, releaseName)
+	img, releaseID, err := c.GetFrontImage(ctx, artistName, releaseName, "")
 
 	for _, se := range serverErrors {
 		t.Error(se)
@@ -125,6 +125,10 @@ func TestClientGetFrontImage(t *testing.T) {
 		)
 	}
 
+	if releaseID != "6518fd52-58bf-44a3-8150-00e7c3ffcae5" {
+		t.Errorf("wrong release ID returned: %s", releaseID)
+	}
+
 	if caaClient.GetReleaseFrontCallCount() != 2 {
 		t.Errorf(
 			"expected 2 calls to the CoverArt image server but got %d",
@@ -133,6 +137,68 @@ func TestClientGetFrontImage(t *testing.T) {
 	}
 }
 
+// TestClientGetFrontImageKnownRelease checks that when the release ID is known
+// there is no search in MusicBrainz and the image is taken directly from the
+// Cover Art Archive.
+func TestClientGetFrontImageKnownRelease(t *testing.T) {
+	const releaseID = "6518fd52-58bf-44a3-8150-00e7c3ffcae5"
+
+	var mbrainzCalled bool
+	mbrainz := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			mbrainzCalled = true
+			w.WriteHeader(http.StatusNotFound)
+		},
+	))
+	defer mbrainz.Close()
+
+	c := art.NewClient("euterpe/testing", 0, "")
+	c.SetMusicBrainzAPIURL(mbrainz.URL)
+	c.SetDiscogsAPIURL(mbrainz.URL)
+
+	caaClient := &artfakes.FakeCAAClient{
+		GetReleaseFrontStub: func(mbid uuid.UUID, size int) (caa.CoverArtImage, error) {
+			if !uuid.Equal(mbid, caa.StringToUUID(releaseID)) {
+				return caa.CoverArtImage{}, caa.HTTPError{
+					StatusCode: http.StatusNotFound,
+					URL:        &url.URL{},
+				}
+			}
+
+			return caa.CoverArtImage{
+				Data:     []byte("image contents"),
+				Mimetype: "text/plain",
+			}, nil
+		},
+	}
+	c.SetCAAClient(caaClient)
+
+	ctx := context.Background()
+	img, foundID, err := c.GetFrontImage(ctx, "Iron Maiden", "Killers", releaseID)
+	if err != nil {
+		t.Fatalf("expected no error but got `%s`", err)
+	}
+
+	if mbrainzCalled {
+		t.Error("MusicBrainz was searched even though the release ID was known")
+	}
+
+	if string(img) != "image contents" {
+		t.Errorf("unexpected image returned: `%s`", img)
+	}
+
+	if foundID != releaseID {
+		t.Errorf("wrong release ID returned: %s", foundID)
+	}
+
+	if caaClient.GetReleaseFrontCallCount() != 1 {
+		t.Errorf(
+			"expected 1 call to the CoverArt image server but got %d",
+			caaClient.GetReleaseFrontCallCount(),
+		)
+	}
+}
+
 func parseMBQuery(s string) (string, string) {
 	parts := strings.Split(s, "AND")
 
@@ -22,12 +22,19 @@ var ErrImageTooBig = errors.New("image is too big")
 // Finder defines a type which is capable of finding art for artists or albums.
 type Finder interface {
 	// GetFrontImage returns the front album artwork for particular album
-	// by an artist.
-	GetFrontImage(ctx context.Context, artist, album string) ([]byte, error)
+	// by an artist. The second returned value is the MusicBrainz ID of the
+	// release for which the artwork was found. When releaseID is not empty
+	// it is used directly instead of searching for the album.
+	GetFrontImage(
+		ctx context.Context,
+		artist, album, releaseID string,
+	) ([]byte, string, error)
 
 	// GetArtistImage returns an image which represents a particular artist.
-	// Hopefully a good one! ;D
-	GetArtistImage(ctx context.Context, artist string) ([]byte, error)
+	// Hopefully a good one! ;D The second returned value is the MusicBrainz
+	// ID of the artist for which the image was found. When artistID is not
+	// empty it is used directly instead of searching for the artist.
+	GetArtistImage(ctx context.Context, artist, artistID string) ([]byte, string, error)
 }
 
 // Client is a client for recovering artwork. It supports getting images from
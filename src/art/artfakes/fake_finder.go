@@ -9,57 +9,64 @@ import (
 )
 
 type FakeFinder struct {
-	GetArtistImageStub        func(context.Context, string) ([]byte, error)
+	GetArtistImageStub        func(context.Context, string, string) ([]byte, string, error)
 	getArtistImageMutex       sync.RWMutex
 	getArtistImageArgsForCall []struct {
 		arg1 context.Context
 		arg2 string
+		arg3 string
 	}
 	getArtistImageReturns struct {
 		result1 []byte
-		result2 error
+		result2 string
+		result3 error
 	}
 	getArtistImageReturnsOnCall map[int]struct {
 		result1 []byte
-		result2 error
+		result2 string
+		result3 error
 	}
-	GetFrontImageStub        func(context.Context, string, string) ([]byte, error)
+	GetFrontImageStub        func(context.Context, string, string, string) ([]byte, string, error)
 	getFrontImageMutex       sync.RWMutex
 	getFrontImageArgsForCall []struct {
 		arg1 context.Context
 		arg2 string
 		arg3 string
+		arg4 string
 	}
 	getFrontImageReturns struct {
 		result1 []byte
-		result2 error
+		result2 string
+		result3 error
 	}
 	getFrontImageReturnsOnCall map[int]struct {
 		result1 []byte
-		result2 error
+		result2 string
+		result3 error
 	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeFinder) GetArtistImage(arg1 context.Context, arg2 string) ([]byte, error) {
+func (fake *FakeFinder) GetArtistImage(arg1 context.Context, arg2 string, arg3 string) ([]byte, string, error) {
 	fake.getArtistImageMutex.Lock()
 	ret, specificReturn := fake.getArtistImageReturnsOnCall[len(fake.getArtistImageArgsForCall)]
 	fake.getArtistImageArgsForCall = append(fake.getArtistImageArgsForCall, struct {
 		arg1 context.Context
 		arg2 string
-	}{arg1, arg2})
+		arg3 string
+	}{arg1, arg2, arg3})
 	stub := fake.GetArtistImageStub
 	fakeReturns := fake.getArtistImageReturns
-	fake.recordInvocation("GetArtistImage", []interface{}{arg1, arg2})
+	fake.recordInvocation("GetArtistImage", []interface{}{arg1, arg2, arg3})
 	fake.getArtistImageMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
-		return ret.result1, ret.result2
+		return ret.result1, ret.result2, ret.result3
 	}
-	return fakeReturns.result1, fakeReturns.result2
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
 }
 
 func (fake *FakeFinder) GetArtistImageCallCount() int {
@@ -68,64 +75,68 @@ func (fake *FakeFinder) GetArtistImageCallCount() int {
 	return len(fake.getArtistImageArgsForCall)
 }
 
-func (fake *FakeFinder) GetArtistImageCalls(stub func(context.Context, string) ([]byte, error)) {
+func (fake *FakeFinder) GetArtistImageCalls(stub func(context.Context, string, string) ([]byte, string, error)) {
 	fake.getArtistImageMutex.Lock()
 	defer fake.getArtistImageMutex.Unlock()
 	fake.GetArtistImageStub = stub
 }
 
-func (fake *FakeFinder) GetArtistImageArgsForCall(i int) (context.Context, string) {
+func (fake *FakeFinder) GetArtistImageArgsForCall(i int) (context.Context, string, string) {
 	fake.getArtistImageMutex.RLock()
 	defer fake.getArtistImageMutex.RUnlock()
 	argsForCall := fake.getArtistImageArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
-func (fake *FakeFinder) GetArtistImageReturns(result1 []byte, result2 error) {
+func (fake *FakeFinder) GetArtistImageReturns(result1 []byte, result2 string, result3 error) {
 	fake.getArtistImageMutex.Lock()
 	defer fake.getArtistImageMutex.Unlock()
 	fake.GetArtistImageStub = nil
 	fake.getArtistImageReturns = struct {
 		result1 []byte
-		result2 error
-	}{result1, result2}
+		result2 string
+		result3 error
+	}{result1, result2, result3}
 }
 
-func (fake *FakeFinder) GetArtistImageReturnsOnCall(i int, result1 []byte, result2 error) {
+func (fake *FakeFinder) GetArtistImageReturnsOnCall(i int, result1 []byte, result2 string, result3 error) {
 	fake.getArtistImageMutex.Lock()
 	defer fake.getArtistImageMutex.Unlock()
 	fake.GetArtistImageStub = nil
 	if fake.getArtistImageReturnsOnCall == nil {
 		fake.getArtistImageReturnsOnCall = make(map[int]struct {
 			result1 []byte
-			result2 error
+			result2 string
+			result3 error
 		})
 	}
 	fake.getArtistImageReturnsOnCall[i] = struct {
 		result1 []byte
-		result2 error
-	}{result1, result2}
+		result2 string
+		result3 error
+	}{result1, result2, result3}
 }
 
-func (fake *FakeFinder) GetFrontImage(arg1 context.Context, arg2 string, arg3 string) ([]byte, error) {
+func (fake *FakeFinder) GetFrontImage(arg1 context.Context, arg2 string, arg3 string, arg4 string) ([]byte, string, error) {
 	fake.getFrontImageMutex.Lock()
 	ret, specificReturn := fake.getFrontImageReturnsOnCall[len(fake.getFrontImageArgsForCall)]
 	fake.getFrontImageArgsForCall = append(fake.getFrontImageArgsForCall, struct {
 		arg1 context.Context
 		arg2 string
 		arg3 string
-	}{arg1, arg2, arg3})
+		arg4 string
+	}{arg1, arg2, arg3, arg4})
 	stub := fake.GetFrontImageStub
 	fakeReturns := fake.getFrontImageReturns
-	fake.recordInvocation("GetFrontImage", []interface{}{arg1, arg2, arg3})
+	fake.recordInvocation("GetFrontImage", []interface{}{arg1, arg2, arg3, arg4})
 	fake.getFrontImageMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2, arg3)
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
-		return ret.result1, ret.result2
+		return ret.result1, ret.result2, ret.result3
 	}
-	return fakeReturns.result1, fakeReturns.result2
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
 }
 
 func (fake *FakeFinder) GetFrontImageCallCount() int {
@@ -134,43 +145,46 @@ func (fake *FakeFinder) GetFrontImageCallCount() int {
 	return len(fake.getFrontImageArgsForCall)
 }
 
-func (fake *FakeFinder) GetFrontImageCalls(stub func(context.Context, string, string) ([]byte, error)) {
+func (fake *FakeFinder) GetFrontImageCalls(stub func(context.Context, string, string, string) ([]byte, string, error)) {
 	fake.getFrontImageMutex.Lock()
 	defer fake.getFrontImageMutex.Unlock()
 	fake.GetFrontImageStub = stub
 }
 
-func (fake *FakeFinder) GetFrontImageArgsForCall(i int) (context.Context, string, string) {
+func (fake *FakeFinder) GetFrontImageArgsForCall(i int) (context.Context, string, string, string) {
 	fake.getFrontImageMutex.RLock()
 	defer fake.getFrontImageMutex.RUnlock()
 	argsForCall := fake.getFrontImageArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
-func (fake *FakeFinder) GetFrontImageReturns(result1 []byte, result2 error) {
+func (fake *FakeFinder) GetFrontImageReturns(result1 []byte, result2 string, result3 error) {
 	fake.getFrontImageMutex.Lock()
 	defer fake.getFrontImageMutex.Unlock()
 	fake.GetFrontImageStub = nil
 	fake.getFrontImageReturns = struct {
 		result1 []byte
-		result2 error
-	}{result1, result2}
+		result2 string
+		result3 error
+	}{result1, result2, result3}
 }
 
-func (fake *FakeFinder) GetFrontImageReturnsOnCall(i int, result1 []byte, result2 error) {
+func (fake *FakeFinder) GetFrontImageReturnsOnCall(i int, result1 []byte, result2 string, result3 error) {
 	fake.getFrontImageMutex.Lock()
 	defer fake.getFrontImageMutex.Unlock()
 	fake.GetFrontImageStub = nil
 	if fake.getFrontImageReturnsOnCall == nil {
 		fake.getFrontImageReturnsOnCall = make(map[int]struct {
 			result1 []byte
-			result2 error
+			result2 string
+			result3 error
 		})
 	}
 	fake.getFrontImageReturnsOnCall[i] = struct {
 		result1 []byte
-		result2 error
-	}{result1, result2}
+		result2 string
+		result3 error
+	}{result1, result2, result3}
 }
 
 func (fake *FakeFinder) Invocations() map[string][][]interface{} {
@@ -0,0 +1,121 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package artfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ironsmile/euterpe/src/art"
+)
+
+type FakeInfoFinder struct {
+	GetArtistInfoStub        func(context.Context, string, string) (art.ArtistInfo, error)
+	getArtistInfoMutex       sync.RWMutex
+	getArtistInfoArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	getArtistInfoReturns struct {
+		result1 art.ArtistInfo
+		result2 error
+	}
+	getArtistInfoReturnsOnCall map[int]struct {
+		result1 art.ArtistInfo
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeInfoFinder) GetArtistInfo(arg1 context.Context, arg2 string, arg3 string) (art.ArtistInfo, error) {
+	fake.getArtistInfoMutex.Lock()
+	ret, specificReturn := fake.getArtistInfoReturnsOnCall[len(fake.getArtistInfoArgsForCall)]
+	fake.getArtistInfoArgsForCall = append(fake.getArtistInfoArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.GetArtistInfoStub
+	fakeReturns := fake.getArtistInfoReturns
+	fake.recordInvocation("GetArtistInfo", []interface{}{arg1, arg2, arg3})
+	fake.getArtistInfoMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeInfoFinder) GetArtistInfoCallCount() int {
+	fake.getArtistInfoMutex.RLock()
+	defer fake.getArtistInfoMutex.RUnlock()
+	return len(fake.getArtistInfoArgsForCall)
+}
+
+func (fake *FakeInfoFinder) GetArtistInfoCalls(stub func(context.Context, string, string) (art.ArtistInfo, error)) {
+	fake.getArtistInfoMutex.Lock()
+	defer fake.getArtistInfoMutex.Unlock()
+	fake.GetArtistInfoStub = stub
+}
+
+func (fake *FakeInfoFinder) GetArtistInfoArgsForCall(i int) (context.Context, string, string) {
+	fake.getArtistInfoMutex.RLock()
+	defer fake.getArtistInfoMutex.RUnlock()
+	argsForCall := fake.getArtistInfoArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeInfoFinder) GetArtistInfoReturns(result1 art.ArtistInfo, result2 error) {
+	fake.getArtistInfoMutex.Lock()
+	defer fake.getArtistInfoMutex.Unlock()
+	fake.GetArtistInfoStub = nil
+	fake.getArtistInfoReturns = struct {
+		result1 art.ArtistInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeInfoFinder) GetArtistInfoReturnsOnCall(i int, result1 art.ArtistInfo, result2 error) {
+	fake.getArtistInfoMutex.Lock()
+	defer fake.getArtistInfoMutex.Unlock()
+	fake.GetArtistInfoStub = nil
+	if fake.getArtistInfoReturnsOnCall == nil {
+		fake.getArtistInfoReturnsOnCall = make(map[int]struct {
+			result1 art.ArtistInfo
+			result2 error
+		})
+	}
+	fake.getArtistInfoReturnsOnCall[i] = struct {
+		result1 art.ArtistInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeInfoFinder) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getArtistInfoMutex.RLock()
+	defer fake.getArtistInfoMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeInfoFinder) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ art.InfoFinder = new(FakeInfoFinder)
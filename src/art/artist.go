@@ -16,7 +16,7 @@ import (
 
 const (
 	musicBrainzArtistSearchEndpint = "%s/ws/2/artist/"
-	musicBrainzArtistRelsEndpint   = "%s/ws/2/artist/%s?inc=url-rels"
+	musicBrainzArtistEndpint       = "%s/ws/2/artist/%s?inc=%s"
 	musicBrainzArtistQueryValue    = "artist:%s"
 
 	discogsArtistEndpoint = "%s/artists/%s"
@@ -31,35 +31,44 @@ var ErrNoDiscogsAuth = fmt.Errorf("authentication with Discogs is not configured
 // in its Music Brainz information or when this relation was not parsed as expected.
 var errNoDiscogsRel = fmt.Errorf("no Discogs relation found in Music Brainz info")
 
-// GetArtistImage finds and returns an image of particular artist. If none is found
-// it returns ErrImageNotFound.
+// GetArtistImage finds and returns an image of particular artist together with
+// the MusicBrainz ID of the artist for which it was found. If none is found it
+// returns ErrImageNotFound. When `artistID` is known, for example from the tags
+// of the media files, there is no search for the artist in MusicBrainz.
 func (c *Client) GetArtistImage(
 	ctx context.Context,
-	artist string,
-) ([]byte, error) {
+	artist,
+	artistID string,
+) ([]byte, string, error) {
 	if c.discogsAuthToken == "" {
-		return nil, ErrNoDiscogsAuth
+		return nil, "", ErrNoDiscogsAuth
 	}
 
-	mbIDs, err := c.getMusicBrainzArtistID(ctx, artist)
-	if err != nil {
-		return nil, err
+	mbIDs := []string{artistID}
+	if artistID == "" {
+		var err error
+		mbIDs, err = c.getMusicBrainzArtistID(ctx, artist)
+		if err != nil {
+			return nil, "", err
+		}
 	}
 
 	const maxTries = 2
 	var (
 		discogID string
+		foundID  string
 		tries    int
 	)
 
 	for _, mbID := range mbIDs {
 		if tries >= maxTries {
-			return nil, ErrImageNotFound
+			return nil, "", ErrImageNotFound
 		}
 
 		dID, err := c.getDiscogsArtistID(ctx, mbID)
 		if err == nil {
 			discogID = dID
+			foundID = mbID
 			break
 		}
 		tries++
@@ -68,14 +77,19 @@ func (c *Client) GetArtistImage(
 			continue
 		}
 
-		return nil, err
+		return nil, "", err
 	}
 
 	if discogID == "" {
-		return nil, ErrImageNotFound
+		return nil, "", ErrImageNotFound
+	}
+
+	img, err := c.getDiscogsArtistImage(ctx, discogID)
+	if err != nil {
+		return nil, "", err
 	}
 
-	return c.getDiscogsArtistImage(ctx, discogID)
+	return img, foundID, nil
 }
 
 // getMusicBrainzArtistID uses the MusicBrainz API to retrieve a list of matching
@@ -146,12 +160,14 @@ func (c *Client) getMusicBrainzArtistID(
 	return artistIDs, nil
 }
 
-// getDiscogsArtistID parses the URL relations for particular MusicBrainz ID and searches
-// for the Discogs ID among them. Then returns it if found.
-func (c *Client) getDiscogsArtistID(
+// getMusicBrainzArtist returns the MusicBrainz information for the artist with
+// particular MusicBrainz ID. The `inc` argument is used for the "inc" query
+// parameter which controls which relations are included in the response.
+func (c *Client) getMusicBrainzArtist(
 	ctx context.Context,
 	artistMBid string,
-) (string, error) {
+	inc string,
+) (mbArtist, error) {
 	c.Lock()
 	defer c.Unlock()
 
@@ -159,13 +175,14 @@ func (c *Client) getDiscogsArtistID(
 	defer c.delayer.Reset(c.delay)
 
 	endpointURL := fmt.Sprintf(
-		musicBrainzArtistRelsEndpint,
+		musicBrainzArtistEndpint,
 		c.musicBrainzAPIHost,
 		url.PathEscape(artistMBid),
+		url.QueryEscape(inc),
 	)
 	req, err := http.NewRequest(http.MethodGet, endpointURL, nil)
 	if err != nil {
-		return "", fmt.Errorf("error creating MusicBrainz XML API req: %w", err)
+		return mbArtist{}, fmt.Errorf("error creating MusicBrainz XML API req: %w", err)
 	}
 	req.Header.Set("User-Agent", c.useragent)
 
@@ -175,12 +192,12 @@ func (c *Client) getDiscogsArtistID(
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", err
+		return mbArtist{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf(
+		return mbArtist{}, fmt.Errorf(
 			"artist XML API (MusicBrainz) returned HTTP %d",
 			resp.StatusCode,
 		)
@@ -190,10 +207,33 @@ func (c *Client) getDiscogsArtistID(
 	dec := xml.NewDecoder(resp.Body)
 
 	if err := dec.Decode(&root); err != nil {
-		return "", fmt.Errorf("decoding MusicBrainz artist XML API response: %w", err)
+		return mbArtist{}, fmt.Errorf(
+			"decoding MusicBrainz artist XML API response: %w",
+			err,
+		)
 	}
 
-	for _, artistXML := range root.Artist.RelationsList.Relations {
+	return root.Artist, nil
+}
+
+// getDiscogsArtistID parses the URL relations for particular MusicBrainz ID and searches
+// for the Discogs ID among them. Then returns it if found.
+func (c *Client) getDiscogsArtistID(
+	ctx context.Context,
+	artistMBid string,
+) (string, error) {
+	artist, err := c.getMusicBrainzArtist(ctx, artistMBid, "url-rels")
+	if err != nil {
+		return "", err
+	}
+
+	return discogsIDFromRelations(artist)
+}
+
+// discogsIDFromRelations searches the URL relations of a MusicBrainz artist for
+// the one which points to Discogs and returns the Discogs ID from it.
+func discogsIDFromRelations(artist mbArtist) (string, error) {
+	for _, artistXML := range artist.relations("url") {
 		if artistXML.Type != "discogs" {
 			continue
 		}
@@ -221,6 +261,37 @@ func (c *Client) getDiscogsArtistImage(
 	ctx context.Context,
 	discogID string,
 ) ([]byte, error) {
+	dca, err := c.getDiscogsArtist(ctx, discogID)
+	if err != nil {
+		return nil, err
+	}
+
+	// First search for the primary image and use it if found.
+	for _, image := range dca.Images {
+		if image.URI == "" {
+			continue
+		}
+
+		imgBytes, err := c.downloadDiscogsImage(ctx, image.URI)
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, err
+		} else if err != nil {
+			log.Printf("error downloading Discogs image: %s\n", err)
+			continue
+		}
+
+		return imgBytes, nil
+	}
+
+	return nil, ErrImageNotFound
+}
+
+// getDiscogsArtist returns the Discogs information about the artist with
+// Discogs ID `discogID`.
+func (c *Client) getDiscogsArtist(
+	ctx context.Context,
+	discogID string,
+) (dcArtist, error) {
 	// The Discogs API requests are not guarded behind the Client delayer since all
 	// of them are naturally throttled by the MusicBrainz API delays. Why? Because
 	// the Discogs API calls can only happen as a result from a MusicBrainz API call
@@ -233,7 +304,7 @@ func (c *Client) getDiscogsArtistImage(
 	)
 	req, err := http.NewRequest(http.MethodGet, endpointURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("error creating Discogs API req: %w", err)
+		return dcArtist{}, fmt.Errorf("error creating Discogs API req: %w", err)
 	}
 	req.Header.Set("User-Agent", c.useragent)
 	req.Header.Set("Authorization", fmt.Sprintf("Discogs token=%s", c.discogsAuthToken))
@@ -244,12 +315,12 @@ func (c *Client) getDiscogsArtistImage(
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request to Discogs API failed: %w", err)
+		return dcArtist{}, fmt.Errorf("request to Discogs API failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(
+		return dcArtist{}, fmt.Errorf(
 			"artist XML API (Discogs) returned HTTP %d",
 			resp.StatusCode,
 		)
@@ -258,27 +329,10 @@ func (c *Client) getDiscogsArtistImage(
 	var dca dcArtist
 	dec := json.NewDecoder(resp.Body)
 	if err := dec.Decode(&dca); err != nil {
-		return nil, fmt.Errorf("unrecognised JSON returned by Discogs: %w", err)
-	}
-
-	// First search for the primary image and use it if found.
-	for _, image := range dca.Images {
-		if image.URI == "" {
-			continue
-		}
-
-		imgBytes, err := c.downloadDiscogsImage(ctx, image.URI)
-		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
-			return nil, err
-		} else if err != nil {
-			log.Printf("error downloading Discogs image: %s\n", err)
-			continue
-		}
-
-		return imgBytes, nil
+		return dcArtist{}, fmt.Errorf("unrecognised JSON returned by Discogs: %w", err)
 	}
 
-	return nil, ErrImageNotFound
+	return dca, nil
 }
 
 func (c *Client) downloadDiscogsImage(
@@ -328,10 +382,30 @@ type mbArtistList struct {
 }
 
 type mbArtist struct {
-	ID            string                `xml:"id,attr"`
-	Score         int                   `xml:"score,attr"`
-	Name          string                `xml:"name"`
-	RelationsList mbArtistRelationsList `xml:"relation-list"`
+	ID             string                  `xml:"id,attr"`
+	Type           string                  `xml:"type,attr"`
+	Score          int                     `xml:"score,attr"`
+	Name           string                  `xml:"name"`
+	Country        string                  `xml:"country"`
+	LifeSpan       mbLifeSpan              `xml:"life-span"`
+	RelationsLists []mbArtistRelationsList `xml:"relation-list"`
+}
+
+// relations returns all relations of the artist to entities of `targetType`.
+func (a mbArtist) relations(targetType string) []mbArtistRelation {
+	var rels []mbArtistRelation
+	for _, list := range a.RelationsLists {
+		if list.TargetType == targetType {
+			rels = append(rels, list.Relations...)
+		}
+	}
+	return rels
+}
+
+type mbLifeSpan struct {
+	Begin string `xml:"begin"`
+	End   string `xml:"end"`
+	Ended bool   `xml:"ended"`
 }
 
 /*
@@ -355,20 +429,30 @@ type mbArtistData struct {
 }
 
 type mbArtistRelationsList struct {
-	Relations []mbArtistRelation `xml:"relation"`
+	TargetType string             `xml:"target-type,attr"`
+	Relations  []mbArtistRelation `xml:"relation"`
 }
 
 type mbArtistRelation struct {
-	Type   string `xml:"type,attr"`
-	Target string `xml:"target"`
+	Type      string       `xml:"type,attr"`
+	Target    string       `xml:"target"`
+	Direction string       `xml:"direction"`
+	Artist    *mbRelArtist `xml:"artist"`
+}
+
+// mbRelArtist is the artist at the other end of an artist to artist relation.
+type mbRelArtist struct {
+	ID   string `xml:"id,attr"`
+	Name string `xml:"name"`
 }
 
 // dcArtist is a type which matches the Discogs JSON representation of an
 // artist. It defines only the strictly required fields by the art Finder.
 type dcArtist struct {
-	ID     int64          `json:"id"`
-	Name   string         `json:"name"`
-	Images []dcArtstImage `json:"images"`
+	ID      int64          `json:"id"`
+	Name    string         `json:"name"`
+	Profile string         `json:"profile"`
+	Images  []dcArtstImage `json:"images"`
 }
 
 type dcArtstImage struct {
@@ -215,7 +215,7 @@ func TestClientGetArtistImage(t *testing.T) {
 	c.SetMusicBrainzAPIURL(mbrainz.URL)
 	c.SetDiscogsAPIURL(discogs.URL)
 
-	foundImage, err := c.GetArtistImage(context.Background(), artistName)
+	foundImage, artistID, err := c.GetArtistImage(context.Background(), artistName, "")
 
 	for _, serverError := range serverErrors {
 		t.Errorf("test server error: %s", serverError)
@@ -240,13 +240,16 @@ func TestClientGetArtistImage(t *testing.T) {
 		t.Errorf("expected image response to be `%s` but got `%s`",
 			imageBytes, foundImage)
 	}
+	if artistID != "ca891d65-d9b0-4258-89f7-e6ba29d83767" {
+		t.Errorf("wrong artist ID returned: %s", artistID)
+	}
 }
 
 // TestClientNoDiscogsAuth makes sure the appropriate error is returned when
 // the Discogs client hasn't been configured.
 func TestClientNoDiscogsAuth(t *testing.T) {
 	c := art.NewClient("euterpe/testing", 0, "")
-	buff, err := c.GetArtistImage(context.Background(), "Iron Maiden")
+	buff, _, err := c.GetArtistImage(context.Background(), "Iron Maiden", "")
 
 	if !errors.Is(err, art.ErrNoDiscogsAuth) {
 		t.Errorf("Wrong error returned. Expected ErrNoDiscogsAuth, got %v", err)
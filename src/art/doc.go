@@ -6,7 +6,9 @@ It finds album artwork by first querying the MusicBrainz web service for a relea
 the artist name and album name. Then using this ID it queries the Cover Art Archive
 for the corresponding album front art.
 
-Artist images are found using the MusicBrainz database and Discogs.
+Artist images are found using the MusicBrainz database and Discogs. The same
+databases are used for finding information about artists such as their biographies,
+links and related artists.
 
 The following APIs are used to achieve this packages' objective:
 
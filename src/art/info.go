@@ -0,0 +1,172 @@
+package art
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// ErrInfoNotFound is returned by the InfoFinder when no information for particular
+// artist could be found.
+var ErrInfoNotFound = errors.New("info not found")
+
+//counterfeiter:generate . InfoFinder
+
+// InfoFinder defines a type which is capable of finding information about artists
+// such as their biographies and links.
+type InfoFinder interface {
+	// GetArtistInfo returns information for particular artist. When `mbID` is
+	// not empty it is used as the MusicBrainz ID of the artist and no search
+	// by name is done.
+	GetArtistInfo(ctx context.Context, artist, mbID string) (ArtistInfo, error)
+}
+
+// ArtistInfo is the information which the InfoFinder has managed to find about
+// an artist.
+type ArtistInfo struct {
+	// MBID is the MusicBrainz ID of the artist.
+	MBID string
+
+	// Name is the name of the artist as it is in the MusicBrainz database.
+	Name string
+
+	// Type is the MusicBrainz artist type. Such as "Person" or "Group".
+	Type string
+
+	// Country is the ISO 3166-1 code of the country the artist is from.
+	Country string
+
+	// BeginDate and EndDate are the dates the artist was active in. For
+	// persons these are the dates of birth and death. They may be partial
+	// dates such as "1975" or "1975-12".
+	BeginDate string
+	EndDate   string
+
+	// Ended is true when the artist is no longer active.
+	Ended bool
+
+	// Biography is the artist's profile in Discogs.
+	Biography string
+
+	// Links are URLs of web pages about the artist.
+	Links []ArtistLink
+
+	// Related are artists which are related to this one. Such as band members
+	// or bands in which this artist is a member.
+	Related []RelatedArtist
+}
+
+// ArtistLink is a link to a web page about an artist.
+type ArtistLink struct {
+	// Type is the kind of this web page. For example "official homepage" or
+	// "wikidata".
+	Type string
+	URL  string
+}
+
+// RelatedArtist is an artist which is related to another one.
+type RelatedArtist struct {
+	MBID string
+	Name string
+
+	// Relation is the MusicBrainz relation type. Such as "member of band".
+	Relation string
+}
+
+// GetArtistInfo implements the InfoFinder interface. It uses the MusicBrainz API
+// for all of the information but the biography which comes from Discogs. The
+// biography is found only when the Client has Discogs authentication token.
+func (c *Client) GetArtistInfo(
+	ctx context.Context,
+	artist string,
+	mbID string,
+) (ArtistInfo, error) {
+	if mbID == "" {
+		mbIDs, err := c.getMusicBrainzArtistID(ctx, artist)
+		if errors.Is(err, ErrImageNotFound) {
+			return ArtistInfo{}, ErrInfoNotFound
+		} else if err != nil {
+			return ArtistInfo{}, err
+		}
+
+		// The results are ordered by their score. So the first one is the
+		// best match.
+		mbID = mbIDs[0]
+	}
+
+	mbArtist, err := c.getMusicBrainzArtist(ctx, mbID, "url-rels+artist-rels")
+	if err != nil {
+		return ArtistInfo{}, err
+	}
+
+	info := ArtistInfo{
+		MBID:      mbID,
+		Name:      mbArtist.Name,
+		Type:      mbArtist.Type,
+		Country:   mbArtist.Country,
+		BeginDate: mbArtist.LifeSpan.Begin,
+		EndDate:   mbArtist.LifeSpan.End,
+		Ended:     mbArtist.LifeSpan.Ended,
+	}
+
+	for _, rel := range mbArtist.relations("url") {
+		info.Links = append(info.Links, ArtistLink{
+			Type: rel.Type,
+			URL:  rel.Target,
+		})
+	}
+
+	for _, rel := range mbArtist.relations("artist") {
+		if rel.Artist == nil {
+			continue
+		}
+
+		info.Related = append(info.Related, RelatedArtist{
+			MBID:     rel.Artist.ID,
+			Name:     rel.Artist.Name,
+			Relation: rel.Type,
+		})
+	}
+
+	if c.discogsAuthToken == "" {
+		return info, nil
+	}
+
+	discogsID, err := discogsIDFromRelations(mbArtist)
+	if errors.Is(err, errNoDiscogsRel) {
+		return info, nil
+	} else if err != nil {
+		return ArtistInfo{}, err
+	}
+
+	dca, err := c.getDiscogsArtist(ctx, discogsID)
+	if err != nil {
+		return ArtistInfo{}, err
+	}
+
+	info.Biography = cleanDiscogsMarkup(dca.Profile)
+
+	return info, nil
+}
+
+var (
+	// discogsLinkMarkup matches the Discogs markup for links to artists, labels
+	// and masters which use names. Such as [a=Iron Maiden].
+	discogsLinkMarkup = regexp.MustCompile(`\[[almr]=([^\]]+)\]`)
+
+	// discogsIDMarkup matches the Discogs markup for links which use only IDs.
+	// Such as [a251595]. The names are not known so they are removed.
+	discogsIDMarkup = regexp.MustCompile(`\[[almr]\d+\]`)
+
+	// discogsFormatMarkup matches formatting markup such as [b] and [/i].
+	discogsFormatMarkup = regexp.MustCompile(`\[/?[biu]\]`)
+)
+
+// cleanDiscogsMarkup converts a text with Discogs markup into plain text.
+func cleanDiscogsMarkup(text string) string {
+	text = discogsLinkMarkup.ReplaceAllString(text, "$1")
+	text = discogsIDMarkup.ReplaceAllString(text, "")
+	text = discogsFormatMarkup.ReplaceAllString(text, "")
+	return strings.TrimSpace(text)
+}
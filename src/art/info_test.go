@@ -0,0 +1,149 @@
+package art_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/art"
+)
+
+// TestClientGetArtistInfo checks that the art.Client gathers the artist
+// information from the Music Brainz and Discogs APIs.
+func TestClientGetArtistInfo(t *testing.T) {
+	const (
+		artistMBID = "ca891d65-d9b0-4258-89f7-e6ba29d83767"
+		discogsID  = "251595"
+	)
+
+	var serverErrors []string
+
+	mbrainzHandler := func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/ws/2/artist/":
+			if query := req.URL.Query().Get("query"); query != "artist:Iron Maiden" {
+				fmt.Fprint(w, `<metadata><artist-list count="0" offset="0"/></metadata>`)
+				return
+			}
+
+			fmt.Fprintf(w, `
+				<metadata>
+				<artist-list count="2" offset="0">
+					<artist id="%s" type="Group" ns2:score="100">
+						<name>Iron Maiden</name>
+					</artist>
+					<artist id="not-the-good-maiden" type="Group" ns2:score="96">
+						<name>Iron Maiden</name>
+					</artist>
+				</artist-list>
+				</metadata>
+			`, artistMBID)
+		case "/ws/2/artist/" + artistMBID:
+			if inc := req.URL.Query().Get("inc"); inc != "url-rels+artist-rels" {
+				serverErrors = append(serverErrors,
+					fmt.Sprintf("mbhandler: unexpected inc parameter `%s`", inc))
+			}
+
+			fmt.Fprintf(w, `
+				<metadata>
+					<artist id="%s" type="Group">
+						<name>Iron Maiden</name>
+						<country>GB</country>
+						<life-span>
+							<begin>1975-12-25</begin>
+						</life-span>
+						<relation-list target-type="artist">
+							<relation type="member of band">
+								<target>bruce-id</target>
+								<direction>backward</direction>
+								<artist id="bruce-id">
+									<name>Bruce Dickinson</name>
+								</artist>
+							</relation>
+						</relation-list>
+						<relation-list target-type="url">
+							<relation type="official homepage">
+								<target>https://www.ironmaiden.com/</target>
+							</relation>
+							<relation type="discogs">
+								<target>https://www.discogs.com/artist/%s</target>
+							</relation>
+						</relation-list>
+					</artist>
+				</metadata>
+			`, artistMBID, discogsID)
+		default:
+			serverErrors = append(serverErrors,
+				fmt.Sprintf("mbhandler: unknown URI: `%s`", req.URL.Path))
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+	mbrainz := httptest.NewServer(http.HandlerFunc(mbrainzHandler))
+	defer mbrainz.Close()
+
+	discogsHandler := func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/artists/"+discogsID {
+			serverErrors = append(serverErrors,
+				fmt.Sprintf("dshandler: unknown path requested: `%s`", req.URL.Path))
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		fmt.Fprint(w, `{
+			"id": 251595,
+			"name": "Iron Maiden",
+			"profile": "[b]British[/b] band formed by [a=Steve Harris][a12345]."
+		}`)
+	}
+	discogs := httptest.NewServer(http.HandlerFunc(discogsHandler))
+	defer discogs.Close()
+
+	c := art.NewClient("euterpe/testing", 0, "discogsToken")
+	c.SetMusicBrainzAPIURL(mbrainz.URL)
+	c.SetDiscogsAPIURL(discogs.URL)
+
+	info, err := c.GetArtistInfo(context.Background(), "Iron Maiden", "")
+
+	for _, se := range serverErrors {
+		t.Error(se)
+	}
+
+	if err != nil {
+		t.Fatalf("getting artist info: %s", err)
+	}
+
+	expected := art.ArtistInfo{
+		MBID:      artistMBID,
+		Name:      "Iron Maiden",
+		Type:      "Group",
+		Country:   "GB",
+		BeginDate: "1975-12-25",
+		Biography: "British band formed by Steve Harris.",
+		Links: []art.ArtistLink{
+			{Type: "official homepage", URL: "https://www.ironmaiden.com/"},
+			{Type: "discogs", URL: "https://www.discogs.com/artist/251595"},
+		},
+		Related: []art.RelatedArtist{
+			{MBID: "bruce-id", Name: "Bruce Dickinson", Relation: "member of band"},
+		},
+	}
+
+	if !reflect.DeepEqual(expected, info) {
+		t.Errorf("expected info\n%+v\nbut got\n%+v", expected, info)
+	}
+
+	// With a known MusicBrainz ID no search should be made.
+	_, err = c.GetArtistInfo(context.Background(), "Not Searched", artistMBID)
+	if err != nil {
+		t.Errorf("getting artist info by MusicBrainz ID: %s", err)
+	}
+
+	_, err = c.GetArtistInfo(context.Background(), "Unknown Band", "")
+	if !errors.Is(err, art.ErrInfoNotFound) {
+		t.Errorf("expected ErrInfoNotFound for unknown artist but got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Package chromaprint calculates and compares acoustic fingerprints of media files.
+Unlike hashes of the file contents acoustic fingerprints of the same recording are
+similar even when it is encoded in different formats or with different bit rates.
+
+Fingerprints are calculated by the fpcalc program from the Chromaprint project.
+See https://acoustid.org/chromaprint.
+*/
+package chromaprint
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/bits"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// fpcalcLength is the number of seconds from the beginning of the audio which
+// fpcalc uses for the fingerprint.
+const fpcalcLength = 120
+
+// maxOffset is the largest number of fingerprint items by which the audio in two
+// fingerprints could be shifted and still be compared. Every item describes about
+// 0.12 seconds of audio so this is around two seconds of leading silence.
+const maxOffset = 16
+
+// Fingerprint is an acoustic fingerprint of the audio in a media file.
+type Fingerprint []uint32
+
+// String returns the fingerprint as comma separated numbers. This is how fpcalc
+// prints raw fingerprints and how they could be parsed with Parse.
+func (fp Fingerprint) String() string {
+	var sb strings.Builder
+	for ind, item := range fp {
+		if ind > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatUint(uint64(item), 10))
+	}
+	return sb.String()
+}
+
+// Parse parses a fingerprint in the format returned by Fingerprint.String.
+func Parse(text string) (Fingerprint, error) {
+	if text == "" {
+		return nil, errors.New("empty fingerprint")
+	}
+
+	items := strings.Split(text, ",")
+	fp := make(Fingerprint, 0, len(items))
+	for _, item := range items {
+		value, err := strconv.ParseUint(strings.TrimSpace(item), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("parsing fingerprint: %w", err)
+		}
+		fp = append(fp, uint32(value))
+	}
+
+	return fp, nil
+}
+
+// Calculator calculates fingerprints with fpcalc. It is safe for concurrent use.
+type Calculator struct {
+	fpcalc string
+}
+
+// NewCalculator returns a Calculator which runs the fpcalc program at `fpcalc`.
+// It could be only the name of the program when it is in the PATH.
+func NewCalculator(fpcalc string) *Calculator {
+	return &Calculator{
+		fpcalc: fpcalc,
+	}
+}
+
+// Calculate returns the fingerprint of the media file at path.
+func (c *Calculator) Calculate(ctx context.Context, path string) (Fingerprint, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.fpcalc,
+		"-raw",
+		"-length", strconv.Itoa(fpcalcLength),
+		path,
+	)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("fpcalc failed: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("fpcalc failed: %w", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(nil, len(out)+1)
+	for scanner.Scan() {
+		value, found := strings.CutPrefix(scanner.Text(), "FINGERPRINT=")
+		if found {
+			return Parse(value)
+		}
+	}
+
+	return nil, errors.New("no fingerprint in the fpcalc output")
+}
+
+// Similarity returns how similar the audio of two fingerprints is. It is between
+// 0 and 1 where 1 means the fingerprints are the same. Unrelated audio is around
+// 0.5. The audio in one of them may be shifted a bit in time, as when one of the
+// files has more silence at its beginning.
+func Similarity(a, b Fingerprint) float64 {
+	var best float64
+	for offset := -maxOffset; offset <= maxOffset; offset++ {
+		if similarity := similarityAt(a, b, offset); similarity > best {
+			best = similarity
+		}
+	}
+	return best
+}
+
+// similarityAt compares the items of `a` with the items of `b` which are `offset`
+// positions after them. At least half of the shorter fingerprint must be compared.
+func similarityAt(a, b Fingerprint, offset int) float64 {
+	if offset < 0 {
+		a, b, offset = b, a, -offset
+	}
+
+	shortest := len(a)
+	if len(b) < shortest {
+		shortest = len(b)
+	}
+	if offset >= len(b) {
+		return 0
+	}
+	b = b[offset:]
+
+	compared := len(a)
+	if len(b) < compared {
+		compared = len(b)
+	}
+	if compared == 0 || compared*2 < shortest {
+		return 0
+	}
+
+	var differentBits int
+	for ind := 0; ind < compared; ind++ {
+		differentBits += bits.OnesCount32(a[ind] ^ b[ind])
+	}
+
+	return 1 - float64(differentBits)/float64(compared*32)
+}
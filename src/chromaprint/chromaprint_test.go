@@ -0,0 +1,86 @@
+package chromaprint_test
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/chromaprint"
+)
+
+// TestCalculate checks that the fingerprint is read from the output of fpcalc.
+func TestCalculate(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	fpcalc := filepath.Join(t.TempDir(), "fpcalc")
+	script := "#!/bin/sh\necho FILE=$4\necho DURATION=215\necho FINGERPRINT=1,2,4294967295\n"
+	if err := os.WriteFile(fpcalc, []byte(script), 0o700); err != nil {
+		t.Fatalf("writing fake fpcalc: %s", err)
+	}
+
+	calc := chromaprint.NewCalculator(fpcalc)
+	fp, err := calc.Calculate(context.Background(), "song.mp3")
+	if err != nil {
+		t.Fatalf("calculating fingerprint: %s", err)
+	}
+
+	expected := chromaprint.Fingerprint{1, 2, 4294967295}
+	if !reflect.DeepEqual(fp, expected) {
+		t.Errorf("expected fingerprint %v but got %v", expected, fp)
+	}
+
+	parsed, err := chromaprint.Parse(fp.String())
+	if err != nil {
+		t.Fatalf("parsing fingerprint: %s", err)
+	}
+	if !reflect.DeepEqual(parsed, expected) {
+		t.Errorf("expected parsed fingerprint %v but got %v", expected, parsed)
+	}
+
+	failing := chromaprint.NewCalculator(filepath.Join(t.TempDir(), "missing"))
+	if _, err := failing.Calculate(context.Background(), "song.mp3"); err == nil {
+		t.Errorf("expected an error for missing fpcalc")
+	}
+}
+
+// TestSimilarity checks that shifted and slightly changed audio is similar while
+// unrelated audio is not.
+func TestSimilarity(t *testing.T) {
+	random := rand.New(rand.NewSource(42))
+	fingerprint := func(length int) chromaprint.Fingerprint {
+		fp := make(chromaprint.Fingerprint, length)
+		for ind := range fp {
+			fp[ind] = random.Uint32()
+		}
+		return fp
+	}
+
+	original := fingerprint(1000)
+
+	// The same audio with a bit of silence at the beginning and a few changed
+	// bits as if encoded with a lower bit rate.
+	shifted := append(fingerprint(5), original...)
+	for ind := 0; ind < len(shifted); ind += 3 {
+		shifted[ind] ^= 1 << (ind % 32)
+	}
+
+	if similarity := chromaprint.Similarity(original, original); similarity != 1 {
+		t.Errorf("expected the same fingerprints to have similarity 1 but got %f",
+			similarity)
+	}
+	if similarity := chromaprint.Similarity(original, shifted); similarity < 0.95 {
+		t.Errorf("expected shifted audio to be similar but got %f", similarity)
+	}
+	if similarity := chromaprint.Similarity(shifted, original); similarity < 0.95 {
+		t.Errorf("expected similarity to be symmetric but got %f", similarity)
+	}
+	if similarity := chromaprint.Similarity(original, fingerprint(1000)); similarity > 0.6 {
+		t.Errorf("expected unrelated audio not to be similar but got %f", similarity)
+	}
+}
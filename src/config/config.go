@@ -16,6 +16,7 @@ import (
 	"log"
 	"os/user"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/ironsmile/euterpe/src/helpers"
@@ -69,6 +70,46 @@ type Config struct {
 	MaxHeadersSize   int         `json:"max_header_bytes,omitempty"`
 	DownloadArtwork  bool        `json:"download_artwork,omitempty"`
 	DiscogsAuthToken string      `json:"discogs_auth_token,omitempty"`
+	PrefetchArtwork  bool        `json:"prefetch_artwork,omitempty"`
+	DownloadLyrics   bool        `json:"download_lyrics,omitempty"`
+	LoudnessAnalysis Loudness    `json:"loudness_analysis,omitempty"`
+	PreferredFormats []string    `json:"preferred_formats,omitempty"`
+
+	// Fpcalc is the path to the fpcalc program from Chromaprint. When set it is
+	// used for calculating acoustic fingerprints of tracks which help with
+	// finding duplicates.
+	Fpcalc string `json:"fpcalc,omitempty"`
+
+	// Schedule is for running library jobs periodically while the server is
+	// running.
+	Schedule Schedule `json:"schedule,omitempty"`
+}
+
+// Schedule is the configuration for running library jobs periodically. Every
+// job has a schedule in the cron format, e.g. "30 3 * * *". Jobs without one are
+// not run.
+type Schedule struct {
+	// Scan scans the libraries for new files and then cleans up the database.
+	Scan string `json:"scan,omitempty"`
+
+	// Rescan reads again the metadata of all files in the library.
+	Rescan string `json:"rescan,omitempty"`
+
+	// Cleanup removes from the database everything which is no longer in the
+	// libraries.
+	Cleanup string `json:"cleanup,omitempty"`
+}
+
+// Loudness is the configuration for measuring the loudness of tracks which have
+// no ReplayGain information in their tags.
+type Loudness struct {
+	Enable bool `json:"enable,omitempty"`
+
+	// Decoder is the command and its arguments which decodes media files. The
+	// "{file}" argument is replaced with the path to the media file. It must
+	// write signed 16 bit little endian stereo audio at 48kHz to its standard
+	// output. Only WAV files are analyzed when it is empty.
+	Decoder []string `json:"decoder,omitempty"`
 }
 
 // ScanSection is used for merging the two configs. Its purpose is to essentially
@@ -78,6 +119,38 @@ type ScanSection struct {
 	FilesPerOperation int64         `json:"files_per_operation,omitempty"`
 	SleepPerOperation time.Duration `json:"sleep_after_operation,omitempty"`
 	InitialWait       time.Duration `json:"initial_wait_duration,omitempty"`
+
+	// Exclude maps library directories to patterns of files and directories in
+	// them which are not scanned. The patterns have the syntax of .gitignore files
+	// and are relative to their library. Patterns under the "*" key are used for
+	// all libraries.
+	Exclude map[string][]string `json:"exclude,omitempty"`
+
+	// FollowSymlinks are the library directories in which symbolic links to
+	// directories are followed while scanning. "*" is for all libraries.
+	FollowSymlinks []string `json:"follow_symlinks,omitempty"`
+
+	// Archives are the library directories in which media files inside ZIP
+	// archives are added to the library. Every archive is treated as an album
+	// directory. "*" is for all libraries.
+	Archives []string `json:"archives,omitempty"`
+
+	// PollChanges are the library directories which are checked for changes
+	// every PollInterval instead of being watched by the file system watcher.
+	// This is useful for network file systems. "*" is for all libraries.
+	PollChanges  []string      `json:"poll_changes,omitempty"`
+	PollInterval time.Duration `json:"poll_interval,omitempty"`
+
+	// WatchQuietPeriod is for how long there must be no changes of a file or
+	// directory before its changes are processed. WatchWorkers is the number of
+	// changes processed at the same time.
+	WatchQuietPeriod time.Duration `json:"watch_quiet_period,omitempty"`
+	WatchWorkers     int           `json:"watch_workers,omitempty"`
+
+	// Formats are the extensions of the files which are added to the library,
+	// such as "flac" or "mp3". When empty a default list of all known formats is
+	// used.
+	Formats []string `json:"formats,omitempty"`
 }
 
 // UnmarshalJSON parses a JSON and populets its ScanSection. Satisfies the
@@ -88,6 +161,17 @@ func (ss *ScanSection) UnmarshalJSON(input []byte) error {
 		FilesPerOperation int64  `json:"files_per_operation"`
 		SleepPerOperation string `json:"sleep_after_operation"`
 		InitialWait       string `json:"initial_wait_duration"`
+
+		Exclude        map[string][]string `json:"exclude"`
+		FollowSymlinks []string            `json:"follow_symlinks"`
+		Archives       []string            `json:"archives"`
+		PollChanges    []string            `json:"poll_changes"`
+		PollInterval   string              `json:"poll_interval"`
+
+		WatchQuietPeriod string `json:"watch_quiet_period"`
+		WatchWorkers     int    `json:"watch_workers"`
+
+		Formats []string `json:"formats"`
 	}{}
 	if err := json.Unmarshal(input, ssProxy); err != nil {
 		return err
@@ -95,6 +179,19 @@ func (ss *ScanSection) UnmarshalJSON(input []byte) error {
 
 	ss.Disable = ssProxy.Disable
 	ss.FilesPerOperation = ssProxy.FilesPerOperation
+	ss.Exclude = ssProxy.Exclude
+	ss.FollowSymlinks = ssProxy.FollowSymlinks
+	ss.Archives = ssProxy.Archives
+	ss.PollChanges = ssProxy.PollChanges
+	ss.WatchWorkers = ssProxy.WatchWorkers
+
+	for _, format := range ssProxy.Formats {
+		format = strings.TrimPrefix(strings.TrimSpace(format), ".")
+		if format == "" {
+			return errors.New("formats must not contain empty extensions")
+		}
+		ss.Formats = append(ss.Formats, strings.ToLower(format))
+	}
 
 	if ssProxy.SleepPerOperation != "" {
 		spo, err := time.ParseDuration(ssProxy.SleepPerOperation)
@@ -112,10 +209,38 @@ func (ss *ScanSection) UnmarshalJSON(input []byte) error {
 		ss.InitialWait = iwd
 	}
 
+	if ssProxy.PollInterval != "" {
+		interval, err := time.ParseDuration(ssProxy.PollInterval)
+		if err != nil {
+			return err
+		}
+		ss.PollInterval = interval
+	}
+
+	if ssProxy.WatchQuietPeriod != "" {
+		quietPeriod, err := time.ParseDuration(ssProxy.WatchQuietPeriod)
+		if err != nil {
+			return err
+		}
+		ss.WatchQuietPeriod = quietPeriod
+	}
+
 	if ss.FilesPerOperation < 0 {
 		return errors.New("files_per_operation must be a positive integer")
 	}
 
+	if ss.PollInterval < 0 {
+		return errors.New("poll_interval must be a positive duration")
+	}
+
+	if ss.WatchQuietPeriod < 0 {
+		return errors.New("watch_quiet_period must be a positive duration")
+	}
+
+	if ss.WatchWorkers < 0 {
+		return errors.New("watch_workers must be a positive integer")
+	}
+
 	return nil
 }
 
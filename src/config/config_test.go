@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
@@ -19,7 +20,16 @@ func TestScanSectionUnmarshalJSON(t *testing.T) {
 			"disable": false,
 			"files_per_operation": 100,
 			"sleep_after_operation": "15ms",
-			"initial_wait_duration": "100ms"
+			"initial_wait_duration": "100ms",
+			"exclude": {
+				"*": ["_incoming/"],
+				"/music": ["/audiobooks", "*.wav"]
+			},
+			"follow_symlinks": ["/music"],
+			"poll_changes": ["/mnt/nas"],
+			"poll_interval": "5m",
+			"watch_quiet_period": "3s",
+			"watch_workers": 2
 		}
 	`)
 
@@ -35,9 +45,19 @@ func TestScanSectionUnmarshalJSON(t *testing.T) {
 		FilesPerOperation: 100,
 		SleepPerOperation: 15 * time.Millisecond,
 		InitialWait:       100 * time.Millisecond,
+		Exclude: map[string][]string{
+			"*":      {"_incoming/"},
+			"/music": {"/audiobooks", "*.wav"},
+		},
+		FollowSymlinks: []string{"/music"},
+		PollChanges:    []string{"/mnt/nas"},
+		PollInterval:   5 * time.Minute,
+
+		WatchQuietPeriod: 3 * time.Second,
+		WatchWorkers:     2,
 	}
 
-	if ss != expected {
+	if !reflect.DeepEqual(ss, expected) {
 		t.Errorf("expected `%+v` but got `%+v`", expected, ss)
 	}
 }
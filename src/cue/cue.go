@@ -0,0 +1,246 @@
+// Package cue reads CUE sheets and extracts the parts of audio files which they
+// describe as separate tracks.
+//
+// A CUE sheet is a text file which describes how one or more audio files are split
+// into tracks. It is usually found next to single-file rips of whole albums.
+package cue
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// framesPerSecond is the number of CD frames in a second. Times in CUE sheets are
+// in the mm:ss:ff format where ff are frames.
+const framesPerSecond = 75
+
+// maxSheetSize limits the size of CUE sheets which will be parsed. Real ones are
+// no more than a few kilobytes.
+const maxSheetSize = 1024 * 1024
+
+// Sheet is a parsed CUE sheet.
+type Sheet struct {
+	// Title is the title of the album.
+	Title string
+
+	// Performer is the artist of the album.
+	Performer string
+
+	// Files are all the audio files described in the sheet in order.
+	Files []File
+}
+
+// File is an audio file described in a CUE sheet.
+type File struct {
+	// Name is the path to the file as written in the sheet. It is usually
+	// relative to the directory of the sheet.
+	Name string
+
+	// Tracks are the tracks in the file ordered by their start time.
+	Tracks []Track
+}
+
+// Track is a single track in an audio file.
+type Track struct {
+	// Number is the track number on the album.
+	Number int
+
+	// Title is the name of the track.
+	Title string
+
+	// Performer is the artist of the track. It is empty when the track does
+	// not have its own performer. The Sheet's one should be used then.
+	Performer string
+
+	// Start is the offset from the beginning of the file at which the
+	// track starts.
+	Start time.Duration
+}
+
+// Parse reads a CUE sheet from `r`. Sheets which are not valid UTF-8 are
+// considered to be in the ISO-8859-1 encoding since this is what most ripping
+// software produces.
+func Parse(r io.Reader) (Sheet, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxSheetSize+1))
+	if err != nil {
+		return Sheet{}, fmt.Errorf("reading CUE sheet: %w", err)
+	}
+	if len(data) > maxSheetSize {
+		return Sheet{}, fmt.Errorf("CUE sheet is too big")
+	}
+
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(data) {
+		data = latin1ToUTF8(data)
+	}
+
+	var (
+		sheet Sheet
+		file  *File
+		track *Track
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		command, args := splitCommand(scanner.Text())
+
+		switch command {
+		case "FILE":
+			if len(args) < 1 {
+				return Sheet{}, fmt.Errorf("line %d: FILE without a name", lineNum)
+			}
+			sheet.Files = append(sheet.Files, File{Name: args[0]})
+			file = &sheet.Files[len(sheet.Files)-1]
+			track = nil
+		case "TRACK":
+			if file == nil {
+				return Sheet{}, fmt.Errorf("line %d: TRACK before FILE", lineNum)
+			}
+			if len(args) < 1 {
+				return Sheet{}, fmt.Errorf("line %d: TRACK without a number", lineNum)
+			}
+			number, err := strconv.Atoi(args[0])
+			if err != nil {
+				return Sheet{}, fmt.Errorf("line %d: bad track number: %w", lineNum, err)
+			}
+			// The start is set once INDEX 01 for the track is found.
+			// Tracks without it are dropped.
+			file.Tracks = append(file.Tracks, Track{Number: number, Start: -1})
+			track = &file.Tracks[len(file.Tracks)-1]
+		case "INDEX":
+			if track == nil || len(args) < 2 {
+				continue
+			}
+
+			// Index 01 is where the track actually starts. Index 00 is
+			// the start of its pre-gap which is considered a part of the
+			// previous track.
+			if args[0] != "01" && args[0] != "1" {
+				continue
+			}
+			start, err := parseTime(args[1])
+			if err != nil {
+				return Sheet{}, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			track.Start = start
+		case "TITLE":
+			if len(args) < 1 {
+				continue
+			}
+			if track != nil {
+				track.Title = args[0]
+			} else {
+				sheet.Title = args[0]
+			}
+		case "PERFORMER":
+			if len(args) < 1 {
+				continue
+			}
+			if track != nil {
+				track.Performer = args[0]
+			} else {
+				sheet.Performer = args[0]
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Sheet{}, fmt.Errorf("reading CUE sheet: %w", err)
+	}
+
+	var tracksCount int
+	for ind, file := range sheet.Files {
+		var tracks []Track
+		for _, track := range file.Tracks {
+			if track.Start >= 0 {
+				tracks = append(tracks, track)
+			}
+		}
+		sort.SliceStable(tracks, func(i, j int) bool {
+			return tracks[i].Start < tracks[j].Start
+		})
+		sheet.Files[ind].Tracks = tracks
+		tracksCount += len(tracks)
+	}
+	if tracksCount == 0 {
+		return Sheet{}, fmt.Errorf("CUE sheet has no tracks")
+	}
+
+	return sheet, nil
+}
+
+// splitCommand splits a line from a CUE sheet into its command and arguments.
+// Arguments may be quoted in order to contain spaces.
+func splitCommand(line string) (string, []string) {
+	var (
+		fields  []string
+		current strings.Builder
+		quoted  bool
+		inField bool
+	)
+
+	for _, r := range strings.TrimSpace(line) {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inField = true
+		case (r == ' ' || r == '\t') && !quoted:
+			if inField {
+				fields = append(fields, current.String())
+				current.Reset()
+				inField = false
+			}
+		default:
+			current.WriteRune(r)
+			inField = true
+		}
+	}
+	if inField {
+		fields = append(fields, current.String())
+	}
+
+	if len(fields) == 0 {
+		return "", nil
+	}
+
+	return strings.ToUpper(fields[0]), fields[1:]
+}
+
+// parseTime parses a time in the mm:ss:ff format.
+func parseTime(value string) (time.Duration, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("malformed time `%s`", value)
+	}
+
+	var numbers [3]int
+	for ind, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("malformed time `%s`", value)
+		}
+		numbers[ind] = n
+	}
+
+	if numbers[1] >= 60 || numbers[2] >= framesPerSecond {
+		return 0, fmt.Errorf("malformed time `%s`", value)
+	}
+
+	frames := (numbers[0]*60+numbers[1])*framesPerSecond + numbers[2]
+	return time.Duration(frames) * time.Second / framesPerSecond, nil
+}
+
+func latin1ToUTF8(data []byte) []byte {
+	out := make([]rune, len(data))
+	for i, b := range data {
+		out[i] = rune(b)
+	}
+	return []byte(string(out))
+}
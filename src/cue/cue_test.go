@@ -0,0 +1,106 @@
+package cue_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/cue"
+)
+
+// TestParse checks parsing a CUE sheet with album and track information.
+func TestParse(t *testing.T) {
+	const sheetText = "\xef\xbb\xbfREM GENRE Classical\r\n" +
+		`PERFORMER "Berliner Philharmoniker"
+TITLE "Symphony No. 9"
+FILE "Symphony No. 9.flac" WAVE
+  TRACK 01 AUDIO
+    TITLE "I. Allegro ma non troppo"
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    TITLE "II. Molto vivace"
+    PERFORMER "Herbert von Karajan"
+    INDEX 00 15:30:00
+    INDEX 01 15:32:37
+  TRACK 03 AUDIO
+    TITLE "Without an index"
+FILE "bonus.wav" WAVE
+  TRACK 04 AUDIO
+    TITLE Bonus
+    INDEX 01 00:01:00
+`
+
+	sheet, err := cue.Parse(strings.NewReader(sheetText))
+	if err != nil {
+		t.Fatalf("parsing CUE sheet: %s", err)
+	}
+
+	expected := cue.Sheet{
+		Title:     "Symphony No. 9",
+		Performer: "Berliner Philharmoniker",
+		Files: []cue.File{
+			{
+				Name: "Symphony No. 9.flac",
+				Tracks: []cue.Track{
+					{
+						Number: 1,
+						Title:  "I. Allegro ma non troppo",
+					},
+					{
+						Number:    2,
+						Title:     "II. Molto vivace",
+						Performer: "Herbert von Karajan",
+						Start: 15*time.Minute + 32*time.Second +
+							37*time.Second/75,
+					},
+				},
+			},
+			{
+				Name: "bonus.wav",
+				Tracks: []cue.Track{
+					{
+						Number: 4,
+						Title:  "Bonus",
+						Start:  time.Second,
+					},
+				},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(expected, sheet) {
+		t.Errorf("expected sheet\n%+v\nbut got\n%+v", expected, sheet)
+	}
+}
+
+// TestParseLatin1 checks that sheets which are not UTF-8 are read as ISO-8859-1.
+func TestParseLatin1(t *testing.T) {
+	sheetText := "TITLE \"Caf\xe9\"\nFILE \"a.flac\" WAVE\nTRACK 01 AUDIO\nINDEX 01 00:00:00\n"
+
+	sheet, err := cue.Parse(strings.NewReader(sheetText))
+	if err != nil {
+		t.Fatalf("parsing CUE sheet: %s", err)
+	}
+
+	if sheet.Title != "Café" {
+		t.Errorf("expected title `Café` but got `%s`", sheet.Title)
+	}
+}
+
+// TestParseErrors checks that invalid sheets are rejected.
+func TestParseErrors(t *testing.T) {
+	sheets := []string{
+		"",
+		"TITLE \"No files\"\n",
+		"TRACK 01 AUDIO\nINDEX 01 00:00:00\n",
+		"FILE \"a.flac\" WAVE\nTRACK one AUDIO\n",
+		"FILE \"a.flac\" WAVE\nTRACK 01 AUDIO\nINDEX 01 00:61:00\n",
+	}
+
+	for _, sheetText := range sheets {
+		if _, err := cue.Parse(strings.NewReader(sheetText)); err == nil {
+			t.Errorf("expected error for sheet %q", sheetText)
+		}
+	}
+}
@@ -0,0 +1,433 @@
+package cue
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	flacBlockStreamInfo = 0
+	flacBlockSeekTable  = 3
+
+	// flacBisectLimit is the size of the file region below which the frame
+	// search stops bisecting and starts walking the frames one by one.
+	flacBisectLimit = 256 * 1024
+
+	// flacMaxHeaderSize is the maximum size of a FLAC frame header.
+	flacMaxHeaderSize = 16
+)
+
+// flacStream holds the information about a FLAC file needed for slicing it.
+type flacStream struct {
+	r    io.ReaderAt
+	size int64
+
+	// streamInfo is the raw STREAMINFO metadata block.
+	streamInfo [34]byte
+
+	minBlockSize  int64
+	maxBlockSize  int64
+	sampleRate    int64
+	bitsPerSample int
+	totalSamples  int64
+
+	// audioStart is the offset of the first frame.
+	audioStart int64
+
+	seekPoints []flacSeekPoint
+}
+
+type flacSeekPoint struct {
+	sample int64
+	offset int64
+}
+
+// flacFrame is a frame found in the FLAC file.
+type flacFrame struct {
+	offset    int64
+	sample    int64
+	blockSize int64
+}
+
+// sliceFLAC extracts the frames between `start` and `end` from the FLAC stream
+// which begins at `offset` in `r`. The result has only a STREAMINFO metadata block
+// with the number of samples in the slice.
+func sliceFLAC(
+	r io.ReaderAt,
+	size, offset int64,
+	start, end time.Duration,
+) (io.ReadSeeker, error) {
+	stream, err := readFLACStream(r, size, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	toSample := func(at time.Duration) int64 {
+		return at.Nanoseconds() * stream.sampleRate / int64(time.Second)
+	}
+
+	first, err := stream.findFrame(toSample(start))
+	if err != nil {
+		return nil, err
+	}
+
+	to := size
+	totalSamples := stream.totalSamples - first.sample
+	if end != 0 && (stream.totalSamples == 0 || toSample(end) < stream.totalSamples) {
+		last, err := stream.findFrame(toSample(end))
+		if err != nil {
+			return nil, err
+		}
+		to = last.offset
+		totalSamples = last.sample - first.sample
+	}
+
+	if totalSamples < 0 || stream.totalSamples == 0 {
+		totalSamples = 0
+	}
+
+	info := stream.streamInfo
+
+	// The total samples are the lowest 36 bits of bytes 13 to 17. The MD5
+	// signature which follows them is zeroed since it is unknown for the slice.
+	info[13] = info[13]&0xf0 | byte(totalSamples>>32)&0x0f
+	binary.BigEndian.PutUint32(info[14:18], uint32(totalSamples))
+	for i := 18; i < len(info); i++ {
+		info[i] = 0
+	}
+
+	header := []byte("fLaC")
+	header = append(header, 0x80|flacBlockStreamInfo, 0, 0, byte(len(info)))
+	header = append(header, info[:]...)
+
+	return newSlice(header, r, first.offset, to), nil
+}
+
+// readFLACStream reads the metadata blocks of the FLAC stream at `offset`.
+func readFLACStream(r io.ReaderAt, size, offset int64) (*flacStream, error) {
+	stream := &flacStream{r: r, size: size}
+	var foundInfo bool
+
+	pos := offset + 4
+	for {
+		var header [4]byte
+		if _, err := r.ReadAt(header[:], pos); err != nil {
+			return nil, fmt.Errorf("reading FLAC metadata block header: %w", err)
+		}
+		pos += 4
+
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7f
+		blockSize := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+
+		switch blockType {
+		case flacBlockStreamInfo:
+			if blockSize != int64(len(stream.streamInfo)) {
+				return nil, errors.New("malformed FLAC STREAMINFO block")
+			}
+			if _, err := r.ReadAt(stream.streamInfo[:], pos); err != nil {
+				return nil, fmt.Errorf("reading FLAC STREAMINFO: %w", err)
+			}
+			foundInfo = true
+		case flacBlockSeekTable:
+			table := make([]byte, blockSize)
+			if _, err := r.ReadAt(table, pos); err != nil {
+				return nil, fmt.Errorf("reading FLAC SEEKTABLE: %w", err)
+			}
+			for len(table) >= 18 {
+				sample := binary.BigEndian.Uint64(table[:8])
+				offset := binary.BigEndian.Uint64(table[8:16])
+				table = table[18:]
+
+				// Placeholder points have all bits of the sample set.
+				if sample == ^uint64(0) {
+					continue
+				}
+				stream.seekPoints = append(stream.seekPoints, flacSeekPoint{
+					sample: int64(sample),
+					offset: int64(offset),
+				})
+			}
+		}
+
+		pos += blockSize
+		if last {
+			break
+		}
+	}
+
+	if !foundInfo {
+		return nil, errors.New("FLAC file without STREAMINFO block")
+	}
+
+	info := stream.streamInfo
+	stream.audioStart = pos
+	stream.minBlockSize = int64(binary.BigEndian.Uint16(info[0:2]))
+	stream.maxBlockSize = int64(binary.BigEndian.Uint16(info[2:4]))
+	stream.sampleRate = int64(info[10])<<12 | int64(info[11])<<4 | int64(info[12])>>4
+	stream.bitsPerSample = int((info[12]&0x01)<<4|info[13]>>4) + 1
+	stream.totalSamples = int64(info[13]&0x0f)<<32 | int64(binary.BigEndian.Uint32(info[14:18]))
+
+	if stream.sampleRate == 0 {
+		return nil, errors.New("FLAC file with invalid sample rate")
+	}
+
+	return stream, nil
+}
+
+// findFrame returns the frame which contains `sample`. When `sample` is after the
+// end of the stream the last frame is returned.
+func (s *flacStream) findFrame(sample int64) (flacFrame, error) {
+	lo, hi := s.audioStart, s.size
+
+	// Seek points narrow down the region in which the frame is.
+	for _, point := range s.seekPoints {
+		offset := s.audioStart + point.offset
+		if offset >= s.size {
+			break
+		}
+		if point.sample <= sample {
+			lo = offset
+		} else {
+			hi = offset
+			break
+		}
+	}
+
+	for hi-lo > flacBisectLimit {
+		mid := lo + (hi-lo)/2
+		frame, found, err := s.nextFrame(mid, hi, -1)
+		if err != nil {
+			return flacFrame{}, err
+		}
+		if !found || frame.sample > sample {
+			hi = mid
+		} else {
+			lo = frame.offset
+		}
+	}
+
+	current, found, err := s.nextFrame(lo, s.size, -1)
+	if err != nil {
+		return flacFrame{}, err
+	}
+	if !found {
+		return flacFrame{}, errors.New("no FLAC frames found")
+	}
+
+	for current.sample+current.blockSize <= sample {
+		next, found, err := s.nextFrame(
+			current.offset+1,
+			s.size,
+			current.sample+current.blockSize,
+		)
+		if err != nil {
+			return flacFrame{}, err
+		}
+		if !found {
+			break
+		}
+		current = next
+	}
+
+	return current, nil
+}
+
+// nextFrame finds the first frame which starts between `from` and `to`. When
+// `expectedSample` is not negative only a frame which starts at this sample is
+// accepted.
+func (s *flacStream) nextFrame(
+	from, to, expectedSample int64,
+) (flacFrame, bool, error) {
+	buf := make([]byte, 64*1024)
+	header := make([]byte, flacMaxHeaderSize)
+
+	for pos := from; pos < to; {
+		n, err := s.r.ReadAt(buf, pos)
+		if n == 0 {
+			if err == io.EOF {
+				return flacFrame{}, false, nil
+			}
+			return flacFrame{}, false, fmt.Errorf("reading FLAC frames: %w", err)
+		}
+
+		for i := 0; i+1 < n; i++ {
+			if buf[i] != 0xff || buf[i+1]&0xfe != 0xf8 || pos+int64(i) >= to {
+				continue
+			}
+
+			hn, _ := s.r.ReadAt(header, pos+int64(i))
+			frame, ok := s.parseFrameHeader(header[:hn])
+			if !ok {
+				continue
+			}
+			if expectedSample >= 0 && frame.sample != expectedSample {
+				continue
+			}
+
+			frame.offset = pos + int64(i)
+			return frame, true, nil
+		}
+
+		// The last byte is read again in case it is the start of a sync code.
+		pos += int64(n) - 1
+		if n < 2 {
+			pos++
+		}
+	}
+
+	return flacFrame{}, false, nil
+}
+
+// flacSampleRates are the sample rates for the codes in the frame headers.
+var flacSampleRates = [...]int64{
+	0, 88200, 176400, 192000, 8000, 16000, 22050, 24000,
+	32000, 44100, 48000, 96000,
+}
+
+// flacSampleSizes are the bits per sample for the codes in the frame headers.
+// Zero means "get it from STREAMINFO" and -1 is reserved.
+var flacSampleSizes = [...]int{0, 8, 12, -1, 16, 20, 24, 32}
+
+// parseFrameHeader checks whether `h` starts with a valid frame header which is
+// consistent with the stream and returns the frame's position in samples.
+func (s *flacStream) parseFrameHeader(h []byte) (flacFrame, bool) {
+	if len(h) < 6 || h[3]&0x01 != 0 {
+		return flacFrame{}, false
+	}
+
+	variableBlocks := h[1]&0x01 != 0
+	blockSizeCode := h[2] >> 4
+	sampleRateCode := h[2] & 0x0f
+	channels := h[3] >> 4
+	sampleSize := flacSampleSizes[(h[3]>>1)&0x07]
+
+	if blockSizeCode == 0 || sampleRateCode == 0x0f || channels > 10 || sampleSize < 0 {
+		return flacFrame{}, false
+	}
+	if sampleSize != 0 && sampleSize != s.bitsPerSample {
+		return flacFrame{}, false
+	}
+
+	// The frame or sample number is encoded like UTF-8 characters but with
+	// up to 36 bits.
+	pos := 4
+	first := h[pos]
+	var (
+		number int64
+		extra  int
+	)
+	switch {
+	case first&0x80 == 0:
+		number = int64(first)
+	case first&0xe0 == 0xc0:
+		number, extra = int64(first&0x1f), 1
+	case first&0xf0 == 0xe0:
+		number, extra = int64(first&0x0f), 2
+	case first&0xf8 == 0xf0:
+		number, extra = int64(first&0x07), 3
+	case first&0xfc == 0xf8:
+		number, extra = int64(first&0x03), 4
+	case first&0xfe == 0xfc:
+		number, extra = int64(first&0x01), 5
+	case first == 0xfe:
+		number, extra = 0, 6
+	default:
+		return flacFrame{}, false
+	}
+	pos++
+	if len(h) < pos+extra+1 {
+		return flacFrame{}, false
+	}
+	for i := 0; i < extra; i++ {
+		if h[pos]&0xc0 != 0x80 {
+			return flacFrame{}, false
+		}
+		number = number<<6 | int64(h[pos]&0x3f)
+		pos++
+	}
+
+	var blockSize int64
+	switch {
+	case blockSizeCode == 1:
+		blockSize = 192
+	case blockSizeCode <= 5:
+		blockSize = 576 << (blockSizeCode - 2)
+	case blockSizeCode == 6:
+		if len(h) < pos+1 {
+			return flacFrame{}, false
+		}
+		blockSize = int64(h[pos]) + 1
+		pos++
+	case blockSizeCode == 7:
+		if len(h) < pos+2 {
+			return flacFrame{}, false
+		}
+		blockSize = int64(binary.BigEndian.Uint16(h[pos:])) + 1
+		pos += 2
+	default:
+		blockSize = 256 << (blockSizeCode - 8)
+	}
+
+	var sampleRate int64
+	switch {
+	case sampleRateCode < 12:
+		sampleRate = flacSampleRates[sampleRateCode]
+	case sampleRateCode == 12:
+		if len(h) < pos+1 {
+			return flacFrame{}, false
+		}
+		sampleRate = int64(h[pos]) * 1000
+		pos++
+	default:
+		if len(h) < pos+2 {
+			return flacFrame{}, false
+		}
+		sampleRate = int64(binary.BigEndian.Uint16(h[pos:]))
+		if sampleRateCode == 14 {
+			sampleRate *= 10
+		}
+		pos += 2
+	}
+	if sampleRate != 0 && sampleRate != s.sampleRate {
+		return flacFrame{}, false
+	}
+
+	if len(h) < pos+1 || crc8(h[:pos]) != h[pos] {
+		return flacFrame{}, false
+	}
+
+	if s.maxBlockSize != 0 && blockSize > s.maxBlockSize {
+		return flacFrame{}, false
+	}
+
+	frame := flacFrame{blockSize: blockSize, sample: number}
+	if !variableBlocks {
+		frame.sample = number * s.minBlockSize
+	}
+	if s.totalSamples != 0 && frame.sample >= s.totalSamples {
+		return flacFrame{}, false
+	}
+
+	return frame, true
+}
+
+// crc8 computes the CRC-8 used by FLAC frame headers. Its polynomial is
+// x^8 + x^2 + x^1 + x^0.
+func crc8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,190 @@
+package cue
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// mp3MaxResync is how many bytes are skipped while searching for the next frame
+// when garbage is found between frames.
+const mp3MaxResync = 64 * 1024
+
+// mp3Bitrates are the bit rates in kbps for every MPEG version and layer. The
+// first index is 0 for MPEG 1 and 1 for MPEG 2 and 2.5. The second one is the
+// layer minus one.
+var mp3Bitrates = [2][3][15]int64{
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	},
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+}
+
+// mp3SampleRates are the sample rates for MPEG 1, 2 and 2.5.
+var mp3SampleRates = [3][3]int64{
+	{44100, 48000, 32000},
+	{22050, 24000, 16000},
+	{11025, 12000, 8000},
+}
+
+// mp3Frame is the information in a MPEG audio frame header.
+type mp3Frame struct {
+	size       int64
+	samples    int64
+	sampleRate int64
+}
+
+// sliceMP3 extracts the MPEG audio frames between `start` and `end` from the
+// file. Frames begin at `offset`. Frames are walked from the start of the file
+// since their duration could only be known by reading them.
+func sliceMP3(
+	r io.ReaderAt,
+	size, offset int64,
+	start, end time.Duration,
+) (io.ReadSeeker, error) {
+	reader := bufio.NewReaderSize(io.NewSectionReader(r, offset, size-offset), 64*1024)
+
+	var (
+		pos      = offset
+		elapsed  time.Duration
+		from     int64 = -1
+		to             = size
+		isFirst        = true
+		skipped  int
+		foundAny bool
+	)
+
+	for {
+		header, err := reader.Peek(4)
+		if err != nil {
+			break
+		}
+
+		frame, ok := parseMP3FrameHeader(header)
+		if !ok {
+			if string(header[:3]) == "TAG" || skipped >= mp3MaxResync {
+				// The ID3v1 tag at the end of the file or too much
+				// garbage. Either way there are no more frames.
+				break
+			}
+			if _, err := reader.Discard(1); err != nil {
+				break
+			}
+			pos++
+			skipped++
+			continue
+		}
+		skipped = 0
+
+		if isFirst {
+			isFirst = false
+
+			// The first frame may hold a Xing or VBRI header with the
+			// number of frames in the whole file. It does not have any
+			// audio and is not included in the slice.
+			peekSize := frame.size
+			if peekSize > 200 {
+				peekSize = 200
+			}
+			data, _ := reader.Peek(int(peekSize))
+			if bytes.Contains(data, []byte("Xing")) ||
+				bytes.Contains(data, []byte("Info")) ||
+				bytes.Contains(data, []byte("VBRI")) {
+				if _, err := reader.Discard(int(frame.size)); err != nil {
+					break
+				}
+				pos += frame.size
+				continue
+			}
+		}
+
+		foundAny = true
+		duration := time.Duration(frame.samples) * time.Second /
+			time.Duration(frame.sampleRate)
+
+		if from < 0 && elapsed+duration > start {
+			from = pos
+		}
+		if end != 0 && elapsed+duration > end {
+			to = pos
+			break
+		}
+
+		if _, err := reader.Discard(int(frame.size)); err != nil {
+			break
+		}
+		pos += frame.size
+		elapsed += duration
+	}
+
+	if !foundAny {
+		return nil, errors.New("no MPEG audio frames found")
+	}
+	if from < 0 {
+		return nil, fmt.Errorf("start %s is after the end of the file", start)
+	}
+
+	return newSlice(nil, r, from, to), nil
+}
+
+// parseMP3FrameHeader parses the four bytes of a MPEG audio frame header.
+func parseMP3FrameHeader(h []byte) (mp3Frame, bool) {
+	if h[0] != 0xff || h[1]&0xe0 != 0xe0 {
+		return mp3Frame{}, false
+	}
+
+	version := (h[1] >> 3) & 0x03
+	layer := 4 - int((h[1]>>1)&0x03)
+	bitrateIndex := h[2] >> 4
+	sampleRateIndex := (h[2] >> 2) & 0x03
+	padding := int64((h[2] >> 1) & 0x01)
+
+	if version == 1 || layer == 4 || bitrateIndex == 0 || bitrateIndex == 15 ||
+		sampleRateIndex == 3 {
+		return mp3Frame{}, false
+	}
+
+	var (
+		bitrateTable = 0
+		rateTable    = 0
+	)
+	switch version {
+	case 0:
+		// MPEG 2.5
+		bitrateTable, rateTable = 1, 2
+	case 2:
+		// MPEG 2
+		bitrateTable, rateTable = 1, 1
+	}
+
+	bitrate := mp3Bitrates[bitrateTable][layer-1][bitrateIndex] * 1000
+	sampleRate := mp3SampleRates[rateTable][sampleRateIndex]
+
+	frame := mp3Frame{sampleRate: sampleRate}
+	switch {
+	case layer == 1:
+		frame.samples = 384
+		frame.size = (12*bitrate/sampleRate + padding) * 4
+	case layer == 2 || version == 3:
+		frame.samples = 1152
+		frame.size = 144*bitrate/sampleRate + padding
+	default:
+		frame.samples = 576
+		frame.size = 72*bitrate/sampleRate + padding
+	}
+
+	if frame.size < 4 {
+		return mp3Frame{}, false
+	}
+
+	return frame, true
+}
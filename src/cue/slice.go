@@ -0,0 +1,92 @@
+package cue
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrUnsupportedFormat is returned by Slice for audio files from which parts
+// could not be extracted without transcoding.
+var ErrUnsupportedFormat = errors.New("slicing is not supported for this audio format")
+
+// Slice returns the part of the audio file `r` with `size` between `start` and
+// `end`. When `end` is zero the part is until the end of the file. The result is
+// a valid file in the same format as `r`. FLAC, WAV and MP3 files are supported.
+// For other formats ErrUnsupportedFormat is returned.
+//
+// Compressed formats are cut at frame boundaries so the part could start and end
+// up to a frame earlier than requested. Consecutive parts never overlap.
+//
+// The result reads from `r` so it must not be closed while the result is in use.
+func Slice(r io.ReaderAt, size int64, start, end time.Duration) (io.ReadSeeker, error) {
+	if start < 0 || end < 0 || (end != 0 && end <= start) {
+		return nil, fmt.Errorf("invalid slice from %s to %s", start, end)
+	}
+
+	var magic [12]byte
+	if _, err := r.ReadAt(magic[:], 0); err != nil {
+		return nil, fmt.Errorf("reading file header: %w", err)
+	}
+
+	var offset int64
+	if bytes.HasPrefix(magic[:], []byte("ID3")) {
+		// Skipping the ID3v2 tag at the beginning of the file. These could be
+		// found in front of both MP3 and FLAC files.
+		offset = 10 + (int64(magic[6]&0x7f)<<21 | int64(magic[7]&0x7f)<<14 |
+			int64(magic[8]&0x7f)<<7 | int64(magic[9]&0x7f))
+		if magic[5]&0x10 != 0 {
+			// There is a footer as well.
+			offset += 10
+		}
+
+		if _, err := r.ReadAt(magic[:4], offset); err != nil {
+			return nil, fmt.Errorf("reading file header: %w", err)
+		}
+	}
+
+	switch {
+	case bytes.HasPrefix(magic[:], []byte("fLaC")):
+		return sliceFLAC(r, size, offset, start, end)
+	case offset == 0 && string(magic[:4]) == "RIFF" && string(magic[8:12]) == "WAVE":
+		return sliceWAV(r, size, start, end)
+	case magic[0] == 0xff && magic[1]&0xe0 == 0xe0:
+		return sliceMP3(r, size, offset, start, end)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// newSlice returns an io.ReadSeeker which reads `header` followed by the part of
+// `r` from `from` to `to`.
+func newSlice(header []byte, r io.ReaderAt, from, to int64) io.ReadSeeker {
+	body := io.NewSectionReader(r, from, to-from)
+	return io.NewSectionReader(
+		&headerReaderAt{header: header, body: body},
+		0,
+		int64(len(header))+body.Size(),
+	)
+}
+
+// headerReaderAt is an io.ReaderAt which returns the contents of `header` and then
+// the contents of `body`.
+type headerReaderAt struct {
+	header []byte
+	body   *io.SectionReader
+}
+
+func (h *headerReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	var n int
+	if off < int64(len(h.header)) {
+		n = copy(p, h.header[off:])
+		if n == len(p) {
+			return n, nil
+		}
+		off = int64(len(h.header))
+	}
+
+	m, err := h.body.ReadAt(p[n:], off-int64(len(h.header)))
+	return n + m, err
+}
@@ -0,0 +1,263 @@
+package cue_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/cue"
+)
+
+// TestSliceWAV checks that exactly the requested samples are extracted from WAV
+// files.
+func TestSliceWAV(t *testing.T) {
+	const sampleRate = 8000
+
+	// Mono 16 bit audio which is three seconds long. Every sample has the
+	// number of the second it is in.
+	var samples []byte
+	for second := 0; second < 3; second++ {
+		for i := 0; i < sampleRate; i++ {
+			samples = binary.LittleEndian.AppendUint16(samples, uint16(second))
+		}
+	}
+
+	format := binary.LittleEndian.AppendUint16(nil, 1)
+	format = binary.LittleEndian.AppendUint16(format, 1)
+	format = binary.LittleEndian.AppendUint32(format, sampleRate)
+	format = binary.LittleEndian.AppendUint32(format, sampleRate*2)
+	format = binary.LittleEndian.AppendUint16(format, 2)
+	format = binary.LittleEndian.AppendUint16(format, 16)
+
+	file := []byte("RIFF")
+	file = binary.LittleEndian.AppendUint32(file, uint32(4+8+len(format)+8+len(samples)))
+	file = append(file, "WAVE"...)
+	file = append(file, "fmt "...)
+	file = binary.LittleEndian.AppendUint32(file, uint32(len(format)))
+	file = append(file, format...)
+	file = append(file, "data"...)
+	file = binary.LittleEndian.AppendUint32(file, uint32(len(samples)))
+	file = append(file, samples...)
+
+	slice := readSlice(t, file, time.Second, 2*time.Second)
+
+	if !bytes.HasPrefix(slice, []byte("RIFF")) {
+		t.Fatalf("slice is not a RIFF file")
+	}
+
+	dataSize := binary.LittleEndian.Uint32(slice[40:44])
+	if dataSize != sampleRate*2 {
+		t.Errorf("expected %d bytes of data but got %d", sampleRate*2, dataSize)
+	}
+
+	data := slice[44:]
+	if !bytes.Equal(data, samples[sampleRate*2:sampleRate*4]) {
+		t.Errorf("the slice does not have the samples of the second second")
+	}
+
+	// Until the end of the file.
+	slice = readSlice(t, file, 2*time.Second, 0)
+	if !bytes.Equal(slice[44:], samples[sampleRate*4:]) {
+		t.Errorf("the slice does not have the samples of the last second")
+	}
+}
+
+// TestSliceFLAC checks that frames which contain the start and end of the slice
+// are found in FLAC files. The file is big enough so that the frames search has
+// to bisect it.
+func TestSliceFLAC(t *testing.T) {
+	for _, withSeekTable := range []bool{false, true} {
+		file, frameOffsets := flacFile(600, withSeekTable)
+
+		// Every frame is 4096 samples long. So the 30th second is in frame 322
+		// and the 40th in frame 430.
+		slice := readSlice(t, file, 30*time.Second, 40*time.Second)
+
+		expected := append([]byte(nil), file[:4]...)
+		if !bytes.HasPrefix(slice, expected) {
+			t.Fatalf("the slice is not a FLAC file")
+		}
+
+		if slice[4] != 0x80 {
+			t.Errorf("expected STREAMINFO to be the only metadata block")
+		}
+
+		totalSamples := binary.BigEndian.Uint32(slice[8+14 : 8+18])
+		if totalSamples != (430-322)*4096 {
+			t.Errorf("expected %d total samples but got %d",
+				(430-322)*4096, totalSamples)
+		}
+
+		frames := slice[8+34:]
+		if !bytes.Equal(frames, file[frameOffsets[322]:frameOffsets[430]]) {
+			t.Errorf("the slice does not contain frames from 322 to 430 "+
+				"(seek table: %t)", withSeekTable)
+		}
+
+		// Until the end.
+		slice = readSlice(t, file, 30*time.Second, 0)
+		if !bytes.Equal(slice[8+34:], file[frameOffsets[322]:]) {
+			t.Errorf("the slice until the end does not contain the right frames")
+		}
+	}
+}
+
+// TestSliceMP3 checks that MP3 files are cut at frame boundaries and that the
+// Xing frame is not included.
+func TestSliceMP3(t *testing.T) {
+	// MPEG 1 Layer III with 128kbps bit rate and 44100Hz sample rate.
+	frameHeader := []byte{0xff, 0xfb, 0x90, 0x00}
+	const frameSize = 417
+
+	var file []byte
+	file = append(file, "ID3\x03\x00\x00\x00\x00\x00\x05"...)
+	file = append(file, make([]byte, 5)...)
+
+	xing := append([]byte(nil), frameHeader...)
+	xing = append(xing, make([]byte, 32)...)
+	xing = append(xing, "Xing"...)
+	xing = append(xing, make([]byte, frameSize-len(xing))...)
+	file = append(file, xing...)
+
+	audioStart := len(file)
+	for i := 0; i < 200; i++ {
+		frame := append([]byte(nil), frameHeader...)
+		frame = append(frame, bytes.Repeat([]byte{byte(i)}, frameSize-4)...)
+		file = append(file, frame...)
+	}
+	file = append(file, "TAG"...)
+	file = append(file, make([]byte, 125)...)
+
+	// Frames are 1152 samples or ~26.12ms long. So the first second is in the
+	// frame 38 and the second in 76.
+	slice := readSlice(t, file, time.Second, 2*time.Second)
+	expected := file[audioStart+38*frameSize : audioStart+76*frameSize]
+	if !bytes.Equal(slice, expected) {
+		t.Errorf("expected frames 38 to 76 but got %d bytes", len(slice))
+	}
+
+	slice = readSlice(t, file, 0, time.Second)
+	expected = file[audioStart : audioStart+38*frameSize]
+	if !bytes.Equal(slice, expected) {
+		t.Errorf("expected frames 0 to 38 without the Xing frame")
+	}
+}
+
+// TestSliceUnsupported checks that formats which could not be sliced return
+// cue.ErrUnsupportedFormat.
+func TestSliceUnsupported(t *testing.T) {
+	file := []byte("OggS\x00\x02 some ogg stream")
+	_, err := cue.Slice(bytes.NewReader(file), int64(len(file)), time.Second, 0)
+	if !errors.Is(err, cue.ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat but got %v", err)
+	}
+}
+
+func readSlice(t *testing.T, file []byte, start, end time.Duration) []byte {
+	t.Helper()
+
+	rs, err := cue.Slice(bytes.NewReader(file), int64(len(file)), start, end)
+	if err != nil {
+		t.Fatalf("slicing from %s to %s: %s", start, end, err)
+	}
+
+	// Making sure seeking works since the slices are served with range
+	// requests.
+	if _, err := rs.Seek(10, io.SeekStart); err != nil {
+		t.Fatalf("seeking: %s", err)
+	}
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("seeking: %s", err)
+	}
+
+	out, err := io.ReadAll(rs)
+	if err != nil {
+		t.Fatalf("reading slice: %s", err)
+	}
+	return out
+}
+
+// flacFile creates a FLAC stream with fixed block size of 4096 samples at 44100Hz
+// and `frames` frames. Frames do not contain real audio. It returns the file and
+// the offsets of all frames in it.
+func flacFile(frames int, withSeekTable bool) ([]byte, []int) {
+	const blockSize = 4096
+
+	info := binary.BigEndian.AppendUint16(nil, blockSize)
+	info = binary.BigEndian.AppendUint16(info, blockSize)
+	info = append(info, 0, 0, 0, 0, 0, 0)
+
+	// 20 bits sample rate, 3 bits channels-1, 5 bits bits per sample-1 and
+	// 36 bits total samples.
+	var packed uint64 = 44100<<44 | 1<<41 | 15<<36 | uint64(frames*blockSize)
+	info = binary.BigEndian.AppendUint64(info, packed)
+	info = append(info, make([]byte, 16)...)
+
+	file := []byte("fLaC")
+	lastFlag := byte(0x80)
+	if withSeekTable {
+		lastFlag = 0
+	}
+	file = append(file, lastFlag|0, 0, 0, byte(len(info)))
+	file = append(file, info...)
+
+	seekTableOffset := len(file)
+	const seekPoints = 10
+	if withSeekTable {
+		file = append(file, 0x80|3, 0, 0, seekPoints*18)
+		file = append(file, make([]byte, seekPoints*18)...)
+	}
+
+	audioStart := len(file)
+	var offsets []int
+	for i := 0; i < frames; i++ {
+		offsets = append(offsets, len(file))
+
+		header := []byte{0xff, 0xf8, 0xc9, 0x18}
+		header = append(header, utf8Number(i)...)
+		header = append(header, crc8(header))
+		file = append(file, header...)
+
+		// Some payload with a false sync code in it.
+		payload := bytes.Repeat([]byte{0x11}, 1000)
+		copy(payload[100:], []byte{0xff, 0xf8, 0x00, 0x18, 0x00, 0x00})
+		file = append(file, payload...)
+	}
+
+	if withSeekTable {
+		for p := 0; p < seekPoints; p++ {
+			frame := p * frames / seekPoints
+			point := file[seekTableOffset+4+p*18:]
+			binary.BigEndian.PutUint64(point, uint64(frame*blockSize))
+			binary.BigEndian.PutUint64(point[8:], uint64(offsets[frame]-audioStart))
+			binary.BigEndian.PutUint16(point[16:], blockSize)
+		}
+	}
+
+	return file, offsets
+}
+
+func utf8Number(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	return []byte{0xc0 | byte(n>>6), 0x80 | byte(n&0x3f)}
+}
+
+func crc8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,89 @@
+package cue
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// sliceWAV extracts a part of a RIFF WAVE file. Since it contains uncompressed
+// samples the part is exact.
+func sliceWAV(r io.ReaderAt, size int64, start, end time.Duration) (io.ReadSeeker, error) {
+	var (
+		format     []byte
+		dataOffset int64
+		dataSize   int64
+	)
+
+	for pos := int64(12); pos+8 <= size; {
+		var header [8]byte
+		if _, err := r.ReadAt(header[:], pos); err != nil {
+			return nil, fmt.Errorf("reading WAV chunk header: %w", err)
+		}
+		chunkSize := int64(binary.LittleEndian.Uint32(header[4:]))
+		pos += 8
+
+		switch string(header[:4]) {
+		case "fmt ":
+			if chunkSize < 16 || chunkSize > 1024 {
+				return nil, errors.New("malformed WAV format chunk")
+			}
+			format = make([]byte, chunkSize)
+			if _, err := r.ReadAt(format, pos); err != nil {
+				return nil, fmt.Errorf("reading WAV format chunk: %w", err)
+			}
+		case "data":
+			dataOffset = pos
+			dataSize = chunkSize
+			if dataOffset+dataSize > size {
+				dataSize = size - dataOffset
+			}
+		}
+
+		if format != nil && dataOffset != 0 {
+			break
+		}
+
+		// Chunks are aligned on even bytes.
+		pos += chunkSize + chunkSize%2
+	}
+
+	if format == nil || dataOffset == 0 {
+		return nil, errors.New("WAV file without format or data chunks")
+	}
+
+	sampleRate := int64(binary.LittleEndian.Uint32(format[4:8]))
+	blockAlign := int64(binary.LittleEndian.Uint16(format[12:14]))
+	if sampleRate == 0 || blockAlign == 0 {
+		return nil, errors.New("malformed WAV format chunk")
+	}
+
+	byteOffset := func(at time.Duration) int64 {
+		offset := at.Nanoseconds() * sampleRate / int64(time.Second) * blockAlign
+		if offset > dataSize {
+			offset = dataSize
+		}
+		return offset
+	}
+
+	from, to := byteOffset(start), dataSize
+	if end != 0 {
+		to = byteOffset(end)
+	}
+
+	header := []byte("RIFF")
+	riffSize := 4 + 8 + len(format) + len(format)%2 + 8 + int(to-from)
+	header = binary.LittleEndian.AppendUint32(header, uint32(riffSize))
+	header = append(header, "WAVEfmt "...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(format)))
+	header = append(header, format...)
+	if len(format)%2 != 0 {
+		header = append(header, 0)
+	}
+	header = append(header, "data"...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(to-from))
+
+	return newSlice(header, r, dataOffset+from, dataOffset+to), nil
+}
@@ -0,0 +1,173 @@
+/*
+Package fingerprint computes fingerprints of the content of media files. Files with
+the same audio have the same fingerprint no matter their names or where they are.
+
+Tags are left out of the fingerprint where it is possible to find them without
+parsing the whole file. This is the case for ID3 and APE tags, FLAC metadata
+blocks and everything but the audio data in WAV files. So for MP3, FLAC and WAV
+files editing the tags does not change the fingerprint. For other formats the
+whole file is fingerprinted.
+
+Only parts from the start, the middle and the end of the audio are read so that
+fingerprinting is fast even for very big files.
+*/
+package fingerprint
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// sampleSize is the size of each of the parts of the audio which are read for
+// the fingerprint.
+const sampleSize = 64 * 1024
+
+// Compute returns the fingerprint of the media file `r` with `size` bytes.
+func Compute(r io.ReaderAt, size int64) (string, error) {
+	from, to, err := audioRange(r, size)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(to-from))
+	hash.Write(length[:])
+
+	offsets := []int64{from}
+	if to-from > 3*sampleSize {
+		offsets = append(offsets, from+(to-from)/2-sampleSize/2, to-sampleSize)
+	}
+
+	n := int64(sampleSize)
+	if len(offsets) == 1 {
+		n = to - from
+	}
+
+	for _, offset := range offsets {
+		if _, err := io.Copy(hash, io.NewSectionReader(r, offset, n)); err != nil {
+			return "", fmt.Errorf("reading media file: %w", err)
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// audioRange returns where the audio in the file starts and ends as far as it
+// could be known without parsing the whole file.
+func audioRange(r io.ReaderAt, size int64) (from, to int64, err error) {
+	to = size
+
+	var magic [12]byte
+	if _, err := r.ReadAt(magic[:], 0); err != nil && !errors.Is(err, io.EOF) {
+		return 0, 0, fmt.Errorf("reading file header: %w", err)
+	}
+
+	if string(magic[:4]) == "RIFF" && string(magic[8:12]) == "WAVE" {
+		return wavDataRange(r, size)
+	}
+
+	if bytes.HasPrefix(magic[:], []byte("ID3")) {
+		// The ID3v2 tag at the beginning of the file. These could be found
+		// in front of both MP3 and FLAC files.
+		from = 10 + (int64(magic[6]&0x7f)<<21 | int64(magic[7]&0x7f)<<14 |
+			int64(magic[8]&0x7f)<<7 | int64(magic[9]&0x7f))
+		if magic[5]&0x10 != 0 {
+			// There is a footer as well.
+			from += 10
+		}
+
+		if _, err := r.ReadAt(magic[:4], from); err != nil && !errors.Is(err, io.EOF) {
+			return 0, 0, fmt.Errorf("reading file header: %w", err)
+		}
+	}
+
+	if string(magic[:4]) == "fLaC" {
+		from, err = flacAudioStart(r, from)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	var tag [3]byte
+	if to-128 >= from {
+		if _, err := r.ReadAt(tag[:], to-128); err == nil && string(tag[:]) == "TAG" {
+			// The ID3v1 tag at the end of the file.
+			to -= 128
+		}
+	}
+
+	var footer [32]byte
+	if to-32 >= from {
+		_, err := r.ReadAt(footer[:], to-32)
+		if err == nil && string(footer[:8]) == "APETAGEX" {
+			// The APEv2 tag at the end of the file. Its size includes the
+			// footer but not the header.
+			tagSize := int64(binary.LittleEndian.Uint32(footer[12:16]))
+			if binary.LittleEndian.Uint32(footer[20:24])&(1<<31) != 0 {
+				tagSize += 32
+			}
+			if to-tagSize >= from {
+				to -= tagSize
+			}
+		}
+	}
+
+	if from > to {
+		from = to
+	}
+
+	return from, to, nil
+}
+
+// flacAudioStart returns where the audio frames start in the FLAC stream which
+// starts at `offset`.
+func flacAudioStart(r io.ReaderAt, offset int64) (int64, error) {
+	pos := offset + 4
+	for {
+		var header [4]byte
+		if _, err := r.ReadAt(header[:], pos); err != nil {
+			return 0, fmt.Errorf("reading FLAC metadata block header: %w", err)
+		}
+
+		last := header[0]&0x80 != 0
+		blockSize := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+
+		pos += 4 + blockSize
+		if last {
+			return pos, nil
+		}
+	}
+}
+
+// wavDataRange returns the range of the "data" chunk of a WAV file.
+func wavDataRange(r io.ReaderAt, size int64) (from, to int64, err error) {
+	pos := int64(12)
+	for pos+8 <= size {
+		var header [8]byte
+		if _, err := r.ReadAt(header[:], pos); err != nil {
+			return 0, 0, fmt.Errorf("reading WAV chunk header: %w", err)
+		}
+
+		chunkSize := int64(binary.LittleEndian.Uint32(header[4:8]))
+		if string(header[:4]) == "data" {
+			from = pos + 8
+			to = from + chunkSize
+			if to > size {
+				to = size
+			}
+			return from, to, nil
+		}
+
+		// Chunks are padded to an even size.
+		pos += 8 + chunkSize + chunkSize%2
+	}
+
+	return 0, 0, errors.New("WAV file without data chunk")
+}
@@ -0,0 +1,147 @@
+package fingerprint_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/fingerprint"
+)
+
+// TestFingerprintIgnoresTags checks that the tags of the supported formats are
+// not part of the fingerprint while the audio is.
+func TestFingerprintIgnoresTags(t *testing.T) {
+	small := audio(1000, 1)
+	big := audio(1024*1024, 2)
+
+	tests := []struct {
+		desc   string
+		file   []byte
+		tagged []byte
+	}{
+		{
+			desc:   "mp3",
+			file:   small,
+			tagged: withID3v1(withID3v2(small, 300), "Some Song"),
+		},
+		{
+			desc:   "big mp3",
+			file:   withID3v2(big, 20),
+			tagged: withAPEv2(withID3v2(big, 4000)),
+		},
+		{
+			desc:   "flac",
+			file:   flac(small, 40),
+			tagged: flac(small, 1200),
+		},
+		{
+			desc:   "wav",
+			file:   wav(big, nil),
+			tagged: wav(big, []byte("INFOISFT some tagger")),
+		},
+	}
+
+	for _, test := range tests {
+		expected := compute(t, test.file)
+
+		if found := compute(t, test.tagged); found != expected {
+			t.Errorf("%s: tags changed the fingerprint from %s to %s",
+				test.desc, expected, found)
+		}
+
+		changed := bytes.Clone(test.tagged)
+		changed[len(changed)-200] ^= 0xff
+		if found := compute(t, changed); found == expected {
+			t.Errorf("%s: changing the audio did not change the fingerprint",
+				test.desc)
+		}
+	}
+
+	if compute(t, small) == compute(t, big) {
+		t.Errorf("different files have the same fingerprint")
+	}
+}
+
+func compute(t *testing.T, file []byte) string {
+	t.Helper()
+
+	found, err := fingerprint.Compute(bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatalf("computing fingerprint: %s", err)
+	}
+	return found
+}
+
+// audio returns `size` bytes which pass for MPEG audio frames.
+func audio(size int, seed byte) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i*7) + seed
+	}
+	data[0], data[1] = 0xff, 0xfb
+	return data
+}
+
+func withID3v2(data []byte, tagSize int) []byte {
+	tag := []byte{'I', 'D', '3', 4, 0, 0,
+		byte(tagSize >> 21 & 0x7f), byte(tagSize >> 14 & 0x7f),
+		byte(tagSize >> 7 & 0x7f), byte(tagSize & 0x7f)}
+	tag = append(tag, make([]byte, tagSize)...)
+	return append(tag, data...)
+}
+
+func withID3v1(data []byte, title string) []byte {
+	tag := make([]byte, 128)
+	copy(tag, "TAG")
+	copy(tag[3:], title)
+	return append(bytes.Clone(data), tag...)
+}
+
+func withAPEv2(data []byte) []byte {
+	items := []byte("some APE items")
+	footer := []byte("APETAGEX")
+	footer = binary.LittleEndian.AppendUint32(footer, 2000)
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(items)+32))
+	footer = binary.LittleEndian.AppendUint32(footer, 1)
+	footer = binary.LittleEndian.AppendUint32(footer, 0)
+	footer = append(footer, make([]byte, 8)...)
+
+	data = append(bytes.Clone(data), items...)
+	return append(data, footer...)
+}
+
+// flac returns a FLAC file with a STREAMINFO block and a padding block of
+// `padding` bytes.
+func flac(frames []byte, padding int) []byte {
+	file := []byte("fLaC")
+	file = append(file, 0, 0, 0, 34)
+	file = append(file, make([]byte, 34)...)
+	file = append(file, 0x80|1, byte(padding>>16), byte(padding>>8), byte(padding))
+	file = append(file, make([]byte, padding)...)
+	return append(file, frames...)
+}
+
+// wav returns a WAV file with `samples` in its data chunk. When `list` is not
+// nil a LIST chunk with it is put in front of the data.
+func wav(samples, list []byte) []byte {
+	var chunks []byte
+	chunks = append(chunks, "fmt "...)
+	chunks = binary.LittleEndian.AppendUint32(chunks, 16)
+	chunks = append(chunks, make([]byte, 16)...)
+	if list != nil {
+		chunks = append(chunks, "LIST"...)
+		chunks = binary.LittleEndian.AppendUint32(chunks, uint32(len(list)))
+		chunks = append(chunks, list...)
+		if len(list)%2 != 0 {
+			chunks = append(chunks, 0)
+		}
+	}
+	chunks = append(chunks, "data"...)
+	chunks = binary.LittleEndian.AppendUint32(chunks, uint32(len(samples)))
+	chunks = append(chunks, samples...)
+
+	file := []byte("RIFF")
+	file = binary.LittleEndian.AppendUint32(file, uint32(len(chunks)+4))
+	file = append(file, "WAVE"...)
+	return append(file, chunks...)
+}
@@ -0,0 +1,532 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+)
+
+//counterfeiter:generate . EditionsResolver
+
+// EditionsResolver is an interface for working with the editions of albums. The
+// same album could be in the library more than once. For example as FLAC and as
+// MP3 files in different directories. These are editions of the same album. Only
+// one of them is shown when browsing and searching.
+type EditionsResolver interface {
+	// GetAlbumEditions returns all editions of the album with albumID. The album
+	// itself is one of them. The main edition is always the first one.
+	GetAlbumEditions(ctx context.Context, albumID int64) ([]AlbumEdition, error)
+
+	// PreferEditions replaces every track from an album with more than one
+	// edition with the same track from the edition in the most preferred format.
+	// Duplicates are removed. Formats such as "flac" and "mp3" are in order of
+	// preference. When formats is empty the preferred formats of the library
+	// are used.
+	PreferEditions(
+		ctx context.Context,
+		tracks []SearchResult,
+		formats []string,
+	) ([]SearchResult, error)
+
+	// PreferredTrack is like PreferEditions but for a single track by its ID.
+	// It returns the ID of the track which must be used instead of it.
+	PreferredTrack(ctx context.Context, trackID int64, formats []string) (int64, error)
+}
+
+// AlbumEdition is one of the editions of an album.
+type AlbumEdition struct {
+	AlbumID int64  `json:"album_id"`
+	Name    string `json:"album"`
+
+	// Format is the format of most of the tracks in this edition.
+	Format string `json:"format"`
+
+	// Tracks is the number of tracks in this edition.
+	Tracks int64 `json:"tracks"`
+}
+
+// albumEditions are all albums in the library which have more than one edition.
+type albumEditions struct {
+	// mainOf maps album IDs to the ID of their main edition.
+	mainOf map[int64]int64
+
+	// editions are the editions for every main edition ID. The main edition is
+	// the first one.
+	editions map[int64][]AlbumEdition
+}
+
+// SetPreferredFormats sets the formats of the editions of albums which are
+// preferred when searching. Formats are in order of preference.
+func (lib *LocalLibrary) SetPreferredFormats(formats []string) {
+	lib.preferredFormats = nil
+	for _, format := range formats {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if format != "" {
+			lib.preferredFormats = append(lib.preferredFormats, format)
+		}
+	}
+}
+
+// GetAlbumEditions implements the EditionsResolver interface.
+func (lib *LocalLibrary) GetAlbumEditions(
+	ctx context.Context,
+	albumID int64,
+) ([]AlbumEdition, error) {
+	all, err := lib.getAlbumEditions(ctx, albumID)
+	if err != nil {
+		return nil, err
+	}
+
+	if mainID, ok := all.mainOf[albumID]; ok {
+		return all.editions[mainID], nil
+	}
+
+	var edition AlbumEdition
+	work := func(db *sql.DB) error {
+		var format, fsPath string
+		err := db.QueryRowContext(ctx, `
+			SELECT
+				al.id,
+				al.name,
+				COUNT(t.id),
+				IFNULL(MIN(t.format), ''),
+				IFNULL(MIN(t.fs_path), '')
+			FROM
+				albums al
+				JOIN tracks t ON t.album_id = al.id
+			WHERE
+				al.id = ?
+			GROUP BY
+				al.id
+		`, albumID).Scan(
+			&edition.AlbumID,
+			&edition.Name,
+			&edition.Tracks,
+			&format,
+			&fsPath,
+		)
+		edition.Format = trackFormat(format, fsPath)
+		return err
+	}
+	if err := lib.executeDBJobAndWait(work); errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAlbumNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return []AlbumEdition{edition}, nil
+}
+
+// PreferEditions implements the EditionsResolver interface.
+func (lib *LocalLibrary) PreferEditions(
+	ctx context.Context,
+	tracks []SearchResult,
+	formats []string,
+) ([]SearchResult, error) {
+	if len(tracks) == 0 {
+		return tracks, nil
+	}
+
+	all, err := lib.getAlbumEditions(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return lib.preferEditions(tracks, formats, all), nil
+}
+
+// preferEditions is PreferEditions with the editions of the albums of the tracks
+// in `all`.
+func (lib *LocalLibrary) preferEditions(
+	tracks []SearchResult,
+	formats []string,
+	all albumEditions,
+) []SearchResult {
+	if len(all.mainOf) == 0 {
+		return tracks
+	}
+
+	if len(formats) == 0 {
+		formats = lib.preferredFormats
+	}
+
+	var (
+		output      = make([]SearchResult, 0, len(tracks))
+		seen        = make(map[int64]struct{}, len(tracks))
+		albumTracks = make(map[int64][]SearchResult)
+	)
+
+	for _, track := range tracks {
+		mainID, ok := all.mainOf[track.AlbumID]
+		if ok {
+			preferredID := preferredEdition(all.editions[mainID], formats)
+			if preferredID != track.AlbumID {
+				if _, ok := albumTracks[preferredID]; !ok {
+					albumTracks[preferredID] = lib.GetAlbumFiles(preferredID)
+				}
+				if same, ok := sameTrack(albumTracks[preferredID], track); ok {
+					track = same
+				}
+			}
+		}
+
+		if _, ok := seen[track.ID]; ok {
+			continue
+		}
+		seen[track.ID] = struct{}{}
+		output = append(output, track)
+	}
+
+	return output
+}
+
+// PreferredTrack implements the EditionsResolver interface.
+func (lib *LocalLibrary) PreferredTrack(
+	ctx context.Context,
+	trackID int64,
+	formats []string,
+) (int64, error) {
+	track, err := lib.getTrack(trackID)
+	if err != nil {
+		return 0, err
+	}
+
+	all, err := lib.getAlbumEditions(ctx, track.AlbumID)
+	if err != nil {
+		return 0, err
+	}
+
+	found := lib.preferEditions([]SearchResult{track}, formats, all)
+	return found[0].ID, nil
+}
+
+// getAlbumEditions returns all albums which have more than one edition. When
+// albumID is not zero only the editions of this album are returned.
+func (lib *LocalLibrary) getAlbumEditions(
+	ctx context.Context,
+	albumID int64,
+) (albumEditions, error) {
+	all := albumEditions{
+		mainOf:   make(map[int64]int64),
+		editions: make(map[int64][]AlbumEdition),
+	}
+
+	// formats counts the tracks in every format for each album.
+	formats := make(map[int64]map[string]int64)
+
+	work := func(db *sql.DB) error {
+		var (
+			where = `
+				al.edition_of IS NOT NULL OR
+				al.id IN (SELECT edition_of FROM albums)
+			`
+			args []interface{}
+		)
+		if albumID != 0 {
+			var mainID int64
+			err := db.QueryRowContext(ctx, `
+				SELECT IFNULL(edition_of, id)
+				FROM albums
+				WHERE id = ?
+			`, albumID).Scan(&mainID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("querying main edition: %w", err)
+			}
+
+			where = `al.id = ? OR al.edition_of = ?`
+			args = append(args, mainID, mainID)
+		}
+
+		rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+			SELECT
+				al.id,
+				IFNULL(al.edition_of, al.id) as main_id,
+				al.name,
+				t.fs_path,
+				IFNULL(t.format, '')
+			FROM
+				albums al
+				JOIN tracks t ON t.album_id = al.id
+			WHERE
+				%s
+			ORDER BY
+				main_id, al.edition_of IS NOT NULL, al.id
+		`, where), args...)
+		if err != nil {
+			return fmt.Errorf("querying album editions: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				edition AlbumEdition
+				mainID  int64
+				fsPath  string
+				format  string
+			)
+			err := rows.Scan(
+				&edition.AlbumID, &mainID, &edition.Name, &fsPath, &format,
+			)
+			if err != nil {
+				return fmt.Errorf("scanning album edition: %w", err)
+			}
+
+			editions := all.editions[mainID]
+			if _, ok := all.mainOf[edition.AlbumID]; !ok {
+				all.mainOf[edition.AlbumID] = mainID
+				formats[edition.AlbumID] = make(map[string]int64)
+				editions = append(editions, edition)
+			}
+			editions[len(editions)-1].Tracks++
+			formats[edition.AlbumID][trackFormat(format, fsPath)]++
+			all.editions[mainID] = editions
+		}
+
+		return rows.Err()
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return all, err
+	}
+
+	for mainID, editions := range all.editions {
+		for i := range editions {
+			var most int64
+			for format, count := range formats[editions[i].AlbumID] {
+				if count > most || (count == most && format < editions[i].Format) {
+					editions[i].Format = format
+					most = count
+				}
+			}
+		}
+
+		if len(editions) < 2 {
+			// The other editions have no tracks at the moment.
+			delete(all.editions, mainID)
+			for _, edition := range editions {
+				delete(all.mainOf, edition.AlbumID)
+			}
+		}
+	}
+
+	return all, nil
+}
+
+// groupAlbumEditions finds out which albums are editions of the same album.
+// These are albums with the same name, ignoring the case, and the same artist.
+// The artist of an album is the one with most of its tracks. The edition with
+// the lowest ID which has tracks is the main one. Albums without tracks are
+// editions of nothing. It is for whole scans and clean-ups. Changes of some
+// albums use groupEditionsOf.
+func (lib *LocalLibrary) groupAlbumEditions() {
+	work := func(db *sql.DB) error {
+		return regroupEditions(db, "1 = 1", "1 = 1", nil)
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		log.Printf("Error grouping album editions: %s", err)
+	}
+}
+
+// groupEditionsOf is groupAlbumEditions only for the albums with albumIDs and
+// the albums which could be their editions. It must be called after every
+// change which adds, removes or renames albums with the albums before and after
+// the change.
+func (lib *LocalLibrary) groupEditionsOf(albumIDs []int64) {
+	seen := make(map[int64]struct{}, len(albumIDs))
+	ids := make([]interface{}, 0, len(albumIDs))
+	for _, id := range albumIDs {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > batchLimit {
+			batch = batch[:batchLimit]
+		}
+		ids = ids[len(batch):]
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(batch)), ", ")
+		groups := fmt.Sprintf(`
+			LOWER(TRIM(name)) IN (
+				SELECT LOWER(TRIM(name))
+				FROM albums
+				WHERE id IN (%s) OR edition_of IN (%s)
+			)
+		`, placeholders, placeholders)
+		albums := fmt.Sprintf("id IN (%s)", placeholders)
+		args := append(append([]interface{}(nil), batch...), batch...)
+
+		work := func(db *sql.DB) error {
+			return regroupEditions(db, groups, albums, args)
+		}
+		if err := lib.executeDBJobAndWait(work); err != nil {
+			log.Printf("Error grouping album editions: %s", err)
+			return
+		}
+	}
+}
+
+// regroupEditions groups again the editions of the albums which match the
+// `groups` condition. Albums which match the `albums` condition and have no
+// tracks are made editions of nothing. args are the arguments of the groups
+// condition followed by the ones of the albums condition.
+func regroupEditions(db *sql.DB, groups, albums string, args []interface{}) error {
+	_, err := db.Exec(fmt.Sprintf(`
+		UPDATE albums
+		SET edition_of = NULLIF(album_editions.main_id, albums.id)
+		FROM (
+			WITH grouped AS (
+				SELECT
+					id,
+					name
+				FROM
+					albums
+				WHERE
+					name != ? AND
+					%s
+			),
+			album_artists AS (
+				SELECT
+					album_id,
+					artist_id
+				FROM (
+					SELECT
+						album_id,
+						artist_id,
+						ROW_NUMBER() OVER (
+							PARTITION BY album_id
+							ORDER BY COUNT(*) DESC, artist_id
+						) AS position
+					FROM
+						tracks
+					WHERE
+						album_id IN (SELECT id FROM grouped)
+					GROUP BY
+						album_id, artist_id
+				)
+				WHERE
+					position = 1
+			)
+			SELECT
+				al.id AS album_id,
+				MIN(al.id) OVER (
+					PARTITION BY LOWER(TRIM(al.name)), aa.artist_id
+				) AS main_id
+			FROM
+				grouped al
+				JOIN album_artists aa ON aa.album_id = al.id
+		) AS album_editions
+		WHERE
+			album_editions.album_id = albums.id AND
+			IFNULL(albums.edition_of, albums.id) != album_editions.main_id
+	`, groups), append([]interface{}{UnknownLabel}, args...)...)
+	if err != nil {
+		return fmt.Errorf("grouping album editions: %w", err)
+	}
+
+	_, err = db.Exec(fmt.Sprintf(`
+		UPDATE albums
+		SET edition_of = NULL
+		WHERE
+			edition_of IS NOT NULL AND
+			%s AND
+			NOT EXISTS (SELECT 1 FROM tracks t WHERE t.album_id = albums.id)
+	`, albums), args[len(args)/2:]...)
+	if err != nil {
+		return fmt.Errorf("ungrouping albums without tracks: %w", err)
+	}
+
+	return nil
+}
+
+// albumsOfPaths returns the albums of the tracks of the files at paths and of
+// the files in them when they are directories.
+func (lib *LocalLibrary) albumsOfPaths(paths ...string) []int64 {
+	var albums []int64
+	work := func(db *sql.DB) error {
+		for _, path := range paths {
+			rows, err := db.Query(`
+				SELECT DISTINCT
+					album_id
+				FROM
+					tracks
+				WHERE
+					fs_path = ? OR
+					(fs_path >= ? AND fs_path < ?)
+			`, path, path+string(filepath.Separator),
+				path+string(filepath.Separator+1))
+			if err != nil {
+				return err
+			}
+
+			for rows.Next() {
+				var albumID int64
+				if err := rows.Scan(&albumID); err != nil {
+					rows.Close()
+					return err
+				}
+				albums = append(albums, albumID)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		log.Printf("Error getting albums of %d paths: %s", len(paths), err)
+	}
+
+	return albums
+}
+
+// preferredEdition returns the ID of the edition in the most preferred format.
+// When no edition is in any of the formats the main edition is preferred.
+func preferredEdition(editions []AlbumEdition, formats []string) int64 {
+	preferred := editions[0]
+	rank := len(formats)
+
+	for _, edition := range editions {
+		for i, format := range formats {
+			if i < rank && strings.EqualFold(format, edition.Format) {
+				preferred = edition
+				rank = i
+				break
+			}
+		}
+	}
+
+	return preferred.AlbumID
+}
+
+// sameTrack finds `track` from another edition of its album in `tracks`. Tracks
+// are the same when they are on the same disc and have the same number. Tracks
+// without numbers must have the same title.
+func sameTrack(tracks []SearchResult, track SearchResult) (SearchResult, bool) {
+	for _, other := range tracks {
+		if other.Disc != track.Disc {
+			continue
+		}
+
+		if track.TrackNumber > 0 && other.TrackNumber == track.TrackNumber {
+			return other, true
+		}
+
+		if track.TrackNumber <= 0 && strings.EqualFold(other.Title, track.Title) {
+			return other, true
+		}
+	}
+
+	return SearchResult{}, false
+}
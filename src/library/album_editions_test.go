@@ -0,0 +1,199 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestAlbumEditions checks that the same album in different directories is
+// grouped into editions and that the preferred edition is used when searching.
+func TestAlbumEditions(t *testing.T) {
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	albums := []struct {
+		dir    string
+		ext    string
+		artist string
+		name   string
+	}{
+		{"/music/flac/Album", "flac", "Artist", "Album"},
+		{"/music/mp3/Album", "mp3", "Artist", "album"},
+		{"/music/covers/Album", "mp3", "Other Artist", "Album"},
+	}
+	for _, album := range albums {
+		for track := 1; track <= 2; track++ {
+			media := MockMedia{
+				artist: album.artist,
+				album:  album.name,
+				title:  fmt.Sprintf("Song %d", track),
+				track:  track,
+				length: time.Minute,
+			}
+			fsPath := filepath.Join(album.dir, fmt.Sprintf("%d.%s", track, album.ext))
+			if err := lib.insertMediaIntoDatabase(&media, fsPath); err != nil {
+				t.Fatalf("inserting %s: %s", fsPath, err)
+			}
+		}
+	}
+
+	lib.groupAlbumEditions()
+
+	flacID, _ := lib.GetAlbumID("Album", "/music/flac/Album")
+	mp3ID, _ := lib.GetAlbumID("album", "/music/mp3/Album")
+	coversID, _ := lib.GetAlbumID("Album", "/music/covers/Album")
+
+	assertAlbums := func(desc string, found []SearchResult, expected ...int64) {
+		t.Helper()
+
+		if len(found) != len(expected)*2 {
+			t.Fatalf("%s: expected %d tracks but found %d",
+				desc, len(expected)*2, len(found))
+		}
+
+		albums := make(map[int64]int)
+		for _, track := range found {
+			albums[track.AlbumID]++
+		}
+		for _, albumID := range expected {
+			if albums[albumID] != 2 {
+				t.Errorf("%s: expected both tracks of album %d but found %d",
+					desc, albumID, albums[albumID])
+			}
+		}
+	}
+
+	assertAlbums("searching", lib.Search("Song"), flacID, coversID)
+
+	lib.SetPreferredFormats([]string{" MP3 ", "flac"})
+	assertAlbums("searching with preferred mp3", lib.Search("Song"), mp3ID, coversID)
+
+	found, err := lib.PreferEditions(ctx, lib.Search("Song"), []string{"ogg", "flac"})
+	if err != nil {
+		t.Fatalf("preferring editions: %s", err)
+	}
+	assertAlbums("preferring flac", found, flacID, coversID)
+
+	mp3Tracks := lib.GetAlbumFiles(mp3ID)
+	flacTracks := lib.GetAlbumFiles(flacID)
+	preferredID, err := lib.PreferredTrack(ctx, mp3Tracks[1].ID, []string{"flac"})
+	if err != nil {
+		t.Fatalf("getting preferred track: %s", err)
+	}
+	if preferredID != flacTracks[1].ID {
+		t.Errorf("expected track %d to be preferred but got %d",
+			flacTracks[1].ID, preferredID)
+	}
+
+	editions, err := lib.GetAlbumEditions(ctx, mp3ID)
+	if err != nil {
+		t.Fatalf("getting album editions: %s", err)
+	}
+	expected := []AlbumEdition{
+		{AlbumID: flacID, Name: "Album", Format: "flac", Tracks: 2},
+		{AlbumID: mp3ID, Name: "album", Format: "mp3", Tracks: 2},
+	}
+	if !reflect.DeepEqual(editions, expected) {
+		t.Errorf("expected editions %+v but got %+v", expected, editions)
+	}
+
+	editions, err = lib.GetAlbumEditions(ctx, coversID)
+	if err != nil {
+		t.Fatalf("getting album editions: %s", err)
+	}
+	if len(editions) != 1 || editions[0].AlbumID != coversID {
+		t.Errorf("expected a single edition for album %d but got %+v",
+			coversID, editions)
+	}
+
+	_, err = lib.GetAlbumEditions(ctx, 9999)
+	if !errors.Is(err, ErrAlbumNotFound) {
+		t.Errorf("expected ErrAlbumNotFound but got %v", err)
+	}
+
+	browsed, count := lib.BrowseAlbums(BrowseArgs{PerPage: 10, OrderBy: OrderByID})
+	if count != 2 || len(browsed) != 2 {
+		t.Fatalf("expected two albums when browsing but got %d (count %d)",
+			len(browsed), count)
+	}
+	if browsed[0].ID != flacID || browsed[0].Editions != 1 || browsed[1].Editions != 0 {
+		t.Errorf("unexpected albums when browsing: %+v", browsed)
+	}
+
+	// Once the files of the main edition are removed by the watcher another
+	// edition becomes the main one.
+	lib.ScanConfig.WatchQuietPeriod = 10 * time.Millisecond
+	lib.dirs.Lock()
+	lib.paths = append(lib.paths, filepath.FromSlash("/music"))
+	lib.dirs.Unlock()
+
+	lib.queueWatchEvent(watchEvent{
+		Name: filepath.FromSlash("/music/flac/Album"),
+		Op:   watchDelete,
+	})
+
+	timeout := time.After(5 * time.Second)
+	for lib.WatchQueueMetrics().Processed < 1 {
+		select {
+		case <-timeout:
+			t.Fatalf("the removal of the main edition was not processed")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	browsed, count = lib.BrowseAlbums(BrowseArgs{PerPage: 10, OrderBy: OrderByID})
+	if count != 2 || len(browsed) != 2 {
+		t.Fatalf("expected two albums after removing the main edition but got %d "+
+			"(count %d)", len(browsed), count)
+	}
+	if browsed[0].ID != mp3ID || browsed[0].Editions != 0 {
+		t.Errorf("expected album %d to be the main edition but got %+v",
+			mp3ID, browsed)
+	}
+
+	// Only the editions of the changed albums are grouped again.
+	for _, fsPath := range []string{
+		"/music/flac/Second/1.flac",
+		"/music/mp3/Second/1.mp3",
+		"/music/flac/Third/1.flac",
+		"/music/mp3/Third/1.mp3",
+	} {
+		media := MockMedia{
+			artist: "Artist",
+			album:  filepath.Base(filepath.Dir(fsPath)),
+			title:  "Song",
+			track:  1,
+			length: time.Minute,
+		}
+		if err := lib.insertMediaIntoDatabase(&media, fsPath); err != nil {
+			t.Fatalf("inserting %s: %s", fsPath, err)
+		}
+	}
+
+	secondID, _ := lib.GetAlbumID("Second", "/music/mp3/Second")
+	lib.groupEditionsOf([]int64{secondID})
+
+	if found := lib.Search("Second"); len(found) != 1 {
+		t.Errorf("expected the editions of the changed album to be grouped "+
+			"but found %d tracks", len(found))
+	}
+	if found := lib.Search("Third"); len(found) != 2 {
+		t.Errorf("expected the editions of other albums to stay ungrouped "+
+			"but found %d tracks", len(found))
+	}
+}
@@ -0,0 +1,162 @@
+package library
+
+import (
+	"archive/zip"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/ironsmile/euterpe/src/ziparchive"
+)
+
+// archivesInAllLibraries is the value in the configuration for adding the media
+// files in archives in all library directories.
+const archivesInAllLibraries = "*"
+
+// scansArchives returns true when the media files inside ZIP archives are added
+// to the library for the library directory in which path is.
+func (lib *LocalLibrary) scansArchives(path string) bool {
+	root, ok := lib.libraryRootOf(filepath.Clean(path))
+	if !ok {
+		return false
+	}
+
+	for _, dir := range lib.ScanConfig.Archives {
+		if dir == archivesInAllLibraries || filepath.Clean(dir) == root {
+			return true
+		}
+	}
+	return false
+}
+
+// AddArchive adds to the library the media files inside the ZIP archive at path
+// which are not in it already. Tracks of files which are no longer in the archive
+// are removed.
+func (lib *LocalLibrary) AddArchive(path string) error {
+	return lib.addArchive(path, false)
+}
+
+// updateArchive reads again all media files inside the ZIP archive at path.
+func (lib *LocalLibrary) updateArchive(path string) error {
+	return lib.addArchive(path, true)
+}
+
+// addArchive adds the media files inside the ZIP archive at path to the library.
+// Files which are in the library already have their tags read again only when
+// refresh is set.
+func (lib *LocalLibrary) addArchive(path string, refresh bool) error {
+	path = filepath.Clean(path)
+
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("opening archive: %w", err)
+	}
+
+	var mediaPaths []string
+	for _, zipFile := range reader.File {
+		if zipFile.FileInfo().IsDir() {
+			continue
+		}
+
+		mediaPath := ziparchive.Join(path, zipFile.Name)
+		if !insideRoots(mediaPath, []string{path}) {
+			// Names such as "../song.mp3" would be outside of the archive.
+			continue
+		}
+
+		if !lib.isSupportedFormat(mediaPath) || lib.isExcluded(mediaPath, false) {
+			continue
+		}
+		mediaPaths = append(mediaPaths, mediaPath)
+	}
+	reader.Close()
+
+	var seen []string
+	for _, mediaPath := range mediaPaths {
+		if !refresh && lib.MediaExistsInLibrary(mediaPath) {
+			seen = append(seen, mediaPath)
+			continue
+		}
+
+		if err := lib.updateMedia(mediaPath); err != nil {
+			log.Printf("Error adding `%s`: %s\n", mediaPath, err)
+		}
+	}
+
+	lib.markSeen(seen)
+
+	return lib.removeStaleArchiveTracks(path, mediaPaths)
+}
+
+// removeStaleArchiveTracks removes the tracks from the archive at path which are
+// not for any of the mediaPaths.
+func (lib *LocalLibrary) removeStaleArchiveTracks(path string, mediaPaths []string) error {
+	keep := make(map[string]struct{}, len(mediaPaths))
+	for _, mediaPath := range mediaPaths {
+		keep[mediaPath] = struct{}{}
+	}
+
+	var stale []string
+	work := func(db *sql.DB) error {
+		rows, err := db.Query(`
+			SELECT
+				fs_path
+			FROM
+				tracks
+			WHERE
+				fs_path >= ? AND
+				fs_path < ?
+		`, path+string(filepath.Separator), path+string(filepath.Separator+1))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var fsPath string
+			if err := rows.Scan(&fsPath); err != nil {
+				return err
+			}
+			if _, ok := keep[fsPath]; !ok {
+				stale = append(stale, fsPath)
+			}
+		}
+		return rows.Err()
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return fmt.Errorf("finding stale tracks of archive %s: %w", path, err)
+	}
+
+	for _, fsPath := range stale {
+		lib.removeFile(fsPath)
+	}
+
+	return nil
+}
+
+// inArchive returns true when the file at path is inside a ZIP archive.
+func inArchive(path string) bool {
+	_, _, ok := ziparchive.Split(path)
+	return ok
+}
+
+// withLocalFile calls fn with the path of a file on the file system with the
+// content of the file at path. For files inside archives this is a temporary
+// file which is removed after fn returns. It is for reading files with libraries
+// and programs which know nothing about archives.
+func withLocalFile(path string, fn func(localPath string) error) error {
+	archivePath, name, ok := ziparchive.Split(path)
+	if !ok {
+		return fn(path)
+	}
+
+	localPath, err := ziparchive.Extract(archivePath, name)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(localPath)
+
+	return fn(localPath)
+}
@@ -0,0 +1,182 @@
+package library
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ironsmile/euterpe/src/helpers"
+	"github.com/ironsmile/euterpe/src/ziparchive"
+)
+
+// TestArchives checks that media files in ZIP archives are added to the library
+// when this is enabled, that their artwork is found in the archive and that
+// their tracks are removed once they are no longer in it.
+func TestArchives(t *testing.T) {
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+	lib.DisableWatching()
+
+	projRoot, err := helpers.ProjectRoot()
+	if err != nil {
+		t.Fatalf("Was not able to find test_files directory: %s", err)
+	}
+	testMp3, err := os.ReadFile(
+		filepath.Join(projRoot, "test_files", "more_mp3s", "test_file_added.mp3"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cover := []byte("not really a JPEG")
+
+	root := t.TempDir()
+	archivePath := filepath.Join(root, "Artist - Album.zip")
+	writeTestArchive(t, archivePath, map[string][]byte{
+		"01 Song.mp3": testMp3,
+		"cover.jpg":   cover,
+		"notes.txt":   []byte("liner notes"),
+	})
+
+	lib.AddLibraryPath(root)
+	lib.Scan()
+
+	archivedMp3 := ziparchive.Join(archivePath, "01 Song.mp3")
+	if lib.MediaExistsInLibrary(archivedMp3) {
+		t.Fatalf("archives were scanned without being enabled")
+	}
+
+	lib.ScanConfig.Archives = []string{"*"}
+	lib.Scan()
+
+	if !lib.MediaExistsInLibrary(archivedMp3) {
+		t.Fatalf("the file in the archive was not added to the library")
+	}
+
+	results := lib.Search("")
+	if len(results) != 1 {
+		t.Fatalf("expected one track in the library but found %d", len(results))
+	}
+	if results[0].Format != "mp3" {
+		t.Errorf("expected format mp3 but got %s", results[0].Format)
+	}
+
+	// Files in archives are fingerprinted from the archives themselves.
+	libFS := lib.fs
+	lib.fs = fstest.MapFS{}
+	if _, err := lib.computeFingerprint(archivedMp3); err != nil {
+		t.Errorf("fingerprinting the file in the archive: %s", err)
+	}
+	lib.fs = libFS
+
+	albumPath, err := lib.GetAlbumFSPathByID(results[0].AlbumID)
+	if err != nil {
+		t.Fatalf("getting album path: %s", err)
+	}
+	if albumPath != archivePath {
+		t.Errorf("expected the archive %s to be the album directory but got %s",
+			archivePath, albumPath)
+	}
+
+	artwork, err := lib.albumArtworkFromFS(ctx, results[0].AlbumID)
+	if err != nil {
+		t.Fatalf("finding artwork in the archive: %s", err)
+	}
+	found, _ := io.ReadAll(artwork)
+	artwork.Close()
+	if string(found) != string(cover) {
+		t.Errorf("expected the cover from the archive but got %q", found)
+	}
+
+	// Files removed from the archive are removed from the library.
+	writeTestArchive(t, archivePath, map[string][]byte{
+		"cover.jpg": cover,
+	})
+	if err := lib.updateArchive(archivePath); err != nil {
+		t.Fatalf("updating archive: %s", err)
+	}
+	if lib.MediaExistsInLibrary(archivedMp3) {
+		t.Errorf("the file removed from the archive is still in the library")
+	}
+
+	// Tracks from archives whose names only look alike are kept. "_" would match
+	// any character in a LIKE pattern.
+	underscored := filepath.Join(root, "Album_1.zip")
+	similar := filepath.Join(root, "AlbumX1.zip")
+	for _, archive := range []string{underscored, similar} {
+		writeTestArchive(t, archive, map[string][]byte{
+			"01 Song.mp3": testMp3,
+		})
+		if err := lib.AddArchive(archive); err != nil {
+			t.Fatalf("adding archive: %s", err)
+		}
+	}
+	writeTestArchive(t, underscored, map[string][]byte{
+		"cover.jpg": cover,
+	})
+	if err := lib.updateArchive(underscored); err != nil {
+		t.Fatalf("updating archive: %s", err)
+	}
+	if lib.MediaExistsInLibrary(ziparchive.Join(underscored, "01 Song.mp3")) {
+		t.Errorf("the file removed from %s is still in the library", underscored)
+	}
+	if !lib.MediaExistsInLibrary(ziparchive.Join(similar, "01 Song.mp3")) {
+		t.Errorf("the file in %s was removed with the other archive", similar)
+	}
+
+	// Tracks in archives are cleaned up once archives are no longer scanned.
+	writeTestArchive(t, archivePath, map[string][]byte{
+		"01 Song.mp3": testMp3,
+	})
+	if err := lib.AddArchive(archivePath); err != nil {
+		t.Fatalf("adding archive: %s", err)
+	}
+	if !lib.MediaExistsInLibrary(archivedMp3) {
+		t.Fatalf("the file in the archive was not added again")
+	}
+
+	lib.ScanConfig.Archives = nil
+	lib.cleanupTracks()
+
+	if lib.MediaExistsInLibrary(archivedMp3) {
+		t.Errorf("the file in the archive was not removed by the clean-up")
+	}
+}
+
+// writeTestArchive writes a ZIP archive at archivePath with the files.
+func writeTestArchive(t *testing.T, archivePath string, files map[string][]byte) {
+	t.Helper()
+
+	out, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	zw := zip.NewWriter(out)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
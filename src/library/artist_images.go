@@ -19,7 +19,7 @@ import (
 // by calling Close().
 //
 // When image for an artist is found on the internet then it will be saved in the
-// database for later retrieval.
+// image store for later retrieval. The returned reader implements StoredImage.
 func (lib *LocalLibrary) FindAndSaveArtistImage(
 	ctx context.Context,
 	artistID int64,
@@ -111,14 +111,21 @@ func (lib *LocalLibrary) artistImageFromDB(
 	artistID int64,
 	size ImageSize,
 ) (io.ReadCloser, ImageSize, error) {
-	buff, unixTime, err := lib.artistImageFromDBForSize(ctx, artistID, size)
+	hash, unixTime, err := lib.artistImageFromDBForSize(ctx, artistID, size)
 	if err != nil {
 		return nil, size, err
 	}
 
-	if len(buff) >= 1 {
+	if hash != "" {
 		// The image with the desires size has been found!
-		return newBytesReadCloser(buff), size, nil
+		img, err := lib.readStoredImage(hash, unixTime)
+		if err != ErrArtworkNotFound {
+			return img, size, err
+		}
+
+		// The image is missing from the store. Act as if it has never been
+		// found so that it is searched for again.
+		unixTime = 0
 	}
 
 	selectNotFound := func(lastChanged int64) (io.ReadCloser, ImageSize, error) {
@@ -138,28 +145,38 @@ func (lib *LocalLibrary) artistImageFromDB(
 
 	// No image of the desired size was found. Let us try and see if the original image
 	// is in the database and use it to generate the desired size.
-	buff, unixTime, err = lib.artistImageFromDBForSize(ctx, artistID, OriginalImage)
+	hash, unixTime, err = lib.artistImageFromDBForSize(ctx, artistID, OriginalImage)
 	if err != nil {
 		return nil, size, err
 	}
 
-	if len(buff) < 1 {
+	if hash == "" {
 		return selectNotFound(unixTime)
 	}
 
-	return newBytesReadCloser(buff), OriginalImage, nil
+	img, err := lib.readStoredImage(hash, unixTime)
+	if err == ErrArtworkNotFound {
+		return selectNotFound(0)
+	} else if err != nil {
+		return nil, size, err
+	}
+
+	return img, OriginalImage, nil
 }
 
+// artistImageFromDBForSize returns the hash of the stored artist image with `size`
+// and the last time it was changed. The hash is an empty string when there is no
+// such image.
 func (lib *LocalLibrary) artistImageFromDBForSize(
 	ctx context.Context,
 	artistID int64,
 	size ImageSize,
-) ([]byte, int64, error) {
+) (string, int64, error) {
 
 	var (
-		buff          []byte
+		hash          sql.NullString
 		unixTime      int64
-		blobColumn    = "image"
+		hashColumn    = "image_hash"
 		imageSQLQuery = `
 			SELECT
 				%s,
@@ -171,11 +188,11 @@ func (lib *LocalLibrary) artistImageFromDBForSize(
 		`
 	)
 	if size == SmallImage {
-		blobColumn = "image_small"
+		hashColumn = "image_small_hash"
 	}
 
 	work := func(db *sql.DB) error {
-		smt, err := db.PrepareContext(ctx, fmt.Sprintf(imageSQLQuery, blobColumn))
+		smt, err := db.PrepareContext(ctx, fmt.Sprintf(imageSQLQuery, hashColumn))
 
 		if err != nil {
 			log.Printf("could not prepare album artwork sql statement: %s", err)
@@ -183,7 +200,7 @@ func (lib *LocalLibrary) artistImageFromDBForSize(
 		}
 		defer smt.Close()
 
-		err = smt.QueryRowContext(ctx, artistID).Scan(&buff, &unixTime)
+		err = smt.QueryRowContext(ctx, artistID).Scan(&hash, &unixTime)
 		if err == sql.ErrNoRows {
 			return ErrArtworkNotFound
 		} else if err != nil {
@@ -194,10 +211,10 @@ func (lib *LocalLibrary) artistImageFromDBForSize(
 		return nil
 	}
 	if err := lib.executeDBJobAndWait(work); err != nil {
-		return nil, 0, err
+		return "", 0, err
 	}
 
-	return buff, unixTime, nil
+	return hash.String, unixTime, nil
 }
 
 func (lib *LocalLibrary) artistImageFromInternet(
@@ -208,12 +225,16 @@ func (lib *LocalLibrary) artistImageFromInternet(
 		return nil, ErrArtworkNotFound
 	}
 
-	var artistName string
+	var (
+		artistName string
+		artistMBID sql.NullString
+	)
 
 	work := func(db *sql.DB) error {
 		row, err := db.QueryContext(ctx, `
 			SELECT
-				name
+				name,
+				mbid
 			FROM
 				artists
 			WHERE
@@ -232,7 +253,7 @@ func (lib *LocalLibrary) artistImageFromInternet(
 			return ErrArtistNotFound
 		}
 
-		if err := row.Scan(&artistName); err != nil {
+		if err := row.Scan(&artistName, &artistMBID); err != nil {
 			return fmt.Errorf("scanning db result: %s", err)
 		}
 
@@ -242,7 +263,11 @@ func (lib *LocalLibrary) artistImageFromInternet(
 		return nil, err
 	}
 
-	cover, err := lib.artFinder.GetArtistImage(ctx, artistName)
+	cover, mbID, err := lib.artFinder.GetArtistImage(
+		ctx,
+		artistName,
+		artistMBID.String,
+	)
 	if errors.Is(err, art.ErrImageNotFound) {
 		return nil, ErrArtworkNotFound
 	}
@@ -250,11 +275,17 @@ func (lib *LocalLibrary) artistImageFromInternet(
 		return nil, err
 	}
 
+	if mbID != "" && mbID != artistMBID.String {
+		if err := lib.saveMBID("artists", artistID, mbID); err != nil {
+			log.Printf("Saving MusicBrainz ID for artist %d: %s\n", artistID, err)
+		}
+	}
+
 	return newBytesReadCloser(cover), nil
 }
 
 func (lib *LocalLibrary) storeArtistImage(
-	albumID int64,
+	artistID int64,
 	image io.ReadCloser,
 	size ImageSize,
 ) (io.ReadCloser, ImageSize, error) {
@@ -265,22 +296,28 @@ func (lib *LocalLibrary) storeArtistImage(
 		return nil, size, err
 	}
 
-	imageColumn := "image"
+	hash, err := lib.images.put(buff)
+	if err != nil {
+		return nil, size, fmt.Errorf("storing artist image: %w", err)
+	}
+
+	hashColumn := "image_hash"
 	if size == SmallImage {
-		imageColumn = "image_small"
+		hashColumn = "image_small_hash"
 	}
 
 	storeQuery := fmt.Sprintf(`
 		INSERT INTO
 			artists_images (artist_id, %s, updated_at)
-		VALUES				
+		VALUES
 			($1, $2, $3)
 		ON CONFLICT (artist_id) DO
 		UPDATE SET
 			%s = $2,
 			updated_at = $3
-	`, imageColumn, imageColumn)
+	`, hashColumn, hashColumn)
 
+	updatedAt := time.Now()
 	work := func(db *sql.DB) error {
 		stmt, err := db.Prepare(storeQuery)
 
@@ -290,7 +327,7 @@ func (lib *LocalLibrary) storeArtistImage(
 
 		defer stmt.Close()
 
-		_, err = stmt.Exec(albumID, buff, time.Now().Unix())
+		_, err = stmt.Exec(artistID, hash, updatedAt.Unix())
 
 		if err != nil {
 			return err
@@ -303,7 +340,11 @@ func (lib *LocalLibrary) storeArtistImage(
 		return nil, size, err
 	}
 
-	return newBytesReadCloser(buff), size, nil
+	if err := lib.saveImagePlaceholder("artists_images", artistID, buff); err != nil {
+		log.Printf("Error saving artist %d image placeholder: %s", artistID, err)
+	}
+
+	return newStoredImage(buff, hash, updatedAt), size, nil
 }
 
 func (lib *LocalLibrary) saveArtistImageNotFound(artistID int64) error {
@@ -341,9 +382,9 @@ func (lib *LocalLibrary) saveArtistImageNotFound(artistID int64) error {
 
 // SaveArtistImage implements the ArtistImageManager interface for the local library.
 //
-// It saves the image in `r` in the database. It will read up to 5MB of data from
+// It saves the image in `r` in the image store. It will read up to 5MB of data from
 // `r` and if this limit is reached, the image is considered too big and will not
-// be saved in the db.
+// be saved.
 func (lib *LocalLibrary) SaveArtistImage(
 	ctx context.Context,
 	artistID int64,
@@ -373,10 +414,15 @@ func (lib *LocalLibrary) SaveArtistImage(
 		return NewArtworkError("uploaded artist image is empty")
 	}
 
+	hash, err := lib.images.put(buff)
+	if err != nil {
+		return fmt.Errorf("storing artist %d image: %w", artistID, err)
+	}
+
 	work := func(db *sql.DB) error {
 		stmt, err := db.Prepare(`
 			INSERT OR REPLACE INTO
-				artists_images (artist_id, image, updated_at)
+				artists_images (artist_id, image_hash, updated_at)
 			VALUES
 				(?, ?, ?)
 		`)
@@ -386,17 +432,22 @@ func (lib *LocalLibrary) SaveArtistImage(
 
 		defer stmt.Close()
 
-		_, err = stmt.Exec(artistID, buff, time.Now().Unix())
+		_, err = stmt.Exec(artistID, hash, time.Now().Unix())
 		return err
 	}
 	if err := lib.executeDBJobAndWait(work); err != nil {
 		return err
 	}
 
+	if err := lib.saveImagePlaceholder("artists_images", artistID, buff); err != nil {
+		log.Printf("Error saving artist %d image placeholder: %s", artistID, err)
+	}
+
 	return nil
 }
 
-// RemoveArtistImage removes particular artist image from the database.
+// RemoveArtistImage removes particular artist image from the database. The image
+// itself is removed from the image store during the next clean-up.
 func (lib *LocalLibrary) RemoveArtistImage(ctx context.Context, artistID int64) error {
 	return lib.saveArtistImageNotFound(artistID)
 }
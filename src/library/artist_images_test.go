@@ -44,15 +44,15 @@ func TestLocalLibraryFindAndSaveArtistImage(t *testing.T) {
 	defer func() { _ = lib.Truncate() }()
 
 	fakeAF := &artfakes.FakeFinder{
-		GetArtistImageStub: func(_ context.Context, name string) ([]byte, error) {
+		GetArtistImageStub: func(_ context.Context, name, _ string) ([]byte, string, error) {
 			if name != mediaFile.artist {
-				return nil, art.ErrImageNotFound
+				return nil, "", art.ErrImageNotFound
 			}
 
 			retSlice := make([]byte, len(bigImage))
 			copy(retSlice, bigImage)
 
-			return retSlice, nil
+			return retSlice, "", nil
 		},
 	}
 	lib.SetArtFinder(fakeAF)
@@ -155,8 +155,8 @@ func TestLocalLibraryFindAndSaveArtistImage(t *testing.T) {
 	// Insert a new artist and make sure it caches the "not-found" response at least
 	// for a while.
 	alwaysNotFoundFinder := &artfakes.FakeFinder{
-		GetArtistImageStub: func(_ context.Context, _ string) ([]byte, error) {
-			return nil, art.ErrImageNotFound
+		GetArtistImageStub: func(_ context.Context, _, _ string) ([]byte, string, error) {
+			return nil, "", art.ErrImageNotFound
 		},
 	}
 	lib.SetArtFinder(alwaysNotFoundFinder)
@@ -0,0 +1,295 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/art"
+)
+
+// ErrArtistInfoNotFound is returned when no information could be found for
+// particular artist.
+var ErrArtistInfoNotFound = errors.New("artist info not found")
+
+// artistInfoCacheTTL is the time for which found artist information is used
+// before it is searched for again.
+var artistInfoCacheTTL = 30 * 24 * time.Hour
+
+//counterfeiter:generate . ArtistInfoManager
+
+// ArtistInfoManager is an interface for getting information about artists such
+// as their biographies and links.
+type ArtistInfoManager interface {
+	// GetArtistInfo returns the information for a particular artist by its ID.
+	GetArtistInfo(ctx context.Context, artistID int64) (ArtistInfo, error)
+}
+
+// ArtistInfo is the information about an artist found in external databases
+// such as MusicBrainz and Discogs.
+type ArtistInfo struct {
+	ArtistID int64 `json:"artist_id"`
+
+	// MBID is the MusicBrainz ID of the artist.
+	MBID string `json:"mbid"`
+
+	// Name is the name of the artist in the external databases. It may be
+	// different from the one in the library.
+	Name string `json:"name"`
+
+	// Type is the kind of artist. Such as "Person" or "Group".
+	Type string `json:"type,omitempty"`
+
+	// Country is the ISO 3166-1 code of the country the artist is from.
+	Country string `json:"country,omitempty"`
+
+	// BeginDate and EndDate are the dates the artist was active in. They may
+	// be partial such as "1975" or "1975-12".
+	BeginDate string `json:"begin_date,omitempty"`
+	EndDate   string `json:"end_date,omitempty"`
+
+	// Ended is true when the artist is no longer active.
+	Ended bool `json:"ended"`
+
+	Biography string `json:"biography,omitempty"`
+
+	Links   []ArtistLink    `json:"links"`
+	Related []RelatedArtist `json:"related_artists"`
+
+	// UpdatedAt is the unix timestamp at which the information was found.
+	UpdatedAt int64 `json:"updated_at"`
+}
+
+// ArtistLink is a link to a web page about an artist.
+type ArtistLink struct {
+	// Type is the kind of web page. For example "official homepage".
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// RelatedArtist is an artist related to another one. Such as a member of a band.
+type RelatedArtist struct {
+	MBID string `json:"mbid"`
+	Name string `json:"name"`
+
+	// Relation is the type of the relation. Such as "member of band".
+	Relation string `json:"relation"`
+}
+
+// SetInfoFinder binds a particular art.InfoFinder to this library. It will be used
+// for getting information about artists.
+func (lib *LocalLibrary) SetInfoFinder(inf art.InfoFinder) {
+	lib.infoFinder = inf
+}
+
+// GetArtistInfo implements the ArtistInfoManager interface. Found information is
+// cached in the database for artistInfoCacheTTL. When nothing is found then no
+// new search will be made for notFoundCacheTTL. Stale information is returned
+// when it could not be refreshed.
+func (lib *LocalLibrary) GetArtistInfo(
+	ctx context.Context,
+	artistID int64,
+) (ArtistInfo, error) {
+	var (
+		artistName string
+		mbID       sql.NullString
+		infoJSON   sql.NullString
+		updatedAt  sql.NullInt64
+	)
+
+	work := func(db *sql.DB) error {
+		err := db.QueryRowContext(ctx, `
+			SELECT
+				ar.name,
+				ar.mbid,
+				ai.info,
+				ai.updated_at
+			FROM
+				artists ar
+				LEFT JOIN
+					artists_info ai ON ai.artist_id = ar.id
+			WHERE
+				ar.id = ?
+		`, artistID).Scan(&artistName, &mbID, &infoJSON, &updatedAt)
+		if err == sql.ErrNoRows {
+			return ErrArtistNotFound
+		}
+		return err
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return ArtistInfo{}, err
+	}
+
+	var (
+		cached    ArtistInfo
+		hasCached bool
+	)
+	if infoJSON.Valid {
+		if err := json.Unmarshal([]byte(infoJSON.String), &cached); err != nil {
+			log.Printf("Malformed cached info for artist %d: %s\n", artistID, err)
+		} else {
+			hasCached = true
+			cached.ArtistID = artistID
+			cached.UpdatedAt = updatedAt.Int64
+		}
+	}
+
+	lastUpdate := time.Unix(updatedAt.Int64, 0)
+	if hasCached && time.Now().Before(lastUpdate.Add(artistInfoCacheTTL)) {
+		return cached, nil
+	}
+	if updatedAt.Valid && !infoJSON.Valid &&
+		time.Now().Before(lastUpdate.Add(notFoundCacheTTL)) {
+		return ArtistInfo{}, ErrArtistInfoNotFound
+	}
+
+	if lib.infoFinder == nil {
+		if hasCached {
+			return cached, nil
+		}
+		return ArtistInfo{}, ErrArtistInfoNotFound
+	}
+
+	if err := lib.aquireArtworkSem(ctx); err != nil {
+		return ArtistInfo{}, err
+	}
+	defer lib.releaseArtworkSem()
+
+	found, err := lib.infoFinder.GetArtistInfo(ctx, artistName, mbID.String)
+	if errors.Is(err, art.ErrInfoNotFound) {
+		if hasCached {
+			// Keep the old information but do not search again for a while.
+			return cached, lib.touchArtistInfo(artistID)
+		}
+		if err := lib.saveArtistInfo(artistID, nil); err != nil {
+			return ArtistInfo{}, err
+		}
+		return ArtistInfo{}, ErrArtistInfoNotFound
+	} else if err != nil {
+		if hasCached {
+			log.Printf("Refreshing info for artist %d: %s\n", artistID, err)
+			return cached, nil
+		}
+		return ArtistInfo{}, err
+	}
+
+	info := artistInfoFromArt(found)
+	if err := lib.saveArtistInfo(artistID, &info); err != nil {
+		return ArtistInfo{}, err
+	}
+
+	if info.MBID != "" && info.MBID != mbID.String {
+		if err := lib.saveMBID("artists", artistID, info.MBID); err != nil {
+			log.Printf("Saving MusicBrainz ID for artist %d: %s\n", artistID, err)
+		}
+	}
+
+	info.ArtistID = artistID
+	info.UpdatedAt = time.Now().Unix()
+
+	return info, nil
+}
+
+// saveArtistInfo stores `info` as the current information for particular artist.
+// A nil `info` means that no information was found.
+func (lib *LocalLibrary) saveArtistInfo(artistID int64, info *ArtistInfo) error {
+	var infoJSON sql.NullString
+	if info != nil {
+		encoded, err := json.Marshal(info)
+		if err != nil {
+			return fmt.Errorf("encoding artist info: %w", err)
+		}
+		infoJSON = sql.NullString{String: string(encoded), Valid: true}
+	}
+
+	work := func(db *sql.DB) error {
+		_, err := db.Exec(`
+			INSERT INTO
+				artists_info (artist_id, info, updated_at)
+			VALUES
+				($1, $2, $3)
+			ON CONFLICT (artist_id) DO UPDATE SET
+				info = $2,
+				updated_at = $3
+		`, artistID, infoJSON, time.Now().Unix())
+		return err
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return fmt.Errorf("saving artist info: %w", err)
+	}
+
+	return nil
+}
+
+// touchArtistInfo updates the time at which the information for an artist was
+// last searched for without changing it.
+func (lib *LocalLibrary) touchArtistInfo(artistID int64) error {
+	work := func(db *sql.DB) error {
+		_, err := db.Exec(`
+			UPDATE artists_info
+			SET updated_at = ?
+			WHERE artist_id = ?
+		`, time.Now().Unix(), artistID)
+		return err
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return fmt.Errorf("updating artist info: %w", err)
+	}
+
+	return nil
+}
+
+// saveMBID stores the MusicBrainz ID for the row with `id` in `table`. The table
+// must be either "artists" or "albums".
+func (lib *LocalLibrary) saveMBID(table string, id int64, mbID string) error {
+	if table != "artists" && table != "albums" {
+		return fmt.Errorf("table %s does not have MusicBrainz IDs", table)
+	}
+
+	work := func(db *sql.DB) error {
+		_, err := db.Exec(fmt.Sprintf(`
+			UPDATE %s
+			SET mbid = ?
+			WHERE id = ?
+		`, table), mbID, id)
+		return err
+	}
+
+	return lib.executeDBJobAndWait(work)
+}
+
+func artistInfoFromArt(found art.ArtistInfo) ArtistInfo {
+	info := ArtistInfo{
+		MBID:      found.MBID,
+		Name:      found.Name,
+		Type:      found.Type,
+		Country:   found.Country,
+		BeginDate: found.BeginDate,
+		EndDate:   found.EndDate,
+		Ended:     found.Ended,
+		Biography: found.Biography,
+		Links:     []ArtistLink{},
+		Related:   []RelatedArtist{},
+	}
+
+	for _, link := range found.Links {
+		info.Links = append(info.Links, ArtistLink{
+			Type: link.Type,
+			URL:  link.URL,
+		})
+	}
+
+	for _, related := range found.Related {
+		info.Related = append(info.Related, RelatedArtist{
+			MBID:     related.MBID,
+			Name:     related.Name,
+			Relation: related.Relation,
+		})
+	}
+
+	return info
+}
@@ -0,0 +1,150 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/art"
+	"github.com/ironsmile/euterpe/src/art/artfakes"
+)
+
+// TestLocalLibraryGetArtistInfo checks that artist information is found with the
+// library's art.InfoFinder, cached in the database and that its MusicBrainz ID
+// is stored for the artist.
+func TestLocalLibraryGetArtistInfo(t *testing.T) {
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	files := []MockMedia{
+		{artist: "Iron Maiden", album: "Killers", title: "Wrathchild", track: 2},
+		{artist: "Nobody Knows", album: "Obscure", title: "Hidden", track: 1},
+	}
+	for ind, file := range files {
+		file := file
+		trackPath := filepath.FromSlash("/music/" + file.album + "/track.mp3")
+		if err := lib.insertMediaIntoDatabase(&file, trackPath); err != nil {
+			t.Fatalf("inserting media file %d failed: %s", ind, err)
+		}
+	}
+
+	maidenID, _ := lib.GetArtistID("Iron Maiden")
+	nobodyID, _ := lib.GetArtistID("Nobody Knows")
+
+	// Without an info finder there is nothing to be found.
+	_, err = lib.GetArtistInfo(ctx, maidenID)
+	if !errors.Is(err, ErrArtistInfoNotFound) {
+		t.Errorf("expected ErrArtistInfoNotFound without finder but got %v", err)
+	}
+
+	fakeIF := &artfakes.FakeInfoFinder{
+		GetArtistInfoStub: func(
+			_ context.Context,
+			artist string,
+			_ string,
+		) (art.ArtistInfo, error) {
+			if artist != "Iron Maiden" {
+				return art.ArtistInfo{}, art.ErrInfoNotFound
+			}
+
+			return art.ArtistInfo{
+				MBID:    "maiden-mbid",
+				Name:    "Iron Maiden",
+				Country: "GB",
+				Links: []art.ArtistLink{
+					{Type: "official homepage", URL: "https://www.ironmaiden.com/"},
+				},
+			}, nil
+		},
+	}
+	lib.SetInfoFinder(fakeIF)
+
+	info, err := lib.GetArtistInfo(ctx, maidenID)
+	if err != nil {
+		t.Fatalf("getting artist info: %s", err)
+	}
+
+	if info.ArtistID != maidenID || info.MBID != "maiden-mbid" || info.Country != "GB" {
+		t.Errorf("unexpected artist info: %+v", info)
+	}
+	if len(info.Links) != 1 || info.Links[0].URL != "https://www.ironmaiden.com/" {
+		t.Errorf("unexpected artist links: %+v", info.Links)
+	}
+
+	// The second time it must come from the cache.
+	cached, err := lib.GetArtistInfo(ctx, maidenID)
+	if err != nil {
+		t.Fatalf("getting cached artist info: %s", err)
+	}
+	if cached.MBID != info.MBID || cached.Country != info.Country {
+		t.Errorf("expected cached info %+v but got %+v", info, cached)
+	}
+	if calls := fakeIF.GetArtistInfoCallCount(); calls != 1 {
+		t.Errorf("expected one call to the info finder but got %d", calls)
+	}
+
+	var storedMBID sql.NullString
+	err = lib.executeDBJobAndWait(func(db *sql.DB) error {
+		return db.QueryRow(
+			`SELECT mbid FROM artists WHERE id = ?`, maidenID,
+		).Scan(&storedMBID)
+	})
+	if err != nil {
+		t.Fatalf("selecting artist MusicBrainz ID: %s", err)
+	}
+	if storedMBID.String != "maiden-mbid" {
+		t.Errorf("expected artist MusicBrainz ID to be stored but got `%s`",
+			storedMBID.String)
+	}
+
+	// Not found responses are cached as well.
+	for i := 0; i < 2; i++ {
+		_, err = lib.GetArtistInfo(ctx, nobodyID)
+		if !errors.Is(err, ErrArtistInfoNotFound) {
+			t.Errorf("expected ErrArtistInfoNotFound but got %v", err)
+		}
+	}
+	if calls := fakeIF.GetArtistInfoCallCount(); calls != 2 {
+		t.Errorf("expected not found result to be cached but got %d calls", calls)
+	}
+
+	// Stale information is refreshed using the stored MusicBrainz ID.
+	err = lib.executeDBJobAndWait(func(db *sql.DB) error {
+		_, err := db.Exec(
+			`UPDATE artists_info SET updated_at = ? WHERE artist_id = ?`,
+			time.Now().Add(-2*artistInfoCacheTTL).Unix(),
+			maidenID,
+		)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("making artist info stale: %s", err)
+	}
+
+	if _, err := lib.GetArtistInfo(ctx, maidenID); err != nil {
+		t.Fatalf("refreshing artist info: %s", err)
+	}
+	if calls := fakeIF.GetArtistInfoCallCount(); calls != 3 {
+		t.Fatalf("expected stale info to be refreshed but got %d calls", calls)
+	}
+	if _, _, mbID := fakeIF.GetArtistInfoArgsForCall(2); mbID != "maiden-mbid" {
+		t.Errorf("expected the stored MusicBrainz ID to be used but got `%s`", mbID)
+	}
+
+	if _, err := lib.GetArtistInfo(ctx, 9999); !errors.Is(err, ErrArtistNotFound) {
+		t.Errorf("expected ErrArtistNotFound for missing artist but got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package library
 
 import (
+	"archive/zip"
 	"context"
 	"database/sql"
 	"errors"
@@ -14,6 +15,7 @@ import (
 	"time"
 
 	"github.com/ironsmile/euterpe/src/art"
+	"github.com/ironsmile/euterpe/src/ziparchive"
 )
 
 // FindAndSaveAlbumArtwork implements the ArtworkManager interface for the local library.
@@ -21,16 +23,16 @@ import (
 // filesystem or _on the internet_! This function returns ReadCloser and the caller
 // is responsible for freeing the used resources by calling Close().
 //
-// When an artwork is found it will be saved in the database and once there it will be
-// served from the db. Wait, wait! Serving binary files from the database?! Isn't that
-// slow? Apparently no with sqlite3. See the following:
+// When an artwork is found it will be saved in the library's image store and its hash
+// will be recorded in the database. Once there it will be served from the store. Images
+// used to be kept as blobs in the database but this made it grow into gigabytes for
+// big libraries.
 //
-// * https://www.sqlite.org/intern-v-extern-blob.html
-// * https://www.sqlite.org/fasterthanfs.html
+// Artwork found on the internet will not be saved in the album's directory and thus
+// "pollute" it with unexpected files. It will be nicely contained in the app's
+// image store.
 //
-// This behaviour have an additional bonus that artwork found on the internet will not
-// be saved on the filesystem and thus "pollute" it with unexpected files. It will be
-// nicely contained in the app's database.
+// The returned reader implements StoredImage.
 //
 // !TODO: Make sure there is no race conditions while getting/saving artwork for
 // particular album. Wink, wink, the database.
@@ -150,22 +152,28 @@ func (lib *LocalLibrary) storeAlbumArtwork(
 		return nil, size, err
 	}
 
-	albumColumn := "artwork_cover"
+	hash, err := lib.images.put(buff)
+	if err != nil {
+		return nil, size, fmt.Errorf("storing album artwork: %w", err)
+	}
+
+	hashColumn := "artwork_cover_hash"
 	if size == SmallImage {
-		albumColumn = "artwork_cover_small"
+		hashColumn = "artwork_cover_small_hash"
 	}
 
 	storeQuery := fmt.Sprintf(`
 		INSERT INTO
 			albums_artworks (album_id, %s, updated_at)
-		VALUES				
+		VALUES
 			($1, $2, $3)
 		ON CONFLICT (album_id) DO
 		UPDATE SET
 			%s = $2,
 			updated_at = $3
-	`, albumColumn, albumColumn)
+	`, hashColumn, hashColumn)
 
+	updatedAt := time.Now()
 	work := func(db *sql.DB) error {
 		stmt, err := db.Prepare(storeQuery)
 
@@ -175,7 +183,7 @@ func (lib *LocalLibrary) storeAlbumArtwork(
 
 		defer stmt.Close()
 
-		_, err = stmt.Exec(albumID, buff, time.Now().Unix())
+		_, err = stmt.Exec(albumID, hash, updatedAt.Unix())
 
 		if err != nil {
 			return err
@@ -188,7 +196,28 @@ func (lib *LocalLibrary) storeAlbumArtwork(
 		return nil, size, err
 	}
 
-	return newBytesReadCloser(buff), size, nil
+	if err := lib.saveImagePlaceholder("albums_artworks", albumID, buff); err != nil {
+		log.Printf("Error saving album %d artwork placeholder: %s", albumID, err)
+	}
+
+	return newStoredImage(buff, hash, updatedAt), size, nil
+}
+
+// readStoredImage returns the image with `hash` from the image store. When the
+// image is missing from the store ErrArtworkNotFound is returned.
+func (lib *LocalLibrary) readStoredImage(
+	hash string,
+	updatedAt int64,
+) (io.ReadCloser, error) {
+	buff, err := lib.images.get(hash)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Image %s is missing from the image store\n", hash)
+		return nil, ErrArtworkNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("reading stored image: %w", err)
+	}
+
+	return newStoredImage(buff, hash, time.Unix(updatedAt, 0)), nil
 }
 
 func (lib *LocalLibrary) saveAlbumArtworkNotFound(albumID int64) error {
@@ -232,13 +261,15 @@ func (lib *LocalLibrary) albumArtworkFromInternet(
 	var (
 		albumName  string
 		artistName string
+		releaseID  sql.NullString
 		count      int
 	)
 
 	work := func(db *sql.DB) error {
 		row, err := db.QueryContext(ctx, `
 			SELECT
-				name
+				name,
+				mbid
 			FROM
 				albums
 			WHERE
@@ -257,7 +288,7 @@ func (lib *LocalLibrary) albumArtworkFromInternet(
 			return ErrAlbumNotFound
 		}
 
-		if err := row.Scan(&albumName); err != nil {
+		if err := row.Scan(&albumName, &releaseID); err != nil {
 			return fmt.Errorf("scanning db result: %s", err)
 		}
 
@@ -293,7 +324,12 @@ func (lib *LocalLibrary) albumArtworkFromInternet(
 		return nil, err
 	}
 
-	cover, err := lib.artFinder.GetFrontImage(ctx, artistName, albumName)
+	cover, foundID, err := lib.artFinder.GetFrontImage(
+		ctx,
+		artistName,
+		albumName,
+		releaseID.String,
+	)
 	if errors.Is(err, art.ErrImageNotFound) {
 		return nil, ErrArtworkNotFound
 	}
@@ -301,6 +337,12 @@ func (lib *LocalLibrary) albumArtworkFromInternet(
 		return nil, err
 	}
 
+	if foundID != "" && foundID != releaseID.String {
+		if err := lib.saveMBID("albums", albumID, foundID); err != nil {
+			log.Printf("Saving MusicBrainz ID for album %d: %s\n", albumID, err)
+		}
+	}
+
 	return newBytesReadCloser(cover), nil
 }
 
@@ -313,14 +355,21 @@ func (lib *LocalLibrary) albumArtworkFromDB(
 	size ImageSize,
 ) (io.ReadCloser, ImageSize, error) {
 
-	buff, unixTime, err := lib.albumArtworkFromDBForSize(ctx, albumID, size)
+	hash, unixTime, err := lib.albumArtworkFromDBForSize(ctx, albumID, size)
 	if err != nil {
 		return nil, size, err
 	}
 
-	if len(buff) >= 1 {
+	if hash != "" {
 		// The image with the desires size has been found!
-		return newBytesReadCloser(buff), size, nil
+		img, err := lib.readStoredImage(hash, unixTime)
+		if err != ErrArtworkNotFound {
+			return img, size, err
+		}
+
+		// The image is missing from the store. Act as if it has never been
+		// found so that it is searched for again.
+		unixTime = 0
 	}
 
 	selectNotFound := func(lastChanged int64) (io.ReadCloser, ImageSize, error) {
@@ -340,28 +389,38 @@ func (lib *LocalLibrary) albumArtworkFromDB(
 
 	// No image of the desired size was found. Let us try and see if the original image
 	// is in the database and use it to generate the desired size.
-	buff, unixTime, err = lib.albumArtworkFromDBForSize(ctx, albumID, OriginalImage)
+	hash, unixTime, err = lib.albumArtworkFromDBForSize(ctx, albumID, OriginalImage)
 	if err != nil {
 		return nil, size, err
 	}
 
-	if len(buff) < 1 {
+	if hash == "" {
 		return selectNotFound(unixTime)
 	}
 
-	return newBytesReadCloser(buff), OriginalImage, nil
+	img, err := lib.readStoredImage(hash, unixTime)
+	if err == ErrArtworkNotFound {
+		return selectNotFound(0)
+	} else if err != nil {
+		return nil, size, err
+	}
+
+	return img, OriginalImage, nil
 }
 
+// albumArtworkFromDBForSize returns the hash of the stored album image with `size`
+// and the last time it was changed. The hash is an empty string when there is no
+// such image.
 func (lib *LocalLibrary) albumArtworkFromDBForSize(
 	ctx context.Context,
 	albumID int64,
 	size ImageSize,
-) ([]byte, int64, error) {
+) (string, int64, error) {
 
 	var (
-		buff          []byte
+		hash          sql.NullString
 		unixTime      int64
-		blobColumn    = "artwork_cover"
+		hashColumn    = "artwork_cover_hash"
 		imageSQLQuery = `
 			SELECT
 				%s,
@@ -373,11 +432,11 @@ func (lib *LocalLibrary) albumArtworkFromDBForSize(
 		`
 	)
 	if size == SmallImage {
-		blobColumn = "artwork_cover_small"
+		hashColumn = "artwork_cover_small_hash"
 	}
 
 	work := func(db *sql.DB) error {
-		smt, err := db.PrepareContext(ctx, fmt.Sprintf(imageSQLQuery, blobColumn))
+		smt, err := db.PrepareContext(ctx, fmt.Sprintf(imageSQLQuery, hashColumn))
 
 		if err != nil {
 			log.Printf("could not prepare album artwork sql statement: %s", err)
@@ -385,7 +444,7 @@ func (lib *LocalLibrary) albumArtworkFromDBForSize(
 		}
 		defer smt.Close()
 
-		err = smt.QueryRowContext(ctx, albumID).Scan(&buff, &unixTime)
+		err = smt.QueryRowContext(ctx, albumID).Scan(&hash, &unixTime)
 		if err == sql.ErrNoRows {
 			return ErrArtworkNotFound
 		} else if err != nil {
@@ -396,10 +455,10 @@ func (lib *LocalLibrary) albumArtworkFromDBForSize(
 		return nil
 	}
 	if err := lib.executeDBJobAndWait(work); err != nil {
-		return nil, 0, err
+		return "", 0, err
 	}
 
-	return buff, unixTime, nil
+	return hash.String, unixTime, nil
 }
 
 func (lib *LocalLibrary) albumArtworkFromFS(
@@ -415,6 +474,20 @@ func (lib *LocalLibrary) albumArtworkFromFS(
 	imagesRegexp := regexp.MustCompile(`(?i).*\.(png|gif|jpeg|jpg)$`)
 	var possibleArtworks []string
 
+	// Albums which are whole ZIP archives have their artwork inside them.
+	walkFS, walkRoot := lib.fs, albumPath
+	toFSPath := func(path string) string { return path }
+	if ziparchive.IsArchive(albumPath) {
+		if reader, err := zip.OpenReader(albumPath); err == nil {
+			defer reader.Close()
+
+			walkFS, walkRoot = reader, "."
+			toFSPath = func(path string) string {
+				return ziparchive.Join(albumPath, path)
+			}
+		}
+	}
+
 	walkFn := func(path string, info fs.DirEntry, err error) error {
 		if err := ctx.Err(); err != nil {
 			return err
@@ -428,12 +501,12 @@ func (lib *LocalLibrary) albumArtworkFromFS(
 			return nil
 		}
 		if imagesRegexp.MatchString(path) {
-			possibleArtworks = append(possibleArtworks, path)
+			possibleArtworks = append(possibleArtworks, toFSPath(path))
 		}
 		return nil
 	}
 
-	if err := fs.WalkDir(lib.fs, albumPath, walkFn); err != nil {
+	if err := fs.WalkDir(walkFS, walkRoot, walkFn); err != nil {
 		return nil, err
 	}
 
@@ -489,9 +562,9 @@ func (lib *LocalLibrary) albumArtworkFromFS(
 
 // SaveAlbumArtwork implements the ArtworkManager interface for the local library.
 //
-// It saves the artwork in `r` in the database. It will read up to 5MB of data from
+// It saves the artwork in `r` in the image store. It will read up to 5MB of data from
 // `r` and if this limit is reached, the artwork is considered too big and will not
-// be saved in the db.
+// be saved.
 func (lib *LocalLibrary) SaveAlbumArtwork(
 	ctx context.Context,
 	albumID int64,
@@ -521,10 +594,15 @@ func (lib *LocalLibrary) SaveAlbumArtwork(
 		return NewArtworkError("uploaded artwork is empty")
 	}
 
+	hash, err := lib.images.put(buff)
+	if err != nil {
+		return fmt.Errorf("storing album %d artwork: %w", albumID, err)
+	}
+
 	work := func(db *sql.DB) error {
 		stmt, err := db.Prepare(`
 			INSERT OR REPLACE INTO
-				albums_artworks (album_id, artwork_cover, updated_at)
+				albums_artworks (album_id, artwork_cover_hash, updated_at)
 			VALUES
 				(?, ?, ?)
 		`)
@@ -534,17 +612,22 @@ func (lib *LocalLibrary) SaveAlbumArtwork(
 
 		defer stmt.Close()
 
-		_, err = stmt.Exec(albumID, buff, time.Now().Unix())
+		_, err = stmt.Exec(albumID, hash, time.Now().Unix())
 		return err
 	}
 	if err := lib.executeDBJobAndWait(work); err != nil {
 		return err
 	}
 
+	if err := lib.saveImagePlaceholder("albums_artworks", albumID, buff); err != nil {
+		log.Printf("Error saving album %d artwork placeholder: %s", albumID, err)
+	}
+
 	return nil
 }
 
-// RemoveAlbumArtwork removes the artwork from the library database.
+// RemoveAlbumArtwork removes the artwork from the library database. The image itself
+// is removed from the image store during the next clean-up.
 //
 // Note that this operation does not make sense for artwork which came from disk. Because
 // future requests will find it again and store in the database.
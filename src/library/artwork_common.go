@@ -44,6 +44,19 @@ type ArtistImageManager interface {
 	RemoveArtistImage(ctx context.Context, artistID int64) error
 }
 
+// StoredImage is an image which is kept in the library's image store. The readers
+// returned by ArtworkManager and ArtistImageManager may implement it. In this case
+// callers could use its hash and modification time for caching.
+type StoredImage interface {
+	io.ReadSeekCloser
+
+	// Hash returns the hex encoded SHA-256 hash of the image contents.
+	Hash() string
+
+	// ModTime returns the time at which the image was stored.
+	ModTime() time.Time
+}
+
 // ImageSize is an enum type which defines the different sizes form images from the
 // ArtistImageManager and ArtworkManager.
 type ImageSize int64
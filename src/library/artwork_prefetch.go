@@ -0,0 +1,340 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// prefetchLogEvery controls how often the artwork prefetch job logs its progress.
+// It is measured in checked albums or artists.
+const prefetchLogEvery = 100
+
+//counterfeiter:generate . ArtworkPrefetcher
+
+// ArtworkPrefetcher is an interface for controlling the background job which finds
+// album artwork and artist images before any client has asked for them.
+type ArtworkPrefetcher interface {
+	// StartArtworkPrefetch starts the prefetch job in the background. It returns
+	// false when the job is already running.
+	StartArtworkPrefetch() bool
+
+	// PauseArtworkPrefetch pauses the prefetch job. A job started while paused will
+	// not do anything until it is resumed.
+	PauseArtworkPrefetch()
+
+	// ResumeArtworkPrefetch resumes a paused prefetch job.
+	ResumeArtworkPrefetch()
+
+	// ArtworkPrefetchProgress returns the progress of the current or the last
+	// prefetch job.
+	ArtworkPrefetchProgress() PrefetchProgress
+}
+
+// PrefetchProgress describes the state of the artwork prefetch job.
+type PrefetchProgress struct {
+	// Running is true while the job is working, including when it is paused.
+	Running bool `json:"running"`
+
+	// Paused is true when the job has been paused.
+	Paused bool `json:"paused"`
+
+	// Albums shows how far is the job into the albums without artwork.
+	Albums PrefetchCounters `json:"albums"`
+
+	// Artists shows how far is the job into the artists without image.
+	Artists PrefetchCounters `json:"artists"`
+
+	// StartedAt is the unix timestamp of the last start of the job. It is zero
+	// when the job has never been started.
+	StartedAt int64 `json:"started_at"`
+
+	// FinishedAt is the unix timestamp at which the last job finished. It is zero
+	// when no job has finished yet.
+	FinishedAt int64 `json:"finished_at"`
+}
+
+// PrefetchCounters is used for counting the work done by the prefetch job for a
+// particular kind of images.
+type PrefetchCounters struct {
+	// Total is the number of items which were missing images when the job started.
+	Total int `json:"total"`
+
+	// Checked is the number of items for which images have been searched so far.
+	Checked int `json:"checked"`
+
+	// Found is the number of items for which images have been found so far.
+	Found int `json:"found"`
+}
+
+// artworkPrefetch holds the state of the artwork prefetch job.
+type artworkPrefetch struct {
+	sync.Mutex
+
+	progress PrefetchProgress
+
+	// resume is non-nil while the job is paused. It is closed on resume.
+	resume chan struct{}
+}
+
+// EnableArtworkPrefetch makes the library start the artwork prefetch job after
+// every scan.
+func (lib *LocalLibrary) EnableArtworkPrefetch() {
+	lib.prefetch.Lock()
+	defer lib.prefetch.Unlock()
+
+	lib.prefetchAfterScan = true
+}
+
+// StartArtworkPrefetch implements the ArtworkPrefetcher interface. The job walks
+// through all albums without artwork and all artists without images and tries to
+// find them the same way as when a client requests them. Albums and artists for
+// which images were not found recently are skipped. Artist images are searched
+// only when an art.Finder is set for the library.
+func (lib *LocalLibrary) StartArtworkPrefetch() bool {
+	lib.prefetch.Lock()
+	defer lib.prefetch.Unlock()
+
+	if lib.prefetch.progress.Running {
+		return false
+	}
+
+	lib.prefetch.progress = PrefetchProgress{
+		Running:   true,
+		Paused:    lib.prefetch.resume != nil,
+		StartedAt: time.Now().Unix(),
+	}
+
+	go lib.runArtworkPrefetch(lib.ctx)
+	return true
+}
+
+// PauseArtworkPrefetch implements the ArtworkPrefetcher interface.
+func (lib *LocalLibrary) PauseArtworkPrefetch() {
+	lib.prefetch.Lock()
+	defer lib.prefetch.Unlock()
+
+	if lib.prefetch.resume != nil {
+		return
+	}
+
+	lib.prefetch.resume = make(chan struct{})
+	lib.prefetch.progress.Paused = true
+}
+
+// ResumeArtworkPrefetch implements the ArtworkPrefetcher interface.
+func (lib *LocalLibrary) ResumeArtworkPrefetch() {
+	lib.prefetch.Lock()
+	defer lib.prefetch.Unlock()
+
+	if lib.prefetch.resume == nil {
+		return
+	}
+
+	close(lib.prefetch.resume)
+	lib.prefetch.resume = nil
+	lib.prefetch.progress.Paused = false
+}
+
+// ArtworkPrefetchProgress implements the ArtworkPrefetcher interface.
+func (lib *LocalLibrary) ArtworkPrefetchProgress() PrefetchProgress {
+	lib.prefetch.Lock()
+	defer lib.prefetch.Unlock()
+
+	return lib.prefetch.progress
+}
+
+// waitArtworkPrefetchResume blocks while the prefetch job is paused. It returns
+// an error only when `ctx` is cancelled while waiting.
+func (lib *LocalLibrary) waitArtworkPrefetchResume(ctx context.Context) error {
+	lib.prefetch.Lock()
+	resume := lib.prefetch.resume
+	lib.prefetch.Unlock()
+
+	if resume == nil {
+		return nil
+	}
+
+	select {
+	case <-resume:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// updatePrefetchProgress calls `update` with the prefetch progress while holding
+// its lock.
+func (lib *LocalLibrary) updatePrefetchProgress(update func(*PrefetchProgress)) {
+	lib.prefetch.Lock()
+	defer lib.prefetch.Unlock()
+
+	update(&lib.prefetch.progress)
+}
+
+func (lib *LocalLibrary) runArtworkPrefetch(ctx context.Context) {
+	start := time.Now()
+
+	defer func() {
+		lib.updatePrefetchProgress(func(p *PrefetchProgress) {
+			p.Running = false
+			p.FinishedAt = time.Now().Unix()
+		})
+		log.Printf("Artwork prefetch took %s", time.Since(start))
+	}()
+
+	albumIDs, err := lib.albumsWithoutArtwork(ctx)
+	if err != nil {
+		log.Printf("Error getting albums without artwork: %s", err)
+		return
+	}
+
+	var artistIDs []int64
+	if lib.artFinder != nil {
+		artistIDs, err = lib.artistsWithoutImage(ctx)
+		if err != nil {
+			log.Printf("Error getting artists without image: %s", err)
+			return
+		}
+	}
+
+	lib.updatePrefetchProgress(func(p *PrefetchProgress) {
+		p.Albums.Total = len(albumIDs)
+		p.Artists.Total = len(artistIDs)
+	})
+
+	log.Printf("Prefetching artwork for %d albums and images for %d artists",
+		len(albumIDs), len(artistIDs))
+
+	// Thumbnails are what clients usually show in lists. Getting them also stores
+	// the original images. But they could be created only when there is a scaler.
+	size := OriginalImage
+	if lib.imageScaler != nil {
+		size = SmallImage
+	}
+
+	for i, albumID := range albumIDs {
+		if err := lib.waitArtworkPrefetchResume(ctx); err != nil {
+			return
+		}
+
+		img, err := lib.FindAndSaveAlbumArtwork(ctx, albumID, size)
+		if err == nil {
+			img.Close()
+		} else if ctx.Err() != nil {
+			return
+		} else if err != ErrArtworkNotFound && err != ErrAlbumNotFound {
+			log.Printf("Prefetching artwork for album %d: %s", albumID, err)
+		}
+
+		lib.updatePrefetchProgress(func(p *PrefetchProgress) {
+			p.Albums.Checked++
+			if err == nil {
+				p.Albums.Found++
+			}
+		})
+
+		if (i+1)%prefetchLogEvery == 0 {
+			log.Printf("Artwork prefetch checked %d of %d albums", i+1, len(albumIDs))
+		}
+	}
+
+	for i, artistID := range artistIDs {
+		if err := lib.waitArtworkPrefetchResume(ctx); err != nil {
+			return
+		}
+
+		img, err := lib.FindAndSaveArtistImage(ctx, artistID, size)
+		if err == nil {
+			img.Close()
+		} else if ctx.Err() != nil {
+			return
+		} else if err != ErrArtworkNotFound && err != ErrArtistNotFound {
+			log.Printf("Prefetching image for artist %d: %s", artistID, err)
+		}
+
+		lib.updatePrefetchProgress(func(p *PrefetchProgress) {
+			p.Artists.Checked++
+			if err == nil {
+				p.Artists.Found++
+			}
+		})
+
+		if (i+1)%prefetchLogEvery == 0 {
+			log.Printf("Artwork prefetch checked %d of %d artists", i+1, len(artistIDs))
+		}
+	}
+}
+
+// albumsWithoutArtwork returns the IDs of all albums which have no stored artwork
+// and for which no artwork has been searched for in the last notFoundCacheTTL.
+func (lib *LocalLibrary) albumsWithoutArtwork(ctx context.Context) ([]int64, error) {
+	return lib.queryIDs(ctx, `
+		SELECT
+			al.id
+		FROM
+			albums al
+			LEFT JOIN
+				albums_artworks aa ON aa.album_id = al.id
+		WHERE
+			aa.artwork_cover_hash IS NULL AND
+			(aa.updated_at IS NULL OR aa.updated_at < ?)
+		ORDER BY
+			al.id
+	`, time.Now().Add(-notFoundCacheTTL).Unix())
+}
+
+// artistsWithoutImage returns the IDs of all artists which have no stored image
+// and for which no image has been searched for in the last notFoundCacheTTL.
+func (lib *LocalLibrary) artistsWithoutImage(ctx context.Context) ([]int64, error) {
+	return lib.queryIDs(ctx, `
+		SELECT
+			ar.id
+		FROM
+			artists ar
+			LEFT JOIN
+				artists_images ai ON ai.artist_id = ar.id
+		WHERE
+			ai.image_hash IS NULL AND
+			(ai.updated_at IS NULL OR ai.updated_at < ?)
+		ORDER BY
+			ar.id
+	`, time.Now().Add(-notFoundCacheTTL).Unix())
+}
+
+// queryIDs executes `query` which must select a single integer column and returns
+// all of the values.
+func (lib *LocalLibrary) queryIDs(
+	ctx context.Context,
+	query string,
+	args ...interface{},
+) ([]int64, error) {
+	var ids []int64
+
+	work := func(db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("query database: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return fmt.Errorf("scanning db result: %w", err)
+			}
+			ids = append(ids, id)
+		}
+
+		return rows.Err()
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,151 @@
+package library
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/art"
+	"github.com/ironsmile/euterpe/src/art/artfakes"
+)
+
+// TestArtworkPrefetch checks that the artwork prefetch job searches images for all
+// albums and artists which do not have one, that it could be paused and that it
+// skips the ones for which nothing has been found recently.
+func TestArtworkPrefetch(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		image = []byte("prefetched-image")
+		files = []MockMedia{
+			{
+				artist: "Found Artist",
+				album:  "Found Album",
+				title:  "First",
+				track:  1,
+				length: 123,
+			},
+			{
+				artist: "Missing Artist",
+				album:  "Missing Album",
+				title:  "Second",
+				track:  1,
+				length: 321,
+			},
+		}
+	)
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	fakeAF := &artfakes.FakeFinder{
+		GetFrontImageStub: func(_ context.Context, _, album, _ string) ([]byte, string, error) {
+			if album != "Found Album" {
+				return nil, "", art.ErrImageNotFound
+			}
+			return append([]byte{}, image...), "", nil
+		},
+		GetArtistImageStub: func(_ context.Context, artist, _ string) ([]byte, string, error) {
+			if artist != "Found Artist" {
+				return nil, "", art.ErrImageNotFound
+			}
+			return append([]byte{}, image...), "", nil
+		},
+	}
+	lib.SetArtFinder(fakeAF)
+
+	// The album directories must exist since the prefetch job first looks for
+	// images in them.
+	libDir := t.TempDir()
+	for ind, mediaFile := range files {
+		mediaFile := mediaFile
+		albumDir := filepath.Join(libDir, mediaFile.album)
+		if err := os.Mkdir(albumDir, 0750); err != nil {
+			t.Fatalf("creating album directory: %s", err)
+		}
+
+		filePath := filepath.Join(albumDir, "file.mp3")
+		if err := lib.insertMediaIntoDatabase(&mediaFile, filePath); err != nil {
+			t.Fatalf("inserting media file %d failed: %s", ind, err)
+		}
+	}
+
+	lib.PauseArtworkPrefetch()
+
+	if !lib.StartArtworkPrefetch() {
+		t.Fatalf("expected the prefetch job to be started")
+	}
+	if lib.StartArtworkPrefetch() {
+		t.Errorf("expected a second start to be a no-op while the job is running")
+	}
+
+	progress := lib.ArtworkPrefetchProgress()
+	if !progress.Running || !progress.Paused {
+		t.Errorf("expected a running and paused job but got %+v", progress)
+	}
+
+	// Give the job some time to do work if it would ignore the pause.
+	time.Sleep(50 * time.Millisecond)
+	if fakeAF.GetFrontImageCallCount() != 0 || fakeAF.GetArtistImageCallCount() != 0 {
+		t.Errorf("images were searched for while the job was paused")
+	}
+
+	lib.ResumeArtworkPrefetch()
+	progress = waitArtworkPrefetch(t, lib)
+
+	expected := PrefetchCounters{Total: 2, Checked: 2, Found: 1}
+	if progress.Albums != expected {
+		t.Errorf("albums: expected %+v but got %+v", expected, progress.Albums)
+	}
+	if progress.Artists != expected {
+		t.Errorf("artists: expected %+v but got %+v", expected, progress.Artists)
+	}
+	if progress.Paused || progress.StartedAt == 0 || progress.FinishedAt == 0 {
+		t.Errorf("unexpected progress after the job finished: %+v", progress)
+	}
+
+	albumID, err := lib.GetAlbumID("Found Album", filepath.Join(libDir, "Found Album"))
+	if err != nil {
+		t.Fatalf("getting album ID: %s", err)
+	}
+	assertAlbumImage(t, lib, albumID, OriginalImage, image)
+
+	// Running the job again must skip everything. Images are either stored already
+	// or were not found just now.
+	if !lib.StartArtworkPrefetch() {
+		t.Fatalf("expected the second prefetch job to be started")
+	}
+	progress = waitArtworkPrefetch(t, lib)
+
+	if progress.Albums.Total != 0 || progress.Artists.Total != 0 {
+		t.Errorf("expected nothing to be prefetched the second time but got %+v",
+			progress)
+	}
+	if calls := fakeAF.GetFrontImageCallCount(); calls != 2 {
+		t.Errorf("expected two calls to GetFrontImage but got %d", calls)
+	}
+}
+
+// waitArtworkPrefetch waits for the running artwork prefetch job to finish and
+// returns its final progress.
+func waitArtworkPrefetch(t *testing.T, lib *LocalLibrary) PrefetchProgress {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if progress := lib.ArtworkPrefetchProgress(); !progress.Running {
+			return progress
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("artwork prefetch job did not finish in time")
+	return PrefetchProgress{}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/ironsmile/euterpe/src/art"
 	"github.com/ironsmile/euterpe/src/art/artfakes"
 	"github.com/ironsmile/euterpe/src/scaler/scalerfakes"
+	"github.com/ironsmile/euterpe/src/tags"
 )
 
 // TestFindAndSaveAlbumArtwork checks that album artwork is stored and then searches
@@ -54,15 +55,16 @@ func TestFindAndSaveAlbumArtwork(t *testing.T) {
 			_ context.Context,
 			artist string,
 			album string,
-		) ([]byte, error) {
+			_ string,
+		) ([]byte, string, error) {
 			if artist != mediaFile.artist || album != mediaFile.album {
-				return nil, art.ErrImageNotFound
+				return nil, "", art.ErrImageNotFound
 			}
 
 			retSlice := make([]byte, len(bigImage))
 			copy(retSlice, bigImage)
 
-			return retSlice, nil
+			return retSlice, "", nil
 		},
 	}
 	lib.SetArtFinder(fakeAF)
@@ -231,8 +233,9 @@ func TestFindAndSaveAlbumArtwork(t *testing.T) {
 			_ context.Context,
 			artist string,
 			album string,
-		) ([]byte, error) {
-			return nil, art.ErrImageNotFound
+			_ string,
+		) ([]byte, string, error) {
+			return nil, "", art.ErrImageNotFound
 		},
 	}
 	lib.SetArtFinder(notFoundAF)
@@ -291,3 +294,84 @@ func assertAlbumImage(
 		t.Errorf("expected image `%s` but got `%s`", expectedImage, foundImgBytes)
 	}
 }
+
+// TestArtworkWithMBIDsFromTags checks that MusicBrainz IDs found in the tags of
+// media files are stored and then given to the art.Finder so that it does not
+// have to search for the album or artist.
+func TestArtworkWithMBIDsFromTags(t *testing.T) {
+	const (
+		releaseID = "6518fd52-58bf-44a3-8150-00e7c3ffcae5"
+		artistID  = "ca891d65-d9b0-4258-89f7-e6ba29d83767"
+		filePath  = "/music/Killers/wrathchild.mp3"
+	)
+
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	file := taggedMediaFile{
+		MediaFile: &MockMedia{
+			artist: "Iron Maiden",
+			album:  "Killers",
+			title:  "Wrathchild",
+			track:  2,
+		},
+		tags: tags.Tags{
+			tags.MusicBrainzAlbumID:  {releaseID},
+			tags.MusicBrainzArtistID: {artistID + "/some-other-artist-id"},
+		},
+	}
+	if err := lib.insertMediaIntoDatabase(file, filePath); err != nil {
+		t.Fatalf("inserting media failed: %s", err)
+	}
+
+	fakeAF := &artfakes.FakeFinder{}
+	fakeAF.GetFrontImageReturns([]byte("album image"), releaseID, nil)
+	fakeAF.GetArtistImageReturns([]byte("artist image"), artistID, nil)
+	lib.SetArtFinder(fakeAF)
+
+	albumID, err := lib.GetAlbumID("Killers", "/music/Killers")
+	if err != nil {
+		t.Fatalf("getting album ID: %s", err)
+	}
+
+	img, err := lib.albumArtworkFromInternet(ctx, albumID)
+	if err != nil {
+		t.Fatalf("getting album artwork: %s", err)
+	}
+	img.Close()
+
+	if fakeAF.GetFrontImageCallCount() != 1 {
+		t.Fatalf("expected one call to GetFrontImage")
+	}
+	if _, _, _, passedID := fakeAF.GetFrontImageArgsForCall(0); passedID != releaseID {
+		t.Errorf("expected release ID `%s` but got `%s`", releaseID, passedID)
+	}
+
+	maidenID, err := lib.GetArtistID("Iron Maiden")
+	if err != nil {
+		t.Fatalf("getting artist ID: %s", err)
+	}
+
+	img, err = lib.artistImageFromInternet(ctx, maidenID)
+	if err != nil {
+		t.Fatalf("getting artist image: %s", err)
+	}
+	img.Close()
+
+	if fakeAF.GetArtistImageCallCount() != 1 {
+		t.Fatalf("expected one call to GetArtistImage")
+	}
+	if _, _, passedID := fakeAF.GetArtistImageArgsForCall(0); passedID != artistID {
+		t.Errorf("expected artist ID `%s` but got `%s`", artistID, passedID)
+	}
+}
@@ -0,0 +1,459 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	taglib "github.com/wtolson/go-taglib"
+
+	"github.com/ironsmile/euterpe/src/cue"
+	"github.com/ironsmile/euterpe/src/helpers"
+)
+
+//counterfeiter:generate . TrackSegmentFinder
+
+// TrackSegmentFinder is an interface for finding which part of its media file a
+// track is. Tracks described by CUE sheets are only a part of a single file which
+// contains the whole album.
+type TrackSegmentFinder interface {
+	// GetTrackSegment returns the segment of the media file for a track by its ID.
+	GetTrackSegment(ctx context.Context, trackID int64) (TrackSegment, error)
+}
+
+// TrackSegment is the part of a media file which a track occupies.
+type TrackSegment struct {
+	// Start is the offset of the track from the beginning of the file.
+	Start time.Duration
+
+	// End is the offset at which the track ends. Zero means the track lasts
+	// until the end of the file.
+	End time.Duration
+}
+
+// WholeFile returns true when the segment is the whole media file. This is the
+// case for all tracks which are not described by CUE sheets.
+func (s TrackSegment) WholeFile() bool {
+	return s.Start == 0 && s.End == 0
+}
+
+// cueSegment is the part of an audio file which a track from a CUE sheet
+// occupies. The zero value is used for tracks which are whole files.
+type cueSegment struct {
+	sheet string
+	start time.Duration
+	end   time.Duration
+}
+
+// isCueSheet returns true when the file at path is a CUE sheet judging by its
+// extension.
+func isCueSheet(path string) bool {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	return base != ext && strings.EqualFold(ext, ".cue")
+}
+
+// GetTrackSegment implements the TrackSegmentFinder interface.
+func (lib *LocalLibrary) GetTrackSegment(
+	ctx context.Context,
+	trackID int64,
+) (TrackSegment, error) {
+	var segment TrackSegment
+
+	work := func(db *sql.DB) error {
+		var (
+			start int64
+			end   sql.NullInt64
+		)
+
+		err := db.QueryRowContext(ctx, `
+			SELECT
+				cue_start,
+				cue_end
+			FROM
+				tracks
+			WHERE
+				id = ?
+		`, trackID).Scan(&start, &end)
+		if err == sql.ErrNoRows {
+			return ErrTrackNotFound
+		} else if err != nil {
+			return err
+		}
+
+		segment.Start = time.Duration(start) * time.Millisecond
+		if end.Valid {
+			segment.End = time.Duration(end.Int64) * time.Millisecond
+		}
+		return nil
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return segment, err
+	}
+
+	return segment, nil
+}
+
+// AddCueSheet adds to the library the tracks described by the CUE sheet at path.
+// Every one of them is a part of an audio file which is next to the sheet. These
+// audio files are no longer tracks on their own.
+func (lib *LocalLibrary) AddCueSheet(path string) error {
+	path = filepath.Clean(path)
+
+	sheetFile, err := lib.fs.Open(path)
+	if err != nil {
+		return err
+	}
+
+	sheet, err := cue.Parse(sheetFile)
+	sheetFile.Close()
+	if err != nil {
+		return fmt.Errorf("parsing CUE sheet %s: %w", path, err)
+	}
+
+	var trackIDs []int64
+	for _, cueFile := range sheet.Files {
+		audioPath := cueAudioPath(path, cueFile.Name)
+		if !lib.isSupportedFormat(audioPath) {
+			log.Printf("CUE sheet %s: unsupported audio file %s", path, cueFile.Name)
+			continue
+		}
+
+		if _, err := fs.Stat(lib.fs, audioPath); err != nil {
+			log.Printf("CUE sheet %s: %s", path, err)
+			continue
+		}
+
+		audio, err := taglib.Read(audioPath)
+		if err != nil {
+			log.Printf("Taglib error for %s: %s", audioPath, err)
+			continue
+		}
+
+		ids, err := lib.insertCueFileIntoDatabase(
+			path,
+			sheet,
+			cueFile,
+			withAllTags(audio, audioPath),
+			audioPath,
+		)
+		audio.Close()
+		if err != nil {
+			return err
+		}
+
+		trackIDs = append(trackIDs, ids...)
+	}
+
+	return lib.removeStaleCueTracks(path, trackIDs)
+}
+
+// cueAudioPath returns the file system path of an audio file referenced in the
+// CUE sheet at sheetPath. The file names in sheets are relative to the sheet
+// and may use Windows path separators.
+func cueAudioPath(sheetPath, name string) string {
+	name = filepath.FromSlash(strings.ReplaceAll(name, `\`, "/"))
+	if filepath.IsAbs(name) {
+		return filepath.Clean(name)
+	}
+	return filepath.Join(filepath.Dir(sheetPath), name)
+}
+
+// insertCueFileIntoDatabase inserts the tracks of a single FILE entry from a CUE
+// sheet. The audio file is the already parsed media file for it. It is used for
+// the track lengths and for artist and album names which are missing in the sheet.
+// Returns the IDs of the inserted tracks.
+func (lib *LocalLibrary) insertCueFileIntoDatabase(
+	sheetPath string,
+	sheet cue.Sheet,
+	cueFile cue.File,
+	audio MediaFile,
+	audioPath string,
+) ([]int64, error) {
+	if err := lib.removeWholeFileTrack(audioPath); err != nil {
+		return nil, fmt.Errorf("removing whole file track %s: %w", audioPath, err)
+	}
+
+	album := strings.TrimSpace(sheet.Title)
+	if album == "" {
+		album = strings.TrimSpace(audio.Album())
+	}
+
+	var (
+		year  int64
+		genre string
+	)
+	if tagged, ok := audio.(TaggedMediaFile); ok {
+		year, genre = yearAndGenreFromTags(tagged.Tags())
+	}
+	format := lib.mediaFileFormat(audioPath)
+
+	var trackIDs []int64
+	for ind, cueTrack := range cueFile.Tracks {
+		segment := cueSegment{
+			sheet: sheetPath,
+			start: cueTrack.Start,
+		}
+
+		duration := audio.Length() - cueTrack.Start
+		if ind+1 < len(cueFile.Tracks) {
+			segment.end = cueFile.Tracks[ind+1].Start
+			duration = segment.end - segment.start
+		}
+		if duration < 0 {
+			duration = 0
+		}
+
+		artist := strings.TrimSpace(cueTrack.Performer)
+		if artist == "" {
+			artist = strings.TrimSpace(sheet.Performer)
+		}
+		if artist == "" {
+			artist = strings.TrimSpace(audio.Artist())
+		}
+
+		trackNumber := int64(cueTrack.Number)
+		if trackNumber == 0 {
+			trackNumber = helpers.GuessTrackNumber(audioPath)
+		}
+
+		title := strings.TrimSpace(cueTrack.Title)
+		if title == "" {
+			title = fmt.Sprintf("%s #%d", filepath.Base(audioPath), cueTrack.Number)
+		}
+
+		md := trackMetadata{
+			artist:  artist,
+			album:   album,
+			title:   title,
+			number:  trackNumber,
+			year:    year,
+			genre:   genre,
+			disc:    discFromDir(filepath.Dir(audioPath)),
+			bitrate: mediaBitrate(audio),
+			format:  format,
+		}
+		lib.applyOverrides(&md, audioPath, segment.start.Milliseconds())
+
+		artistID, err := lib.setArtistID(md.artist)
+		if err != nil {
+			return nil, err
+		}
+
+		albumID, err := lib.setAlbumID(md.album, albumDir(audioPath))
+		if err != nil {
+			return nil, err
+		}
+
+		trackID, err := lib.setSegmentTrackID(
+			md.title,
+			audioPath,
+			md.number,
+			artistID,
+			albumID,
+			duration.Milliseconds(),
+			segment,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := lib.saveTrackDetails(trackID, md); err != nil {
+			log.Printf("Error saving track details for %s: %s", audioPath, err)
+		}
+
+		trackIDs = append(trackIDs, trackID)
+	}
+
+	return trackIDs, nil
+}
+
+// removeWholeFileTrack removes the track for the whole audio file at fsPath. Once
+// a CUE sheet describes its tracks the file is no longer a track by itself.
+func (lib *LocalLibrary) removeWholeFileTrack(fsPath string) error {
+	return lib.executeDBJobAndWait(func(db *sql.DB) error {
+		_, err := db.Exec(`
+			DELETE FROM tracks
+			WHERE
+				fs_path = ? AND
+				cue_sheet IS NULL
+		`, fsPath)
+		return err
+	})
+}
+
+// removeStaleCueTracks removes the tracks of a CUE sheet which are not among
+// trackIDs. These are tracks which were removed from the sheet since it was
+// last added.
+func (lib *LocalLibrary) removeStaleCueTracks(sheetPath string, trackIDs []int64) error {
+	keep := make(map[int64]struct{}, len(trackIDs))
+	for _, id := range trackIDs {
+		keep[id] = struct{}{}
+	}
+
+	return lib.executeDBJobAndWait(func(db *sql.DB) error {
+		rows, err := db.Query(`
+			SELECT
+				id
+			FROM
+				tracks
+			WHERE
+				cue_sheet = ?
+		`, sheetPath)
+		if err != nil {
+			return err
+		}
+
+		var stale []int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			if _, ok := keep[id]; !ok {
+				stale = append(stale, id)
+			}
+		}
+		rows.Close()
+
+		for _, id := range stale {
+			if _, err := db.Exec(`DELETE FROM tracks WHERE id = ?`, id); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// removeCueSheet removes the tracks described by the CUE sheet at path. Their
+// audio files are added back as tracks on their own.
+func (lib *LocalLibrary) removeCueSheet(path string) {
+	path = filepath.Clean(path)
+
+	var audioFiles []string
+	work := func(db *sql.DB) error {
+		rows, err := db.Query(`
+			SELECT DISTINCT
+				fs_path
+			FROM
+				tracks
+			WHERE
+				cue_sheet = ?
+		`, path)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var fsPath string
+			if err := rows.Scan(&fsPath); err != nil {
+				return err
+			}
+			audioFiles = append(audioFiles, fsPath)
+		}
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			DELETE FROM tracks
+			WHERE cue_sheet = ?
+		`, path)
+		return err
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		log.Printf("Error removing CUE sheet %s: %s", path, err)
+		return
+	}
+
+	for _, audioPath := range audioFiles {
+		if err := lib.AddMedia(audioPath); err != nil &&
+			!errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Error adding %s back: %s", audioPath, err)
+		}
+	}
+}
+
+// cueSheetsForMedia returns the CUE sheets which describe tracks in the audio
+// file at fsPath.
+func (lib *LocalLibrary) cueSheetsForMedia(fsPath string) []string {
+	var sheets []string
+	work := func(db *sql.DB) error {
+		rows, err := db.Query(`
+			SELECT DISTINCT
+				cue_sheet
+			FROM
+				tracks
+			WHERE
+				fs_path = ? AND
+				cue_sheet IS NOT NULL
+		`, fsPath)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var sheet string
+			if err := rows.Scan(&sheet); err != nil {
+				return err
+			}
+			sheets = append(sheets, sheet)
+		}
+
+		return rows.Err()
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		log.Printf("Error getting CUE sheets for %s: %s", fsPath, err)
+	}
+
+	return sheets
+}
+
+// getCueSheets returns all CUE sheets which describe tracks in the library.
+func (lib *LocalLibrary) getCueSheets(ctx context.Context) ([]string, error) {
+	var sheets []string
+	work := func(db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, `
+			SELECT DISTINCT
+				cue_sheet
+			FROM
+				tracks
+			WHERE
+				cue_sheet IS NOT NULL
+		`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var sheet string
+			if err := rows.Scan(&sheet); err != nil {
+				return err
+			}
+			sheets = append(sheets, sheet)
+		}
+
+		return rows.Err()
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return nil, err
+	}
+
+	return sheets, nil
+}
@@ -0,0 +1,166 @@
+package library
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/cue"
+)
+
+// TestCueSheetTracks checks that the tracks from a CUE sheet replace the track
+// for their whole audio file and that their segments are stored.
+func TestCueSheetTracks(t *testing.T) {
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	albumDir := filepath.FromSlash("/music/live")
+	audioPath := filepath.Join(albumDir, "live.flac")
+	sheetPath := filepath.Join(albumDir, "live.cue")
+
+	audio := MockMedia{
+		artist: "Tag Artist",
+		album:  "Tag Album",
+		title:  "Whole Concert",
+		length: 10 * time.Minute,
+	}
+
+	// At first the file is a track on its own.
+	if err := lib.insertMediaIntoDatabase(&audio, audioPath); err != nil {
+		t.Fatalf("inserting media file: %s", err)
+	}
+
+	sheet := cue.Sheet{
+		Title: "Live in Sofia",
+		Files: []cue.File{
+			{
+				Name: "live.flac",
+				Tracks: []cue.Track{
+					{Number: 1, Title: "Intro"},
+					{Number: 2, Title: "Song", Performer: "Guest", Start: 2 * time.Minute},
+					{Number: 3, Title: "Outro", Start: 7 * time.Minute},
+				},
+			},
+		},
+	}
+
+	trackIDs, err := lib.insertCueFileIntoDatabase(
+		sheetPath, sheet, sheet.Files[0], &audio, audioPath,
+	)
+	if err != nil {
+		t.Fatalf("inserting CUE sheet: %s", err)
+	}
+	if len(trackIDs) != 3 {
+		t.Fatalf("expected 3 tracks but got %d", len(trackIDs))
+	}
+
+	albumID, err := lib.GetAlbumID("Live in Sofia", albumDir)
+	if err != nil {
+		t.Fatalf("getting album ID: %s", err)
+	}
+
+	var titles []string
+	for _, track := range lib.GetAlbumFiles(albumID) {
+		titles = append(titles, track.Title)
+	}
+	if !reflect.DeepEqual(titles, []string{"Intro", "Song", "Outro"}) {
+		t.Errorf("unexpected album tracks: %v", titles)
+	}
+
+	found := lib.Search("Whole Concert")
+	if len(found) != 0 {
+		t.Errorf("expected the whole file track to be removed but found %d", len(found))
+	}
+
+	found = lib.Search("Song")
+	if len(found) != 1 || found[0].Artist != "Guest" {
+		t.Errorf("expected a track by the performer from the sheet but got %+v", found)
+	}
+
+	expectedSegments := []TrackSegment{
+		{Start: 0, End: 2 * time.Minute},
+		{Start: 2 * time.Minute, End: 7 * time.Minute},
+		{Start: 7 * time.Minute},
+	}
+	for ind, trackID := range trackIDs {
+		segment, err := lib.GetTrackSegment(ctx, trackID)
+		if err != nil {
+			t.Fatalf("getting segment for track %d: %s", trackID, err)
+		}
+		if segment != expectedSegments[ind] {
+			t.Errorf("track %d: expected segment %+v but got %+v",
+				ind, expectedSegments[ind], segment)
+		}
+		if lib.GetFilePath(trackID) != audioPath {
+			t.Errorf("track %d: expected its file to be %s", ind, audioPath)
+		}
+	}
+
+	if _, err := lib.GetTrackSegment(ctx, 9999); err != ErrTrackNotFound {
+		t.Errorf("expected ErrTrackNotFound but got %v", err)
+	}
+
+	if sheets := lib.cueSheetsForMedia(audioPath); !reflect.DeepEqual(
+		sheets, []string{sheetPath},
+	) {
+		t.Errorf("unexpected CUE sheets for the audio file: %v", sheets)
+	}
+
+	// The last track is removed from the sheet.
+	sheet.Files[0].Tracks = sheet.Files[0].Tracks[:2]
+	updatedIDs, err := lib.insertCueFileIntoDatabase(
+		sheetPath, sheet, sheet.Files[0], &audio, audioPath,
+	)
+	if err != nil {
+		t.Fatalf("inserting the updated CUE sheet: %s", err)
+	}
+	if !reflect.DeepEqual(updatedIDs, trackIDs[:2]) {
+		t.Errorf("expected track IDs to be kept %v but got %v", trackIDs[:2], updatedIDs)
+	}
+	if err := lib.removeStaleCueTracks(sheetPath, updatedIDs); err != nil {
+		t.Fatalf("removing stale tracks: %s", err)
+	}
+
+	if tracks := lib.GetAlbumFiles(albumID); len(tracks) != 2 {
+		t.Errorf("expected 2 tracks after the update but got %d", len(tracks))
+	}
+
+	segment, err := lib.GetTrackSegment(ctx, updatedIDs[1])
+	if err != nil {
+		t.Fatalf("getting segment: %s", err)
+	}
+	if segment != (TrackSegment{Start: 2 * time.Minute}) {
+		t.Errorf("expected the last track to last until the end but got %+v", segment)
+	}
+}
+
+// TestCueAudioPath checks that paths to audio files are relative to the sheet.
+func TestCueAudioPath(t *testing.T) {
+	sheetPath := filepath.FromSlash("/music/album/album.cue")
+
+	tests := map[string]string{
+		"album.flac":        "/music/album/album.flac",
+		`CD1\album.flac`:    "/music/album/CD1/album.flac",
+		"../other/rip.wav":  "/music/other/rip.wav",
+		"/abs/path/rip.wav": "/abs/path/rip.wav",
+	}
+
+	for name, expected := range tests {
+		found := cueAudioPath(sheetPath, name)
+		if found != filepath.FromSlash(expected) {
+			t.Errorf("%s: expected %s but got %s", name, expected, found)
+		}
+	}
+}
@@ -0,0 +1,512 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/ironsmile/euterpe/src/chromaprint"
+)
+
+// The ways in which duplicate tracks are matched.
+const (
+	// MatchedByTags is for tracks with the same artist and title and about the
+	// same duration.
+	MatchedByTags = "tags"
+
+	// MatchedByChromaprint is for tracks with similar acoustic fingerprints and
+	// about the same duration.
+	MatchedByChromaprint = "acoustic_fingerprint"
+)
+
+// duplicateDurationDelta is the largest difference in the durations of tracks
+// which could still be duplicates.
+const duplicateDurationDelta = 3 * time.Second
+
+// chromaprintItems is how much of the acoustic fingerprints is compared when
+// looking for duplicates. It is about the first 30 seconds of audio. This keeps
+// the comparison fast enough for large libraries.
+const chromaprintItems = 256
+
+// chromaprintSimilarity is the smallest similarity of acoustic fingerprints for
+// which the tracks are considered the same recording.
+const chromaprintSimilarity = 0.85
+
+//counterfeiter:generate . DuplicatesFinder
+
+// DuplicatesFinder is an interface for finding tracks which are in the library
+// more than once. Such as the same song in two albums or in different formats.
+type DuplicatesFinder interface {
+	// FindDuplicates returns groups of tracks which are likely the same song.
+	FindDuplicates(ctx context.Context) ([]DuplicateTracks, error)
+}
+
+// DuplicateTracks is a group of tracks which are likely the same song.
+type DuplicateTracks struct {
+	// MatchedBy is how the tracks were found to be the same. One of
+	// MatchedByTags and MatchedByChromaprint.
+	MatchedBy string `json:"matched_by"`
+
+	Tracks []DuplicateTrack `json:"tracks"`
+}
+
+// DuplicateTrack is a track in a group of DuplicateTracks.
+type DuplicateTrack struct {
+	ReportTrack
+
+	// Format is the format of the track's file. Such as "mp3" or "flac".
+	Format string `json:"format"`
+
+	// Bitrate is the bit rate of the audio in kbps. It is zero when unknown.
+	Bitrate int64 `json:"bitrate,omitempty"`
+
+	// Duration is the duration of the track in milliseconds.
+	Duration int64 `json:"duration"`
+}
+
+// chromaprintCalculation holds the state of the acoustic fingerprints job.
+type chromaprintCalculation struct {
+	sync.Mutex
+
+	calculator *chromaprint.Calculator
+	running    bool
+}
+
+// EnableChromaprints makes the library calculate acoustic fingerprints for its
+// tracks. They are used for finding duplicate tracks with different tags. The
+// calculation runs in the background after every scan.
+func (lib *LocalLibrary) EnableChromaprints(calculator *chromaprint.Calculator) {
+	lib.chromaprintJob.Lock()
+	defer lib.chromaprintJob.Unlock()
+
+	lib.chromaprintJob.calculator = calculator
+}
+
+// startChromaprintCalculation starts calculating acoustic fingerprints in the
+// background when it is enabled and not already running.
+func (lib *LocalLibrary) startChromaprintCalculation() {
+	lib.chromaprintJob.Lock()
+	defer lib.chromaprintJob.Unlock()
+
+	if lib.chromaprintJob.calculator == nil || lib.chromaprintJob.running {
+		return
+	}
+	lib.chromaprintJob.running = true
+
+	go func() {
+		defer func() {
+			lib.chromaprintJob.Lock()
+			lib.chromaprintJob.running = false
+			lib.chromaprintJob.Unlock()
+		}()
+
+		start := time.Now()
+		if err := lib.calculateChromaprints(lib.ctx); err != nil {
+			log.Printf("Calculating acoustic fingerprints stopped: %s", err)
+			return
+		}
+		log.Printf("Calculating acoustic fingerprints took %s", time.Since(start))
+	}()
+}
+
+// calculateChromaprints calculates the acoustic fingerprints of all tracks which
+// have none or whose audio has changed since. Tracks from CUE sheets are skipped
+// since their audio is only part of a file.
+func (lib *LocalLibrary) calculateChromaprints(ctx context.Context) error {
+	lib.chromaprintJob.Lock()
+	calculator := lib.chromaprintJob.calculator
+	lib.chromaprintJob.Unlock()
+
+	if calculator == nil {
+		return errors.New("acoustic fingerprints are not enabled")
+	}
+
+	type pendingTrack struct {
+		id          int64
+		fsPath      string
+		fingerprint sql.NullString
+	}
+
+	var tracks []pendingTrack
+	work := func(db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, `
+			SELECT
+				t.id,
+				t.fs_path,
+				t.fingerprint
+			FROM
+				tracks t
+				LEFT JOIN tracks_chromaprints c ON c.track_id = t.id
+			WHERE
+				t.cue_sheet IS NULL AND (
+					c.track_id IS NULL OR
+					IFNULL(c.audio_fingerprint, '') != IFNULL(t.fingerprint, '')
+				)
+		`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var track pendingTrack
+			if err := rows.Scan(&track.id, &track.fsPath, &track.fingerprint); err != nil {
+				return err
+			}
+			tracks = append(tracks, track)
+		}
+		return rows.Err()
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return err
+	}
+
+	for _, track := range tracks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var chromaprintValue sql.NullString
+		var fp chromaprint.Fingerprint
+		err := withLocalFile(track.fsPath, func(localPath string) (err error) {
+			fp, err = calculator.Calculate(ctx, localPath)
+			return err
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Calculating acoustic fingerprint of %s: %s", track.fsPath, err)
+		} else {
+			chromaprintValue = sql.NullString{String: fp.String(), Valid: true}
+		}
+
+		err = lib.executeDBJobAndWait(func(db *sql.DB) error {
+			_, err := db.Exec(`
+				INSERT OR REPLACE INTO tracks_chromaprints
+					(track_id, chromaprint, audio_fingerprint)
+				VALUES
+					(?, ?, ?)
+			`, track.id, chromaprintValue, track.fingerprint)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("saving acoustic fingerprint: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// FindDuplicates implements the DuplicatesFinder interface. Tracks are matched by
+// their artist and title and by their acoustic fingerprints when there are such.
+// The artist and title are compared only by their letters and digits without
+// regard to case. Tracks must be about the same duration in both cases. Groups
+// found by their acoustic fingerprints which were already found by their tags are
+// not repeated.
+func (lib *LocalLibrary) FindDuplicates(ctx context.Context) ([]DuplicateTracks, error) {
+	var (
+		tracks       []DuplicateTrack
+		chromaprints []trackChromaprint
+	)
+
+	work := func(db *sql.DB) error {
+		var err error
+
+		tracks, err = duplicateCandidates(ctx, db)
+		if err != nil {
+			return fmt.Errorf("getting tracks: %w", err)
+		}
+
+		chromaprints, err = tracksChromaprints(ctx, db)
+		if err != nil {
+			return fmt.Errorf("getting acoustic fingerprints: %w", err)
+		}
+
+		return nil
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return nil, err
+	}
+
+	byTags := duplicatesByTags(tracks)
+	byChromaprints := duplicatesByChromaprints(ctx, tracks, chromaprints, byTags)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return append(byTags, byChromaprints...), nil
+}
+
+// trackChromaprint is the acoustic fingerprint of a track.
+type trackChromaprint struct {
+	trackID  int64
+	duration int64
+	print    chromaprint.Fingerprint
+}
+
+func duplicateCandidates(ctx context.Context, db *sql.DB) ([]DuplicateTrack, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			t.id,
+			t.name,
+			IFNULL(ar.name, ''),
+			IFNULL(al.name, ''),
+			t.fs_path,
+			IFNULL(t.format, ''),
+			IFNULL(t.bitrate, 0),
+			IFNULL(t.duration, 0)
+		FROM
+			tracks t
+			LEFT JOIN
+				albums al ON al.id = t.album_id
+			LEFT JOIN
+				artists ar ON ar.id = t.artist_id
+		ORDER BY
+			t.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query database: %w", err)
+	}
+	defer rows.Close()
+
+	var tracks []DuplicateTrack
+	for rows.Next() {
+		var track DuplicateTrack
+		err := rows.Scan(
+			&track.ID,
+			&track.Title,
+			&track.Artist,
+			&track.Album,
+			&track.FSPath,
+			&track.Format,
+			&track.Bitrate,
+			&track.Duration,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanning db result: %w", err)
+		}
+		track.Format = trackFormat(track.Format, track.FSPath)
+		tracks = append(tracks, track)
+	}
+
+	return tracks, rows.Err()
+}
+
+func tracksChromaprints(ctx context.Context, db *sql.DB) ([]trackChromaprint, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			c.track_id,
+			IFNULL(t.duration, 0),
+			c.chromaprint
+		FROM
+			tracks_chromaprints c
+			JOIN tracks t ON t.id = c.track_id
+		WHERE
+			c.chromaprint IS NOT NULL
+		ORDER BY
+			t.duration, c.track_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query database: %w", err)
+	}
+	defer rows.Close()
+
+	var chromaprints []trackChromaprint
+	for rows.Next() {
+		var (
+			found trackChromaprint
+			value string
+		)
+		if err := rows.Scan(&found.trackID, &found.duration, &value); err != nil {
+			return nil, fmt.Errorf("scanning db result: %w", err)
+		}
+
+		found.print, err = chromaprint.Parse(value)
+		if err != nil {
+			log.Printf("Acoustic fingerprint of track %d: %s", found.trackID, err)
+			continue
+		}
+		if len(found.print) > chromaprintItems {
+			found.print = found.print[:chromaprintItems:chromaprintItems]
+		}
+		chromaprints = append(chromaprints, found)
+	}
+
+	return chromaprints, rows.Err()
+}
+
+// duplicatesByTags groups the tracks with the same normalized artist and title
+// and about the same duration.
+func duplicatesByTags(tracks []DuplicateTrack) []DuplicateTracks {
+	type keyedTrack struct {
+		key   string
+		track DuplicateTrack
+	}
+
+	keyed := make([]keyedTrack, 0, len(tracks))
+	for _, track := range tracks {
+		title := normalizeForDuplicates(track.Title)
+		if title == "" {
+			continue
+		}
+		keyed = append(keyed, keyedTrack{
+			key:   normalizeForDuplicates(track.Artist) + "\x00" + title,
+			track: track,
+		})
+	}
+
+	sort.SliceStable(keyed, func(i, j int) bool {
+		if keyed[i].key != keyed[j].key {
+			return keyed[i].key < keyed[j].key
+		}
+		return keyed[i].track.Duration < keyed[j].track.Duration
+	})
+
+	var (
+		groups  = []DuplicateTracks{}
+		current []DuplicateTrack
+	)
+
+	// flush adds the current group to the result if it has duplicates.
+	flush := func() {
+		if len(current) > 1 {
+			groups = append(groups, DuplicateTracks{
+				MatchedBy: MatchedByTags,
+				Tracks:    current,
+			})
+		}
+		current = nil
+	}
+
+	for ind, item := range keyed {
+		if ind > 0 {
+			previous := keyed[ind-1]
+			delta := time.Duration(item.track.Duration-previous.track.Duration) *
+				time.Millisecond
+			if item.key != previous.key || delta > duplicateDurationDelta {
+				flush()
+			}
+		}
+		current = append(current, item.track)
+	}
+	flush()
+
+	return groups
+}
+
+// duplicatesByChromaprints groups the tracks with similar acoustic fingerprints
+// and about the same duration. Groups which are entirely in one of the `known`
+// groups are skipped. `chromaprints` must be sorted by duration.
+func duplicatesByChromaprints(
+	ctx context.Context,
+	tracks []DuplicateTrack,
+	chromaprints []trackChromaprint,
+	known []DuplicateTracks,
+) []DuplicateTracks {
+	// parent is a disjoint-set forest of track IDs. Similar tracks are in the
+	// same set.
+	parent := make(map[int64]int64)
+	var find func(int64) int64
+	find = func(id int64) int64 {
+		if p, ok := parent[id]; ok && p != id {
+			root := find(p)
+			parent[id] = root
+			return root
+		}
+		return id
+	}
+
+	for ind, current := range chromaprints {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		for _, other := range chromaprints[ind+1:] {
+			delta := time.Duration(other.duration-current.duration) * time.Millisecond
+			if delta > duplicateDurationDelta {
+				break
+			}
+			if chromaprint.Similarity(current.print, other.print) < chromaprintSimilarity {
+				continue
+			}
+
+			currentRoot, otherRoot := find(current.trackID), find(other.trackID)
+			if currentRoot != otherRoot {
+				parent[currentRoot] = currentRoot
+				parent[otherRoot] = currentRoot
+			}
+		}
+	}
+
+	knownGroup := make(map[int64]int)
+	for ind, group := range known {
+		for _, track := range group.Tracks {
+			knownGroup[track.ID] = ind
+		}
+	}
+
+	var (
+		sets  = make(map[int64][]DuplicateTrack)
+		roots []int64
+	)
+	for _, track := range tracks {
+		if _, ok := parent[track.ID]; !ok {
+			continue
+		}
+		root := find(track.ID)
+		if _, ok := sets[root]; !ok {
+			roots = append(roots, root)
+		}
+		sets[root] = append(sets[root], track)
+	}
+
+	groups := []DuplicateTracks{}
+	for _, root := range roots {
+		set := sets[root]
+		if len(set) < 2 || inOneGroup(set, knownGroup) {
+			continue
+		}
+		groups = append(groups, DuplicateTracks{
+			MatchedBy: MatchedByChromaprint,
+			Tracks:    set,
+		})
+	}
+
+	return groups
+}
+
+// inOneGroup returns true when all tracks are in the same group according to
+// `groups` which maps track IDs to groups.
+func inOneGroup(tracks []DuplicateTrack, groups map[int64]int) bool {
+	first, ok := groups[tracks[0].ID]
+	if !ok {
+		return false
+	}
+
+	for _, track := range tracks[1:] {
+		if group, ok := groups[track.ID]; !ok || group != first {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalizeForDuplicates returns only the letters and digits of `text` in lower
+// case. So that "AC/DC" and "ac-dc" are the same.
+func normalizeForDuplicates(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, text)
+}
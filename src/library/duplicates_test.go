@@ -0,0 +1,131 @@
+package library
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/chromaprint"
+)
+
+// TestFindDuplicates checks that duplicate tracks are found by their tags and by
+// their acoustic fingerprints.
+func TestFindDuplicates(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	// The fake fpcalc prints the contents of the media file as its fingerprint.
+	tmpDir := t.TempDir()
+	fpcalc := filepath.Join(tmpDir, "fpcalc")
+	script := "#!/bin/sh\necho FINGERPRINT=$(cat \"$4\")\n"
+	if err := os.WriteFile(fpcalc, []byte(script), 0o700); err != nil {
+		t.Fatalf("writing fake fpcalc: %s", err)
+	}
+
+	random := rand.New(rand.NewSource(42))
+	fingerprint := func() string {
+		fp := make(chromaprint.Fingerprint, 100)
+		for ind := range fp {
+			fp[ind] = random.Uint32()
+		}
+		return fp.String()
+	}
+	sameAudio := fingerprint()
+
+	tracks := []struct {
+		file   string
+		audio  string
+		artist string
+		title  string
+		length time.Duration
+	}{
+		{"original.mp3", sameAudio, "Artist", "Original", 200 * time.Second},
+		{"retagged.flac", sameAudio, "Artist", "Other Title", 201 * time.Second},
+		{"unrelated.mp3", fingerprint(), "Other", "Unrelated", 200 * time.Second},
+		{"tnt.mp3", fingerprint(), "AC/DC", "Thunderstruck", 292 * time.Second},
+		{"best-of.mp3", fingerprint(), "ac-dc", "thunderstruck", 293 * time.Second},
+		{"live.mp3", fingerprint(), "AC/DC", "Thunderstruck", 320 * time.Second},
+	}
+
+	paths := make(map[string]string)
+	for _, track := range tracks {
+		fsPath := filepath.Join(tmpDir, track.file)
+		if err := os.WriteFile(fsPath, []byte(track.audio), 0o600); err != nil {
+			t.Fatalf("writing %s: %s", fsPath, err)
+		}
+		paths[track.file] = fsPath
+
+		media := MockMedia{
+			artist: track.artist,
+			album:  "Album",
+			title:  track.title,
+			track:  1,
+			length: track.length,
+		}
+		if err := lib.insertMediaIntoDatabase(&media, fsPath); err != nil {
+			t.Fatalf("inserting %s: %s", fsPath, err)
+		}
+	}
+
+	lib.EnableChromaprints(chromaprint.NewCalculator(fpcalc))
+	if err := lib.calculateChromaprints(ctx); err != nil {
+		t.Fatalf("calculating acoustic fingerprints: %s", err)
+	}
+
+	duplicates, err := lib.FindDuplicates(ctx)
+	if err != nil {
+		t.Fatalf("finding duplicates: %s", err)
+	}
+
+	if len(duplicates) != 2 {
+		t.Fatalf("expected two groups of duplicates but got %+v", duplicates)
+	}
+
+	expected := []struct {
+		matchedBy string
+		files     []string
+	}{
+		{MatchedByTags, []string{"tnt.mp3", "best-of.mp3"}},
+		{MatchedByChromaprint, []string{"original.mp3", "retagged.flac"}},
+	}
+	for ind, group := range expected {
+		found := duplicates[ind]
+		if found.MatchedBy != group.matchedBy {
+			t.Errorf("expected group %d to be matched by %s but it is by %s",
+				ind, group.matchedBy, found.MatchedBy)
+		}
+		if len(found.Tracks) != len(group.files) {
+			t.Errorf("expected %d tracks in group %d but got %+v",
+				len(group.files), ind, found.Tracks)
+			continue
+		}
+		for trackInd, file := range group.files {
+			if found.Tracks[trackInd].FSPath != paths[file] {
+				t.Errorf("expected track %d in group %d to be %s but got %s",
+					trackInd, ind, paths[file], found.Tracks[trackInd].FSPath)
+			}
+		}
+	}
+
+	if format := duplicates[1].Tracks[1].Format; format != "flac" {
+		t.Errorf("expected the retagged track to be flac but got %s", format)
+	}
+}
@@ -0,0 +1,353 @@
+package library
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// IgnoreFileName is the name of the files with patterns of files and directories
+// which are left out of the library. Their syntax is the same as the one of the
+// .gitignore files. Patterns in them are relative to the directory of the file.
+const IgnoreFileName = ".euterpeignore"
+
+// allLibrariesExclude is the key in the exclusion configuration for patterns
+// which are used for all library directories.
+const allLibrariesExclude = "*"
+
+// ignoreRule is a single pattern from an ignore file or the configuration.
+type ignoreRule struct {
+	// base is the directory to which the pattern is relative.
+	base string
+
+	// segments are the parts of the pattern between slashes. Patterns which
+	// match at any depth start with "**".
+	segments []string
+
+	// negate is set for patterns which start with "!". Files which match them
+	// are included again.
+	negate bool
+
+	// dirOnly is set for patterns which end with "/". They match directories
+	// only.
+	dirOnly bool
+}
+
+// ignoreRules are ignore patterns in the order in which they were defined. Later
+// rules take precedence.
+type ignoreRules []ignoreRule
+
+// parseIgnoreRules parses patterns in the .gitignore syntax which are relative to
+// base. Empty lines and comments are skipped.
+func parseIgnoreRules(base string, patterns []string) ignoreRules {
+	var rules ignoreRules
+	for _, pattern := range patterns {
+		pattern = strings.TrimRight(pattern, " \t\r")
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+
+		rule := ignoreRule{base: base}
+		if strings.HasPrefix(pattern, "!") {
+			rule.negate = true
+			pattern = pattern[1:]
+		} else if strings.HasPrefix(pattern, `\#`) || strings.HasPrefix(pattern, `\!`) {
+			pattern = pattern[1:]
+		}
+
+		if strings.HasSuffix(pattern, "/") {
+			rule.dirOnly = true
+			pattern = strings.TrimRight(pattern, "/")
+		}
+
+		// Patterns with a slash at the beginning or in the middle are relative
+		// to the base. The rest match at any depth.
+		anchored := strings.Contains(pattern, "/")
+		pattern = strings.TrimLeft(pattern, "/")
+		if pattern == "" {
+			continue
+		}
+
+		if !anchored {
+			rule.segments = append(rule.segments, "**")
+		}
+		rule.segments = append(rule.segments, strings.Split(pattern, "/")...)
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// excludes returns true when the file or directory at filePath is excluded by
+// the rules. It does not check the parent directories of filePath.
+func (rules ignoreRules) excludes(filePath string, isDir bool) bool {
+	excluded := false
+	for _, rule := range rules {
+		if rule.matches(filePath, isDir) {
+			excluded = !rule.negate
+		}
+	}
+	return excluded
+}
+
+// matches returns true when the rule's pattern matches filePath.
+func (rule ignoreRule) matches(filePath string, isDir bool) bool {
+	if rule.dirOnly && !isDir {
+		return false
+	}
+
+	rel, err := filepath.Rel(rule.base, filePath)
+	if err != nil || rel == "." || rel == ".." ||
+		strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+
+	return matchSegments(rule.segments, strings.Split(filepath.ToSlash(rel), "/"))
+}
+
+// matchSegments matches the path segments against the pattern segments. A "**"
+// segment matches any number of path segments. At the end of the pattern it
+// matches everything inside a directory but not the directory itself.
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		if len(pattern) == 1 {
+			return len(segments) > 0
+		}
+		for ind := range segments {
+			if matchSegments(pattern[1:], segments[ind:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	matched, err := path.Match(pattern[0], segments[0])
+	if err != nil || !matched {
+		return false
+	}
+
+	return matchSegments(pattern[1:], segments[1:])
+}
+
+// ignoreCache keeps the parsed exclusion rules so that the files are not read
+// for every checked path.
+type ignoreCache struct {
+	sync.Mutex
+
+	// config holds the rules from the configuration by library directory.
+	config map[string]ignoreRules
+
+	// files holds the rules from the ignore files by directory. Directories
+	// without an ignore file have no rules.
+	files map[string]ignoreRules
+}
+
+// forget removes the rules of the ignore file in dir so that they are read
+// again the next time they are needed.
+func (ic *ignoreCache) forget(dir string) {
+	ic.Lock()
+	defer ic.Unlock()
+
+	delete(ic.files, dir)
+}
+
+// reset removes all rules from the cache.
+func (ic *ignoreCache) reset() {
+	ic.Lock()
+	defer ic.Unlock()
+
+	ic.config = nil
+	ic.files = nil
+}
+
+// isExcluded returns true when the file or directory at filePath must be left out
+// of the library. This is so when it or any of its parent directories matches the
+// patterns for its library from the configuration or from the ignore files in the
+// directories above it. Paths outside of the library are never excluded.
+func (lib *LocalLibrary) isExcluded(filePath string, isDir bool) bool {
+	filePath = filepath.Clean(filePath)
+
+	root, ok := lib.libraryRootOf(filePath)
+	if !ok || root == filePath {
+		return false
+	}
+
+	rel, err := filepath.Rel(root, filePath)
+	if err != nil {
+		return false
+	}
+
+	rules := lib.configIgnoreRules(root)
+	segments := strings.Split(rel, string(filepath.Separator))
+
+	dir := root
+	for ind, segment := range segments {
+		fileRules := lib.ignoreFileRules(dir)
+		rules = append(rules[:len(rules):len(rules)], fileRules...)
+
+		current := filepath.Join(dir, segment)
+		if rules.excludes(current, isDir || ind < len(segments)-1) {
+			return true
+		}
+		dir = current
+	}
+
+	return false
+}
+
+// libraryRootOf returns the library directory, enabled or not, in which
+// filePath is.
+func (lib *LocalLibrary) libraryRootOf(filePath string) (string, bool) {
+	roots := append(lib.libraryPaths(), lib.disabledLibraryPaths()...)
+	for _, root := range roots {
+		root = filepath.Clean(root)
+		if insideRoots(filePath, []string{root}) {
+			return root, true
+		}
+	}
+	return "", false
+}
+
+// configIgnoreRules returns the exclusion rules from the configuration for the
+// library directory root.
+func (lib *LocalLibrary) configIgnoreRules(root string) ignoreRules {
+	lib.ignores.Lock()
+	defer lib.ignores.Unlock()
+
+	if rules, ok := lib.ignores.config[root]; ok {
+		return rules
+	}
+
+	var patterns []string
+	patterns = append(patterns, lib.ScanConfig.Exclude[allLibrariesExclude]...)
+	for dir, dirPatterns := range lib.ScanConfig.Exclude {
+		if dir != allLibrariesExclude && filepath.Clean(dir) == root {
+			patterns = append(patterns, dirPatterns...)
+		}
+	}
+
+	if lib.ignores.config == nil {
+		lib.ignores.config = make(map[string]ignoreRules)
+	}
+	rules := parseIgnoreRules(root, patterns)
+	lib.ignores.config[root] = rules
+
+	return rules
+}
+
+// ignoreFileRules returns the rules from the ignore file in dir. Directories
+// without one have no rules.
+func (lib *LocalLibrary) ignoreFileRules(dir string) ignoreRules {
+	lib.ignores.Lock()
+	defer lib.ignores.Unlock()
+
+	if rules, ok := lib.ignores.files[dir]; ok {
+		return rules
+	}
+
+	var rules ignoreRules
+	content, err := fs.ReadFile(lib.fs, filepath.Join(dir, IgnoreFileName))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Error reading ignore file in %s: %s\n", dir, err)
+	}
+	if err == nil {
+		var patterns []string
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		for scanner.Scan() {
+			patterns = append(patterns, scanner.Text())
+		}
+		rules = parseIgnoreRules(dir, patterns)
+	}
+
+	if lib.ignores.files == nil {
+		lib.ignores.files = make(map[string]ignoreRules)
+	}
+	lib.ignores.files[dir] = rules
+
+	return rules
+}
+
+// updateIgnoreFile applies the changed ignore file in dir. Tracks which are
+// excluded now are removed from the library and files which are no longer
+// excluded are added to it.
+func (lib *LocalLibrary) updateIgnoreFile(dir string) {
+	lib.ignores.forget(dir)
+
+	tracks, err := lib.tracksInDirectory(lib.ctx, dir)
+	if err != nil {
+		log.Printf("Error getting tracks in %s: %s\n", dir, err)
+		return
+	}
+
+	if _, err := lib.checkAndRemoveTracks(tracks, nil); err != nil {
+		log.Printf("Error removing excluded tracks in %s: %s\n", dir, err)
+	}
+
+	lib.waitScanLock.Lock()
+	lib.walkWG.Add(1)
+	lib.waitScanLock.Unlock()
+
+	lib.scanPath(dir)
+}
+
+// tracksInDirectory returns the tracks which are somewhere in dir.
+func (lib *LocalLibrary) tracksInDirectory(
+	ctx context.Context,
+	dir string,
+) ([]track, error) {
+	prefix := dir
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+
+	var tracks []track
+	work := func(db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, `
+			SELECT
+				id,
+				fs_path,
+				cue_sheet
+			FROM
+				tracks
+			WHERE
+				fs_path >= ? AND
+				fs_path < ?
+		`, prefix, prefix[:len(prefix)-1]+string(filepath.Separator+1))
+		if err != nil {
+			return fmt.Errorf("querying tracks: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var tr track
+			if err := rows.Scan(&tr.id, &tr.fsPath, &tr.cueSheet); err != nil {
+				return fmt.Errorf("scanning track: %w", err)
+			}
+			tracks = append(tracks, tr)
+		}
+
+		return rows.Err()
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return nil, err
+	}
+
+	return tracks, nil
+}
@@ -0,0 +1,193 @@
+package library
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/helpers"
+)
+
+// TestIgnoreRules checks matching paths against patterns in the .gitignore syntax.
+func TestIgnoreRules(t *testing.T) {
+	base := filepath.FromSlash("/music")
+
+	tests := []struct {
+		desc     string
+		patterns []string
+		path     string
+		isDir    bool
+		excluded bool
+	}{
+		{
+			desc:     "name at any depth",
+			patterns: []string{"*.wav"},
+			path:     "/music/a/b/sample.wav",
+			excluded: true,
+		},
+		{
+			desc:     "name which does not match",
+			patterns: []string{"*.wav"},
+			path:     "/music/a/b/song.mp3",
+		},
+		{
+			desc:     "anchored pattern at its base",
+			patterns: []string{"/audiobooks"},
+			path:     "/music/audiobooks",
+			isDir:    true,
+			excluded: true,
+		},
+		{
+			desc:     "anchored pattern deeper",
+			patterns: []string{"/audiobooks"},
+			path:     "/music/old/audiobooks",
+			isDir:    true,
+		},
+		{
+			desc:     "pattern with a slash in the middle",
+			patterns: []string{"old/samples"},
+			path:     "/music/old/samples",
+			isDir:    true,
+			excluded: true,
+		},
+		{
+			desc:     "directory pattern for a file",
+			patterns: []string{"_incoming/"},
+			path:     "/music/_incoming",
+		},
+		{
+			desc:     "directory pattern for a directory",
+			patterns: []string{"_incoming/"},
+			path:     "/music/rock/_incoming",
+			isDir:    true,
+			excluded: true,
+		},
+		{
+			desc:     "double star in the middle",
+			patterns: []string{"live/**/*.flac"},
+			path:     "/music/live/1969/woodstock/set.flac",
+			excluded: true,
+		},
+		{
+			desc:     "double star at the end",
+			patterns: []string{"live/**"},
+			path:     "/music/live",
+			isDir:    true,
+		},
+		{
+			desc:     "negated pattern",
+			patterns: []string{"*.mp3", "# comment", "", "!keep.mp3"},
+			path:     "/music/keep.mp3",
+		},
+		{
+			desc:     "path outside of the base",
+			patterns: []string{"*.mp3"},
+			path:     "/podcasts/episode.mp3",
+		},
+	}
+
+	for _, test := range tests {
+		rules := parseIgnoreRules(base, test.patterns)
+		excluded := rules.excludes(filepath.FromSlash(test.path), test.isDir)
+		if excluded != test.excluded {
+			t.Errorf("%s: expected excluded %t for %s but got %t",
+				test.desc, test.excluded, test.path, excluded)
+		}
+	}
+}
+
+// TestScanExclusions checks that excluded files are not scanned and that tracks
+// are removed when they become excluded.
+func TestScanExclusions(t *testing.T) {
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+	lib.DisableWatching()
+
+	projRoot, err := helpers.ProjectRoot()
+	if err != nil {
+		t.Fatalf("Was not able to find test_files directory: %s", err)
+	}
+	testMp3 := filepath.Join(projRoot, "test_files", "more_mp3s", "test_file_added.mp3")
+
+	root := t.TempDir()
+	files := []string{
+		"keep.mp3",
+		filepath.Join("_incoming", "new.mp3"),
+		filepath.Join("audiobooks", "book.mp3"),
+		filepath.Join("album", "skip.mp3"),
+		filepath.Join("album", "keep.mp3"),
+	}
+	for _, file := range files {
+		dst := filepath.Join(root, file)
+		if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := copyFile(testMp3, dst); err != nil {
+			t.Fatalf("copying test file: %s", err)
+		}
+	}
+
+	ignoreFile := filepath.Join(root, "album", IgnoreFileName)
+	err = os.WriteFile(ignoreFile, []byte("*.mp3\n!keep.mp3\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lib.ScanConfig.Exclude = map[string][]string{
+		"*":  {"_incoming/"},
+		root: {"/audiobooks"},
+	}
+	lib.AddLibraryPath(root)
+	lib.Scan()
+
+	assertInLibrary := func(expected map[string]bool) {
+		t.Helper()
+		for file, inLibrary := range expected {
+			found := lib.MediaExistsInLibrary(filepath.Join(root, file))
+			if found != inLibrary {
+				t.Errorf("expected %s in library to be %t but it was %t",
+					file, inLibrary, found)
+			}
+		}
+	}
+
+	assertInLibrary(map[string]bool{
+		files[0]: true,
+		files[1]: false,
+		files[2]: false,
+		files[3]: false,
+		files[4]: true,
+	})
+
+	err = os.WriteFile(ignoreFile, []byte("*.mp3\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib.updateIgnoreFile(filepath.Dir(ignoreFile))
+
+	assertInLibrary(map[string]bool{
+		files[0]: true,
+		files[4]: false,
+	})
+
+	if err := os.Remove(ignoreFile); err != nil {
+		t.Fatal(err)
+	}
+	lib.updateIgnoreFile(filepath.Dir(ignoreFile))
+
+	assertInLibrary(map[string]bool{
+		files[3]: true,
+		files[4]: true,
+	})
+}
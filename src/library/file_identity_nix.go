@@ -0,0 +1,22 @@
+//go:build !windows
+// +build !windows
+
+package library
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileIdentityOf returns the device and inode of the file at path.
+func fileIdentityOf(_ string, info fs.FileInfo) (fileIdentity, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileIdentity{}, false
+	}
+
+	return fileIdentity{
+		device: uint64(st.Dev),
+		inode:  uint64(st.Ino),
+	}, true
+}
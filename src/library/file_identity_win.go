@@ -0,0 +1,20 @@
+//go:build windows
+// +build windows
+
+package library
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// fileIdentityOf returns the path of the file at path with all symbolic links
+// resolved. There are no inodes on Windows.
+func fileIdentityOf(path string, _ fs.FileInfo) (fileIdentity, bool) {
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fileIdentity{}, false
+	}
+
+	return fileIdentity{path: realPath}, true
+}
@@ -0,0 +1,532 @@
+package library
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+//counterfeiter:generate . FolderBrowser
+
+// FolderBrowser defines the methods for browsing a library by its directories.
+// Folders are identified by opaque IDs so that their paths are never revealed.
+type FolderBrowser interface {
+	// RootFolders returns the directories of the library.
+	RootFolders(ctx context.Context) ([]Folder, error)
+
+	// BrowseFolder returns the folder with folderID together with a page of its
+	// sub-folders and tracks. Sub-folders are listed before the tracks. It also
+	// returns the number of all sub-folders and tracks in the folder.
+	// ErrFolderNotFound is returned when there is no such folder in the library.
+	BrowseFolder(
+		ctx context.Context,
+		folderID string,
+		args BrowseArgs,
+	) (FolderContents, int, error)
+}
+
+// Folder is a directory in the library.
+type Folder struct {
+	ID   string `json:"folder_id"`
+	Name string `json:"name"`
+}
+
+// FolderContents is a page of the contents of a folder.
+type FolderContents struct {
+	Folder
+
+	// ParentID is the ID of the parent folder. It is empty for the directories
+	// of the library.
+	ParentID string `json:"parent_id,omitempty"`
+
+	Folders []Folder       `json:"folders"`
+	Tracks  []SearchResult `json:"tracks"`
+}
+
+// folderIDBytes is the number of random bytes in the ID of a folder.
+const folderIDBytes = 8
+
+// folderPaths caches the IDs of the folders stored in the database by their
+// paths. Folders are never removed from the database so they do not go stale.
+type folderPaths struct {
+	sync.Mutex
+
+	byPath map[string]string
+}
+
+// add remembers that the folder at path has `id`.
+func (fp *folderPaths) add(path, id string) {
+	fp.Lock()
+	defer fp.Unlock()
+
+	if fp.byPath == nil {
+		fp.byPath = make(map[string]string)
+	}
+	fp.byPath[path] = id
+}
+
+// get returns the ID of the folder at path.
+func (fp *folderPaths) get(path string) (string, bool) {
+	fp.Lock()
+	defer fp.Unlock()
+
+	id, ok := fp.byPath[path]
+	return id, ok
+}
+
+// RootFolders implements the FolderBrowser interface. The folders are named as
+// the library directories.
+func (lib *LocalLibrary) RootFolders(ctx context.Context) ([]Folder, error) {
+	names, err := lib.libraryNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := []Folder{}
+	for _, root := range lib.rootPaths() {
+		id, err := lib.folderOf(root)
+		if err != nil {
+			return nil, err
+		}
+
+		roots = append(roots, Folder{
+			ID:   id.String,
+			Name: folderName(root, names),
+		})
+	}
+
+	return roots, nil
+}
+
+// BrowseFolder implements the FolderBrowser interface. Sub-folders are only the
+// directories with media files in them at some depth. Sub-folders are ordered by
+// their names and tracks by the names of their files.
+func (lib *LocalLibrary) BrowseFolder(
+	ctx context.Context,
+	folderID string,
+	args BrowseArgs,
+) (FolderContents, int, error) {
+	path, err := lib.findFolder(ctx, folderID)
+	if err != nil {
+		return FolderContents{}, 0, err
+	}
+
+	libraryNames, err := lib.libraryNames(ctx)
+	if err != nil {
+		return FolderContents{}, 0, err
+	}
+
+	contents := FolderContents{
+		Folder: Folder{
+			ID:   folderID,
+			Name: folderName(path, libraryNames),
+		},
+		Folders: []Folder{},
+		Tracks:  []SearchResult{},
+	}
+	if !isRootPath(path, lib.rootPaths()) {
+		parentID, err := lib.folderOf(filepath.Dir(path))
+		if err != nil {
+			return FolderContents{}, 0, err
+		}
+		contents.ParentID = parentID.String
+	}
+
+	order := "ASC"
+	if args.Order == OrderDesc {
+		order = "DESC"
+	}
+
+	type folderTrack struct {
+		id      int64
+		albumID int64
+	}
+
+	var (
+		foldersCount int
+		tracksCount  int
+		tracks       []folderTrack
+		offset       = int(args.Page * args.PerPage)
+		limit        = int(args.PerPage)
+	)
+
+	work := func(db *sql.DB) error {
+		// The paths of the tracks in a folder are between its path with a
+		// separator at the end and its path with the next character instead.
+		// This uses the index on the paths.
+		err := db.QueryRowContext(ctx, `
+			SELECT
+				COUNT(*)
+			FROM
+				folders f
+			WHERE
+				f.parent = ? AND
+				EXISTS (
+					SELECT 1
+					FROM tracks t
+					WHERE t.fs_path >= f.path || ? AND t.fs_path < f.path || ?
+				)
+		`, path, string(filepath.Separator), string(filepath.Separator+1)).Scan(
+			&foldersCount,
+		)
+		if err != nil {
+			return fmt.Errorf("counting sub-folders: %w", err)
+		}
+
+		err = db.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM tracks WHERE folder_id = ?
+		`, folderID).Scan(&tracksCount)
+		if err != nil {
+			return fmt.Errorf("counting folder tracks: %w", err)
+		}
+
+		if offset < foldersCount {
+			rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+				SELECT
+					f.id,
+					f.name
+				FROM
+					folders f
+				WHERE
+					f.parent = ? AND
+					EXISTS (
+						SELECT 1
+						FROM tracks t
+						WHERE t.fs_path >= f.path || ? AND t.fs_path < f.path || ?
+					)
+				ORDER BY
+					LOWER(f.name) %s, f.name %s
+				LIMIT ? OFFSET ?
+			`, order, order), path, string(filepath.Separator),
+				string(filepath.Separator+1), limit, offset)
+			if err != nil {
+				return fmt.Errorf("querying sub-folders: %w", err)
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var folder Folder
+				if err := rows.Scan(&folder.ID, &folder.Name); err != nil {
+					return fmt.Errorf("scanning sub-folder: %w", err)
+				}
+				contents.Folders = append(contents.Folders, folder)
+			}
+			if err := rows.Err(); err != nil {
+				return err
+			}
+		}
+
+		tracksOffset := offset - foldersCount
+		if tracksOffset < 0 {
+			tracksOffset = 0
+		}
+		tracksLimit := limit - len(contents.Folders)
+		if tracksLimit <= 0 {
+			return nil
+		}
+
+		// All tracks are in the same directory so their paths are ordered the
+		// same way as the names of their files.
+		rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+			SELECT
+				id,
+				album_id
+			FROM
+				tracks
+			WHERE
+				folder_id = ?
+			ORDER BY
+				LOWER(fs_path) %s, fs_path %s, IFNULL(number, 0), id
+			LIMIT ? OFFSET ?
+		`, order, order), folderID, tracksLimit, tracksOffset)
+		if err != nil {
+			return fmt.Errorf("querying folder tracks: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var track folderTrack
+			if err := rows.Scan(&track.id, &track.albumID); err != nil {
+				return fmt.Errorf("scanning folder track: %w", err)
+			}
+			tracks = append(tracks, track)
+		}
+
+		return rows.Err()
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return FolderContents{}, 0, err
+	}
+
+	albumFiles := make(map[int64][]SearchResult)
+	for _, track := range tracks {
+		if _, ok := albumFiles[track.albumID]; !ok {
+			albumFiles[track.albumID] = lib.GetAlbumFiles(track.albumID)
+		}
+
+		for _, found := range albumFiles[track.albumID] {
+			if found.ID == track.id {
+				contents.Tracks = append(contents.Tracks, found)
+				break
+			}
+		}
+	}
+
+	return contents, foldersCount + tracksCount, nil
+}
+
+// findFolder returns the path of the folder with `id`. Only the library
+// directories and the folders in them with tracks at some depth are found.
+func (lib *LocalLibrary) findFolder(ctx context.Context, id string) (string, error) {
+	var (
+		path      string
+		hasTracks bool
+	)
+	work := func(db *sql.DB) error {
+		return db.QueryRowContext(ctx, `
+			SELECT
+				f.path,
+				EXISTS (
+					SELECT 1
+					FROM tracks t
+					WHERE t.fs_path >= f.path || ? AND t.fs_path < f.path || ?
+				)
+			FROM
+				folders f
+			WHERE
+				f.id = ?
+		`, string(filepath.Separator), string(filepath.Separator+1), id).Scan(
+			&path,
+			&hasTracks,
+		)
+	}
+	if err := lib.executeDBJobAndWait(work); errors.Is(err, sql.ErrNoRows) {
+		return "", ErrFolderNotFound
+	} else if err != nil {
+		return "", err
+	}
+
+	roots := lib.rootPaths()
+	if !insideRoots(path, roots) || (!hasTracks && !isRootPath(path, roots)) {
+		return "", ErrFolderNotFound
+	}
+
+	return path, nil
+}
+
+// folderOf returns the ID of the folder at dir. The folder and its parents up to
+// its library directory are stored when they are not already. It is NULL for
+// directories which are in no library directory.
+func (lib *LocalLibrary) folderOf(dir string) (sql.NullString, error) {
+	if id, ok := lib.folders.get(dir); ok {
+		return sql.NullString{String: id, Valid: true}, nil
+	}
+
+	root, ok := lib.libraryRootOf(dir)
+	if !ok {
+		return sql.NullString{}, nil
+	}
+
+	var missing []string
+	for path := dir; ; path = filepath.Dir(path) {
+		if _, ok := lib.folders.get(path); ok {
+			break
+		}
+		missing = append(missing, path)
+		if path == root || filepath.Dir(path) == path {
+			break
+		}
+	}
+
+	ids := make([]string, len(missing))
+	work := func(db *sql.DB) error {
+		for ind, path := range missing {
+			id, err := newFolderID()
+			if err != nil {
+				return err
+			}
+
+			_, err = db.Exec(`
+				INSERT INTO folders (id, path, parent, name)
+				VALUES (?, ?, ?, ?)
+				ON CONFLICT (path) DO NOTHING
+			`, id, path, filepath.Dir(path), filepath.Base(path))
+			if err != nil {
+				return err
+			}
+
+			err = db.QueryRow(`
+				SELECT id FROM folders WHERE path = ?
+			`, path).Scan(&ids[ind])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return sql.NullString{}, fmt.Errorf("storing folder %s: %w", dir, err)
+	}
+
+	for ind, path := range missing {
+		lib.folders.add(path, ids[ind])
+	}
+
+	return sql.NullString{String: ids[0], Valid: true}, nil
+}
+
+// fillTrackFolders stores the folders of the tracks which were added before
+// folders were stored.
+func (lib *LocalLibrary) fillTrackFolders() {
+	type trackPath struct {
+		id     int64
+		fsPath string
+	}
+
+	var cursor int64
+	for {
+		var tracks []trackPath
+		work := func(db *sql.DB) error {
+			rows, err := db.Query(`
+				SELECT
+					id,
+					fs_path
+				FROM
+					tracks
+				WHERE
+					folder_id IS NULL AND
+					id > ?
+				ORDER BY
+					id
+				LIMIT ?
+			`, cursor, batchLimit)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var track trackPath
+				if err := rows.Scan(&track.id, &track.fsPath); err != nil {
+					return err
+				}
+				tracks = append(tracks, track)
+			}
+			return rows.Err()
+		}
+		if err := lib.executeDBJobAndWait(work); err != nil {
+			log.Printf("Error getting tracks without folders: %s", err)
+			return
+		}
+		if len(tracks) == 0 {
+			return
+		}
+		cursor = tracks[len(tracks)-1].id
+
+		folders := make([]sql.NullString, len(tracks))
+		for ind, track := range tracks {
+			folder, err := lib.folderOf(filepath.Dir(track.fsPath))
+			if err != nil {
+				log.Printf("Error finding folder of %s: %s", track.fsPath, err)
+				continue
+			}
+			folders[ind] = folder
+		}
+
+		work = func(db *sql.DB) error {
+			for ind, track := range tracks {
+				if !folders[ind].Valid {
+					continue
+				}
+
+				_, err := db.Exec(`
+					UPDATE tracks SET folder_id = ? WHERE id = ?
+				`, folders[ind], track.id)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := lib.executeDBJobAndWait(work); err != nil {
+			log.Printf("Error storing track folders: %s", err)
+			return
+		}
+	}
+}
+
+// rootPaths returns the cleaned up directories of the library.
+func (lib *LocalLibrary) rootPaths() []string {
+	paths := lib.libraryPaths()
+
+	roots := make([]string, 0, len(paths))
+	for _, path := range paths {
+		roots = append(roots, filepath.Clean(path))
+	}
+	return roots
+}
+
+// folderName returns the name of the folder at path. Library directories have
+// names of their own in `libraryNames`.
+func folderName(path string, libraryNames map[string]string) string {
+	if name, ok := libraryNames[path]; ok {
+		return name
+	}
+	return filepath.Base(path)
+}
+
+// newFolderID returns a new random ID for a folder. IDs are random so that
+// nothing could be learned about the paths of the folders from them.
+func newFolderID() (string, error) {
+	buf := make([]byte, folderIDBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("creating folder ID: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// isRootPath returns true when path is one of the roots.
+func isRootPath(path string, roots []string) bool {
+	for _, root := range roots {
+		if path == root {
+			return true
+		}
+	}
+	return false
+}
+
+// insideRoots returns true when path is one of the roots or is somewhere in
+// one of them.
+func insideRoots(path string, roots []string) bool {
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == ".." {
+			continue
+		}
+		if !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// lessFolderName compares folder and file names without regard to case. The
+// names are compared in reverse for OrderDesc.
+func lessFolderName(a, b string, order BrowseOrder) bool {
+	if order == OrderDesc {
+		a, b = b, a
+	}
+
+	lowerA, lowerB := strings.ToLower(a), strings.ToLower(b)
+	if lowerA != lowerB {
+		return lowerA < lowerB
+	}
+	return a < b
+}
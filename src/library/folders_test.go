@@ -0,0 +1,148 @@
+package library
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestBrowseFolders checks browsing the library by its directories.
+func TestBrowseFolders(t *testing.T) {
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	root := t.TempDir()
+	lib.AddLibraryPath(root)
+
+	files := []string{
+		"a/Album One/2.mp3",
+		"a/Album One/1.mp3",
+		"a/Album Two/CD1/1.mp3",
+		"B/1.mp3",
+		"loose.mp3",
+	}
+	for ind, file := range files {
+		media := MockMedia{
+			artist: "Artist",
+			album:  filepath.Base(filepath.Dir(file)),
+			title:  file,
+			track:  ind + 1,
+			length: time.Minute,
+		}
+		fsPath := filepath.Join(root, filepath.FromSlash(file))
+		if err := lib.insertMediaIntoDatabase(&media, fsPath); err != nil {
+			t.Fatalf("inserting %s: %s", fsPath, err)
+		}
+	}
+
+	roots, err := lib.RootFolders(ctx)
+	if err != nil {
+		t.Fatalf("getting root folders: %s", err)
+	}
+	if len(roots) != 1 || roots[0].Name != filepath.Base(root) {
+		t.Fatalf("unexpected root folders: %+v", roots)
+	}
+
+	browse := func(folderID string, args BrowseArgs) (FolderContents, int) {
+		t.Helper()
+
+		contents, count, err := lib.BrowseFolder(ctx, folderID, args)
+		if err != nil {
+			t.Fatalf("browsing folder %s: %s", folderID, err)
+		}
+		return contents, count
+	}
+
+	rootContents, count := browse(roots[0].ID, BrowseArgs{PerPage: 10})
+	if count != 3 || rootContents.ParentID != "" {
+		t.Errorf("expected three entries and no parent for the root but got %d: %+v",
+			count, rootContents)
+	}
+	assertFolderNames(t, rootContents.Folders, "a", "B")
+	if len(rootContents.Tracks) != 1 || rootContents.Tracks[0].Title != "loose.mp3" {
+		t.Errorf("expected loose.mp3 in the root but got %+v", rootContents.Tracks)
+	}
+
+	descContents, _ := browse(roots[0].ID, BrowseArgs{PerPage: 2, Order: OrderDesc})
+	assertFolderNames(t, descContents.Folders, "B", "a")
+	if len(descContents.Tracks) != 0 {
+		t.Errorf("expected no tracks on the first page but got %+v", descContents.Tracks)
+	}
+
+	folderA, count := browse(rootContents.Folders[0].ID, BrowseArgs{PerPage: 10})
+	if count != 2 || folderA.ParentID != roots[0].ID || len(folderA.Tracks) != 0 {
+		t.Errorf("unexpected contents of folder a: %+v", folderA)
+	}
+	assertFolderNames(t, folderA.Folders, "Album One", "Album Two")
+
+	secondPage, count := browse(folderA.Folders[0].ID, BrowseArgs{Page: 1, PerPage: 1})
+	if count != 2 {
+		t.Errorf("expected two tracks in Album One but got %d", count)
+	}
+	if len(secondPage.Tracks) != 1 || secondPage.Tracks[0].Title != files[0] {
+		t.Errorf("expected %s on the second page but got %+v", files[0], secondPage.Tracks)
+	}
+
+	albumTwo, _ := browse(folderA.Folders[1].ID, BrowseArgs{PerPage: 10})
+	assertFolderNames(t, albumTwo.Folders, "CD1")
+
+	// Folders are found by their IDs from the database after a restart.
+	lib.folders = folderPaths{}
+	discID := albumTwo.Folders[0].ID
+	disc, _ := browse(discID, BrowseArgs{PerPage: 10})
+	if disc.Name != "CD1" || len(disc.Tracks) != 1 || disc.ParentID != albumTwo.ID {
+		t.Errorf("unexpected contents of CD1: %+v", disc)
+	}
+
+	// IDs tell nothing about the paths of the folders.
+	sum := sha256.Sum256([]byte(filepath.Join(root, "a", "Album Two", "CD1")))
+	if discID == hex.EncodeToString(sum[:8]) {
+		t.Errorf("the ID of the folder is derived from its path")
+	}
+
+	// Tracks from before folders were stored get them with the next scan.
+	if _, err := lib.db.Exec(`UPDATE tracks SET folder_id = NULL`); err != nil {
+		t.Fatal(err)
+	}
+	lib.fillTrackFolders()
+	if _, count := browse(roots[0].ID, BrowseArgs{PerPage: 10}); count != 3 {
+		t.Errorf("expected three entries in the root after filling folders but got %d",
+			count)
+	}
+
+	for _, missing := range []string{"nope", "0123456789abcdef"} {
+		_, _, err = lib.BrowseFolder(ctx, missing, BrowseArgs{PerPage: 10})
+		if !errors.Is(err, ErrFolderNotFound) {
+			t.Errorf("expected ErrFolderNotFound for %s but got %v", missing, err)
+		}
+	}
+}
+
+func assertFolderNames(t *testing.T, folders []Folder, expected ...string) {
+	t.Helper()
+
+	if len(folders) != len(expected) {
+		t.Errorf("expected folders %v but got %+v", expected, folders)
+		return
+	}
+
+	for ind, name := range expected {
+		if folders[ind].Name != name {
+			t.Errorf("expected folder %d to be %s but it is %s", ind, name, folders[ind].Name)
+		}
+	}
+}
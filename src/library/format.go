@@ -1,14 +1,120 @@
 package library
 
 import (
+	"context"
+	"database/sql"
+	"io"
+	"log"
 	"path/filepath"
 	"strings"
+
+	"github.com/ironsmile/euterpe/src/mediaformat"
 )
 
-func mediaFormatFromFileName(path string) string {
-	format := strings.TrimLeft(filepath.Ext(path), ".")
-	if format == "" {
-		format = "mp3"
+//counterfeiter:generate . TrackFormatFinder
+
+// TrackFormatFinder is an interface for finding out the format of the media file
+// of a track, such as "flac" or "m4a".
+type TrackFormatFinder interface {
+	// GetTrackFormat returns the format of a track by its ID.
+	GetTrackFormat(ctx context.Context, trackID int64) (string, error)
+}
+
+// GetTrackFormat implements the TrackFormatFinder interface.
+func (lib *LocalLibrary) GetTrackFormat(
+	ctx context.Context,
+	trackID int64,
+) (string, error) {
+	var format string
+
+	work := func(db *sql.DB) error {
+		var fsPath string
+		err := db.QueryRowContext(ctx, `
+			SELECT
+				fs_path,
+				IFNULL(format, '')
+			FROM
+				tracks
+			WHERE
+				id = ?
+		`, trackID).Scan(&fsPath, &format)
+		if err == sql.ErrNoRows {
+			return ErrTrackNotFound
+		} else if err != nil {
+			return err
+		}
+
+		format = trackFormat(format, fsPath)
+		return nil
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return "", err
+	}
+
+	return format, nil
+}
+
+// trackFormat returns the format of a track. It is the stored format when there
+// is one. Tracks added before formats were stored have theirs guessed by the
+// extension of their file.
+func trackFormat(stored, fsPath string) string {
+	if stored != "" {
+		return stored
+	}
+	return mediaformat.FromFileName(fsPath)
+}
+
+// mediaFileFormat finds out the format of the media file at path by its
+// contents. When they are not recognized its extension is used instead.
+func (lib *LocalLibrary) mediaFileFormat(path string) string {
+	file, err := lib.fs.Open(path)
+	if err != nil {
+		log.Printf("Error opening %s for finding its format: %s\n", path, err)
+		return mediaformat.FromFileName(path)
+	}
+	defer file.Close()
+
+	st, err := file.Stat()
+	readerAt, ok := file.(io.ReaderAt)
+	if err != nil || !ok {
+		return mediaformat.FromFileName(path)
+	}
+
+	format, err := mediaformat.Sniff(readerAt, st.Size())
+	if err != nil {
+		return mediaformat.FromFileName(path)
+	}
+	return format
+}
+
+// supportedExtensions returns the extensions, without the dot, of the files
+// which are added to the library.
+func (lib *LocalLibrary) supportedExtensions() []string {
+	if len(lib.ScanConfig.Formats) > 0 {
+		return lib.ScanConfig.Formats
+	}
+	return mediaformat.DefaultExtensions()
+}
+
+// Determines if the file will be saved to the database. Only media files with
+// the configured extensions, or the extensions of all known formats by default,
+// are saved.
+func (lib *LocalLibrary) isSupportedFormat(path string) bool {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	if base == ext {
+		// This is a file such as "path/to/.hidden". There is no point in
+		// checking these. They really don't have extension. What is after
+		// the dot is the actual file name. It is just hidden.
+		return false
+	}
+
+	ext = strings.TrimPrefix(ext, ".")
+	for _, format := range lib.supportedExtensions() {
+		if strings.EqualFold(ext, format) {
+			return true
+		}
 	}
-	return strings.ToLower(format)
+	return false
 }
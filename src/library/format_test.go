@@ -0,0 +1,88 @@
+package library
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/helpers"
+)
+
+// TestTrackFormats checks that the format of tracks is found out by the contents
+// of their files and that the scanned extensions could be configured.
+func TestTrackFormats(t *testing.T) {
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	projRoot, err := helpers.ProjectRoot()
+	if err != nil {
+		t.Fatalf("Was not able to find test_files directory: %s", err)
+	}
+	testMp3 := filepath.Join(projRoot, "test_files", "more_mp3s", "test_file_added.mp3")
+
+	// An MP3 file with the wrong extension.
+	disguised := filepath.Join(t.TempDir(), "disguised.ogg")
+	if err := copyFile(testMp3, disguised); err != nil {
+		t.Fatalf("copying test file: %s", err)
+	}
+
+	if err := lib.AddMedia(disguised); err != nil {
+		t.Fatalf("adding media: %s", err)
+	}
+
+	results := lib.Search("")
+	if len(results) != 1 {
+		t.Fatalf("expected one track in the library but found %d", len(results))
+	}
+	if results[0].Format != "mp3" {
+		t.Errorf("expected search result format mp3 but got %s", results[0].Format)
+	}
+	trackID := results[0].ID
+
+	format, err := lib.GetTrackFormat(ctx, trackID)
+	if err != nil {
+		t.Fatalf("getting track format: %s", err)
+	}
+	if format != "mp3" {
+		t.Errorf("expected format mp3 but got %s", format)
+	}
+
+	if _, err := lib.GetTrackFormat(ctx, trackID+100); err != ErrTrackNotFound {
+		t.Errorf("expected ErrTrackNotFound for missing track but got %v", err)
+	}
+
+	for path, supported := range map[string]bool{
+		"/music/song.aiff":  true,
+		"/music/song.WV":    true,
+		"/music/song.dsf":   true,
+		"/music/cover.jpg":  false,
+		"/music/.flac":      false,
+		"/music/notes.text": false,
+	} {
+		if lib.isSupportedFormat(path) != supported {
+			t.Errorf("expected %s to be supported: %t", path, supported)
+		}
+	}
+
+	lib.ScanConfig.Formats = []string{"flac", "text"}
+	for path, supported := range map[string]bool{
+		"/music/song.flac":  true,
+		"/music/song.mp3":   false,
+		"/music/notes.text": true,
+	} {
+		if lib.isSupportedFormat(path) != supported {
+			t.Errorf("with configured formats expected %s to be supported: %t",
+				path, supported)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package library
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/ironsmile/euterpe/src/placeholder"
+)
+
+// imagePlaceholderTables maps the tables which hold images to the column with the
+// ID of the album or artist and the column with the hash of the original image.
+var imagePlaceholderTables = map[string]struct {
+	idColumn   string
+	hashColumn string
+}{
+	"albums_artworks": {"album_id", "COALESCE(artwork_cover_hash, artwork_cover_small_hash)"},
+	"artists_images":  {"artist_id", "COALESCE(image_hash, image_small_hash)"},
+}
+
+// saveImagePlaceholder computes the dominant colour and the BlurHash of `img` and
+// stores them for the album or artist with `id` in `table`. When they could not be
+// computed empty strings are stored so that this is not tried again.
+func (lib *LocalLibrary) saveImagePlaceholder(table string, id int64, img []byte) error {
+	tableInfo, ok := imagePlaceholderTables[table]
+	if !ok {
+		return fmt.Errorf("table %s does not have image placeholders", table)
+	}
+
+	found, err := placeholder.Compute(img)
+	if err != nil {
+		log.Printf("Computing image placeholder for %s %d: %s", table, id, err)
+	}
+
+	work := func(db *sql.DB) error {
+		_, err := db.Exec(fmt.Sprintf(`
+			UPDATE %s
+			SET
+				dominant_color = ?,
+				blurhash = ?
+			WHERE
+				%s = ?
+		`, table, tableInfo.idColumn), found.Color, found.BlurHash, id)
+		return err
+	}
+
+	return lib.executeDBJobAndWait(work)
+}
+
+// fillImagePlaceholders computes placeholders for all stored images which do not
+// have one. Such are the images stored before placeholders were introduced.
+func (lib *LocalLibrary) fillImagePlaceholders() {
+	for table, tableInfo := range imagePlaceholderTables {
+		type missing struct {
+			id   int64
+			hash string
+		}
+		var toFill []missing
+
+		work := func(db *sql.DB) error {
+			rows, err := db.QueryContext(lib.ctx, fmt.Sprintf(`
+				SELECT
+					%s,
+					%s
+				FROM
+					%s
+				WHERE
+					blurhash IS NULL AND
+					%s IS NOT NULL
+			`,
+				tableInfo.idColumn,
+				tableInfo.hashColumn,
+				table,
+				tableInfo.hashColumn,
+			))
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var row missing
+				if err := rows.Scan(&row.id, &row.hash); err != nil {
+					return err
+				}
+				toFill = append(toFill, row)
+			}
+
+			return rows.Err()
+		}
+		if err := lib.executeDBJobAndWait(work); err != nil {
+			log.Printf("Error finding images without placeholders in %s: %s", table, err)
+			continue
+		}
+
+		for _, row := range toFill {
+			if lib.ctx.Err() != nil {
+				return
+			}
+
+			img, err := lib.images.get(row.hash)
+			if err != nil {
+				log.Printf("Reading image %s for placeholder: %s", row.hash, err)
+				continue
+			}
+
+			if err := lib.saveImagePlaceholder(table, row.id, img); err != nil {
+				log.Printf("Saving image placeholder in %s: %s", table, err)
+			}
+		}
+	}
+}
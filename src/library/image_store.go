@@ -0,0 +1,279 @@
+package library
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// imagesDirName is the name of the directory, next to the library database, in
+// which album artwork and artist images are stored.
+const imagesDirName = "images"
+
+// imageStore is a content-addressed storage for images. Every image is identified
+// by the hex encoded SHA-256 hash of its contents. Storing the same image twice
+// results in a single copy. The library database keeps only the hashes.
+type imageStore interface {
+	// put stores the image and returns its hash.
+	put(img []byte) (string, error)
+
+	// get returns the contents of the image with this hash. When there is no
+	// such image it returns an error which wraps fs.ErrNotExist.
+	get(hash string) ([]byte, error)
+
+	// removeUnreferenced removes all images which are not in `referenced`
+	// and were stored before `olderThan`. Returns the number of removed images.
+	removeUnreferenced(referenced map[string]struct{}, olderThan time.Time) (int, error)
+
+	// removeAll removes every image in the store.
+	removeAll() error
+
+	// persistent returns true when images survive the process restarts.
+	persistent() bool
+}
+
+// hashImage returns the key under which `img` will be stored in an imageStore.
+func hashImage(img []byte) string {
+	sum := sha256.Sum256(img)
+	return hex.EncodeToString(sum[:])
+}
+
+// isImageHash checks that `hash` looks like something returned by hashImage. It
+// makes sure no hash could be used for escaping the store directory.
+func isImageHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+// fsImageStore is an imageStore which keeps its images in a directory. Images are
+// spread into sub-directories by the first two characters of their hash so that
+// no single directory becomes too big.
+type fsImageStore struct {
+	dir string
+}
+
+func newFSImageStore(dir string) *fsImageStore {
+	return &fsImageStore{dir: dir}
+}
+
+func (s *fsImageStore) path(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash)
+}
+
+func (s *fsImageStore) put(img []byte) (string, error) {
+	hash := hashImage(img)
+	imgPath := s.path(hash)
+
+	if _, err := os.Stat(imgPath); err == nil {
+		// Touch the file so that a concurrent clean-up will not consider
+		// it a stale one.
+		now := time.Now()
+		_ = os.Chtimes(imgPath, now, now)
+		return hash, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(imgPath), 0750); err != nil {
+		return "", fmt.Errorf("creating image store directory: %w", err)
+	}
+
+	// The image is first written into a temporary file and then moved at its
+	// place so that readers never see partially written images.
+	tmp, err := os.CreateTemp(filepath.Dir(imgPath), ".tmp-"+hash[:8]+"-")
+	if err != nil {
+		return "", fmt.Errorf("creating temporary image file: %w", err)
+	}
+
+	if _, err := tmp.Write(img); err != nil {
+		tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return "", fmt.Errorf("writing image file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", fmt.Errorf("closing image file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), imgPath); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", fmt.Errorf("moving image file in place: %w", err)
+	}
+
+	return hash, nil
+}
+
+func (s *fsImageStore) get(hash string) ([]byte, error) {
+	if !isImageHash(hash) {
+		return nil, fmt.Errorf("malformed image hash `%s`: %w", hash, fs.ErrNotExist)
+	}
+
+	return os.ReadFile(s.path(hash))
+}
+
+func (s *fsImageStore) removeUnreferenced(
+	referenced map[string]struct{},
+	olderThan time.Time,
+) (int, error) {
+	var removed int
+
+	walkFn := func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		name := d.Name()
+		if _, ok := referenced[name]; ok {
+			return nil
+		}
+
+		st, err := d.Info()
+		if err != nil || st.ModTime().After(olderThan) {
+			return nil
+		}
+
+		// Leftovers from interrupted writes are removed as well.
+		if !isImageHash(name) && !strings.HasPrefix(name, ".tmp-") {
+			return nil
+		}
+
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+
+		return nil
+	}
+
+	return removed, filepath.WalkDir(s.dir, walkFn)
+}
+
+func (s *fsImageStore) removeAll() error {
+	return os.RemoveAll(s.dir)
+}
+
+func (s *fsImageStore) persistent() bool {
+	return true
+}
+
+// memImageStore is an imageStore which keeps everything in memory. It is used
+// together with in-memory databases.
+type memImageStore struct {
+	sync.RWMutex
+
+	images   map[string][]byte
+	storedAt map[string]time.Time
+}
+
+func newMemImageStore() *memImageStore {
+	return &memImageStore{
+		images:   make(map[string][]byte),
+		storedAt: make(map[string]time.Time),
+	}
+}
+
+func (s *memImageStore) put(img []byte) (string, error) {
+	hash := hashImage(img)
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.images[hash] = bytes.Clone(img)
+	s.storedAt[hash] = time.Now()
+
+	return hash, nil
+}
+
+func (s *memImageStore) get(hash string) ([]byte, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	img, ok := s.images[hash]
+	if !ok {
+		return nil, fmt.Errorf("image `%s`: %w", hash, fs.ErrNotExist)
+	}
+
+	return bytes.Clone(img), nil
+}
+
+func (s *memImageStore) removeUnreferenced(
+	referenced map[string]struct{},
+	olderThan time.Time,
+) (int, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	var removed int
+	for hash := range s.images {
+		if _, ok := referenced[hash]; ok || s.storedAt[hash].After(olderThan) {
+			continue
+		}
+
+		delete(s.images, hash)
+		delete(s.storedAt, hash)
+		removed++
+	}
+
+	return removed, nil
+}
+
+func (s *memImageStore) removeAll() error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.images = make(map[string][]byte)
+	s.storedAt = make(map[string]time.Time)
+	return nil
+}
+
+func (s *memImageStore) persistent() bool {
+	return false
+}
+
+// storedImage is the io.ReadCloser returned for images which came from the
+// image store. It implements StoredImage.
+type storedImage struct {
+	*bytes.Reader
+
+	hash    string
+	modTime time.Time
+}
+
+func newStoredImage(img []byte, hash string, modTime time.Time) *storedImage {
+	return &storedImage{
+		Reader:  bytes.NewReader(img),
+		hash:    hash,
+		modTime: modTime,
+	}
+}
+
+// Close is a no-op which is here only to implement io.Closer.
+func (si *storedImage) Close() error {
+	return nil
+}
+
+// Hash implements StoredImage.
+func (si *storedImage) Hash() string {
+	return si.hash
+}
+
+// ModTime implements StoredImage.
+func (si *storedImage) ModTime() time.Time {
+	return si.modTime
+}
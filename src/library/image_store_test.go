@@ -0,0 +1,257 @@
+package library
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestFSImageStore checks that images are stored by their hash, that storing the
+// same image twice is fine and that unreferenced images are removed.
+func TestFSImageStore(t *testing.T) {
+	store := newFSImageStore(filepath.Join(t.TempDir(), imagesDirName))
+
+	var (
+		firstImage  = []byte("first-image")
+		secondImage = []byte("second-image")
+	)
+
+	firstHash, err := store.put(firstImage)
+	if err != nil {
+		t.Fatalf("storing first image: %s", err)
+	}
+
+	if firstHash != hashImage(firstImage) {
+		t.Errorf("expected hash %s but got %s", hashImage(firstImage), firstHash)
+	}
+
+	againHash, err := store.put(firstImage)
+	if err != nil {
+		t.Fatalf("storing first image again: %s", err)
+	}
+
+	if againHash != firstHash {
+		t.Errorf("storing the same image returned different hashes")
+	}
+
+	secondHash, err := store.put(secondImage)
+	if err != nil {
+		t.Fatalf("storing second image: %s", err)
+	}
+
+	found, err := store.get(firstHash)
+	if err != nil {
+		t.Fatalf("getting first image: %s", err)
+	}
+
+	if !bytes.Equal(found, firstImage) {
+		t.Errorf("expected image `%s` but got `%s`", firstImage, found)
+	}
+
+	_, err = store.get("../../etc/passwd")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected not exists error for malformed hash but got %+v", err)
+	}
+
+	referenced := map[string]struct{}{
+		secondHash: {},
+	}
+
+	removed, err := store.removeUnreferenced(referenced, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("removing unreferenced images: %s", err)
+	}
+
+	if removed != 0 {
+		t.Errorf("expected recently stored images to be kept but %d were removed",
+			removed)
+	}
+
+	removed, err = store.removeUnreferenced(referenced, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("removing unreferenced images: %s", err)
+	}
+
+	if removed != 1 {
+		t.Errorf("expected one removed image but got %d", removed)
+	}
+
+	if _, err := store.get(firstHash); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected first image to be removed but got error %+v", err)
+	}
+
+	if _, err := store.get(secondHash); err != nil {
+		t.Errorf("expected second image to be kept but got error %s", err)
+	}
+}
+
+// TestExportImageBlobs makes sure that images stored as blobs in the database
+// by older versions are moved into the image store on initialization.
+func TestExportImageBlobs(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		tmpDir   = t.TempDir()
+		dbPath   = filepath.Join(tmpDir, "library.db")
+		original = []byte("album-artwork-from-the-old-days")
+		small    = []byte("small-artwork")
+		artist   = []byte("artist-image-from-the-old-days")
+	)
+
+	lib, err := NewLocalLibrary(ctx, dbPath, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lib.Truncate() }()
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	mediaFile := &MockMedia{
+		artist: "Old Timer",
+		album:  "Blobs And Blobs",
+		title:  "Bloat",
+		track:  1,
+		length: 123,
+	}
+	mediaPath := filepath.Join(tmpDir, "album", "track.mp3")
+
+	if err := lib.insertMediaIntoDatabase(mediaFile, mediaPath); err != nil {
+		t.Fatalf("inserting media: %s", err)
+	}
+
+	albumID, err := lib.GetAlbumID(mediaFile.album, filepath.Dir(mediaPath))
+	if err != nil {
+		t.Fatalf("getting album ID: %s", err)
+	}
+
+	artistID, err := lib.GetArtistID(mediaFile.artist)
+	if err != nil {
+		t.Fatalf("getting artist ID: %s", err)
+	}
+
+	// Store the images the way older versions did.
+	err = lib.executeDBJobAndWait(func(db *sql.DB) error {
+		_, err := db.Exec(`
+			INSERT INTO albums_artworks
+				(album_id, artwork_cover, artwork_cover_small, updated_at)
+			VALUES
+				(?, ?, ?, ?)
+		`, albumID, original, small, time.Now().Unix())
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			INSERT INTO artists_images
+				(artist_id, image, updated_at)
+			VALUES
+				(?, ?, ?)
+		`, artistID, artist, time.Now().Unix())
+		return err
+	})
+	if err != nil {
+		t.Fatalf("inserting image blobs: %s", err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library for the second time: %s", err)
+	}
+
+	var blobs int
+	err = lib.executeDBJobAndWait(func(db *sql.DB) error {
+		return db.QueryRow(`
+			SELECT
+				(SELECT COUNT(*) FROM albums_artworks
+					WHERE artwork_cover IS NOT NULL OR artwork_cover_small IS NOT NULL) +
+				(SELECT COUNT(*) FROM artists_images
+					WHERE image IS NOT NULL OR image_small IS NOT NULL)
+		`).Scan(&blobs)
+	})
+	if err != nil {
+		t.Fatalf("counting left blobs: %s", err)
+	}
+
+	if blobs != 0 {
+		t.Errorf("expected no image blobs in the database but found %d", blobs)
+	}
+
+	expected := []struct {
+		name string
+		get  func() (io.ReadCloser, error)
+		img  []byte
+	}{
+		{
+			name: "album original",
+			get: func() (io.ReadCloser, error) {
+				return lib.FindAndSaveAlbumArtwork(ctx, albumID, OriginalImage)
+			},
+			img: original,
+		},
+		{
+			name: "album small",
+			get: func() (io.ReadCloser, error) {
+				return lib.FindAndSaveAlbumArtwork(ctx, albumID, SmallImage)
+			},
+			img: small,
+		},
+		{
+			name: "artist original",
+			get: func() (io.ReadCloser, error) {
+				return lib.FindAndSaveArtistImage(ctx, artistID, OriginalImage)
+			},
+			img: artist,
+		},
+	}
+
+	for _, test := range expected {
+		r, err := test.get()
+		if err != nil {
+			t.Errorf("%s: getting image: %s", test.name, err)
+			continue
+		}
+
+		stored, ok := r.(StoredImage)
+		if !ok {
+			t.Errorf("%s: returned image is not a StoredImage", test.name)
+		} else if stored.Hash() != hashImage(test.img) {
+			t.Errorf("%s: expected hash %s but got %s",
+				test.name, hashImage(test.img), stored.Hash())
+		}
+
+		found, _ := io.ReadAll(r)
+		r.Close()
+
+		if !bytes.Equal(found, test.img) {
+			t.Errorf("%s: expected image `%s` but got `%s`", test.name, test.img, found)
+		}
+
+		imgPath := filepath.Join(tmpDir, imagesDirName, hashImage(test.img)[:2],
+			hashImage(test.img))
+		if _, err := os.Stat(imgPath); err != nil {
+			t.Errorf("%s: image file not found in the store: %s", test.name, err)
+		}
+	}
+
+	// Removing the album artwork and cleaning up the database should remove
+	// its images from the store while keeping the artist image.
+	if err := lib.RemoveAlbumArtwork(ctx, albumID); err != nil {
+		t.Fatalf("removing album artwork: %s", err)
+	}
+	lib.cleanupImages()
+
+	if _, err := lib.images.get(hashImage(original)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected album artwork to be removed from the store: %+v", err)
+	}
+
+	if _, err := lib.images.get(hashImage(artist)); err != nil {
+		t.Errorf("expected artist image to be kept in the store: %s", err)
+	}
+}
@@ -0,0 +1,539 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrLibraryNotFound is returned when no library directory could be found for
+	// particular operation.
+	ErrLibraryNotFound = errors.New("Library Not Found")
+
+	// ErrLibraryExists is returned when adding a library directory which is
+	// already in the library or is inside or contains one which is.
+	ErrLibraryExists = errors.New("Library Already Exists")
+
+	// ErrLibraryFromConfig is returned when removing a library directory which
+	// comes from the configuration. It must be removed from there instead.
+	ErrLibraryFromConfig = errors.New("Library Is In The Configuration")
+
+	// ErrInvalidLibrary is returned when a library directory could not be added
+	// or changed because of the values given for it.
+	ErrInvalidLibrary = errors.New("Invalid Library")
+)
+
+//counterfeiter:generate . LibrariesManager
+
+// LibrariesManager is an interface for managing the directories of the library
+// while it is running.
+type LibrariesManager interface {
+	// Libraries returns all library directories. Including the disabled ones.
+	Libraries(ctx context.Context) ([]LibraryDir, error)
+
+	// AddLibrary adds a new directory to the library and starts scanning it.
+	// When name is empty the name of the directory is used.
+	AddLibrary(ctx context.Context, path, name string) (LibraryDir, error)
+
+	// UpdateLibrary changes the name of a library directory or disables and
+	// enables it. Returns the library directory after the changes.
+	UpdateLibrary(
+		ctx context.Context,
+		libraryID int64,
+		changes LibraryChanges,
+	) (LibraryDir, error)
+
+	// RemoveLibrary removes a directory from the library together with all of
+	// its tracks.
+	RemoveLibrary(ctx context.Context, libraryID int64) error
+}
+
+// LibraryDir is a directory with media files which is part of the library.
+type LibraryDir struct {
+	ID   int64  `json:"library_id"`
+	Path string `json:"path"`
+	Name string `json:"name"`
+
+	// Disabled directories are not scanned or watched for changes. Their
+	// tracks are kept as they are. Even when the directory is missing.
+	Disabled bool `json:"disabled"`
+
+	// FromConfig is true for the directories from the configuration file.
+	FromConfig bool `json:"from_config"`
+}
+
+// LibraryChanges are changes to a library directory. Nil fields are not changed.
+type LibraryChanges struct {
+	Name     *string `json:"name,omitempty"`
+	Disabled *bool   `json:"disabled,omitempty"`
+}
+
+// libraryDirs holds the directories of the library which are not scanned and
+// guards LocalLibrary.paths which are.
+type libraryDirs struct {
+	sync.RWMutex
+
+	// disabled are the disabled directories of the library.
+	disabled []string
+
+	// config are the directories from the configuration added since the start.
+	config map[string]struct{}
+
+	// missing are the directories from the configuration which were not found.
+	// They are in disabled until they are found.
+	missing map[string]struct{}
+}
+
+// libraryPaths returns the directories of the library which are scanned.
+func (lib *LocalLibrary) libraryPaths() []string {
+	lib.dirs.RLock()
+	defer lib.dirs.RUnlock()
+
+	return append([]string(nil), lib.paths...)
+}
+
+// disabledLibraryPaths returns the disabled directories of the library.
+func (lib *LocalLibrary) disabledLibraryPaths() []string {
+	lib.dirs.RLock()
+	defer lib.dirs.RUnlock()
+
+	return append([]string(nil), lib.dirs.disabled...)
+}
+
+// RestoreLibraries adds the enabled directories which were added with the API to
+// the scanned directories of the library. It must be called after AddLibraryPath
+// for all of the directories in the configuration.
+func (lib *LocalLibrary) RestoreLibraries(ctx context.Context) error {
+	dirs, err := lib.Libraries(ctx)
+	if err != nil {
+		return err
+	}
+
+	lib.dirs.Lock()
+	defer lib.dirs.Unlock()
+
+	for _, dir := range dirs {
+		if _, ok := lib.dirs.config[dir.Path]; ok || dir.FromConfig {
+			continue
+		}
+
+		if dir.Disabled {
+			lib.dirs.disabled = append(lib.dirs.disabled, dir.Path)
+		} else {
+			lib.paths = append(lib.paths, dir.Path)
+		}
+	}
+
+	return nil
+}
+
+// RemoveStaleLibraries removes the directories which were in the configuration
+// before but are no longer there together with their tracks. It must be called
+// after AddLibraryPath for all of the directories in the configuration.
+func (lib *LocalLibrary) RemoveStaleLibraries(ctx context.Context) error {
+	dirs, err := lib.Libraries(ctx)
+	if err != nil {
+		return err
+	}
+
+	var stale []LibraryDir
+
+	lib.dirs.RLock()
+	for _, dir := range dirs {
+		if _, ok := lib.dirs.config[dir.Path]; !ok && dir.FromConfig {
+			stale = append(stale, dir)
+		}
+	}
+	lib.dirs.RUnlock()
+
+	for _, dir := range stale {
+		log.Printf("Removing library %s which is no longer in the configuration", dir.Path)
+		if err := lib.forgetLibrary(dir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreMissingLibraries starts scanning the directories from the configuration
+// which were missing and are found now. Unless they are disabled.
+func (lib *LocalLibrary) restoreMissingLibraries() {
+	lib.dirs.RLock()
+	var found []string
+	for path := range lib.dirs.missing {
+		if st, err := fs.Stat(lib.fs, path); err == nil && st.IsDir() {
+			found = append(found, path)
+		}
+	}
+	lib.dirs.RUnlock()
+
+	if len(found) == 0 {
+		return
+	}
+
+	dirs, err := lib.Libraries(lib.ctx)
+	if err != nil {
+		log.Printf("Error restoring missing libraries: %s", err)
+		return
+	}
+	disabled := make(map[string]bool, len(dirs))
+	for _, dir := range dirs {
+		disabled[dir.Path] = dir.Disabled
+	}
+
+	lib.dirs.Lock()
+	defer lib.dirs.Unlock()
+
+	for _, path := range found {
+		log.Printf("Library %s was found", path)
+		delete(lib.dirs.missing, path)
+		if !disabled[path] {
+			lib.dirs.disabled = withoutPath(lib.dirs.disabled, path)
+			lib.paths = append(lib.paths, path)
+		}
+	}
+}
+
+// Libraries implements the LibrariesManager interface.
+func (lib *LocalLibrary) Libraries(ctx context.Context) ([]LibraryDir, error) {
+	dirs := []LibraryDir{}
+	work := func(db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, `
+			SELECT
+				id,
+				path,
+				name,
+				disabled,
+				from_config
+			FROM
+				libraries
+			ORDER BY
+				id
+		`)
+		if err != nil {
+			return fmt.Errorf("querying libraries: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var dir LibraryDir
+			err := rows.Scan(&dir.ID, &dir.Path, &dir.Name, &dir.Disabled, &dir.FromConfig)
+			if err != nil {
+				return fmt.Errorf("scanning library: %w", err)
+			}
+			dirs = append(dirs, dir)
+		}
+
+		return rows.Err()
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return nil, err
+	}
+
+	return dirs, nil
+}
+
+// AddLibrary implements the LibrariesManager interface. The path must be an
+// absolute path to a directory.
+func (lib *LocalLibrary) AddLibrary(
+	ctx context.Context,
+	path, name string,
+) (LibraryDir, error) {
+	if !filepath.IsAbs(path) {
+		return LibraryDir{}, fmt.Errorf("%w: path must be absolute", ErrInvalidLibrary)
+	}
+	path = filepath.Clean(path)
+
+	st, err := fs.Stat(lib.fs, path)
+	if err != nil {
+		return LibraryDir{}, fmt.Errorf("%w: %s", ErrInvalidLibrary, err)
+	}
+	if !st.IsDir() {
+		return LibraryDir{}, fmt.Errorf("%w: %s is not a directory", ErrInvalidLibrary, path)
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = filepath.Base(path)
+	}
+
+	dirs, err := lib.Libraries(ctx)
+	if err != nil {
+		return LibraryDir{}, err
+	}
+	for _, dir := range dirs {
+		if insideRoots(path, []string{dir.Path}) || insideRoots(dir.Path, []string{path}) {
+			return LibraryDir{}, fmt.Errorf("%w: overlaps with %s", ErrLibraryExists, dir.Path)
+		}
+	}
+
+	dir := LibraryDir{
+		Path: path,
+		Name: name,
+	}
+	work := func(db *sql.DB) error {
+		res, err := db.ExecContext(ctx, `
+			INSERT INTO libraries (path, name)
+			VALUES (?, ?)
+		`, path, name)
+		if err != nil {
+			return fmt.Errorf("inserting library: %w", err)
+		}
+
+		dir.ID, err = res.LastInsertId()
+		return err
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return LibraryDir{}, err
+	}
+
+	lib.dirs.Lock()
+	lib.paths = append(lib.paths, path)
+	lib.dirs.Unlock()
+
+	lib.scanLibraryDir(path)
+
+	return dir, nil
+}
+
+// UpdateLibrary implements the LibrariesManager interface. Enabled directories
+// are scanned again since they could have changed while disabled.
+func (lib *LocalLibrary) UpdateLibrary(
+	ctx context.Context,
+	libraryID int64,
+	changes LibraryChanges,
+) (LibraryDir, error) {
+	dir, err := lib.getLibrary(ctx, libraryID)
+	if err != nil {
+		return LibraryDir{}, err
+	}
+
+	if changes.Name != nil {
+		name := strings.TrimSpace(*changes.Name)
+		if name == "" {
+			return LibraryDir{}, fmt.Errorf("%w: name must not be empty", ErrInvalidLibrary)
+		}
+		dir.Name = name
+	}
+
+	wasDisabled := dir.Disabled
+	if changes.Disabled != nil {
+		dir.Disabled = *changes.Disabled
+	}
+
+	work := func(db *sql.DB) error {
+		_, err := db.ExecContext(ctx, `
+			UPDATE libraries
+			SET
+				name = ?,
+				disabled = ?
+			WHERE
+				id = ?
+		`, dir.Name, dir.Disabled, dir.ID)
+		return err
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return LibraryDir{}, fmt.Errorf("updating library: %w", err)
+	}
+
+	if dir.Disabled == wasDisabled {
+		return dir, nil
+	}
+
+	lib.dirs.Lock()
+	_, missing := lib.dirs.missing[dir.Path]
+	switch {
+	case missing:
+		// Missing directories stay disabled until they are found.
+	case dir.Disabled:
+		lib.paths = withoutPath(lib.paths, dir.Path)
+		lib.dirs.disabled = append(lib.dirs.disabled, dir.Path)
+	default:
+		lib.dirs.disabled = withoutPath(lib.dirs.disabled, dir.Path)
+		lib.paths = append(lib.paths, dir.Path)
+	}
+	lib.dirs.Unlock()
+
+	if missing {
+		return dir, nil
+	}
+
+	if dir.Disabled {
+		lib.unwatchDirectory(dir.Path)
+	} else {
+		lib.scanLibraryDir(dir.Path)
+	}
+
+	return dir, nil
+}
+
+// RemoveLibrary implements the LibrariesManager interface. Directories from the
+// configuration could only be disabled.
+func (lib *LocalLibrary) RemoveLibrary(ctx context.Context, libraryID int64) error {
+	dir, err := lib.getLibrary(ctx, libraryID)
+	if err != nil {
+		return err
+	}
+
+	if dir.FromConfig {
+		return ErrLibraryFromConfig
+	}
+
+	if err := lib.forgetLibrary(dir); err != nil {
+		return err
+	}
+
+	// The albums and artists of the removed tracks are removed by the clean-up.
+	go lib.cleanUpDatabase()
+
+	return nil
+}
+
+// forgetLibrary removes a directory from the library together with its tracks.
+// Tracks which are in another library directory too are kept.
+func (lib *LocalLibrary) forgetLibrary(dir LibraryDir) error {
+	work := func(db *sql.DB) error {
+		_, err := db.Exec(`DELETE FROM libraries WHERE id = ?`, dir.ID)
+		return err
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return fmt.Errorf("removing library: %w", err)
+	}
+
+	lib.dirs.Lock()
+	lib.paths = withoutPath(lib.paths, dir.Path)
+	lib.dirs.disabled = withoutPath(lib.dirs.disabled, dir.Path)
+	others := append(append([]string(nil), lib.paths...), lib.dirs.disabled...)
+	lib.dirs.Unlock()
+
+	lib.unwatchDirectory(dir.Path)
+
+	if !insideRoots(dir.Path, others) {
+		lib.removeDirectory(dir.Path)
+	}
+
+	return nil
+}
+
+// getLibrary returns the library directory with `libraryID`.
+func (lib *LocalLibrary) getLibrary(ctx context.Context, libraryID int64) (LibraryDir, error) {
+	var dir LibraryDir
+	work := func(db *sql.DB) error {
+		return db.QueryRowContext(ctx, `
+			SELECT
+				id,
+				path,
+				name,
+				disabled,
+				from_config
+			FROM
+				libraries
+			WHERE
+				id = ?
+		`, libraryID).Scan(&dir.ID, &dir.Path, &dir.Name, &dir.Disabled, &dir.FromConfig)
+	}
+	err := lib.executeDBJobAndWait(work)
+	if errors.Is(err, sql.ErrNoRows) {
+		return LibraryDir{}, ErrLibraryNotFound
+	} else if err != nil {
+		return LibraryDir{}, fmt.Errorf("getting library: %w", err)
+	}
+
+	return dir, nil
+}
+
+// saveConfigLibrary stores a library directory from the configuration and returns
+// it. Directories which are stored already keep their names and whether they are
+// disabled.
+func (lib *LocalLibrary) saveConfigLibrary(path string) (LibraryDir, error) {
+	dir := LibraryDir{
+		Path:       path,
+		FromConfig: true,
+	}
+	work := func(db *sql.DB) error {
+		_, err := db.Exec(`
+			INSERT INTO libraries (path, name, from_config)
+			VALUES (?, ?, 1)
+			ON CONFLICT (path) DO UPDATE SET
+				from_config = 1
+		`, path, filepath.Base(path))
+		if err != nil {
+			return err
+		}
+
+		return db.QueryRow(`
+			SELECT id, name, disabled
+			FROM libraries
+			WHERE path = ?
+		`, path).Scan(&dir.ID, &dir.Name, &dir.Disabled)
+	}
+
+	return dir, lib.executeDBJobAndWait(work)
+}
+
+// libraryNames returns the names of the library directories by their paths.
+func (lib *LocalLibrary) libraryNames(ctx context.Context) (map[string]string, error) {
+	dirs, err := lib.Libraries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(dirs))
+	for _, dir := range dirs {
+		names[dir.Path] = dir.Name
+	}
+	return names, nil
+}
+
+// scanLibraryDir scans a single library directory in the background. Its albums
+// are grouped with their editions before the scan is done.
+func (lib *LocalLibrary) scanLibraryDir(path string) {
+	lib.initializeWatcher()
+
+	lib.waitScanLock.Lock()
+	lib.walkWG.Add(2)
+	lib.waitScanLock.Unlock()
+
+	go func() {
+		lib.scanPath(path)
+		lib.groupAlbumEditions()
+		lib.walkWG.Done()
+		lib.startPollingWatchers()
+	}()
+}
+
+// unwatchDirectory stops watching `dir` and all directories in it.
+func (lib *LocalLibrary) unwatchDirectory(dir string) {
+	lib.watchLock.Lock()
+	defer lib.watchLock.Unlock()
+
+	if lib.watch == nil {
+		return
+	}
+
+	_ = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.IsDir() {
+			_ = lib.watch.RemoveWatch(path)
+		}
+		return nil
+	})
+}
+
+// withoutPath returns `paths` without `path`.
+func withoutPath(paths []string, path string) []string {
+	var left []string
+	for _, other := range paths {
+		if other != path {
+			left = append(left, other)
+		}
+	}
+	return left
+}
@@ -0,0 +1,196 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/helpers"
+)
+
+// TestManagingLibraries checks adding, changing and removing library directories
+// while the library is running.
+func TestManagingLibraries(t *testing.T) {
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+	lib.DisableWatching()
+
+	projRoot, err := helpers.ProjectRoot()
+	if err != nil {
+		t.Fatalf("Was not able to find test_files directory: %s", err)
+	}
+	testMp3 := filepath.Join(projRoot, "test_files", "more_mp3s", "test_file_added.mp3")
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, "config")
+	apiDir := filepath.Join(tmpDir, "api")
+	for _, dir := range []string{configDir, apiDir} {
+		if err := os.Mkdir(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := copyFile(testMp3, filepath.Join(dir, "song.mp3")); err != nil {
+			t.Fatalf("copying test file: %s", err)
+		}
+	}
+
+	waitScan := func() {
+		lib.waitScanLock.RLock()
+		lib.walkWG.Wait()
+		lib.waitScanLock.RUnlock()
+	}
+	assertTracks := func(desc string, expected int) {
+		t.Helper()
+		// The songs are the same so they are editions of the same album when
+		// searching. Hence the tracks are counted in the database.
+		if found := lib.getTableSize("tracks"); found != expected {
+			t.Errorf("%s: expected %d tracks but found %d", desc, expected, found)
+		}
+	}
+
+	lib.AddLibraryPath(configDir)
+	if err := lib.AddMedia(filepath.Join(configDir, "song.mp3")); err != nil {
+		t.Fatalf("adding media: %s", err)
+	}
+
+	for _, invalid := range []string{"relative/path", filepath.Join(tmpDir, "missing"), testMp3} {
+		_, err := lib.AddLibrary(ctx, invalid, "")
+		if !errors.Is(err, ErrInvalidLibrary) {
+			t.Errorf("expected ErrInvalidLibrary for %s but got %v", invalid, err)
+		}
+	}
+	for _, overlapping := range []string{configDir, tmpDir} {
+		_, err := lib.AddLibrary(ctx, overlapping, "")
+		if !errors.Is(err, ErrLibraryExists) {
+			t.Errorf("expected ErrLibraryExists for %s but got %v", overlapping, err)
+		}
+	}
+
+	apiLib, err := lib.AddLibrary(ctx, apiDir, "")
+	if err != nil {
+		t.Fatalf("adding library: %s", err)
+	}
+	waitScan()
+	assertTracks("after adding a library", 2)
+
+	dirs, err := lib.Libraries(ctx)
+	if err != nil {
+		t.Fatalf("getting libraries: %s", err)
+	}
+	expected := []LibraryDir{
+		{ID: dirs[0].ID, Path: configDir, Name: "config", FromConfig: true},
+		{ID: apiLib.ID, Path: apiDir, Name: "api"},
+	}
+	if len(dirs) != 2 || dirs[0] != expected[0] || dirs[1] != expected[1] {
+		t.Errorf("expected libraries %+v but got %+v", expected, dirs)
+	}
+
+	name, disabled := "Podcasts", true
+	changes := LibraryChanges{Name: &name, Disabled: &disabled}
+	if _, err := lib.UpdateLibrary(ctx, apiLib.ID, changes); err != nil {
+		t.Fatalf("disabling library: %s", err)
+	}
+
+	roots, err := lib.RootFolders(ctx)
+	if err != nil {
+		t.Fatalf("getting root folders: %s", err)
+	}
+	if len(roots) != 1 || roots[0].Name != "config" {
+		t.Errorf("expected only the config library in the root folders but got %+v", roots)
+	}
+
+	// Tracks of disabled libraries are kept even when their files are missing.
+	if err := os.Remove(filepath.Join(apiDir, "song.mp3")); err != nil {
+		t.Fatal(err)
+	}
+	lib.cleanupTracks()
+	assertTracks("after cleaning up a disabled library", 2)
+
+	disabled = false
+	apiLib, err = lib.UpdateLibrary(ctx, apiLib.ID, LibraryChanges{Disabled: &disabled})
+	if err != nil {
+		t.Fatalf("enabling library: %s", err)
+	}
+	waitScan()
+	if apiLib.Name != "Podcasts" || apiLib.Disabled {
+		t.Errorf("unexpected library after enabling it: %+v", apiLib)
+	}
+
+	empty := " "
+	_, err = lib.UpdateLibrary(ctx, apiLib.ID, LibraryChanges{Name: &empty})
+	if !errors.Is(err, ErrInvalidLibrary) {
+		t.Errorf("expected ErrInvalidLibrary for empty name but got %v", err)
+	}
+
+	if err := lib.RemoveLibrary(ctx, dirs[0].ID); !errors.Is(err, ErrLibraryFromConfig) {
+		t.Errorf("expected ErrLibraryFromConfig but got %v", err)
+	}
+
+	// After a restart with the config library missing its tracks are kept and
+	// it is not scanned until it is found.
+	lib.cleanupTracks()
+	tracksCount := lib.getTableSize("tracks")
+	movedDir := configDir + ".unmounted"
+	if err := os.Rename(configDir, movedDir); err != nil {
+		t.Fatal(err)
+	}
+	lib.paths = nil
+	lib.dirs = libraryDirs{}
+	lib.AddLibraryPath(configDir)
+	if err := lib.RestoreLibraries(ctx); err != nil {
+		t.Fatalf("restoring libraries: %s", err)
+	}
+	if err := lib.RemoveStaleLibraries(ctx); err != nil {
+		t.Fatalf("removing stale libraries: %s", err)
+	}
+	if paths := lib.libraryPaths(); len(paths) != 1 || paths[0] != apiDir {
+		t.Errorf("expected the missing library not to be scanned but got %v", paths)
+	}
+	lib.cleanupTracks()
+	assertTracks("after restarting with a missing library", tracksCount)
+
+	if err := os.Rename(movedDir, configDir); err != nil {
+		t.Fatal(err)
+	}
+	lib.restoreMissingLibraries()
+	if paths := lib.libraryPaths(); len(paths) != 2 || paths[1] != configDir {
+		t.Errorf("expected the found library to be scanned but got %v", paths)
+	}
+
+	// After a restart without the config library in the configuration it is
+	// removed together with its tracks. But only by the server.
+	lib.paths = nil
+	lib.dirs = libraryDirs{}
+	if err := lib.RestoreLibraries(ctx); err != nil {
+		t.Fatalf("restoring libraries: %s", err)
+	}
+	if paths := lib.libraryPaths(); len(paths) != 1 || paths[0] != apiDir {
+		t.Errorf("expected only the API library to be restored but got %v", paths)
+	}
+	assertTracks("before removing stale libraries", tracksCount)
+
+	if err := lib.RemoveStaleLibraries(ctx); err != nil {
+		t.Fatalf("removing stale libraries: %s", err)
+	}
+	assertTracks("after removing the config library", tracksCount-1)
+
+	if err := lib.RemoveLibrary(ctx, apiLib.ID); err != nil {
+		t.Fatalf("removing library: %s", err)
+	}
+	assertTracks("after removing the API library", 0)
+
+	if err := lib.RemoveLibrary(ctx, apiLib.ID); !errors.Is(err, ErrLibraryNotFound) {
+		t.Errorf("expected ErrLibraryNotFound but got %v", err)
+	}
+}
@@ -35,12 +35,48 @@ type SearchResult struct {
 
 	// Duration is the track length in milliseconds.
 	Duration int64 `json:"duration"`
+
+	// Meta info: the year in which the track was released. Zero when unknown.
+	Year int64 `json:"year,omitempty"`
+
+	// Meta info: the genre of the track. Empty when unknown.
+	Genre string `json:"genre,omitempty"`
+
+	// Meta info: the disc of a multi-disc album on which the track is. Zero
+	// when unknown or when the album has a single disc.
+	Disc int64 `json:"disc,omitempty"`
+
+	// ReplayGain is the loudness normalisation information for the track. It is
+	// nil when there is none.
+	ReplayGain *ReplayGain `json:"replaygain,omitempty"`
+}
+
+// ReplayGain holds the loudness normalisation information for a track. Gains are
+// in dB and bring the track to -18 LUFS. Peaks are the highest absolute sample
+// values where 1.0 is full scale.
+type ReplayGain struct {
+	TrackGain float64  `json:"track_gain"`
+	TrackPeak *float64 `json:"track_peak,omitempty"`
+	AlbumGain *float64 `json:"album_gain,omitempty"`
+	AlbumPeak *float64 `json:"album_peak,omitempty"`
+
+	// Source is "tags" when the information was read from the media file and
+	// "analysis" when the loudness of the track was measured by the server.
+	Source string `json:"source"`
 }
 
 // Artist represents an artist from the database
 type Artist struct {
 	ID   int64  `json:"artist_id"`
 	Name string `json:"artist"`
+
+	// ImageColor is the dominant colour of the artist image in "#rrggbb" format.
+	// It is empty when there is no stored image.
+	ImageColor string `json:"image_color,omitempty"`
+
+	// ImageBlurHash is the BlurHash of the artist image. It is empty when there
+	// is no stored image.
+	ImageBlurHash string `json:"image_blurhash,omitempty"`
 }
 
 // Album represents an album from the database
@@ -48,6 +84,18 @@ type Album struct {
 	ID     int64  `json:"album_id"`
 	Name   string `json:"album"`
 	Artist string `json:"artist"`
+
+	// ArtworkColor is the dominant colour of the album artwork in "#rrggbb"
+	// format. It is empty when there is no stored artwork.
+	ArtworkColor string `json:"artwork_color,omitempty"`
+
+	// ArtworkBlurHash is the BlurHash of the album artwork. It is empty when
+	// there is no stored artwork.
+	ArtworkBlurHash string `json:"artwork_blurhash,omitempty"`
+
+	// Editions is the number of other editions of this album. Such as the same
+	// album in another format.
+	Editions int64 `json:"editions,omitempty"`
 }
 
 // Library represents the media library which is played using the HTTPMS.
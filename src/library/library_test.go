@@ -106,6 +106,8 @@ func getPathedLibrary(ctx context.Context, t *testing.T) *LocalLibrary {
 		t.Fatalf("Initializing library: %s", err)
 	}
 
+	// The tests wait for the file system changes for a short while only.
+	lib.ScanConfig.WatchQuietPeriod = 10 * time.Millisecond
 	lib.AddLibraryPath(testLibraryPath)
 
 	return lib
@@ -200,6 +200,11 @@ func TestMovingDirectory(t *testing.T) {
 
 	checkAddedSong(lib, t)
 
+	added := lib.Search("Added Song")
+	if len(added) != 1 {
+		t.Fatalf("Did not find exactly one 'Added Song'. Found %d files", len(added))
+	}
+
 	if err := os.Rename(movedDir, secondPlace); err != nil {
 		t.Error(err)
 	} else {
@@ -234,6 +239,10 @@ func TestMovingDirectory(t *testing.T) {
 			foundPath, expectedPath)
 	}
 
+	if found[0].ID != added[0].ID {
+		t.Errorf("Moving the file changed its ID from %d to %d", added[0].ID, found[0].ID)
+	}
+
 }
 
 func TestAddingNewFile(t *testing.T) {
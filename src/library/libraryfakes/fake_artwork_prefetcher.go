@@ -0,0 +1,227 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package libraryfakes
+
+import (
+	"sync"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+type FakeArtworkPrefetcher struct {
+	ArtworkPrefetchProgressStub        func() library.PrefetchProgress
+	artworkPrefetchProgressMutex       sync.RWMutex
+	artworkPrefetchProgressArgsForCall []struct {
+	}
+	artworkPrefetchProgressReturns struct {
+		result1 library.PrefetchProgress
+	}
+	artworkPrefetchProgressReturnsOnCall map[int]struct {
+		result1 library.PrefetchProgress
+	}
+	PauseArtworkPrefetchStub        func()
+	pauseArtworkPrefetchMutex       sync.RWMutex
+	pauseArtworkPrefetchArgsForCall []struct {
+	}
+	ResumeArtworkPrefetchStub        func()
+	resumeArtworkPrefetchMutex       sync.RWMutex
+	resumeArtworkPrefetchArgsForCall []struct {
+	}
+	StartArtworkPrefetchStub        func() bool
+	startArtworkPrefetchMutex       sync.RWMutex
+	startArtworkPrefetchArgsForCall []struct {
+	}
+	startArtworkPrefetchReturns struct {
+		result1 bool
+	}
+	startArtworkPrefetchReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeArtworkPrefetcher) ArtworkPrefetchProgress() library.PrefetchProgress {
+	fake.artworkPrefetchProgressMutex.Lock()
+	ret, specificReturn := fake.artworkPrefetchProgressReturnsOnCall[len(fake.artworkPrefetchProgressArgsForCall)]
+	fake.artworkPrefetchProgressArgsForCall = append(fake.artworkPrefetchProgressArgsForCall, struct {
+	}{})
+	stub := fake.ArtworkPrefetchProgressStub
+	fakeReturns := fake.artworkPrefetchProgressReturns
+	fake.recordInvocation("ArtworkPrefetchProgress", []interface{}{})
+	fake.artworkPrefetchProgressMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeArtworkPrefetcher) ArtworkPrefetchProgressCallCount() int {
+	fake.artworkPrefetchProgressMutex.RLock()
+	defer fake.artworkPrefetchProgressMutex.RUnlock()
+	return len(fake.artworkPrefetchProgressArgsForCall)
+}
+
+func (fake *FakeArtworkPrefetcher) ArtworkPrefetchProgressCalls(stub func() library.PrefetchProgress) {
+	fake.artworkPrefetchProgressMutex.Lock()
+	defer fake.artworkPrefetchProgressMutex.Unlock()
+	fake.ArtworkPrefetchProgressStub = stub
+}
+
+func (fake *FakeArtworkPrefetcher) ArtworkPrefetchProgressReturns(result1 library.PrefetchProgress) {
+	fake.artworkPrefetchProgressMutex.Lock()
+	defer fake.artworkPrefetchProgressMutex.Unlock()
+	fake.ArtworkPrefetchProgressStub = nil
+	fake.artworkPrefetchProgressReturns = struct {
+		result1 library.PrefetchProgress
+	}{result1}
+}
+
+func (fake *FakeArtworkPrefetcher) ArtworkPrefetchProgressReturnsOnCall(i int, result1 library.PrefetchProgress) {
+	fake.artworkPrefetchProgressMutex.Lock()
+	defer fake.artworkPrefetchProgressMutex.Unlock()
+	fake.ArtworkPrefetchProgressStub = nil
+	if fake.artworkPrefetchProgressReturnsOnCall == nil {
+		fake.artworkPrefetchProgressReturnsOnCall = make(map[int]struct {
+			result1 library.PrefetchProgress
+		})
+	}
+	fake.artworkPrefetchProgressReturnsOnCall[i] = struct {
+		result1 library.PrefetchProgress
+	}{result1}
+}
+
+func (fake *FakeArtworkPrefetcher) PauseArtworkPrefetch() {
+	fake.pauseArtworkPrefetchMutex.Lock()
+	fake.pauseArtworkPrefetchArgsForCall = append(fake.pauseArtworkPrefetchArgsForCall, struct {
+	}{})
+	stub := fake.PauseArtworkPrefetchStub
+	fake.recordInvocation("PauseArtworkPrefetch", []interface{}{})
+	fake.pauseArtworkPrefetchMutex.Unlock()
+	if stub != nil {
+		fake.PauseArtworkPrefetchStub()
+	}
+}
+
+func (fake *FakeArtworkPrefetcher) PauseArtworkPrefetchCallCount() int {
+	fake.pauseArtworkPrefetchMutex.RLock()
+	defer fake.pauseArtworkPrefetchMutex.RUnlock()
+	return len(fake.pauseArtworkPrefetchArgsForCall)
+}
+
+func (fake *FakeArtworkPrefetcher) PauseArtworkPrefetchCalls(stub func()) {
+	fake.pauseArtworkPrefetchMutex.Lock()
+	defer fake.pauseArtworkPrefetchMutex.Unlock()
+	fake.PauseArtworkPrefetchStub = stub
+}
+
+func (fake *FakeArtworkPrefetcher) ResumeArtworkPrefetch() {
+	fake.resumeArtworkPrefetchMutex.Lock()
+	fake.resumeArtworkPrefetchArgsForCall = append(fake.resumeArtworkPrefetchArgsForCall, struct {
+	}{})
+	stub := fake.ResumeArtworkPrefetchStub
+	fake.recordInvocation("ResumeArtworkPrefetch", []interface{}{})
+	fake.resumeArtworkPrefetchMutex.Unlock()
+	if stub != nil {
+		fake.ResumeArtworkPrefetchStub()
+	}
+}
+
+func (fake *FakeArtworkPrefetcher) ResumeArtworkPrefetchCallCount() int {
+	fake.resumeArtworkPrefetchMutex.RLock()
+	defer fake.resumeArtworkPrefetchMutex.RUnlock()
+	return len(fake.resumeArtworkPrefetchArgsForCall)
+}
+
+func (fake *FakeArtworkPrefetcher) ResumeArtworkPrefetchCalls(stub func()) {
+	fake.resumeArtworkPrefetchMutex.Lock()
+	defer fake.resumeArtworkPrefetchMutex.Unlock()
+	fake.ResumeArtworkPrefetchStub = stub
+}
+
+func (fake *FakeArtworkPrefetcher) StartArtworkPrefetch() bool {
+	fake.startArtworkPrefetchMutex.Lock()
+	ret, specificReturn := fake.startArtworkPrefetchReturnsOnCall[len(fake.startArtworkPrefetchArgsForCall)]
+	fake.startArtworkPrefetchArgsForCall = append(fake.startArtworkPrefetchArgsForCall, struct {
+	}{})
+	stub := fake.StartArtworkPrefetchStub
+	fakeReturns := fake.startArtworkPrefetchReturns
+	fake.recordInvocation("StartArtworkPrefetch", []interface{}{})
+	fake.startArtworkPrefetchMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeArtworkPrefetcher) StartArtworkPrefetchCallCount() int {
+	fake.startArtworkPrefetchMutex.RLock()
+	defer fake.startArtworkPrefetchMutex.RUnlock()
+	return len(fake.startArtworkPrefetchArgsForCall)
+}
+
+func (fake *FakeArtworkPrefetcher) StartArtworkPrefetchCalls(stub func() bool) {
+	fake.startArtworkPrefetchMutex.Lock()
+	defer fake.startArtworkPrefetchMutex.Unlock()
+	fake.StartArtworkPrefetchStub = stub
+}
+
+func (fake *FakeArtworkPrefetcher) StartArtworkPrefetchReturns(result1 bool) {
+	fake.startArtworkPrefetchMutex.Lock()
+	defer fake.startArtworkPrefetchMutex.Unlock()
+	fake.StartArtworkPrefetchStub = nil
+	fake.startArtworkPrefetchReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeArtworkPrefetcher) StartArtworkPrefetchReturnsOnCall(i int, result1 bool) {
+	fake.startArtworkPrefetchMutex.Lock()
+	defer fake.startArtworkPrefetchMutex.Unlock()
+	fake.StartArtworkPrefetchStub = nil
+	if fake.startArtworkPrefetchReturnsOnCall == nil {
+		fake.startArtworkPrefetchReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.startArtworkPrefetchReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeArtworkPrefetcher) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.artworkPrefetchProgressMutex.RLock()
+	defer fake.artworkPrefetchProgressMutex.RUnlock()
+	fake.pauseArtworkPrefetchMutex.RLock()
+	defer fake.pauseArtworkPrefetchMutex.RUnlock()
+	fake.resumeArtworkPrefetchMutex.RLock()
+	defer fake.resumeArtworkPrefetchMutex.RUnlock()
+	fake.startArtworkPrefetchMutex.RLock()
+	defer fake.startArtworkPrefetchMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeArtworkPrefetcher) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ library.ArtworkPrefetcher = new(FakeArtworkPrefetcher)
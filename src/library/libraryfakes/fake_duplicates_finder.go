@@ -0,0 +1,117 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package libraryfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+type FakeDuplicatesFinder struct {
+	FindDuplicatesStub        func(context.Context) ([]library.DuplicateTracks, error)
+	findDuplicatesMutex       sync.RWMutex
+	findDuplicatesArgsForCall []struct {
+		arg1 context.Context
+	}
+	findDuplicatesReturns struct {
+		result1 []library.DuplicateTracks
+		result2 error
+	}
+	findDuplicatesReturnsOnCall map[int]struct {
+		result1 []library.DuplicateTracks
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeDuplicatesFinder) FindDuplicates(arg1 context.Context) ([]library.DuplicateTracks, error) {
+	fake.findDuplicatesMutex.Lock()
+	ret, specificReturn := fake.findDuplicatesReturnsOnCall[len(fake.findDuplicatesArgsForCall)]
+	fake.findDuplicatesArgsForCall = append(fake.findDuplicatesArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.FindDuplicatesStub
+	fakeReturns := fake.findDuplicatesReturns
+	fake.recordInvocation("FindDuplicates", []interface{}{arg1})
+	fake.findDuplicatesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDuplicatesFinder) FindDuplicatesCallCount() int {
+	fake.findDuplicatesMutex.RLock()
+	defer fake.findDuplicatesMutex.RUnlock()
+	return len(fake.findDuplicatesArgsForCall)
+}
+
+func (fake *FakeDuplicatesFinder) FindDuplicatesCalls(stub func(context.Context) ([]library.DuplicateTracks, error)) {
+	fake.findDuplicatesMutex.Lock()
+	defer fake.findDuplicatesMutex.Unlock()
+	fake.FindDuplicatesStub = stub
+}
+
+func (fake *FakeDuplicatesFinder) FindDuplicatesArgsForCall(i int) context.Context {
+	fake.findDuplicatesMutex.RLock()
+	defer fake.findDuplicatesMutex.RUnlock()
+	argsForCall := fake.findDuplicatesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDuplicatesFinder) FindDuplicatesReturns(result1 []library.DuplicateTracks, result2 error) {
+	fake.findDuplicatesMutex.Lock()
+	defer fake.findDuplicatesMutex.Unlock()
+	fake.FindDuplicatesStub = nil
+	fake.findDuplicatesReturns = struct {
+		result1 []library.DuplicateTracks
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDuplicatesFinder) FindDuplicatesReturnsOnCall(i int, result1 []library.DuplicateTracks, result2 error) {
+	fake.findDuplicatesMutex.Lock()
+	defer fake.findDuplicatesMutex.Unlock()
+	fake.FindDuplicatesStub = nil
+	if fake.findDuplicatesReturnsOnCall == nil {
+		fake.findDuplicatesReturnsOnCall = make(map[int]struct {
+			result1 []library.DuplicateTracks
+			result2 error
+		})
+	}
+	fake.findDuplicatesReturnsOnCall[i] = struct {
+		result1 []library.DuplicateTracks
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDuplicatesFinder) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.findDuplicatesMutex.RLock()
+	defer fake.findDuplicatesMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeDuplicatesFinder) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ library.DuplicatesFinder = new(FakeDuplicatesFinder)
@@ -0,0 +1,300 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package libraryfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+type FakeEditionsResolver struct {
+	GetAlbumEditionsStub        func(context.Context, int64) ([]library.AlbumEdition, error)
+	getAlbumEditionsMutex       sync.RWMutex
+	getAlbumEditionsArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+	}
+	getAlbumEditionsReturns struct {
+		result1 []library.AlbumEdition
+		result2 error
+	}
+	getAlbumEditionsReturnsOnCall map[int]struct {
+		result1 []library.AlbumEdition
+		result2 error
+	}
+	PreferEditionsStub        func(context.Context, []library.SearchResult, []string) ([]library.SearchResult, error)
+	preferEditionsMutex       sync.RWMutex
+	preferEditionsArgsForCall []struct {
+		arg1 context.Context
+		arg2 []library.SearchResult
+		arg3 []string
+	}
+	preferEditionsReturns struct {
+		result1 []library.SearchResult
+		result2 error
+	}
+	preferEditionsReturnsOnCall map[int]struct {
+		result1 []library.SearchResult
+		result2 error
+	}
+	PreferredTrackStub        func(context.Context, int64, []string) (int64, error)
+	preferredTrackMutex       sync.RWMutex
+	preferredTrackArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 []string
+	}
+	preferredTrackReturns struct {
+		result1 int64
+		result2 error
+	}
+	preferredTrackReturnsOnCall map[int]struct {
+		result1 int64
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeEditionsResolver) GetAlbumEditions(arg1 context.Context, arg2 int64) ([]library.AlbumEdition, error) {
+	fake.getAlbumEditionsMutex.Lock()
+	ret, specificReturn := fake.getAlbumEditionsReturnsOnCall[len(fake.getAlbumEditionsArgsForCall)]
+	fake.getAlbumEditionsArgsForCall = append(fake.getAlbumEditionsArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+	}{arg1, arg2})
+	stub := fake.GetAlbumEditionsStub
+	fakeReturns := fake.getAlbumEditionsReturns
+	fake.recordInvocation("GetAlbumEditions", []interface{}{arg1, arg2})
+	fake.getAlbumEditionsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEditionsResolver) GetAlbumEditionsCallCount() int {
+	fake.getAlbumEditionsMutex.RLock()
+	defer fake.getAlbumEditionsMutex.RUnlock()
+	return len(fake.getAlbumEditionsArgsForCall)
+}
+
+func (fake *FakeEditionsResolver) GetAlbumEditionsCalls(stub func(context.Context, int64) ([]library.AlbumEdition, error)) {
+	fake.getAlbumEditionsMutex.Lock()
+	defer fake.getAlbumEditionsMutex.Unlock()
+	fake.GetAlbumEditionsStub = stub
+}
+
+func (fake *FakeEditionsResolver) GetAlbumEditionsArgsForCall(i int) (context.Context, int64) {
+	fake.getAlbumEditionsMutex.RLock()
+	defer fake.getAlbumEditionsMutex.RUnlock()
+	argsForCall := fake.getAlbumEditionsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeEditionsResolver) GetAlbumEditionsReturns(result1 []library.AlbumEdition, result2 error) {
+	fake.getAlbumEditionsMutex.Lock()
+	defer fake.getAlbumEditionsMutex.Unlock()
+	fake.GetAlbumEditionsStub = nil
+	fake.getAlbumEditionsReturns = struct {
+		result1 []library.AlbumEdition
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEditionsResolver) GetAlbumEditionsReturnsOnCall(i int, result1 []library.AlbumEdition, result2 error) {
+	fake.getAlbumEditionsMutex.Lock()
+	defer fake.getAlbumEditionsMutex.Unlock()
+	fake.GetAlbumEditionsStub = nil
+	if fake.getAlbumEditionsReturnsOnCall == nil {
+		fake.getAlbumEditionsReturnsOnCall = make(map[int]struct {
+			result1 []library.AlbumEdition
+			result2 error
+		})
+	}
+	fake.getAlbumEditionsReturnsOnCall[i] = struct {
+		result1 []library.AlbumEdition
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEditionsResolver) PreferEditions(arg1 context.Context, arg2 []library.SearchResult, arg3 []string) ([]library.SearchResult, error) {
+	var arg2Copy []library.SearchResult
+	if arg2 != nil {
+		arg2Copy = make([]library.SearchResult, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	var arg3Copy []string
+	if arg3 != nil {
+		arg3Copy = make([]string, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.preferEditionsMutex.Lock()
+	ret, specificReturn := fake.preferEditionsReturnsOnCall[len(fake.preferEditionsArgsForCall)]
+	fake.preferEditionsArgsForCall = append(fake.preferEditionsArgsForCall, struct {
+		arg1 context.Context
+		arg2 []library.SearchResult
+		arg3 []string
+	}{arg1, arg2Copy, arg3Copy})
+	stub := fake.PreferEditionsStub
+	fakeReturns := fake.preferEditionsReturns
+	fake.recordInvocation("PreferEditions", []interface{}{arg1, arg2Copy, arg3Copy})
+	fake.preferEditionsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEditionsResolver) PreferEditionsCallCount() int {
+	fake.preferEditionsMutex.RLock()
+	defer fake.preferEditionsMutex.RUnlock()
+	return len(fake.preferEditionsArgsForCall)
+}
+
+func (fake *FakeEditionsResolver) PreferEditionsCalls(stub func(context.Context, []library.SearchResult, []string) ([]library.SearchResult, error)) {
+	fake.preferEditionsMutex.Lock()
+	defer fake.preferEditionsMutex.Unlock()
+	fake.PreferEditionsStub = stub
+}
+
+func (fake *FakeEditionsResolver) PreferEditionsArgsForCall(i int) (context.Context, []library.SearchResult, []string) {
+	fake.preferEditionsMutex.RLock()
+	defer fake.preferEditionsMutex.RUnlock()
+	argsForCall := fake.preferEditionsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeEditionsResolver) PreferEditionsReturns(result1 []library.SearchResult, result2 error) {
+	fake.preferEditionsMutex.Lock()
+	defer fake.preferEditionsMutex.Unlock()
+	fake.PreferEditionsStub = nil
+	fake.preferEditionsReturns = struct {
+		result1 []library.SearchResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEditionsResolver) PreferEditionsReturnsOnCall(i int, result1 []library.SearchResult, result2 error) {
+	fake.preferEditionsMutex.Lock()
+	defer fake.preferEditionsMutex.Unlock()
+	fake.PreferEditionsStub = nil
+	if fake.preferEditionsReturnsOnCall == nil {
+		fake.preferEditionsReturnsOnCall = make(map[int]struct {
+			result1 []library.SearchResult
+			result2 error
+		})
+	}
+	fake.preferEditionsReturnsOnCall[i] = struct {
+		result1 []library.SearchResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEditionsResolver) PreferredTrack(arg1 context.Context, arg2 int64, arg3 []string) (int64, error) {
+	var arg3Copy []string
+	if arg3 != nil {
+		arg3Copy = make([]string, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.preferredTrackMutex.Lock()
+	ret, specificReturn := fake.preferredTrackReturnsOnCall[len(fake.preferredTrackArgsForCall)]
+	fake.preferredTrackArgsForCall = append(fake.preferredTrackArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 []string
+	}{arg1, arg2, arg3Copy})
+	stub := fake.PreferredTrackStub
+	fakeReturns := fake.preferredTrackReturns
+	fake.recordInvocation("PreferredTrack", []interface{}{arg1, arg2, arg3Copy})
+	fake.preferredTrackMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEditionsResolver) PreferredTrackCallCount() int {
+	fake.preferredTrackMutex.RLock()
+	defer fake.preferredTrackMutex.RUnlock()
+	return len(fake.preferredTrackArgsForCall)
+}
+
+func (fake *FakeEditionsResolver) PreferredTrackCalls(stub func(context.Context, int64, []string) (int64, error)) {
+	fake.preferredTrackMutex.Lock()
+	defer fake.preferredTrackMutex.Unlock()
+	fake.PreferredTrackStub = stub
+}
+
+func (fake *FakeEditionsResolver) PreferredTrackArgsForCall(i int) (context.Context, int64, []string) {
+	fake.preferredTrackMutex.RLock()
+	defer fake.preferredTrackMutex.RUnlock()
+	argsForCall := fake.preferredTrackArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeEditionsResolver) PreferredTrackReturns(result1 int64, result2 error) {
+	fake.preferredTrackMutex.Lock()
+	defer fake.preferredTrackMutex.Unlock()
+	fake.PreferredTrackStub = nil
+	fake.preferredTrackReturns = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEditionsResolver) PreferredTrackReturnsOnCall(i int, result1 int64, result2 error) {
+	fake.preferredTrackMutex.Lock()
+	defer fake.preferredTrackMutex.Unlock()
+	fake.PreferredTrackStub = nil
+	if fake.preferredTrackReturnsOnCall == nil {
+		fake.preferredTrackReturnsOnCall = make(map[int]struct {
+			result1 int64
+			result2 error
+		})
+	}
+	fake.preferredTrackReturnsOnCall[i] = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEditionsResolver) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getAlbumEditionsMutex.RLock()
+	defer fake.getAlbumEditionsMutex.RUnlock()
+	fake.preferEditionsMutex.RLock()
+	defer fake.preferEditionsMutex.RUnlock()
+	fake.preferredTrackMutex.RLock()
+	defer fake.preferredTrackMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeEditionsResolver) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ library.EditionsResolver = new(FakeEditionsResolver)
@@ -0,0 +1,205 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package libraryfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+type FakeFolderBrowser struct {
+	BrowseFolderStub        func(context.Context, string, library.BrowseArgs) (library.FolderContents, int, error)
+	browseFolderMutex       sync.RWMutex
+	browseFolderArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 library.BrowseArgs
+	}
+	browseFolderReturns struct {
+		result1 library.FolderContents
+		result2 int
+		result3 error
+	}
+	browseFolderReturnsOnCall map[int]struct {
+		result1 library.FolderContents
+		result2 int
+		result3 error
+	}
+	RootFoldersStub        func(context.Context) ([]library.Folder, error)
+	rootFoldersMutex       sync.RWMutex
+	rootFoldersArgsForCall []struct {
+		arg1 context.Context
+	}
+	rootFoldersReturns struct {
+		result1 []library.Folder
+		result2 error
+	}
+	rootFoldersReturnsOnCall map[int]struct {
+		result1 []library.Folder
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeFolderBrowser) BrowseFolder(arg1 context.Context, arg2 string, arg3 library.BrowseArgs) (library.FolderContents, int, error) {
+	fake.browseFolderMutex.Lock()
+	ret, specificReturn := fake.browseFolderReturnsOnCall[len(fake.browseFolderArgsForCall)]
+	fake.browseFolderArgsForCall = append(fake.browseFolderArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 library.BrowseArgs
+	}{arg1, arg2, arg3})
+	stub := fake.BrowseFolderStub
+	fakeReturns := fake.browseFolderReturns
+	fake.recordInvocation("BrowseFolder", []interface{}{arg1, arg2, arg3})
+	fake.browseFolderMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeFolderBrowser) BrowseFolderCallCount() int {
+	fake.browseFolderMutex.RLock()
+	defer fake.browseFolderMutex.RUnlock()
+	return len(fake.browseFolderArgsForCall)
+}
+
+func (fake *FakeFolderBrowser) BrowseFolderCalls(stub func(context.Context, string, library.BrowseArgs) (library.FolderContents, int, error)) {
+	fake.browseFolderMutex.Lock()
+	defer fake.browseFolderMutex.Unlock()
+	fake.BrowseFolderStub = stub
+}
+
+func (fake *FakeFolderBrowser) BrowseFolderArgsForCall(i int) (context.Context, string, library.BrowseArgs) {
+	fake.browseFolderMutex.RLock()
+	defer fake.browseFolderMutex.RUnlock()
+	argsForCall := fake.browseFolderArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeFolderBrowser) BrowseFolderReturns(result1 library.FolderContents, result2 int, result3 error) {
+	fake.browseFolderMutex.Lock()
+	defer fake.browseFolderMutex.Unlock()
+	fake.BrowseFolderStub = nil
+	fake.browseFolderReturns = struct {
+		result1 library.FolderContents
+		result2 int
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeFolderBrowser) BrowseFolderReturnsOnCall(i int, result1 library.FolderContents, result2 int, result3 error) {
+	fake.browseFolderMutex.Lock()
+	defer fake.browseFolderMutex.Unlock()
+	fake.BrowseFolderStub = nil
+	if fake.browseFolderReturnsOnCall == nil {
+		fake.browseFolderReturnsOnCall = make(map[int]struct {
+			result1 library.FolderContents
+			result2 int
+			result3 error
+		})
+	}
+	fake.browseFolderReturnsOnCall[i] = struct {
+		result1 library.FolderContents
+		result2 int
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeFolderBrowser) RootFolders(arg1 context.Context) ([]library.Folder, error) {
+	fake.rootFoldersMutex.Lock()
+	ret, specificReturn := fake.rootFoldersReturnsOnCall[len(fake.rootFoldersArgsForCall)]
+	fake.rootFoldersArgsForCall = append(fake.rootFoldersArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.RootFoldersStub
+	fakeReturns := fake.rootFoldersReturns
+	fake.recordInvocation("RootFolders", []interface{}{arg1})
+	fake.rootFoldersMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeFolderBrowser) RootFoldersCallCount() int {
+	fake.rootFoldersMutex.RLock()
+	defer fake.rootFoldersMutex.RUnlock()
+	return len(fake.rootFoldersArgsForCall)
+}
+
+func (fake *FakeFolderBrowser) RootFoldersCalls(stub func(context.Context) ([]library.Folder, error)) {
+	fake.rootFoldersMutex.Lock()
+	defer fake.rootFoldersMutex.Unlock()
+	fake.RootFoldersStub = stub
+}
+
+func (fake *FakeFolderBrowser) RootFoldersArgsForCall(i int) context.Context {
+	fake.rootFoldersMutex.RLock()
+	defer fake.rootFoldersMutex.RUnlock()
+	argsForCall := fake.rootFoldersArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeFolderBrowser) RootFoldersReturns(result1 []library.Folder, result2 error) {
+	fake.rootFoldersMutex.Lock()
+	defer fake.rootFoldersMutex.Unlock()
+	fake.RootFoldersStub = nil
+	fake.rootFoldersReturns = struct {
+		result1 []library.Folder
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFolderBrowser) RootFoldersReturnsOnCall(i int, result1 []library.Folder, result2 error) {
+	fake.rootFoldersMutex.Lock()
+	defer fake.rootFoldersMutex.Unlock()
+	fake.RootFoldersStub = nil
+	if fake.rootFoldersReturnsOnCall == nil {
+		fake.rootFoldersReturnsOnCall = make(map[int]struct {
+			result1 []library.Folder
+			result2 error
+		})
+	}
+	fake.rootFoldersReturnsOnCall[i] = struct {
+		result1 []library.Folder
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFolderBrowser) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.browseFolderMutex.RLock()
+	defer fake.browseFolderMutex.RUnlock()
+	fake.rootFoldersMutex.RLock()
+	defer fake.rootFoldersMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeFolderBrowser) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ library.FolderBrowser = new(FakeFolderBrowser)
@@ -0,0 +1,359 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package libraryfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+type FakeLibrariesManager struct {
+	AddLibraryStub        func(context.Context, string, string) (library.LibraryDir, error)
+	addLibraryMutex       sync.RWMutex
+	addLibraryArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	addLibraryReturns struct {
+		result1 library.LibraryDir
+		result2 error
+	}
+	addLibraryReturnsOnCall map[int]struct {
+		result1 library.LibraryDir
+		result2 error
+	}
+	LibrariesStub        func(context.Context) ([]library.LibraryDir, error)
+	librariesMutex       sync.RWMutex
+	librariesArgsForCall []struct {
+		arg1 context.Context
+	}
+	librariesReturns struct {
+		result1 []library.LibraryDir
+		result2 error
+	}
+	librariesReturnsOnCall map[int]struct {
+		result1 []library.LibraryDir
+		result2 error
+	}
+	RemoveLibraryStub        func(context.Context, int64) error
+	removeLibraryMutex       sync.RWMutex
+	removeLibraryArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+	}
+	removeLibraryReturns struct {
+		result1 error
+	}
+	removeLibraryReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UpdateLibraryStub        func(context.Context, int64, library.LibraryChanges) (library.LibraryDir, error)
+	updateLibraryMutex       sync.RWMutex
+	updateLibraryArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 library.LibraryChanges
+	}
+	updateLibraryReturns struct {
+		result1 library.LibraryDir
+		result2 error
+	}
+	updateLibraryReturnsOnCall map[int]struct {
+		result1 library.LibraryDir
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeLibrariesManager) AddLibrary(arg1 context.Context, arg2 string, arg3 string) (library.LibraryDir, error) {
+	fake.addLibraryMutex.Lock()
+	ret, specificReturn := fake.addLibraryReturnsOnCall[len(fake.addLibraryArgsForCall)]
+	fake.addLibraryArgsForCall = append(fake.addLibraryArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.AddLibraryStub
+	fakeReturns := fake.addLibraryReturns
+	fake.recordInvocation("AddLibrary", []interface{}{arg1, arg2, arg3})
+	fake.addLibraryMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLibrariesManager) AddLibraryCallCount() int {
+	fake.addLibraryMutex.RLock()
+	defer fake.addLibraryMutex.RUnlock()
+	return len(fake.addLibraryArgsForCall)
+}
+
+func (fake *FakeLibrariesManager) AddLibraryCalls(stub func(context.Context, string, string) (library.LibraryDir, error)) {
+	fake.addLibraryMutex.Lock()
+	defer fake.addLibraryMutex.Unlock()
+	fake.AddLibraryStub = stub
+}
+
+func (fake *FakeLibrariesManager) AddLibraryArgsForCall(i int) (context.Context, string, string) {
+	fake.addLibraryMutex.RLock()
+	defer fake.addLibraryMutex.RUnlock()
+	argsForCall := fake.addLibraryArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeLibrariesManager) AddLibraryReturns(result1 library.LibraryDir, result2 error) {
+	fake.addLibraryMutex.Lock()
+	defer fake.addLibraryMutex.Unlock()
+	fake.AddLibraryStub = nil
+	fake.addLibraryReturns = struct {
+		result1 library.LibraryDir
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLibrariesManager) AddLibraryReturnsOnCall(i int, result1 library.LibraryDir, result2 error) {
+	fake.addLibraryMutex.Lock()
+	defer fake.addLibraryMutex.Unlock()
+	fake.AddLibraryStub = nil
+	if fake.addLibraryReturnsOnCall == nil {
+		fake.addLibraryReturnsOnCall = make(map[int]struct {
+			result1 library.LibraryDir
+			result2 error
+		})
+	}
+	fake.addLibraryReturnsOnCall[i] = struct {
+		result1 library.LibraryDir
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLibrariesManager) Libraries(arg1 context.Context) ([]library.LibraryDir, error) {
+	fake.librariesMutex.Lock()
+	ret, specificReturn := fake.librariesReturnsOnCall[len(fake.librariesArgsForCall)]
+	fake.librariesArgsForCall = append(fake.librariesArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.LibrariesStub
+	fakeReturns := fake.librariesReturns
+	fake.recordInvocation("Libraries", []interface{}{arg1})
+	fake.librariesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLibrariesManager) LibrariesCallCount() int {
+	fake.librariesMutex.RLock()
+	defer fake.librariesMutex.RUnlock()
+	return len(fake.librariesArgsForCall)
+}
+
+func (fake *FakeLibrariesManager) LibrariesCalls(stub func(context.Context) ([]library.LibraryDir, error)) {
+	fake.librariesMutex.Lock()
+	defer fake.librariesMutex.Unlock()
+	fake.LibrariesStub = stub
+}
+
+func (fake *FakeLibrariesManager) LibrariesArgsForCall(i int) context.Context {
+	fake.librariesMutex.RLock()
+	defer fake.librariesMutex.RUnlock()
+	argsForCall := fake.librariesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeLibrariesManager) LibrariesReturns(result1 []library.LibraryDir, result2 error) {
+	fake.librariesMutex.Lock()
+	defer fake.librariesMutex.Unlock()
+	fake.LibrariesStub = nil
+	fake.librariesReturns = struct {
+		result1 []library.LibraryDir
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLibrariesManager) LibrariesReturnsOnCall(i int, result1 []library.LibraryDir, result2 error) {
+	fake.librariesMutex.Lock()
+	defer fake.librariesMutex.Unlock()
+	fake.LibrariesStub = nil
+	if fake.librariesReturnsOnCall == nil {
+		fake.librariesReturnsOnCall = make(map[int]struct {
+			result1 []library.LibraryDir
+			result2 error
+		})
+	}
+	fake.librariesReturnsOnCall[i] = struct {
+		result1 []library.LibraryDir
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLibrariesManager) RemoveLibrary(arg1 context.Context, arg2 int64) error {
+	fake.removeLibraryMutex.Lock()
+	ret, specificReturn := fake.removeLibraryReturnsOnCall[len(fake.removeLibraryArgsForCall)]
+	fake.removeLibraryArgsForCall = append(fake.removeLibraryArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+	}{arg1, arg2})
+	stub := fake.RemoveLibraryStub
+	fakeReturns := fake.removeLibraryReturns
+	fake.recordInvocation("RemoveLibrary", []interface{}{arg1, arg2})
+	fake.removeLibraryMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLibrariesManager) RemoveLibraryCallCount() int {
+	fake.removeLibraryMutex.RLock()
+	defer fake.removeLibraryMutex.RUnlock()
+	return len(fake.removeLibraryArgsForCall)
+}
+
+func (fake *FakeLibrariesManager) RemoveLibraryCalls(stub func(context.Context, int64) error) {
+	fake.removeLibraryMutex.Lock()
+	defer fake.removeLibraryMutex.Unlock()
+	fake.RemoveLibraryStub = stub
+}
+
+func (fake *FakeLibrariesManager) RemoveLibraryArgsForCall(i int) (context.Context, int64) {
+	fake.removeLibraryMutex.RLock()
+	defer fake.removeLibraryMutex.RUnlock()
+	argsForCall := fake.removeLibraryArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLibrariesManager) RemoveLibraryReturns(result1 error) {
+	fake.removeLibraryMutex.Lock()
+	defer fake.removeLibraryMutex.Unlock()
+	fake.RemoveLibraryStub = nil
+	fake.removeLibraryReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLibrariesManager) RemoveLibraryReturnsOnCall(i int, result1 error) {
+	fake.removeLibraryMutex.Lock()
+	defer fake.removeLibraryMutex.Unlock()
+	fake.RemoveLibraryStub = nil
+	if fake.removeLibraryReturnsOnCall == nil {
+		fake.removeLibraryReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.removeLibraryReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLibrariesManager) UpdateLibrary(arg1 context.Context, arg2 int64, arg3 library.LibraryChanges) (library.LibraryDir, error) {
+	fake.updateLibraryMutex.Lock()
+	ret, specificReturn := fake.updateLibraryReturnsOnCall[len(fake.updateLibraryArgsForCall)]
+	fake.updateLibraryArgsForCall = append(fake.updateLibraryArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 library.LibraryChanges
+	}{arg1, arg2, arg3})
+	stub := fake.UpdateLibraryStub
+	fakeReturns := fake.updateLibraryReturns
+	fake.recordInvocation("UpdateLibrary", []interface{}{arg1, arg2, arg3})
+	fake.updateLibraryMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLibrariesManager) UpdateLibraryCallCount() int {
+	fake.updateLibraryMutex.RLock()
+	defer fake.updateLibraryMutex.RUnlock()
+	return len(fake.updateLibraryArgsForCall)
+}
+
+func (fake *FakeLibrariesManager) UpdateLibraryCalls(stub func(context.Context, int64, library.LibraryChanges) (library.LibraryDir, error)) {
+	fake.updateLibraryMutex.Lock()
+	defer fake.updateLibraryMutex.Unlock()
+	fake.UpdateLibraryStub = stub
+}
+
+func (fake *FakeLibrariesManager) UpdateLibraryArgsForCall(i int) (context.Context, int64, library.LibraryChanges) {
+	fake.updateLibraryMutex.RLock()
+	defer fake.updateLibraryMutex.RUnlock()
+	argsForCall := fake.updateLibraryArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeLibrariesManager) UpdateLibraryReturns(result1 library.LibraryDir, result2 error) {
+	fake.updateLibraryMutex.Lock()
+	defer fake.updateLibraryMutex.Unlock()
+	fake.UpdateLibraryStub = nil
+	fake.updateLibraryReturns = struct {
+		result1 library.LibraryDir
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLibrariesManager) UpdateLibraryReturnsOnCall(i int, result1 library.LibraryDir, result2 error) {
+	fake.updateLibraryMutex.Lock()
+	defer fake.updateLibraryMutex.Unlock()
+	fake.UpdateLibraryStub = nil
+	if fake.updateLibraryReturnsOnCall == nil {
+		fake.updateLibraryReturnsOnCall = make(map[int]struct {
+			result1 library.LibraryDir
+			result2 error
+		})
+	}
+	fake.updateLibraryReturnsOnCall[i] = struct {
+		result1 library.LibraryDir
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLibrariesManager) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.addLibraryMutex.RLock()
+	defer fake.addLibraryMutex.RUnlock()
+	fake.librariesMutex.RLock()
+	defer fake.librariesMutex.RUnlock()
+	fake.removeLibraryMutex.RLock()
+	defer fake.removeLibraryMutex.RUnlock()
+	fake.updateLibraryMutex.RLock()
+	defer fake.updateLibraryMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeLibrariesManager) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ library.LibrariesManager = new(FakeLibrariesManager)
@@ -0,0 +1,119 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package libraryfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+type FakeLyricsManager struct {
+	GetLyricsStub        func(context.Context, int64) (library.TrackLyrics, error)
+	getLyricsMutex       sync.RWMutex
+	getLyricsArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+	}
+	getLyricsReturns struct {
+		result1 library.TrackLyrics
+		result2 error
+	}
+	getLyricsReturnsOnCall map[int]struct {
+		result1 library.TrackLyrics
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeLyricsManager) GetLyrics(arg1 context.Context, arg2 int64) (library.TrackLyrics, error) {
+	fake.getLyricsMutex.Lock()
+	ret, specificReturn := fake.getLyricsReturnsOnCall[len(fake.getLyricsArgsForCall)]
+	fake.getLyricsArgsForCall = append(fake.getLyricsArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+	}{arg1, arg2})
+	stub := fake.GetLyricsStub
+	fakeReturns := fake.getLyricsReturns
+	fake.recordInvocation("GetLyrics", []interface{}{arg1, arg2})
+	fake.getLyricsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLyricsManager) GetLyricsCallCount() int {
+	fake.getLyricsMutex.RLock()
+	defer fake.getLyricsMutex.RUnlock()
+	return len(fake.getLyricsArgsForCall)
+}
+
+func (fake *FakeLyricsManager) GetLyricsCalls(stub func(context.Context, int64) (library.TrackLyrics, error)) {
+	fake.getLyricsMutex.Lock()
+	defer fake.getLyricsMutex.Unlock()
+	fake.GetLyricsStub = stub
+}
+
+func (fake *FakeLyricsManager) GetLyricsArgsForCall(i int) (context.Context, int64) {
+	fake.getLyricsMutex.RLock()
+	defer fake.getLyricsMutex.RUnlock()
+	argsForCall := fake.getLyricsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLyricsManager) GetLyricsReturns(result1 library.TrackLyrics, result2 error) {
+	fake.getLyricsMutex.Lock()
+	defer fake.getLyricsMutex.Unlock()
+	fake.GetLyricsStub = nil
+	fake.getLyricsReturns = struct {
+		result1 library.TrackLyrics
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLyricsManager) GetLyricsReturnsOnCall(i int, result1 library.TrackLyrics, result2 error) {
+	fake.getLyricsMutex.Lock()
+	defer fake.getLyricsMutex.Unlock()
+	fake.GetLyricsStub = nil
+	if fake.getLyricsReturnsOnCall == nil {
+		fake.getLyricsReturnsOnCall = make(map[int]struct {
+			result1 library.TrackLyrics
+			result2 error
+		})
+	}
+	fake.getLyricsReturnsOnCall[i] = struct {
+		result1 library.TrackLyrics
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLyricsManager) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getLyricsMutex.RLock()
+	defer fake.getLyricsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeLyricsManager) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ library.LyricsManager = new(FakeLyricsManager)
@@ -0,0 +1,356 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package libraryfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+type FakeOverridesManager struct {
+	GetAlbumOverridesStub        func(context.Context, int64) (library.MetadataOverrides, error)
+	getAlbumOverridesMutex       sync.RWMutex
+	getAlbumOverridesArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+	}
+	getAlbumOverridesReturns struct {
+		result1 library.MetadataOverrides
+		result2 error
+	}
+	getAlbumOverridesReturnsOnCall map[int]struct {
+		result1 library.MetadataOverrides
+		result2 error
+	}
+	GetTrackOverridesStub        func(context.Context, int64) (library.MetadataOverrides, error)
+	getTrackOverridesMutex       sync.RWMutex
+	getTrackOverridesArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+	}
+	getTrackOverridesReturns struct {
+		result1 library.MetadataOverrides
+		result2 error
+	}
+	getTrackOverridesReturnsOnCall map[int]struct {
+		result1 library.MetadataOverrides
+		result2 error
+	}
+	SetAlbumOverridesStub        func(context.Context, int64, library.MetadataOverrides) error
+	setAlbumOverridesMutex       sync.RWMutex
+	setAlbumOverridesArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 library.MetadataOverrides
+	}
+	setAlbumOverridesReturns struct {
+		result1 error
+	}
+	setAlbumOverridesReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SetTrackOverridesStub        func(context.Context, int64, library.MetadataOverrides) error
+	setTrackOverridesMutex       sync.RWMutex
+	setTrackOverridesArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 library.MetadataOverrides
+	}
+	setTrackOverridesReturns struct {
+		result1 error
+	}
+	setTrackOverridesReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeOverridesManager) GetAlbumOverrides(arg1 context.Context, arg2 int64) (library.MetadataOverrides, error) {
+	fake.getAlbumOverridesMutex.Lock()
+	ret, specificReturn := fake.getAlbumOverridesReturnsOnCall[len(fake.getAlbumOverridesArgsForCall)]
+	fake.getAlbumOverridesArgsForCall = append(fake.getAlbumOverridesArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+	}{arg1, arg2})
+	stub := fake.GetAlbumOverridesStub
+	fakeReturns := fake.getAlbumOverridesReturns
+	fake.recordInvocation("GetAlbumOverrides", []interface{}{arg1, arg2})
+	fake.getAlbumOverridesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeOverridesManager) GetAlbumOverridesCallCount() int {
+	fake.getAlbumOverridesMutex.RLock()
+	defer fake.getAlbumOverridesMutex.RUnlock()
+	return len(fake.getAlbumOverridesArgsForCall)
+}
+
+func (fake *FakeOverridesManager) GetAlbumOverridesCalls(stub func(context.Context, int64) (library.MetadataOverrides, error)) {
+	fake.getAlbumOverridesMutex.Lock()
+	defer fake.getAlbumOverridesMutex.Unlock()
+	fake.GetAlbumOverridesStub = stub
+}
+
+func (fake *FakeOverridesManager) GetAlbumOverridesArgsForCall(i int) (context.Context, int64) {
+	fake.getAlbumOverridesMutex.RLock()
+	defer fake.getAlbumOverridesMutex.RUnlock()
+	argsForCall := fake.getAlbumOverridesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeOverridesManager) GetAlbumOverridesReturns(result1 library.MetadataOverrides, result2 error) {
+	fake.getAlbumOverridesMutex.Lock()
+	defer fake.getAlbumOverridesMutex.Unlock()
+	fake.GetAlbumOverridesStub = nil
+	fake.getAlbumOverridesReturns = struct {
+		result1 library.MetadataOverrides
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeOverridesManager) GetAlbumOverridesReturnsOnCall(i int, result1 library.MetadataOverrides, result2 error) {
+	fake.getAlbumOverridesMutex.Lock()
+	defer fake.getAlbumOverridesMutex.Unlock()
+	fake.GetAlbumOverridesStub = nil
+	if fake.getAlbumOverridesReturnsOnCall == nil {
+		fake.getAlbumOverridesReturnsOnCall = make(map[int]struct {
+			result1 library.MetadataOverrides
+			result2 error
+		})
+	}
+	fake.getAlbumOverridesReturnsOnCall[i] = struct {
+		result1 library.MetadataOverrides
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeOverridesManager) GetTrackOverrides(arg1 context.Context, arg2 int64) (library.MetadataOverrides, error) {
+	fake.getTrackOverridesMutex.Lock()
+	ret, specificReturn := fake.getTrackOverridesReturnsOnCall[len(fake.getTrackOverridesArgsForCall)]
+	fake.getTrackOverridesArgsForCall = append(fake.getTrackOverridesArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+	}{arg1, arg2})
+	stub := fake.GetTrackOverridesStub
+	fakeReturns := fake.getTrackOverridesReturns
+	fake.recordInvocation("GetTrackOverrides", []interface{}{arg1, arg2})
+	fake.getTrackOverridesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeOverridesManager) GetTrackOverridesCallCount() int {
+	fake.getTrackOverridesMutex.RLock()
+	defer fake.getTrackOverridesMutex.RUnlock()
+	return len(fake.getTrackOverridesArgsForCall)
+}
+
+func (fake *FakeOverridesManager) GetTrackOverridesCalls(stub func(context.Context, int64) (library.MetadataOverrides, error)) {
+	fake.getTrackOverridesMutex.Lock()
+	defer fake.getTrackOverridesMutex.Unlock()
+	fake.GetTrackOverridesStub = stub
+}
+
+func (fake *FakeOverridesManager) GetTrackOverridesArgsForCall(i int) (context.Context, int64) {
+	fake.getTrackOverridesMutex.RLock()
+	defer fake.getTrackOverridesMutex.RUnlock()
+	argsForCall := fake.getTrackOverridesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeOverridesManager) GetTrackOverridesReturns(result1 library.MetadataOverrides, result2 error) {
+	fake.getTrackOverridesMutex.Lock()
+	defer fake.getTrackOverridesMutex.Unlock()
+	fake.GetTrackOverridesStub = nil
+	fake.getTrackOverridesReturns = struct {
+		result1 library.MetadataOverrides
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeOverridesManager) GetTrackOverridesReturnsOnCall(i int, result1 library.MetadataOverrides, result2 error) {
+	fake.getTrackOverridesMutex.Lock()
+	defer fake.getTrackOverridesMutex.Unlock()
+	fake.GetTrackOverridesStub = nil
+	if fake.getTrackOverridesReturnsOnCall == nil {
+		fake.getTrackOverridesReturnsOnCall = make(map[int]struct {
+			result1 library.MetadataOverrides
+			result2 error
+		})
+	}
+	fake.getTrackOverridesReturnsOnCall[i] = struct {
+		result1 library.MetadataOverrides
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeOverridesManager) SetAlbumOverrides(arg1 context.Context, arg2 int64, arg3 library.MetadataOverrides) error {
+	fake.setAlbumOverridesMutex.Lock()
+	ret, specificReturn := fake.setAlbumOverridesReturnsOnCall[len(fake.setAlbumOverridesArgsForCall)]
+	fake.setAlbumOverridesArgsForCall = append(fake.setAlbumOverridesArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 library.MetadataOverrides
+	}{arg1, arg2, arg3})
+	stub := fake.SetAlbumOverridesStub
+	fakeReturns := fake.setAlbumOverridesReturns
+	fake.recordInvocation("SetAlbumOverrides", []interface{}{arg1, arg2, arg3})
+	fake.setAlbumOverridesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeOverridesManager) SetAlbumOverridesCallCount() int {
+	fake.setAlbumOverridesMutex.RLock()
+	defer fake.setAlbumOverridesMutex.RUnlock()
+	return len(fake.setAlbumOverridesArgsForCall)
+}
+
+func (fake *FakeOverridesManager) SetAlbumOverridesCalls(stub func(context.Context, int64, library.MetadataOverrides) error) {
+	fake.setAlbumOverridesMutex.Lock()
+	defer fake.setAlbumOverridesMutex.Unlock()
+	fake.SetAlbumOverridesStub = stub
+}
+
+func (fake *FakeOverridesManager) SetAlbumOverridesArgsForCall(i int) (context.Context, int64, library.MetadataOverrides) {
+	fake.setAlbumOverridesMutex.RLock()
+	defer fake.setAlbumOverridesMutex.RUnlock()
+	argsForCall := fake.setAlbumOverridesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeOverridesManager) SetAlbumOverridesReturns(result1 error) {
+	fake.setAlbumOverridesMutex.Lock()
+	defer fake.setAlbumOverridesMutex.Unlock()
+	fake.SetAlbumOverridesStub = nil
+	fake.setAlbumOverridesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeOverridesManager) SetAlbumOverridesReturnsOnCall(i int, result1 error) {
+	fake.setAlbumOverridesMutex.Lock()
+	defer fake.setAlbumOverridesMutex.Unlock()
+	fake.SetAlbumOverridesStub = nil
+	if fake.setAlbumOverridesReturnsOnCall == nil {
+		fake.setAlbumOverridesReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setAlbumOverridesReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeOverridesManager) SetTrackOverrides(arg1 context.Context, arg2 int64, arg3 library.MetadataOverrides) error {
+	fake.setTrackOverridesMutex.Lock()
+	ret, specificReturn := fake.setTrackOverridesReturnsOnCall[len(fake.setTrackOverridesArgsForCall)]
+	fake.setTrackOverridesArgsForCall = append(fake.setTrackOverridesArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 library.MetadataOverrides
+	}{arg1, arg2, arg3})
+	stub := fake.SetTrackOverridesStub
+	fakeReturns := fake.setTrackOverridesReturns
+	fake.recordInvocation("SetTrackOverrides", []interface{}{arg1, arg2, arg3})
+	fake.setTrackOverridesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeOverridesManager) SetTrackOverridesCallCount() int {
+	fake.setTrackOverridesMutex.RLock()
+	defer fake.setTrackOverridesMutex.RUnlock()
+	return len(fake.setTrackOverridesArgsForCall)
+}
+
+func (fake *FakeOverridesManager) SetTrackOverridesCalls(stub func(context.Context, int64, library.MetadataOverrides) error) {
+	fake.setTrackOverridesMutex.Lock()
+	defer fake.setTrackOverridesMutex.Unlock()
+	fake.SetTrackOverridesStub = stub
+}
+
+func (fake *FakeOverridesManager) SetTrackOverridesArgsForCall(i int) (context.Context, int64, library.MetadataOverrides) {
+	fake.setTrackOverridesMutex.RLock()
+	defer fake.setTrackOverridesMutex.RUnlock()
+	argsForCall := fake.setTrackOverridesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeOverridesManager) SetTrackOverridesReturns(result1 error) {
+	fake.setTrackOverridesMutex.Lock()
+	defer fake.setTrackOverridesMutex.Unlock()
+	fake.SetTrackOverridesStub = nil
+	fake.setTrackOverridesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeOverridesManager) SetTrackOverridesReturnsOnCall(i int, result1 error) {
+	fake.setTrackOverridesMutex.Lock()
+	defer fake.setTrackOverridesMutex.Unlock()
+	fake.SetTrackOverridesStub = nil
+	if fake.setTrackOverridesReturnsOnCall == nil {
+		fake.setTrackOverridesReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setTrackOverridesReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeOverridesManager) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getAlbumOverridesMutex.RLock()
+	defer fake.getAlbumOverridesMutex.RUnlock()
+	fake.getTrackOverridesMutex.RLock()
+	defer fake.getTrackOverridesMutex.RUnlock()
+	fake.setAlbumOverridesMutex.RLock()
+	defer fake.setAlbumOverridesMutex.RUnlock()
+	fake.setTrackOverridesMutex.RLock()
+	defer fake.setTrackOverridesMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeOverridesManager) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ library.OverridesManager = new(FakeOverridesManager)
@@ -0,0 +1,117 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package libraryfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+type FakeReporter struct {
+	ReportStub        func(context.Context) (library.Report, error)
+	reportMutex       sync.RWMutex
+	reportArgsForCall []struct {
+		arg1 context.Context
+	}
+	reportReturns struct {
+		result1 library.Report
+		result2 error
+	}
+	reportReturnsOnCall map[int]struct {
+		result1 library.Report
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeReporter) Report(arg1 context.Context) (library.Report, error) {
+	fake.reportMutex.Lock()
+	ret, specificReturn := fake.reportReturnsOnCall[len(fake.reportArgsForCall)]
+	fake.reportArgsForCall = append(fake.reportArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.ReportStub
+	fakeReturns := fake.reportReturns
+	fake.recordInvocation("Report", []interface{}{arg1})
+	fake.reportMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeReporter) ReportCallCount() int {
+	fake.reportMutex.RLock()
+	defer fake.reportMutex.RUnlock()
+	return len(fake.reportArgsForCall)
+}
+
+func (fake *FakeReporter) ReportCalls(stub func(context.Context) (library.Report, error)) {
+	fake.reportMutex.Lock()
+	defer fake.reportMutex.Unlock()
+	fake.ReportStub = stub
+}
+
+func (fake *FakeReporter) ReportArgsForCall(i int) context.Context {
+	fake.reportMutex.RLock()
+	defer fake.reportMutex.RUnlock()
+	argsForCall := fake.reportArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeReporter) ReportReturns(result1 library.Report, result2 error) {
+	fake.reportMutex.Lock()
+	defer fake.reportMutex.Unlock()
+	fake.ReportStub = nil
+	fake.reportReturns = struct {
+		result1 library.Report
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReporter) ReportReturnsOnCall(i int, result1 library.Report, result2 error) {
+	fake.reportMutex.Lock()
+	defer fake.reportMutex.Unlock()
+	fake.ReportStub = nil
+	if fake.reportReturnsOnCall == nil {
+		fake.reportReturnsOnCall = make(map[int]struct {
+			result1 library.Report
+			result2 error
+		})
+	}
+	fake.reportReturnsOnCall[i] = struct {
+		result1 library.Report
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReporter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.reportMutex.RLock()
+	defer fake.reportMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeReporter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ library.Reporter = new(FakeReporter)
@@ -0,0 +1,204 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package libraryfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+type FakeTagEditor struct {
+	UpdateAlbumStub        func(context.Context, int64, library.AlbumUpdate) ([]library.SearchResult, error)
+	updateAlbumMutex       sync.RWMutex
+	updateAlbumArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 library.AlbumUpdate
+	}
+	updateAlbumReturns struct {
+		result1 []library.SearchResult
+		result2 error
+	}
+	updateAlbumReturnsOnCall map[int]struct {
+		result1 []library.SearchResult
+		result2 error
+	}
+	UpdateTrackStub        func(context.Context, int64, library.TrackUpdate) (library.SearchResult, error)
+	updateTrackMutex       sync.RWMutex
+	updateTrackArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 library.TrackUpdate
+	}
+	updateTrackReturns struct {
+		result1 library.SearchResult
+		result2 error
+	}
+	updateTrackReturnsOnCall map[int]struct {
+		result1 library.SearchResult
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeTagEditor) UpdateAlbum(arg1 context.Context, arg2 int64, arg3 library.AlbumUpdate) ([]library.SearchResult, error) {
+	fake.updateAlbumMutex.Lock()
+	ret, specificReturn := fake.updateAlbumReturnsOnCall[len(fake.updateAlbumArgsForCall)]
+	fake.updateAlbumArgsForCall = append(fake.updateAlbumArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 library.AlbumUpdate
+	}{arg1, arg2, arg3})
+	stub := fake.UpdateAlbumStub
+	fakeReturns := fake.updateAlbumReturns
+	fake.recordInvocation("UpdateAlbum", []interface{}{arg1, arg2, arg3})
+	fake.updateAlbumMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTagEditor) UpdateAlbumCallCount() int {
+	fake.updateAlbumMutex.RLock()
+	defer fake.updateAlbumMutex.RUnlock()
+	return len(fake.updateAlbumArgsForCall)
+}
+
+func (fake *FakeTagEditor) UpdateAlbumCalls(stub func(context.Context, int64, library.AlbumUpdate) ([]library.SearchResult, error)) {
+	fake.updateAlbumMutex.Lock()
+	defer fake.updateAlbumMutex.Unlock()
+	fake.UpdateAlbumStub = stub
+}
+
+func (fake *FakeTagEditor) UpdateAlbumArgsForCall(i int) (context.Context, int64, library.AlbumUpdate) {
+	fake.updateAlbumMutex.RLock()
+	defer fake.updateAlbumMutex.RUnlock()
+	argsForCall := fake.updateAlbumArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeTagEditor) UpdateAlbumReturns(result1 []library.SearchResult, result2 error) {
+	fake.updateAlbumMutex.Lock()
+	defer fake.updateAlbumMutex.Unlock()
+	fake.UpdateAlbumStub = nil
+	fake.updateAlbumReturns = struct {
+		result1 []library.SearchResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTagEditor) UpdateAlbumReturnsOnCall(i int, result1 []library.SearchResult, result2 error) {
+	fake.updateAlbumMutex.Lock()
+	defer fake.updateAlbumMutex.Unlock()
+	fake.UpdateAlbumStub = nil
+	if fake.updateAlbumReturnsOnCall == nil {
+		fake.updateAlbumReturnsOnCall = make(map[int]struct {
+			result1 []library.SearchResult
+			result2 error
+		})
+	}
+	fake.updateAlbumReturnsOnCall[i] = struct {
+		result1 []library.SearchResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTagEditor) UpdateTrack(arg1 context.Context, arg2 int64, arg3 library.TrackUpdate) (library.SearchResult, error) {
+	fake.updateTrackMutex.Lock()
+	ret, specificReturn := fake.updateTrackReturnsOnCall[len(fake.updateTrackArgsForCall)]
+	fake.updateTrackArgsForCall = append(fake.updateTrackArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 library.TrackUpdate
+	}{arg1, arg2, arg3})
+	stub := fake.UpdateTrackStub
+	fakeReturns := fake.updateTrackReturns
+	fake.recordInvocation("UpdateTrack", []interface{}{arg1, arg2, arg3})
+	fake.updateTrackMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTagEditor) UpdateTrackCallCount() int {
+	fake.updateTrackMutex.RLock()
+	defer fake.updateTrackMutex.RUnlock()
+	return len(fake.updateTrackArgsForCall)
+}
+
+func (fake *FakeTagEditor) UpdateTrackCalls(stub func(context.Context, int64, library.TrackUpdate) (library.SearchResult, error)) {
+	fake.updateTrackMutex.Lock()
+	defer fake.updateTrackMutex.Unlock()
+	fake.UpdateTrackStub = stub
+}
+
+func (fake *FakeTagEditor) UpdateTrackArgsForCall(i int) (context.Context, int64, library.TrackUpdate) {
+	fake.updateTrackMutex.RLock()
+	defer fake.updateTrackMutex.RUnlock()
+	argsForCall := fake.updateTrackArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeTagEditor) UpdateTrackReturns(result1 library.SearchResult, result2 error) {
+	fake.updateTrackMutex.Lock()
+	defer fake.updateTrackMutex.Unlock()
+	fake.UpdateTrackStub = nil
+	fake.updateTrackReturns = struct {
+		result1 library.SearchResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTagEditor) UpdateTrackReturnsOnCall(i int, result1 library.SearchResult, result2 error) {
+	fake.updateTrackMutex.Lock()
+	defer fake.updateTrackMutex.Unlock()
+	fake.UpdateTrackStub = nil
+	if fake.updateTrackReturnsOnCall == nil {
+		fake.updateTrackReturnsOnCall = make(map[int]struct {
+			result1 library.SearchResult
+			result2 error
+		})
+	}
+	fake.updateTrackReturnsOnCall[i] = struct {
+		result1 library.SearchResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTagEditor) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.updateAlbumMutex.RLock()
+	defer fake.updateAlbumMutex.RUnlock()
+	fake.updateTrackMutex.RLock()
+	defer fake.updateTrackMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeTagEditor) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ library.TagEditor = new(FakeTagEditor)
@@ -0,0 +1,119 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package libraryfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+type FakeTrackFormatFinder struct {
+	GetTrackFormatStub        func(context.Context, int64) (string, error)
+	getTrackFormatMutex       sync.RWMutex
+	getTrackFormatArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+	}
+	getTrackFormatReturns struct {
+		result1 string
+		result2 error
+	}
+	getTrackFormatReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeTrackFormatFinder) GetTrackFormat(arg1 context.Context, arg2 int64) (string, error) {
+	fake.getTrackFormatMutex.Lock()
+	ret, specificReturn := fake.getTrackFormatReturnsOnCall[len(fake.getTrackFormatArgsForCall)]
+	fake.getTrackFormatArgsForCall = append(fake.getTrackFormatArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+	}{arg1, arg2})
+	stub := fake.GetTrackFormatStub
+	fakeReturns := fake.getTrackFormatReturns
+	fake.recordInvocation("GetTrackFormat", []interface{}{arg1, arg2})
+	fake.getTrackFormatMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTrackFormatFinder) GetTrackFormatCallCount() int {
+	fake.getTrackFormatMutex.RLock()
+	defer fake.getTrackFormatMutex.RUnlock()
+	return len(fake.getTrackFormatArgsForCall)
+}
+
+func (fake *FakeTrackFormatFinder) GetTrackFormatCalls(stub func(context.Context, int64) (string, error)) {
+	fake.getTrackFormatMutex.Lock()
+	defer fake.getTrackFormatMutex.Unlock()
+	fake.GetTrackFormatStub = stub
+}
+
+func (fake *FakeTrackFormatFinder) GetTrackFormatArgsForCall(i int) (context.Context, int64) {
+	fake.getTrackFormatMutex.RLock()
+	defer fake.getTrackFormatMutex.RUnlock()
+	argsForCall := fake.getTrackFormatArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeTrackFormatFinder) GetTrackFormatReturns(result1 string, result2 error) {
+	fake.getTrackFormatMutex.Lock()
+	defer fake.getTrackFormatMutex.Unlock()
+	fake.GetTrackFormatStub = nil
+	fake.getTrackFormatReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTrackFormatFinder) GetTrackFormatReturnsOnCall(i int, result1 string, result2 error) {
+	fake.getTrackFormatMutex.Lock()
+	defer fake.getTrackFormatMutex.Unlock()
+	fake.GetTrackFormatStub = nil
+	if fake.getTrackFormatReturnsOnCall == nil {
+		fake.getTrackFormatReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.getTrackFormatReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTrackFormatFinder) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getTrackFormatMutex.RLock()
+	defer fake.getTrackFormatMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeTrackFormatFinder) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ library.TrackFormatFinder = new(FakeTrackFormatFinder)
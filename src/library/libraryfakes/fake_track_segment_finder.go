@@ -0,0 +1,119 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package libraryfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+type FakeTrackSegmentFinder struct {
+	GetTrackSegmentStub        func(context.Context, int64) (library.TrackSegment, error)
+	getTrackSegmentMutex       sync.RWMutex
+	getTrackSegmentArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+	}
+	getTrackSegmentReturns struct {
+		result1 library.TrackSegment
+		result2 error
+	}
+	getTrackSegmentReturnsOnCall map[int]struct {
+		result1 library.TrackSegment
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeTrackSegmentFinder) GetTrackSegment(arg1 context.Context, arg2 int64) (library.TrackSegment, error) {
+	fake.getTrackSegmentMutex.Lock()
+	ret, specificReturn := fake.getTrackSegmentReturnsOnCall[len(fake.getTrackSegmentArgsForCall)]
+	fake.getTrackSegmentArgsForCall = append(fake.getTrackSegmentArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+	}{arg1, arg2})
+	stub := fake.GetTrackSegmentStub
+	fakeReturns := fake.getTrackSegmentReturns
+	fake.recordInvocation("GetTrackSegment", []interface{}{arg1, arg2})
+	fake.getTrackSegmentMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTrackSegmentFinder) GetTrackSegmentCallCount() int {
+	fake.getTrackSegmentMutex.RLock()
+	defer fake.getTrackSegmentMutex.RUnlock()
+	return len(fake.getTrackSegmentArgsForCall)
+}
+
+func (fake *FakeTrackSegmentFinder) GetTrackSegmentCalls(stub func(context.Context, int64) (library.TrackSegment, error)) {
+	fake.getTrackSegmentMutex.Lock()
+	defer fake.getTrackSegmentMutex.Unlock()
+	fake.GetTrackSegmentStub = stub
+}
+
+func (fake *FakeTrackSegmentFinder) GetTrackSegmentArgsForCall(i int) (context.Context, int64) {
+	fake.getTrackSegmentMutex.RLock()
+	defer fake.getTrackSegmentMutex.RUnlock()
+	argsForCall := fake.getTrackSegmentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeTrackSegmentFinder) GetTrackSegmentReturns(result1 library.TrackSegment, result2 error) {
+	fake.getTrackSegmentMutex.Lock()
+	defer fake.getTrackSegmentMutex.Unlock()
+	fake.GetTrackSegmentStub = nil
+	fake.getTrackSegmentReturns = struct {
+		result1 library.TrackSegment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTrackSegmentFinder) GetTrackSegmentReturnsOnCall(i int, result1 library.TrackSegment, result2 error) {
+	fake.getTrackSegmentMutex.Lock()
+	defer fake.getTrackSegmentMutex.Unlock()
+	fake.GetTrackSegmentStub = nil
+	if fake.getTrackSegmentReturnsOnCall == nil {
+		fake.getTrackSegmentReturnsOnCall = make(map[int]struct {
+			result1 library.TrackSegment
+			result2 error
+		})
+	}
+	fake.getTrackSegmentReturnsOnCall[i] = struct {
+		result1 library.TrackSegment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTrackSegmentFinder) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getTrackSegmentMutex.RLock()
+	defer fake.getTrackSegmentMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeTrackSegmentFinder) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ library.TrackSegmentFinder = new(FakeTrackSegmentFinder)
@@ -0,0 +1,102 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package libraryfakes
+
+import (
+	"sync"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+type FakeWatchMonitor struct {
+	WatchQueueMetricsStub        func() library.WatchQueueMetrics
+	watchQueueMetricsMutex       sync.RWMutex
+	watchQueueMetricsArgsForCall []struct {
+	}
+	watchQueueMetricsReturns struct {
+		result1 library.WatchQueueMetrics
+	}
+	watchQueueMetricsReturnsOnCall map[int]struct {
+		result1 library.WatchQueueMetrics
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeWatchMonitor) WatchQueueMetrics() library.WatchQueueMetrics {
+	fake.watchQueueMetricsMutex.Lock()
+	ret, specificReturn := fake.watchQueueMetricsReturnsOnCall[len(fake.watchQueueMetricsArgsForCall)]
+	fake.watchQueueMetricsArgsForCall = append(fake.watchQueueMetricsArgsForCall, struct {
+	}{})
+	stub := fake.WatchQueueMetricsStub
+	fakeReturns := fake.watchQueueMetricsReturns
+	fake.recordInvocation("WatchQueueMetrics", []interface{}{})
+	fake.watchQueueMetricsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeWatchMonitor) WatchQueueMetricsCallCount() int {
+	fake.watchQueueMetricsMutex.RLock()
+	defer fake.watchQueueMetricsMutex.RUnlock()
+	return len(fake.watchQueueMetricsArgsForCall)
+}
+
+func (fake *FakeWatchMonitor) WatchQueueMetricsCalls(stub func() library.WatchQueueMetrics) {
+	fake.watchQueueMetricsMutex.Lock()
+	defer fake.watchQueueMetricsMutex.Unlock()
+	fake.WatchQueueMetricsStub = stub
+}
+
+func (fake *FakeWatchMonitor) WatchQueueMetricsReturns(result1 library.WatchQueueMetrics) {
+	fake.watchQueueMetricsMutex.Lock()
+	defer fake.watchQueueMetricsMutex.Unlock()
+	fake.WatchQueueMetricsStub = nil
+	fake.watchQueueMetricsReturns = struct {
+		result1 library.WatchQueueMetrics
+	}{result1}
+}
+
+func (fake *FakeWatchMonitor) WatchQueueMetricsReturnsOnCall(i int, result1 library.WatchQueueMetrics) {
+	fake.watchQueueMetricsMutex.Lock()
+	defer fake.watchQueueMetricsMutex.Unlock()
+	fake.WatchQueueMetricsStub = nil
+	if fake.watchQueueMetricsReturnsOnCall == nil {
+		fake.watchQueueMetricsReturnsOnCall = make(map[int]struct {
+			result1 library.WatchQueueMetrics
+		})
+	}
+	fake.watchQueueMetricsReturnsOnCall[i] = struct {
+		result1 library.WatchQueueMetrics
+	}{result1}
+}
+
+func (fake *FakeWatchMonitor) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.watchQueueMetricsMutex.RLock()
+	defer fake.watchQueueMetricsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeWatchMonitor) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ library.WatchMonitor = new(FakeWatchMonitor)
@@ -31,9 +31,13 @@ func (lib *LocalLibrary) BrowseArtists(args BrowseArgs) ([]Artist, int) {
 		rows, err := db.Query(fmt.Sprintf(`
             SELECT
                 ar.id,
-                ar.name
+                ar.name,
+                ai.dominant_color,
+                ai.blurhash
             FROM
                 artists ar
+                LEFT JOIN
+                    artists_images ai ON ai.artist_id = ar.id
             ORDER BY
                 %s %s
             LIMIT
@@ -46,10 +50,16 @@ func (lib *LocalLibrary) BrowseArtists(args BrowseArgs) ([]Artist, int) {
 
 		defer rows.Close()
 		for rows.Next() {
-			var res Artist
-			if err := rows.Scan(&res.ID, &res.Name); err != nil {
+			var (
+				res      Artist
+				color    sql.NullString
+				blurHash sql.NullString
+			)
+			if err := rows.Scan(&res.ID, &res.Name, &color, &blurHash); err != nil {
 				return fmt.Errorf("scanning db failed: %w", err)
 			}
+			res.ImageColor = color.String
+			res.ImageBlurHash = blurHash.String
 			output = append(output, res)
 		}
 
@@ -81,6 +91,10 @@ func (lib *LocalLibrary) BrowseAlbums(args BrowseArgs) ([]Album, int) {
                 COUNT(DISTINCT tr.album_id) as cnt
             FROM
                 tracks tr
+                JOIN
+                    albums al ON al.id = tr.album_id
+            WHERE
+                al.edition_of IS NULL
         `)
 
 		if err != nil {
@@ -111,13 +125,22 @@ func (lib *LocalLibrary) BrowseAlbums(args BrowseArgs) ([]Album, int) {
                 CASE WHEN COUNT(DISTINCT tr.artist_id) = 1
                 THEN ar.name
                 ELSE "Various Artists"
-                END AS arist_name
+                END AS arist_name,
+                aa.dominant_color,
+                aa.blurhash,
+                (
+                    SELECT COUNT(*) FROM albums ed WHERE ed.edition_of = al.id
+                ) AS editions
             FROM
                 tracks tr
                 LEFT JOIN
                     albums al ON al.id = tr.album_id
                 LEFT JOIN
                     artists ar ON ar.id = tr.artist_id
+                LEFT JOIN
+                    albums_artworks aa ON aa.album_id = tr.album_id
+            WHERE
+                al.edition_of IS NULL
             GROUP BY
                 tr.album_id
             ORDER BY
@@ -132,10 +155,18 @@ func (lib *LocalLibrary) BrowseAlbums(args BrowseArgs) ([]Album, int) {
 
 		defer rows.Close()
 		for rows.Next() {
-			var res Album
-			if err := rows.Scan(&res.ID, &res.Name, &res.Artist); err != nil {
+			var (
+				res      Album
+				color    sql.NullString
+				blurHash sql.NullString
+			)
+			err := rows.Scan(&res.ID, &res.Name, &res.Artist, &color, &blurHash,
+				&res.Editions)
+			if err != nil {
 				return fmt.Errorf("scanning db failed: %w", err)
 			}
+			res.ArtworkColor = color.String
+			res.ArtworkBlurHash = blurHash.String
 			output = append(output, res)
 		}
 
@@ -1,7 +1,12 @@
 package library
 
 import (
+	"bytes"
 	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
 	"testing"
 	"time"
 )
@@ -321,3 +326,72 @@ func TestBrowsingAlbums(t *testing.T) {
 		}
 	}
 }
+
+// TestBrowsingImagePlaceholders checks that the dominant colour and BlurHash of
+// stored images are returned while browsing albums and artists.
+func TestBrowsingImagePlaceholders(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	lib := getPathedLibrary(ctx, t)
+	defer func() { _ = lib.Truncate() }()
+
+	track := MockMedia{
+		artist: "Iron Maiden",
+		album:  "Powerslave",
+		title:  "Aces High",
+		track:  1,
+	}
+	if err := lib.insertMediaIntoDatabase(&track, "/media/powerslave/1.mp3"); err != nil {
+		t.Fatalf("inserting media failed: %s", err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{0xd4, 0xa0, 0x17, 0xff}),
+		image.Point{}, draw.Src)
+
+	var buff bytes.Buffer
+	if err := png.Encode(&buff, img); err != nil {
+		t.Fatalf("encoding image: %s", err)
+	}
+
+	albums, _ := lib.BrowseAlbums(BrowseArgs{PerPage: 10})
+	if len(albums) != 1 {
+		t.Fatalf("expected one album but got %d", len(albums))
+	}
+	if albums[0].ArtworkColor != "" || albums[0].ArtworkBlurHash != "" {
+		t.Errorf("expected no placeholder for album without artwork")
+	}
+
+	artists, _ := lib.BrowseArtists(BrowseArgs{PerPage: 10})
+	if len(artists) != 1 {
+		t.Fatalf("expected one artist but got %d", len(artists))
+	}
+
+	err := lib.SaveAlbumArtwork(ctx, albums[0].ID, bytes.NewReader(buff.Bytes()))
+	if err != nil {
+		t.Fatalf("saving album artwork: %s", err)
+	}
+
+	err = lib.SaveArtistImage(ctx, artists[0].ID, bytes.NewReader(buff.Bytes()))
+	if err != nil {
+		t.Fatalf("saving artist image: %s", err)
+	}
+
+	albums, _ = lib.BrowseAlbums(BrowseArgs{PerPage: 10})
+	if albums[0].ArtworkColor != "#d4a017" {
+		t.Errorf("expected album artwork colour #d4a017 but got `%s`",
+			albums[0].ArtworkColor)
+	}
+	if albums[0].ArtworkBlurHash == "" {
+		t.Errorf("expected album artwork BlurHash but it was empty")
+	}
+
+	artists, _ = lib.BrowseArtists(BrowseArgs{PerPage: 10})
+	if artists[0].ImageColor != "#d4a017" {
+		t.Errorf("expected artist image colour #d4a017 but got `%s`",
+			artists[0].ImageColor)
+	}
+	if artists[0].ImageBlurHash != albums[0].ArtworkBlurHash {
+		t.Errorf("expected the same BlurHash for the same image")
+	}
+}
@@ -24,7 +24,9 @@ import (
 	"github.com/ironsmile/euterpe/src/art"
 	"github.com/ironsmile/euterpe/src/config"
 	"github.com/ironsmile/euterpe/src/helpers"
+	"github.com/ironsmile/euterpe/src/lyrics"
 	"github.com/ironsmile/euterpe/src/scaler"
+	"github.com/ironsmile/euterpe/src/tags"
 )
 
 const (
@@ -63,6 +65,13 @@ var (
 	// ErrArtistNotFound is returned when no artist could be found for particular operation.
 	ErrArtistNotFound = errors.New("Artist Not Found")
 
+	// ErrTrackNotFound is returned when no track could be found for particular operation.
+	ErrTrackNotFound = errors.New("Track Not Found")
+
+	// ErrFolderNotFound is returned when no folder could be found for particular
+	// operation.
+	ErrFolderNotFound = errors.New("Folder Not Found")
+
 	// ErrArtworkNotFound is returned when no artwork can be found for particular album.
 	ErrArtworkNotFound = NewArtworkError("Artwork Not Found")
 
@@ -106,6 +115,7 @@ type LocalLibrary struct {
 
 	database string         // The location of the library's database
 	paths    []string       // FS locations which contain the library's media files
+	dirs     libraryDirs    // Guards paths and holds the rest of the directories
 	db       *sql.DB        // Database handler
 	walkWG   sync.WaitGroup // Used to log how much time scanning took
 
@@ -128,11 +138,21 @@ type LocalLibrary struct {
 
 	artFinder art.Finder
 
+	// infoFinder is used for finding information about artists.
+	infoFinder art.InfoFinder
+
+	// lyricsFinder is used for finding lyrics for tracks which have none.
+	lyricsFinder lyrics.Finder
+
 	fs         fs.FS
 	sqlFilesFS fs.FS
 
 	imageScaler scaler.Scaler
 
+	// images is where album artwork and artist images are stored. The database
+	// keeps only their hashes.
+	images imageStore
+
 	// cleanupLock is used to secure a thread safe access to the runningCleanup property.
 	cleanupLock *sync.RWMutex
 
@@ -145,6 +165,48 @@ type LocalLibrary struct {
 	// When noWatch is set then no file system watchers will be created
 	// for the scanned directories.
 	noWatch bool
+
+	// prefetch holds the state of the artwork prefetch job.
+	prefetch artworkPrefetch
+
+	// loudnessJob holds the state of the loudness analysis job.
+	loudnessJob loudnessAnalysis
+
+	// chromaprintJob holds the state of the acoustic fingerprints job.
+	chromaprintJob chromaprintCalculation
+
+	// preferredFormats are the formats of album editions which are preferred
+	// when searching. In order of preference.
+	preferredFormats []string
+
+	// removed keeps the recently removed tracks so that they could get their
+	// IDs back when they turn out to be moved.
+	removed removedTracks
+
+	// folders caches the IDs of the folders by their paths.
+	folders folderPaths
+
+	// ignores keeps the parsed exclusion patterns from the configuration and
+	// the ignore files.
+	ignores ignoreCache
+
+	// walked keeps the scanned files so that files which could be reached by
+	// more than one path are added only once.
+	walked walkedFiles
+
+	// pollers keeps the library directories which are polled for changes.
+	pollers pollingWatchers
+
+	// watchEvents buffers the changes in the library directories until they
+	// are processed.
+	watchEvents watchQueue
+
+	// schedules makes sure only one scheduled job runs at a time.
+	schedules scheduledJobs
+
+	// prefetchAfterScan shows whether the artwork prefetch job must be started
+	// after every scan. Guarded by the prefetch lock.
+	prefetchAfterScan bool
 }
 
 // Close closes the database connection. It is safe to call it as many times as you want.
@@ -154,13 +216,44 @@ func (lib *LocalLibrary) Close() {
 }
 
 // AddLibraryPath adds a library directory to the list of libraries which will be
-// scanned and consequently watched.
+// scanned and consequently watched. It is meant for the directories from the
+// configuration. They are stored in the database so that they could be disabled
+// with the API and removed together with their tracks once they are no longer in
+// the configuration. See RestoreLibraries and RemoveStaleLibraries. Directories
+// which are missing, such as file systems which are not mounted yet, are kept
+// disabled until they are found by a scan. Their tracks are kept as they are.
 func (lib *LocalLibrary) AddLibraryPath(path string) {
-	if _, err := fs.Stat(lib.fs, path); err != nil {
-		log.Printf("error adding path: %s", err)
-		return
+	path = filepath.Clean(path)
+
+	_, statErr := fs.Stat(lib.fs, path)
+	if statErr != nil {
+		log.Printf("library path %s is missing: %s", path, statErr)
+	}
+
+	dir, err := lib.saveConfigLibrary(path)
+	if err != nil {
+		log.Printf("error storing library path %s: %s", path, err)
+	}
+
+	lib.dirs.Lock()
+	defer lib.dirs.Unlock()
+
+	if lib.dirs.config == nil {
+		lib.dirs.config = make(map[string]struct{})
+	}
+	lib.dirs.config[path] = struct{}{}
+
+	if statErr != nil {
+		if lib.dirs.missing == nil {
+			lib.dirs.missing = make(map[string]struct{})
+		}
+		lib.dirs.missing[path] = struct{}{}
 	}
 
+	if dir.Disabled || statErr != nil {
+		lib.dirs.disabled = append(lib.dirs.disabled, path)
+		return
+	}
 	lib.paths = append(lib.paths, path)
 }
 
@@ -180,7 +273,16 @@ func (lib *LocalLibrary) Search(searchTerm string) []SearchResult {
 				t.number as track_number,
 				t.album_id as album_id,
 				t.fs_path as fs_path,
-				t.duration as duration
+				IFNULL(t.format, '') as format,
+				t.duration as duration,
+				IFNULL(t.year, 0) as year,
+				IFNULL(t.genre, '') as genre,
+				IFNULL(t.disc, 0) as disc,
+				t.track_gain,
+				t.track_peak,
+				t.album_gain,
+				t.album_peak,
+				t.gain_source
 			FROM
 				tracks as t
 					LEFT JOIN albums as al ON al.id = t.album_id
@@ -190,7 +292,7 @@ func (lib *LocalLibrary) Search(searchTerm string) []SearchResult {
 				al.name LIKE ? OR
 				at.name LIKE ?
 			ORDER BY
-				al.name, t.number
+				al.name, t.disc, t.number
 		`, searchTerm, searchTerm, searchTerm)
 		if err != nil {
 			log.Printf("Query not successful: %s\n", err.Error())
@@ -199,17 +301,24 @@ func (lib *LocalLibrary) Search(searchTerm string) []SearchResult {
 
 		defer rows.Close()
 		for rows.Next() {
-			var res SearchResult
+			var (
+				res    SearchResult
+				rgc    replayGainColumns
+				fsPath string
+			)
 
 			err := rows.Scan(&res.ID, &res.Title, &res.Album, &res.Artist,
-				&res.ArtistID, &res.TrackNumber, &res.AlbumID, &res.Format,
-				&res.Duration)
+				&res.ArtistID, &res.TrackNumber, &res.AlbumID, &fsPath, &res.Format,
+				&res.Duration, &res.Year, &res.Genre, &res.Disc, &rgc.trackGain, &rgc.trackPeak,
+				&rgc.albumGain, &rgc.albumPeak, &rgc.source)
 			if err != nil {
 				log.Printf("Error scanning search result: %s\n", err)
 				continue
 			}
 
-			res.Format = mediaFormatFromFileName(res.Format)
+			res.ReplayGain = rgc.replayGain()
+
+			res.Format = trackFormat(res.Format, fsPath)
 
 			output = append(output, res)
 		}
@@ -220,7 +329,14 @@ func (lib *LocalLibrary) Search(searchTerm string) []SearchResult {
 		log.Printf("Error executing search db work: %s", err)
 		return output
 	}
-	return output
+
+	// Every track is shown once even if its album has more than one edition.
+	preferred, err := lib.PreferEditions(lib.ctx, output, nil)
+	if err != nil {
+		log.Printf("Error choosing album editions for search: %s", err)
+		return output
+	}
+	return preferred
 }
 
 // GetFilePath returns the filesystem path for a file specified by its ID.
@@ -270,7 +386,17 @@ func (lib *LocalLibrary) GetAlbumFiles(albumID int64) []SearchResult {
 				at.id as artist_id,
 				t.number as track_number,
 				t.album_id as album_id,
-				t.fs_path as fs_path
+				t.fs_path as fs_path,
+				IFNULL(t.format, '') as format,
+				IFNULL(t.duration, 0) as duration,
+				IFNULL(t.year, 0) as year,
+				IFNULL(t.genre, '') as genre,
+				IFNULL(t.disc, 0) as disc,
+				t.track_gain,
+				t.track_peak,
+				t.album_gain,
+				t.album_peak,
+				t.gain_source
 			FROM
 				tracks as t
 					LEFT JOIN albums as al ON al.id = t.album_id
@@ -278,7 +404,7 @@ func (lib *LocalLibrary) GetAlbumFiles(albumID int64) []SearchResult {
 			WHERE
 				t.album_id = ?
 			ORDER BY
-				al.name, t.number
+				al.name, t.disc, t.number
 		`, albumID)
 		if err != nil {
 			log.Printf("Query not successful: %s\n", err.Error())
@@ -287,7 +413,11 @@ func (lib *LocalLibrary) GetAlbumFiles(albumID int64) []SearchResult {
 
 		defer rows.Close()
 		for rows.Next() {
-			var res SearchResult
+			var (
+				res    SearchResult
+				rgc    replayGainColumns
+				fsPath string
+			)
 			err := rows.Scan(
 				&res.ID,
 				&res.Title,
@@ -296,13 +426,25 @@ func (lib *LocalLibrary) GetAlbumFiles(albumID int64) []SearchResult {
 				&res.ArtistID,
 				&res.TrackNumber,
 				&res.AlbumID,
+				&fsPath,
 				&res.Format,
+				&res.Duration,
+				&res.Year,
+				&res.Genre,
+				&res.Disc,
+				&rgc.trackGain,
+				&rgc.trackPeak,
+				&rgc.albumGain,
+				&rgc.albumPeak,
+				&rgc.source,
 			)
 			if err != nil {
 				return fmt.Errorf("scanning error: %w", err)
 			}
 
-			res.Format = mediaFormatFromFileName(res.Format)
+			res.ReplayGain = rgc.replayGain()
+
+			res.Format = trackFormat(res.Format, fsPath)
 
 			output = append(output, res)
 		}
@@ -328,6 +470,8 @@ func (lib *LocalLibrary) removeFile(filePath string) {
 	}
 
 	work := func(db *sql.DB) error {
+		lib.rememberRemovedTracks(db, "fs_path = ?", fullPath)
+
 		_, err := db.Exec(`
 			DELETE FROM tracks
 			WHERE fs_path = ?
@@ -351,6 +495,8 @@ func (lib *LocalLibrary) removeDirectory(dirPath string) {
 	deleteMatch := fmt.Sprintf("%s/%%", strings.TrimRight(dirPath, "/"))
 
 	work := func(db *sql.DB) error {
+		lib.rememberRemovedTracks(db, "fs_path LIKE ?", deleteMatch)
+
 		_, err := db.Exec(`
 			DELETE FROM tracks
 			WHERE fs_path LIKE ?
@@ -367,39 +513,6 @@ func (lib *LocalLibrary) removeDirectory(dirPath string) {
 	}
 }
 
-// Determines if the file will be saved to the database. Only media files which
-// jplayer can use are saved.
-func (lib *LocalLibrary) isSupportedFormat(path string) bool {
-	supportedFormats := []string{
-		".mp3",
-		".ogg",
-		".oga",
-		".wav",
-		".fla",
-		".flac",
-		".m4a",
-		".opus",
-		".webm",
-		".mp4",
-	}
-
-	base := filepath.Base(path)
-	ext := filepath.Ext(base)
-	if base == ext {
-		// This is a file such as "path/to/.hidden". There is no point in
-		// checking these. They really don't have extension. What is after
-		// the dot is the actual file name. It is just hidden.
-		return false
-	}
-
-	for _, format := range supportedFormats {
-		if strings.EqualFold(ext, format) {
-			return true
-		}
-	}
-	return false
-}
-
 // AddMedia adds a file specified by its file system name to the library. Will create the
 // needed Artist, Album if necessary.
 func (lib *LocalLibrary) AddMedia(filename string) error {
@@ -409,54 +522,149 @@ func (lib *LocalLibrary) AddMedia(filename string) error {
 		return nil
 	}
 
+	return lib.updateMedia(filename)
+}
+
+// updateMedia reads the tags of the file at filename and saves them in the
+// library. A file which is in the library already keeps its track ID and only
+// has its metadata changed. Files inside archives are extracted for reading
+// their tags.
+func (lib *LocalLibrary) updateMedia(filename string) error {
+	filename = filepath.Clean(filename)
+
 	if _, err := fs.Stat(lib.fs, filename); err != nil {
 		return err
 	}
 
-	file, err := taglib.Read(filename)
+	return withLocalFile(filename, func(localPath string) error {
+		file, err := taglib.Read(localPath)
 
-	if err != nil {
-		return fmt.Errorf("Taglib error for %s: %s", filename, err.Error())
-	}
+		if err != nil {
+			return fmt.Errorf("Taglib error for %s: %s", filename, err.Error())
+		}
 
-	defer file.Close()
+		defer file.Close()
 
-	return lib.insertMediaIntoDatabase(file, filename)
+		return lib.insertMediaIntoDatabase(withAllTags(file, localPath), filename)
+	})
 }
 
 // insertMediaIntoDatabase accepts an already parsed media info object, its path.
 // The method inserts this media into the library database.
 func (lib *LocalLibrary) insertMediaIntoDatabase(file MediaFile, filePath string) error {
-	artist := strings.TrimSpace(file.Artist())
-	artistID, err := lib.setArtistID(artist)
-	if err != nil {
-		return err
+	var reuseID int64
+	fp := lib.fileFingerprint(filePath)
+	if fp != "" {
+		if other, ok := lib.sameFileInLibrary(filePath, fp); ok {
+			if other.fsPath < filePath {
+				log.Printf("Skipping %s which is the same file as %s",
+					filePath, other.fsPath)
+				return nil
+			}
+
+			// Files are in the library by the first of their paths so that
+			// it does not depend on the order in which they were found.
+			if lib.MediaExistsInLibrary(filePath) {
+				lib.removeFile(other.fsPath)
+			} else if err := lib.moveTrack(other.id, other.fsPath, filePath); err != nil {
+				return fmt.Errorf("moving track of the same file: %w", err)
+			}
+		}
+
+		var err error
+		reuseID, err = lib.claimMovedTrack(filePath, fp)
+		if err != nil {
+			log.Printf("Error finding out whether %s was moved: %s", filePath, err)
+		}
 	}
 
-	fileDir := filepath.Dir(filePath)
+	trackNumber := int64(file.Track())
+	if trackNumber == 0 {
+		trackNumber = helpers.GuessTrackNumber(filePath)
+	}
 
-	album := strings.TrimSpace(file.Album())
-	albumID, err := lib.setAlbumID(album, fileDir)
+	md := trackMetadata{
+		artist:  strings.TrimSpace(file.Artist()),
+		album:   strings.TrimSpace(file.Album()),
+		title:   strings.TrimSpace(file.Title()),
+		number:  trackNumber,
+		bitrate: mediaBitrate(file),
+		format:  lib.mediaFileFormat(filePath),
+	}
+	if tagged, ok := file.(TaggedMediaFile); ok {
+		md.year, md.genre = yearAndGenreFromTags(tagged.Tags())
+		md.disc = discFromTags(tagged.Tags())
+	}
+	if md.disc == 0 {
+		md.disc = discFromDir(filepath.Dir(filePath))
+	}
+	lib.applyOverrides(&md, filePath, 0)
 
+	artistID, err := lib.setArtistID(md.artist)
 	if err != nil {
 		return err
 	}
 
-	trackNumber := int64(file.Track())
-	if trackNumber == 0 {
-		trackNumber = helpers.GuessTrackNumber(filePath)
+	albumID, err := lib.setAlbumID(md.album, albumDir(filePath))
+
+	if err != nil {
+		return err
 	}
 
-	title := strings.TrimSpace(file.Title())
-	_, err = lib.setTrackID(
-		title,
+	trackID, err := lib.setTrackID(
+		md.title,
 		filePath,
-		trackNumber,
+		md.number,
 		artistID,
 		albumID,
 		file.Length().Milliseconds(),
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	trackID, err = lib.setTrackFingerprint(trackID, reuseID, fp)
+	if err != nil {
+		log.Printf("Error saving fingerprint for %s: %s", filePath, err)
+	}
+
+	if err := lib.saveTrackDetails(trackID, md); err != nil {
+		log.Printf("Error saving track details for %s: %s", filePath, err)
+	}
+
+	if tagged, ok := file.(TaggedMediaFile); ok {
+		lib.saveMBIDsFromTags(tagged, artistID, albumID)
+	}
+
+	if err := lib.saveLocalLyrics(trackID, filePath, lyricsFromTags(file)); err != nil {
+		log.Printf("Error saving lyrics for %s: %s", filePath, err)
+	}
+
+	if err := lib.saveReplayGainFromTags(trackID, file); err != nil {
+		log.Printf("Error saving ReplayGain for %s: %s", filePath, err)
+	}
+
+	return nil
+}
+
+// saveMBIDsFromTags stores the MusicBrainz IDs found in the tags of a media file
+// for its artist and album. With them the artwork and artist information could
+// be found without searching.
+func (lib *LocalLibrary) saveMBIDsFromTags(
+	file TaggedMediaFile,
+	artistID, albumID int64,
+) {
+	if mbID := firstMBID(file, tags.MusicBrainzArtistID); mbID != "" {
+		if err := lib.saveMBID("artists", artistID, mbID); err != nil {
+			log.Printf("Error saving artist MusicBrainz ID: %s", err)
+		}
+	}
+
+	if mbID := firstMBID(file, tags.MusicBrainzAlbumID); mbID != "" {
+		if err := lib.saveMBID("albums", albumID, mbID); err != nil {
+			log.Printf("Error saving album MusicBrainz ID: %s", err)
+		}
+	}
 }
 
 // MediaExistsInLibrary checks if the media file with file system path "filename" has
@@ -832,25 +1040,59 @@ func (lib *LocalLibrary) GetTrackID(
 // is updated with new values for title, number, artist ID and album ID.
 func (lib *LocalLibrary) setTrackID(title, fsPath string,
 	trackNumber, artistID, albumID, duration int64) (int64, error) {
+	return lib.setSegmentTrackID(
+		title, fsPath, trackNumber, artistID, albumID, duration, cueSegment{},
+	)
+}
+
+// setSegmentTrackID is like setTrackID but for tracks which are only a part of
+// the file at fsPath as described by a CUE sheet. A track is identified by its
+// file system path and where it starts in the file.
+func (lib *LocalLibrary) setSegmentTrackID(title, fsPath string,
+	trackNumber, artistID, albumID, duration int64, segment cueSegment) (int64, error) {
 
 	if len(title) < 1 {
 		title = filepath.Base(fsPath)
 	}
 
+	var (
+		cueSheet sql.NullString
+		cueEnd   sql.NullInt64
+	)
+	if segment.sheet != "" {
+		cueSheet = sql.NullString{String: segment.sheet, Valid: true}
+	}
+	if segment.end > 0 {
+		cueEnd = sql.NullInt64{Int64: segment.end.Milliseconds(), Valid: true}
+	}
+	cueStart := segment.start.Milliseconds()
+	generationRoot := lib.scanGenerationRoot(fsPath)
+
+	folder, err := lib.folderOf(filepath.Dir(fsPath))
+	if err != nil {
+		return 0, err
+	}
+
 	var lastInsertID int64
 	work := func(db *sql.DB) error {
 		stmt, err := db.Prepare(`
 			INSERT INTO
-				tracks (name, album_id, artist_id, fs_path, number, duration)
+				tracks (name, album_id, artist_id, fs_path, number, duration,
+					cue_sheet, cue_start, cue_end, scan_generation, folder_id)
 			VALUES
-				($1, $2, $3, $4, $5, $6)
-			ON CONFLICT (fs_path) DO
+				($1, $2, $3, $4, $5, $6, $7, $8, $9,
+					(SELECT scan_generation FROM libraries WHERE path = $10), $11)
+			ON CONFLICT (fs_path, cue_start) DO
 			UPDATE SET
 				name = $1,
 				album_id = $2,
 				artist_id = $3,
 				number = $5,
-				duration = $6
+				duration = $6,
+				cue_sheet = $7,
+				cue_end = $9,
+				scan_generation = excluded.scan_generation,
+				folder_id = $11
 		`)
 		if err != nil {
 			return err
@@ -858,7 +1100,8 @@ func (lib *LocalLibrary) setTrackID(title, fsPath string,
 
 		defer stmt.Close()
 
-		res, err := stmt.Exec(title, albumID, artistID, fsPath, trackNumber, duration)
+		res, err := stmt.Exec(title, albumID, artistID, fsPath, trackNumber, duration,
+			cueSheet, cueStart, cueEnd, generationRoot, folder)
 		if err != nil {
 			return err
 		}
@@ -871,7 +1114,7 @@ func (lib *LocalLibrary) setTrackID(title, fsPath string,
 		return 0, err
 	}
 
-	// Getting the track by its fs_path.
+	// Getting the track by its fs_path and start.
 	var trackID int64
 	work = func(db *sql.DB) error {
 		smt, err := db.Prepare(`
@@ -880,7 +1123,8 @@ func (lib *LocalLibrary) setTrackID(title, fsPath string,
 			FROM
 				tracks
 			WHERE
-				fs_path = ?
+				fs_path = ? AND
+				cue_start = ?
 		`)
 		if err != nil {
 			return err
@@ -889,7 +1133,7 @@ func (lib *LocalLibrary) setTrackID(title, fsPath string,
 		defer smt.Close()
 
 		var id int64
-		err = smt.QueryRow(fsPath).Scan(&id)
+		err = smt.QueryRow(fsPath, cueStart).Scan(&id)
 		if err != nil {
 			return err
 		}
@@ -934,7 +1178,20 @@ func (lib *LocalLibrary) Initialize() error {
 	// This database is already created and populated. We could just apply the
 	// migrations without executing the initial schema.
 	if st, err := fs.Stat(lib.fs, lib.database); err == nil && st.Size() > 0 {
-		return lib.applyMigrations()
+		if err := lib.applyMigrations(); err != nil {
+			return err
+		}
+		if err := lib.exportImageBlobs(); err != nil {
+			return err
+		}
+
+		// Images stored by older versions do not have placeholders. Computing
+		// them may take a while for big libraries so it is done in the
+		// background.
+		if lib.images.persistent() {
+			go lib.fillImagePlaceholders()
+		}
+		return nil
 	}
 
 	sqlSchema, err := lib.readSchema()
@@ -959,7 +1216,11 @@ func (lib *LocalLibrary) Initialize() error {
 		}
 	}
 
-	return lib.applyMigrations()
+	if err := lib.applyMigrations(); err != nil {
+		return err
+	}
+
+	return lib.exportImageBlobs()
 }
 
 // Returns the SQL schema for the library. It is stored in the project root directory
@@ -996,7 +1257,7 @@ func (lib *LocalLibrary) Truncate() error {
 
 	// The database is in-memory. There is no file which must be truncated.
 	if lib.database == SQLiteMemoryFile {
-		return nil
+		return lib.images.removeAll()
 	}
 
 	// The local library is not working with the actual file system. This is probably
@@ -1005,6 +1266,10 @@ func (lib *LocalLibrary) Truncate() error {
 		return nil
 	}
 
+	if err := lib.images.removeAll(); err != nil {
+		return fmt.Errorf("removing stored images: %w", err)
+	}
+
 	return os.Remove(lib.database)
 }
 
@@ -1042,6 +1307,9 @@ func (lib *LocalLibrary) scaleImage(
 // specified by databasePath. Also creates the database connection so you does not
 // need to worry about that. It accepts the parent's context and create its own
 // child context.
+//
+// Images are stored in a directory named "images" next to the database file. For
+// in-memory databases they are kept in memory as well.
 func NewLocalLibrary(
 	ctx context.Context,
 	databasePath string,
@@ -1052,6 +1320,14 @@ func NewLocalLibrary(
 	lib.sqlFilesFS = sqlFilesFS
 	lib.fs = &osFS{}
 
+	if databasePath == SQLiteMemoryFile {
+		lib.images = newMemImageStore()
+	} else {
+		lib.images = newFSImageStore(
+			filepath.Join(filepath.Dir(databasePath), imagesDirName),
+		)
+	}
+
 	libContext, cancelFunc := context.WithCancel(ctx)
 
 	lib.ctx = libContext
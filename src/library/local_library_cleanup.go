@@ -19,7 +19,9 @@ var cleanupBreak = 5 * time.Second
 const batchLimit = 100
 
 // cleanUpDatabase walks through all database records and removes those which point
-// to files which no longer exist. It also removes albums with no tracks into them.
+// to files which no longer exist. It also removes albums with no tracks into them
+// and images which are no longer used by any album or artist. Finally the editions
+// of albums are grouped again.
 func (lib *LocalLibrary) cleanUpDatabase() {
 	lib.cleanupLock.RLock()
 	alreadyRunning := lib.runningCleanup
@@ -43,21 +45,25 @@ func (lib *LocalLibrary) cleanUpDatabase() {
 	lib.cleanupTracks()
 	lib.cleanupAlbums()
 	lib.cleanupArtists()
+	lib.cleanupLyrics()
+	lib.cleanupChromaprints()
+	lib.cleanupImages()
+	lib.groupAlbumEditions()
 }
 
 // cleanupTracks walks through all tracks in the database and cleanups from it any
-// which are not present on the filesystem. It does that in batches with some rest
-// between batches.
+// which are not present on the filesystem. Tracks in library directories whose
+// last scan was completed are removed when this scan has not seen their files.
+// Only the files of the rest of the tracks are checked on the file system, in
+// batches with some rest between them.
 func (lib *LocalLibrary) cleanupTracks() {
-	var (
-		cursor int
-		total  = lib.getTableSize("tracks")
-	)
-
-	if total == 0 {
-		return
+	generations, err := lib.completedScanGenerations()
+	if err != nil {
+		// Every track is checked on the file system then.
+		log.Printf("Error during track cleanup: %s", err)
 	}
 
+	var cursor int64
 	for {
 		var (
 			tracks []track
@@ -68,12 +74,16 @@ func (lib *LocalLibrary) cleanupTracks() {
 			rows, err := db.Query(`
 				SELECT
 					id,
-					fs_path
+					fs_path,
+					cue_sheet,
+					scan_generation
 				FROM
 					tracks
+				WHERE
+					id > ?
 				ORDER BY
 					id
-				LIMIT ?, ?
+				LIMIT ?
 
 			`, cursor, batchLimit)
 			if err != nil {
@@ -82,7 +92,8 @@ func (lib *LocalLibrary) cleanupTracks() {
 			defer rows.Close()
 
 			for rows.Next() {
-				if err := rows.Scan(&tr.id, &tr.fsPath); err != nil {
+				err := rows.Scan(&tr.id, &tr.fsPath, &tr.cueSheet, &tr.scanGeneration)
+				if err != nil {
 					log.Printf("Scanning db error during track cleanup: %s", err)
 				}
 				tracks = append(tracks, tr)
@@ -96,18 +107,24 @@ func (lib *LocalLibrary) cleanupTracks() {
 			return
 		}
 
-		cursor += batchLimit
+		if len(tracks) == 0 {
+			break
+		}
+		cursor = tracks[len(tracks)-1].id
 
-		if err := lib.checkAndRemoveTracks(tracks); err != nil {
+		checked, err := lib.checkAndRemoveTracks(tracks, generations)
+		if err != nil {
 			log.Printf("Error cleaning up tracks: %s", err)
 			return
 		}
 
-		if cursor >= total {
+		if len(tracks) < batchLimit {
 			break
 		}
 
-		time.Sleep(cleanupBreak)
+		if checked > 0 {
+			time.Sleep(cleanupBreak)
+		}
 	}
 }
 
@@ -221,6 +238,91 @@ func (lib *LocalLibrary) cleanupArtists() {
 	}
 }
 
+// cleanupLyrics removes the lyrics of tracks which are no longer in the database.
+func (lib *LocalLibrary) cleanupLyrics() {
+	work := func(db *sql.DB) error {
+		_, err := db.Exec(`
+			DELETE FROM tracks_lyrics
+			WHERE track_id NOT IN (
+				SELECT id FROM tracks
+			)
+		`)
+		return err
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		log.Printf("Error cleaning up lyrics: %s", err)
+	}
+}
+
+// cleanupChromaprints removes the acoustic fingerprints of tracks which are no
+// longer in the database.
+func (lib *LocalLibrary) cleanupChromaprints() {
+	work := func(db *sql.DB) error {
+		_, err := db.Exec(`
+			DELETE FROM tracks_chromaprints
+			WHERE track_id NOT IN (
+				SELECT id FROM tracks
+			)
+		`)
+		return err
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		log.Printf("Error cleaning up acoustic fingerprints: %s", err)
+	}
+}
+
+// cleanupImages removes from the image store all images which are not referenced
+// in the database. Images stored after the clean-up has started are left alone
+// since their hashes might not have reached the database yet.
+func (lib *LocalLibrary) cleanupImages() {
+	start := time.Now()
+	referenced := make(map[string]struct{})
+
+	getHashes := func(db *sql.DB) error {
+		for _, ibc := range imageBlobColumns {
+			rows, err := db.Query(fmt.Sprintf(`
+				SELECT
+					%s
+				FROM
+					%s
+				WHERE
+					%s IS NOT NULL
+			`, ibc.hashColumn, ibc.table, ibc.hashColumn))
+			if err != nil {
+				return err
+			}
+
+			for rows.Next() {
+				var hash string
+				if err := rows.Scan(&hash); err != nil {
+					rows.Close()
+					return err
+				}
+				referenced[hash] = struct{}{}
+			}
+			rows.Close()
+		}
+
+		return nil
+	}
+
+	if err := lib.executeDBJobAndWait(getHashes); err != nil {
+		log.Printf("Error getting image hashes during cleanup: %s", err)
+		return
+	}
+
+	removed, err := lib.images.removeUnreferenced(referenced, start)
+	if err != nil {
+		log.Printf("Error cleaning up images: %s", err)
+	}
+
+	if removed > 0 {
+		log.Printf("Removed %d unused images from the image store", removed)
+	}
+}
+
 // checkAndRemoveAlbums removes from the database the albums with IDs `albumIDs`
 // but not before making sure there are no tracks asscociated with them.
 func (lib *LocalLibrary) checkAndRemoveAlbums(albumIDs []int64) error {
@@ -330,6 +432,14 @@ func (lib *LocalLibrary) checkAndRemoveArtists(artistIDs []int64) error {
 				return err
 			}
 
+			_, err = db.Exec(`
+				DELETE FROM artists_info
+				WHERE artist_id = ?
+			`, artistID)
+			if err != nil {
+				return err
+			}
+
 			return nil
 		}); err != nil {
 			log.Printf("Error deleting artist %d: %s", artistID, err)
@@ -344,9 +454,46 @@ func (lib *LocalLibrary) checkAndRemoveArtists(artistIDs []int64) error {
 //	* Tracks which no longer exist on disk.
 //	* Tracks with unclean file system path. They will be inserted again
 //	  with their clean path by the normal scan.
+//	* Tracks from CUE sheets which no longer exist on disk.
+//	* Tracks which are excluded by the configuration or by ignore files.
+//	* Tracks inside archives in libraries for which archives are not scanned.
 //
-func (lib *LocalLibrary) checkAndRemoveTracks(tracks []track) error {
+// Tracks in the library directories in generations are not checked on the disk.
+// They are stale when their files were not seen by the completed scan with the
+// generation of their directory. It returns the number of tracks which were
+// checked on the disk.
+func (lib *LocalLibrary) checkAndRemoveTracks(
+	tracks []track,
+	generations map[string]int64,
+) (int, error) {
+	enabled, disabled := lib.libraryPaths(), lib.disabledLibraryPaths()
+
+	var checked int
 	for _, track := range tracks {
+		if insideRoots(track.fsPath, disabled) {
+			// Disabled directories may be missing at the moment. Their tracks
+			// are kept as they are.
+			continue
+		}
+
+		if len(enabled) > 0 && !insideRoots(track.fsPath, enabled) {
+			log.Printf("Removing %d - '%s' which is in no library\n", track.id, track.fsPath)
+			lib.removeFile(track.fsPath)
+			continue
+		}
+
+		if lib.isExcluded(track.fsPath, false) {
+			log.Printf("Removing excluded %d - '%s'\n", track.id, track.fsPath)
+			lib.removeFile(track.fsPath)
+			continue
+		}
+
+		if !lib.scansArchives(track.fsPath) && inArchive(track.fsPath) {
+			log.Printf("Removing archived %d - '%s'\n", track.id, track.fsPath)
+			lib.removeFile(track.fsPath)
+			continue
+		}
+
 		cleanedPath := filepath.Clean(track.fsPath)
 		if cleanedPath != track.fsPath {
 			log.Printf("Removing duplicate %d - '%s'\n", track.id, track.fsPath)
@@ -354,6 +501,36 @@ func (lib *LocalLibrary) checkAndRemoveTracks(tracks []track) error {
 			continue
 		}
 
+		if root, ok := lib.libraryRootOf(track.fsPath); ok {
+			if generation, ok := generations[root]; ok {
+				if track.scanGeneration.Valid &&
+					track.scanGeneration.Int64 >= generation {
+					continue
+				}
+
+				log.Printf("Removing %d - '%s' which was not seen by the last scan\n",
+					track.id, track.fsPath)
+				if track.cueSheet.Valid {
+					lib.removeCueSheet(track.cueSheet.String)
+				} else {
+					lib.removeFile(track.fsPath)
+				}
+				continue
+			}
+		}
+
+		checked++
+
+		if track.cueSheet.Valid {
+			_, err := fs.Stat(lib.fs, track.cueSheet.String)
+			if err != nil && os.IsNotExist(err) {
+				log.Printf("Removing tracks of non existent CUE sheet '%s'\n",
+					track.cueSheet.String)
+				lib.removeCueSheet(track.cueSheet.String)
+				continue
+			}
+		}
+
 		if _, err := fs.Stat(lib.fs, track.fsPath); err == nil || !os.IsNotExist(err) {
 			continue
 		}
@@ -362,10 +539,12 @@ func (lib *LocalLibrary) checkAndRemoveTracks(tracks []track) error {
 		lib.removeFile(track.fsPath)
 	}
 
-	return nil
+	return checked, nil
 }
 
 type track struct {
-	id     int64
-	fsPath string
+	id             int64
+	fsPath         string
+	cueSheet       sql.NullString
+	scanGeneration sql.NullInt64
 }
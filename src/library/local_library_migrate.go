@@ -37,3 +37,111 @@ func (lib *LocalLibrary) applyMigrations() error {
 
 	return fmt.Errorf("executing db migration failed: %w", err)
 }
+
+// imageBlobColumns maps the tables and their blob columns which used to hold images
+// to the columns which now hold the hashes of the same images in the image store.
+var imageBlobColumns = []struct {
+	table      string
+	blobColumn string
+	hashColumn string
+}{
+	{"albums_artworks", "artwork_cover", "artwork_cover_hash"},
+	{"albums_artworks", "artwork_cover_small", "artwork_cover_small_hash"},
+	{"artists_images", "image", "image_hash"},
+	{"artists_images", "image_small", "image_small_hash"},
+}
+
+// exportImageBlobs moves all images which are still stored as blobs in the database
+// into the image store. Only their hashes are left in the database. Once something
+// has been exported the database is vacuumed so that it shrinks back on disk.
+//
+// It does nothing when the image store is not persistent since the images would
+// be lost on the next restart.
+func (lib *LocalLibrary) exportImageBlobs() error {
+	if !lib.images.persistent() {
+		return nil
+	}
+
+	const batchSize = 20
+	var exported int
+
+	for _, ibc := range imageBlobColumns {
+		selectQuery := fmt.Sprintf(`
+			SELECT
+				id,
+				%s
+			FROM
+				%s
+			WHERE
+				%s IS NOT NULL
+			LIMIT ?
+		`, ibc.blobColumn, ibc.table, ibc.blobColumn)
+
+		updateQuery := fmt.Sprintf(`
+			UPDATE
+				%s
+			SET
+				%s = ?,
+				%s = NULL
+			WHERE
+				id = ?
+		`, ibc.table, ibc.hashColumn, ibc.blobColumn)
+
+		for {
+			var (
+				ids   []int64
+				blobs [][]byte
+			)
+
+			rows, err := lib.db.Query(selectQuery, batchSize)
+			if err != nil {
+				return fmt.Errorf("selecting %s.%s: %w", ibc.table, ibc.blobColumn, err)
+			}
+
+			for rows.Next() {
+				var (
+					id   int64
+					blob []byte
+				)
+				if err := rows.Scan(&id, &blob); err != nil {
+					rows.Close()
+					return fmt.Errorf("scanning %s.%s: %w", ibc.table, ibc.blobColumn, err)
+				}
+				ids = append(ids, id)
+				blobs = append(blobs, blob)
+			}
+			rows.Close()
+
+			if len(ids) == 0 {
+				break
+			}
+
+			for i, id := range ids {
+				var hash interface{}
+				if len(blobs[i]) > 0 {
+					h, err := lib.images.put(blobs[i])
+					if err != nil {
+						return fmt.Errorf("exporting image from %s: %w", ibc.table, err)
+					}
+					hash = h
+				}
+
+				if _, err := lib.db.Exec(updateQuery, hash, id); err != nil {
+					return fmt.Errorf("updating %s.%s: %w", ibc.table, ibc.hashColumn, err)
+				}
+				exported++
+			}
+		}
+	}
+
+	if exported == 0 {
+		return nil
+	}
+
+	log.Printf("Exported %d images from the database into the image store\n", exported)
+	if _, err := lib.db.Exec("VACUUM"); err != nil {
+		log.Printf("Vacuuming the database after exporting images failed: %s\n", err)
+	}
+
+	return nil
+}
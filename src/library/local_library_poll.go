@@ -0,0 +1,403 @@
+package library
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// pollAllLibraries is the value in the configuration for polling all
+	// library directories for changes.
+	pollAllLibraries = "*"
+
+	// defaultPollInterval is used when no interval is configured for polling
+	// libraries for changes.
+	defaultPollInterval = time.Minute
+)
+
+// pollingWatchers keeps the library directories which are polled for changes
+// at the moment.
+type pollingWatchers struct {
+	sync.Mutex
+
+	running map[string]struct{}
+}
+
+// modTimeResolution is how long after its last change a directory is read again
+// on every poll. Some file systems keep modification times with a resolution of
+// seconds so changes in the same second as a read would be missed otherwise.
+const modTimeResolution = 2 * time.Second
+
+// fileState is the state of a file at the time of a snapshot.
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// dirState is the state of a directory at the time of a snapshot.
+type dirState struct {
+	modTime time.Time
+
+	// readAt is the time at which the directory was last read.
+	readAt time.Time
+
+	// files are the states of the files in the directory by their names.
+	files map[string]fileState
+
+	// dirs are the names of the directories in it.
+	dirs []string
+
+	// changing is set when files in the directory were added or changed when
+	// it was last read. Files which are still being written do not change the
+	// modification time of their directory.
+	changing bool
+}
+
+// dirSnapshot has the states of the directories in a library directory by their
+// paths.
+type dirSnapshot map[string]dirState
+
+// pollsChanges returns true when the library directory in which path is must be
+// polled for changes instead of being watched by the file system watcher.
+func (lib *LocalLibrary) pollsChanges(path string) bool {
+	root, ok := lib.libraryRootOf(filepath.Clean(path))
+	if !ok {
+		return false
+	}
+
+	for _, dir := range lib.ScanConfig.PollChanges {
+		if dir == pollAllLibraries || filepath.Clean(dir) == root {
+			return true
+		}
+	}
+	return false
+}
+
+// startPollingWatchers starts polling for changes the library directories which
+// are configured so and are not polled already.
+func (lib *LocalLibrary) startPollingWatchers() {
+	lib.watchLock.RLock()
+	noWatch := lib.noWatch
+	lib.watchLock.RUnlock()
+
+	if noWatch {
+		return
+	}
+
+	interval := lib.ScanConfig.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	var roots []string
+
+	lib.pollers.Lock()
+	if lib.pollers.running == nil {
+		lib.pollers.running = make(map[string]struct{})
+	}
+	for _, root := range lib.rootPaths() {
+		if _, ok := lib.pollers.running[root]; ok || !lib.pollsChanges(root) {
+			continue
+		}
+
+		lib.pollers.running[root] = struct{}{}
+		roots = append(roots, root)
+	}
+	lib.pollers.Unlock()
+
+	// The first snapshots are taken right away so that changes after the scan
+	// are not missed.
+	for _, root := range roots {
+		snapshot, _, err := lib.takeSnapshot(root, nil)
+		if err != nil {
+			log.Printf("Error polling %s for changes: %s\n", root, err)
+		}
+
+		go lib.pollForChanges(root, interval, snapshot)
+	}
+}
+
+// pollForChanges compares snapshots of the library directory root every
+// interval, starting with `snapshot`, and queues the differences as file system
+// watcher events. It stops when the directory is no longer in the library.
+func (lib *LocalLibrary) pollForChanges(
+	root string,
+	interval time.Duration,
+	snapshot dirSnapshot,
+) {
+	defer func() {
+		lib.pollers.Lock()
+		delete(lib.pollers.running, root)
+		lib.pollers.Unlock()
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-lib.ctx.Done():
+			return
+		}
+
+		if !isRootPath(root, lib.rootPaths()) || !lib.pollsChanges(root) {
+			return
+		}
+
+		current, events, err := lib.takeSnapshot(root, snapshot)
+		if err != nil {
+			// The directory is not reachable at the moment. Maybe a network
+			// file system is not mounted. Its tracks must not be removed
+			// because of that.
+			log.Printf("Error polling %s for changes: %s\n", root, err)
+			continue
+		}
+
+		for _, event := range events {
+			lib.queueWatchEvent(event)
+		}
+		snapshot = current
+	}
+}
+
+// takeSnapshot returns the states of all directories in root which are not
+// excluded from the library together with the events which change the snapshot
+// `before` into it. Only the directories which were changed since `before` are
+// listed again. For the rest only the states of their known files are checked.
+// Files and directories which are in created or deleted directories
+// have no events of their own since the whole directories are scanned or removed.
+// Deletions come before creations so that moved files keep their tracks.
+func (lib *LocalLibrary) takeSnapshot(
+	root string,
+	before dirSnapshot,
+) (dirSnapshot, []watchEvent, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	taker := snapshotTaker{
+		lib:            lib,
+		followSymlinks: lib.followsSymlinks(root),
+		before:         before,
+		after:          make(dirSnapshot),
+	}
+	taker.read(root, info, nil)
+
+	var events []watchEvent
+	addEvents := func(paths []string, op watchOp) {
+		sort.Strings(paths)
+		for _, path := range paths {
+			events = append(events, watchEvent{Name: path, Op: op})
+		}
+	}
+
+	addEvents(taker.deleted, watchDelete)
+	addEvents(taker.created, watchCreate)
+	addEvents(taker.modified, watchModify)
+
+	return taker.after, events, nil
+}
+
+// snapshotTaker takes snapshots of library directories for takeSnapshot.
+type snapshotTaker struct {
+	lib            *LocalLibrary
+	followSymlinks bool
+
+	before dirSnapshot
+	after  dirSnapshot
+
+	deleted  []string
+	created  []string
+	modified []string
+}
+
+// read adds the directory at path with info and the directories in it to the
+// snapshot. parents are the identities of the directories above path.
+func (st *snapshotTaker) read(
+	path string,
+	info fs.FileInfo,
+	parents []fileIdentity,
+) {
+	if id, ok := fileIdentityOf(path, info); ok {
+		for _, parent := range parents {
+			if parent == id {
+				return
+			}
+		}
+		parents = append(parents[:len(parents):len(parents)], id)
+	}
+
+	state, known := st.before[path]
+	if !known || state.needsReading(info) {
+		state = st.readDir(path, info, state, known)
+	} else {
+		state = st.checkFiles(path, state)
+	}
+
+	for _, name := range state.dirs {
+		dir := filepath.Join(path, name)
+		dirInfo, err := os.Stat(dir)
+		if err != nil || !dirInfo.IsDir() {
+			// Only targets of symbolic links could go away without a change
+			// of their parent.
+			if _, ok := st.before[dir]; ok {
+				st.deleted = append(st.deleted, dir)
+			}
+			state.changing = true
+			continue
+		}
+
+		st.read(dir, dirInfo, parents)
+	}
+
+	st.after[path] = state
+}
+
+// readDir lists again the directory at path with info and returns its new state.
+// old is its state in the last snapshot and known tells whether it was in it.
+// Changes are found only for directories which are known. The rest are new and
+// are scanned as a whole.
+func (st *snapshotTaker) readDir(
+	path string,
+	info fs.FileInfo,
+	old dirState,
+	known bool,
+) dirState {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		log.Printf("Error polling %s for changes: %s\n", path, err)
+		old.changing = true
+		return old
+	}
+
+	state := dirState{
+		modTime: info.ModTime(),
+		readAt:  time.Now(),
+		files:   make(map[string]fileState),
+	}
+	for _, entry := range entries {
+		name := filepath.Join(path, entry.Name())
+
+		entryInfo, err := entry.Info()
+		if err == nil && entryInfo.Mode()&fs.ModeSymlink != 0 {
+			entryInfo, err = os.Stat(name)
+			if err == nil && entryInfo.IsDir() && !st.followSymlinks {
+				continue
+			}
+		}
+		if err != nil || st.lib.isExcluded(name, entryInfo.IsDir()) {
+			continue
+		}
+
+		if entryInfo.IsDir() {
+			state.dirs = append(state.dirs, entry.Name())
+		} else {
+			state.files[entry.Name()] = fileStateOf(entryInfo)
+		}
+	}
+
+	if !known {
+		return state
+	}
+
+	oldDirs := make(map[string]struct{}, len(old.dirs))
+	for _, name := range old.dirs {
+		oldDirs[name] = struct{}{}
+	}
+	newDirs := make(map[string]struct{}, len(state.dirs))
+	for _, name := range state.dirs {
+		newDirs[name] = struct{}{}
+		if _, ok := oldDirs[name]; !ok {
+			st.created = append(st.created, filepath.Join(path, name))
+		}
+	}
+	for _, name := range old.dirs {
+		if _, ok := newDirs[name]; !ok {
+			st.deleted = append(st.deleted, filepath.Join(path, name))
+		}
+	}
+
+	for name := range old.files {
+		if _, ok := state.files[name]; !ok {
+			st.deleted = append(st.deleted, filepath.Join(path, name))
+		}
+	}
+	for name, newState := range state.files {
+		oldState, ok := old.files[name]
+		switch {
+		case !ok:
+			st.created = append(st.created, filepath.Join(path, name))
+		case !oldState.equal(newState):
+			st.modified = append(st.modified, filepath.Join(path, name))
+		default:
+			continue
+		}
+		state.changing = true
+	}
+
+	return state
+}
+
+// checkFiles checks the files of the directory at path which was not changed
+// since its state in the last snapshot. Files which are changed in place, such
+// as when their tags are written, do not change their directory.
+func (st *snapshotTaker) checkFiles(path string, state dirState) dirState {
+	var files map[string]fileState
+	for name, oldState := range state.files {
+		info, err := os.Stat(filepath.Join(path, name))
+		if err != nil {
+			// Only targets of symbolic links could go away without a change
+			// of their directory. It is listed again on the next poll.
+			state.changing = true
+			continue
+		}
+
+		newState := fileStateOf(info)
+		if oldState.equal(newState) {
+			continue
+		}
+
+		if files == nil {
+			files = make(map[string]fileState, len(state.files))
+			for name, fileState := range state.files {
+				files[name] = fileState
+			}
+		}
+		files[name] = newState
+		st.modified = append(st.modified, filepath.Join(path, name))
+		state.changing = true
+	}
+
+	if files != nil {
+		state.files = files
+	}
+	return state
+}
+
+// needsReading returns true when the directory with this state must be listed
+// again now that its info is `info`.
+func (ds dirState) needsReading(info fs.FileInfo) bool {
+	return ds.changing ||
+		!ds.modTime.Equal(info.ModTime()) ||
+		ds.readAt.Sub(ds.modTime) < modTimeResolution
+}
+
+// equal returns true when the file did not change between the states.
+func (fst fileState) equal(other fileState) bool {
+	return fst.size == other.size && fst.modTime.Equal(other.modTime)
+}
+
+// fileStateOf returns the state of the file with info.
+func fileStateOf(info fs.FileInfo) fileState {
+	return fileState{
+		size:    info.Size(),
+		modTime: info.ModTime(),
+	}
+}
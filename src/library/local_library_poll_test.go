@@ -0,0 +1,178 @@
+package library
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/helpers"
+)
+
+// TestTakeSnapshot checks that the changes in a directory between its snapshots
+// are turned into watch events. Including the changes of files in directories
+// which are not listed again.
+func TestTakeSnapshot(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+	defer func() { _ = lib.Truncate() }()
+	lib.DisableWatching()
+
+	root := t.TempDir()
+	p := func(name string) string {
+		return filepath.Join(root, filepath.FromSlash(name))
+	}
+	writeFile := func(name string, content string) {
+		t.Helper()
+
+		if err := os.MkdirAll(filepath.Dir(p(name)), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p(name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile("a/one.mp3", "one")
+	writeFile("a/two.mp3", "two")
+	writeFile("old/x.mp3", "x")
+	writeFile("same/song.mp3", "song")
+
+	// Directories which were changed just now are listed on every poll.
+	longAgo := time.Now().Add(-time.Hour)
+	for _, dir := range []string{"", "a", "old", "same"} {
+		if err := os.Chtimes(p(dir), longAgo, longAgo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lib.AddLibraryPath(root)
+
+	before, events, err := lib.takeSnapshot(root, nil)
+	if err != nil {
+		t.Fatalf("taking snapshot: %s", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("expected no events for the first snapshot but got %+v", events)
+	}
+
+	if err := os.Remove(p("a/two.mp3")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile("a/three.mp3", "three")
+	writeFile("a/one.mp3", "one again")
+	if err := os.RemoveAll(p("old")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile("new/y.mp3", "y")
+
+	// Changes of files in place do not change their directories. They are
+	// found even though the directory is not listed again.
+	writeFile("same/song.mp3", "another song")
+	if err := os.Chtimes(p("same"), longAgo, longAgo); err != nil {
+		t.Fatal(err)
+	}
+
+	after, events, err := lib.takeSnapshot(root, before)
+	if err != nil {
+		t.Fatalf("taking snapshot: %s", err)
+	}
+
+	expected := []watchEvent{
+		{Name: p("a/two.mp3"), Op: watchDelete},
+		{Name: p("old"), Op: watchDelete},
+		{Name: p("a/three.mp3"), Op: watchCreate},
+		{Name: p("new"), Op: watchCreate},
+		{Name: p("a/one.mp3"), Op: watchModify},
+		{Name: p("same/song.mp3"), Op: watchModify},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected events %+v but got %+v", expected, events)
+	}
+
+	if _, ok := after[p("new")].files["y.mp3"]; !ok {
+		t.Errorf("expected the files of the new directory in the snapshot")
+	}
+	if _, ok := after[p("old")]; ok {
+		t.Errorf("expected the removed directory not to be in the snapshot")
+	}
+
+	if _, events, _ := lib.takeSnapshot(root, after); len(events) != 0 {
+		t.Errorf("expected no events without changes but got %+v", events)
+	}
+}
+
+// TestPollingForChanges checks that files which are added to or removed from a
+// polled library directory are found.
+func TestPollingForChanges(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+	defer func() { _ = lib.Truncate() }()
+
+	projRoot, err := helpers.ProjectRoot()
+	if err != nil {
+		t.Fatalf("Was not able to find test_files directory: %s", err)
+	}
+	testMp3 := filepath.Join(projRoot, "test_files", "more_mp3s", "test_file_added.mp3")
+
+	root := t.TempDir()
+	lib.ScanConfig.PollChanges = []string{root}
+	lib.ScanConfig.PollInterval = 10 * time.Millisecond
+	lib.ScanConfig.WatchQuietPeriod = 10 * time.Millisecond
+	lib.AddLibraryPath(root)
+	lib.Scan()
+
+	waitFor := func(file string, inLibrary bool) {
+		t.Helper()
+
+		for !t.Failed() {
+			if lib.MediaExistsInLibrary(file) == inLibrary {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				t.Fatalf("expected %s in library to become %t", file, inLibrary)
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+
+	newDir := filepath.Join(root, "album")
+	newFile := filepath.Join(newDir, "song.mp3")
+	if err := os.Mkdir(newDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyFile(testMp3, newFile); err != nil {
+		t.Fatalf("copying test file: %s", err)
+	}
+	waitFor(newFile, true)
+
+	if err := os.Remove(newFile); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(newFile, false)
+}
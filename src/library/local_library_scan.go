@@ -9,12 +9,26 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/ironsmile/euterpe/src/ziparchive"
 	taglib "github.com/wtolson/go-taglib"
 )
 
 // Scan scans all of the folders in paths for media files. New files will be added to the
-// database.
+// database. It is the scan on start up so it first waits for ScanConfig.InitialWait.
 func (lib *LocalLibrary) Scan() {
+	lib.initializeWatcher()
+	initialWait := lib.ScanConfig.InitialWait
+	if !LibraryFastScan && initialWait > 0 {
+		log.Printf("Pausing initial library scan for %s as configured", initialWait)
+		time.Sleep(initialWait)
+	}
+
+	lib.scanAll()
+}
+
+// scanAll walks all library directories, adds the new files to the database and
+// then cleans it up.
+func (lib *LocalLibrary) scanAll() {
 	// Make sure there are no other scans working at the moment
 	lib.waitScanLock.RLock()
 	lib.walkWG.Wait()
@@ -22,15 +36,23 @@ func (lib *LocalLibrary) Scan() {
 
 	start := time.Now()
 
+	// Directories from the configuration which were missing could be there now.
+	lib.restoreMissingLibraries()
+
+	// The ignore files are read again in case they were changed while
+	// nothing was watching them.
+	lib.ignores.reset()
+
+	// Tracks from before folders were stored get theirs.
+	lib.fillTrackFolders()
+
+	// Albums of single discs from before discs were put together are merged.
+	lib.mergeDiscAlbums()
+
 	lib.initializeWatcher()
-	initialWait := lib.ScanConfig.InitialWait
-	if !LibraryFastScan && initialWait > 0 {
-		log.Printf("Pausing initial library scan for %s as configured", initialWait)
-		time.Sleep(initialWait)
-	}
 
 	lib.waitScanLock.Lock()
-	for _, path := range lib.paths {
+	for _, path := range lib.libraryPaths() {
 		lib.walkWG.Add(1)
 		go lib.scanPath(path)
 	}
@@ -44,17 +66,32 @@ func (lib *LocalLibrary) Scan() {
 	start = time.Now()
 	lib.cleanUpDatabase()
 	log.Printf("Cleaning up took %s", time.Since(start))
+
+	lib.prefetch.Lock()
+	prefetch := lib.prefetchAfterScan
+	lib.prefetch.Unlock()
+
+	if prefetch {
+		lib.StartArtworkPrefetch()
+	}
+
+	lib.startPollingWatchers()
+	lib.startLoudnessAnalysis()
+	lib.startChromaprintCalculation()
 }
 
 // This is the goroutine which actually scans a library path.
 // For now it ignores everything but the list of supported files. It is so
 // because jplayer cannot play anything else. Sends every suitable
-// file into the media channel
+// file into the media channel. Symbolic links to directories are followed only
+// when this is enabled for the library.
 func (lib *LocalLibrary) scanPath(scannedPath string) {
 	start := time.Now()
+	lib.walked.begin()
 
 	defer func() {
 		log.Printf("Walking %s took %s", scannedPath, time.Since(start))
+		lib.walked.end()
 		lib.walkWG.Done()
 	}()
 
@@ -63,10 +100,46 @@ func (lib *LocalLibrary) scanPath(scannedPath string) {
 
 	var scannedFiles int64
 
+	// Libraries which are polled for changes do not need a file system watch.
+	polled := lib.pollsChanges(scannedPath)
+
+	// Scans of whole library directories are new scan generations. A generation
+	// is completed only when all of the directory was walked without errors.
+	var (
+		root       = filepath.Clean(scannedPath)
+		generation int64
+		isRoot     = isRootPath(root, lib.rootPaths())
+		complete   = true
+		seen       []string
+	)
+	if isRoot {
+		var err error
+		if generation, err = lib.startScanGeneration(root); err != nil {
+			log.Printf("%s", err)
+			isRoot = false
+		}
+	}
+
 	walkFunc := func(path string, info os.FileInfo, err error) error {
 
 		if err != nil {
 			log.Printf("error while scanning %s: %s", path, err)
+			complete = false
+			return nil
+		}
+
+		if lib.isExcluded(path, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !lib.walked.claim(path, info) {
+			// The same file or directory was scanned by another path.
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
@@ -75,10 +148,24 @@ func (lib *LocalLibrary) scanPath(scannedPath string) {
 			if err != nil {
 				log.Printf("Error adding `%s`: %s\n", path, err)
 			}
+
+			seen = append(seen, filepath.Clean(path))
+			if len(seen) >= seenBatchSize {
+				lib.markSeen(seen)
+				seen = seen[:0]
+			}
+		} else if !info.IsDir() && isCueSheet(path) {
+			if err := lib.AddCueSheet(path); err != nil {
+				log.Printf("Error adding CUE sheet `%s`: %s\n", path, err)
+			}
+		} else if !info.IsDir() && ziparchive.IsArchive(path) && lib.scansArchives(path) {
+			if err := lib.AddArchive(path); err != nil {
+				log.Printf("Error adding archive `%s`: %s\n", path, err)
+			}
 		}
 
 		lib.watchLock.RLock()
-		if lib.watch != nil && info.IsDir() && !lib.noWatch {
+		if lib.watch != nil && info.IsDir() && !lib.noWatch && !polled {
 			if err := lib.watch.Watch(path); err != nil {
 				log.Printf("Starting a file system watch for %s failed: %s", path, err)
 			}
@@ -100,10 +187,18 @@ func (lib *LocalLibrary) scanPath(scannedPath string) {
 		return nil
 	}
 
-	err := filepath.Walk(scannedPath, walkFunc)
+	err := walkLibraryPath(scannedPath, lib.followsSymlinks(scannedPath), walkFunc)
+	lib.markSeen(seen)
 
 	if err != nil {
 		log.Printf("error while walking %s: %s", scannedPath, err)
+		complete = false
+	}
+
+	if isRoot && complete && lib.ctx.Err() == nil {
+		if err := lib.completeScanGeneration(root, generation); err != nil {
+			log.Printf("%s", err)
+		}
 	}
 }
 
@@ -129,16 +224,39 @@ func (lib *LocalLibrary) Rescan(ctx context.Context) error {
 		cursor += int64(len(mediaFiles))
 
 		for _, fileName := range mediaFiles {
-			file, err := taglib.Read(fileName)
-			if err != nil {
-				log.Printf("Taglib error for %s: %s\n", fileName, err)
+			if lib.isExcluded(fileName, false) {
+				// It will be removed by the next clean up.
 				continue
 			}
 
-			if err := lib.insertMediaIntoDatabase(file, fileName); err != nil {
+			err := withLocalFile(fileName, func(localPath string) error {
+				file, err := taglib.Read(localPath)
+				if err != nil {
+					return fmt.Errorf("Taglib error: %w", err)
+				}
+				defer file.Close()
+
+				return lib.insertMediaIntoDatabase(withAllTags(file, localPath), fileName)
+			})
+			if err != nil {
 				log.Printf("failed updating file %s: %s\n", fileName, err)
 			}
-			file.Close()
+		}
+	}
+
+	// Tracks from CUE sheets are updated by reading their sheets again.
+	cueSheets, err := lib.getCueSheets(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting CUE sheets from the db: %w", err)
+	}
+
+	for _, sheet := range cueSheets {
+		if lib.isExcluded(sheet, false) {
+			continue
+		}
+
+		if err := lib.AddCueSheet(sheet); err != nil {
+			log.Printf("failed updating CUE sheet %s: %s\n", sheet, err)
 		}
 	}
 
@@ -146,7 +264,7 @@ func (lib *LocalLibrary) Rescan(ctx context.Context) error {
 }
 
 // getMediaFilenames returns batchSize media files after moving the db offset at
-// cursor size.
+// cursor size. Files which are split into tracks by CUE sheets are not returned.
 func (lib *LocalLibrary) getMediaFilenames(
 	ctx context.Context,
 	cursor,
@@ -159,6 +277,8 @@ func (lib *LocalLibrary) getMediaFilenames(
 				fs_path
 			FROM
 				tracks
+			WHERE
+				cue_sheet IS NULL
 			LIMIT $1 OFFSET $2
 		`)
 		if err != nil {
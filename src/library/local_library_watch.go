@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"io/fs"
 	"log"
+	"os"
+	"path/filepath"
 
 	"github.com/howeyc/fsnotify"
+	"github.com/ironsmile/euterpe/src/ziparchive"
 )
 
 // Creates the directory watcher if none was created before. On failure logs the
@@ -60,7 +63,7 @@ func (lib *LocalLibrary) watchEventRoutine() {
 				return
 			}
 
-			lib.handleWatchEvent(ev)
+			lib.queueWatchEvent(newWatchEvent(ev))
 		case err := <-lib.watch.Error:
 			if err == nil {
 				return
@@ -78,43 +81,69 @@ func (lib *LocalLibrary) watchEventRoutine() {
 //  * deleted files should be removed from the library
 //  * deleted directories should be unwatched
 //  * modfied files should be updated in the database
-//  * renamed ...
-func (lib *LocalLibrary) handleWatchEvent(event *fsnotify.FileEvent) {
+//  * events for directories which are no longer in the library are ignored
+//  * events for excluded files and directories are ignored
+//  * changed ignore files are applied to the tracks in their directories
+//  * new symbolic links to directories are scanned only when following them
+//    is enabled for their library
+//  * renamed files are removed and then found again under their new names. They
+//    keep their IDs since they are recognised by their fingerprints.
+//  * new and modified ZIP archives have their media files added when archives
+//    are scanned for their library. Deleted ones have their tracks removed.
+func (lib *LocalLibrary) handleWatchEvent(event watchEvent) {
 
 	if event.IsAttrib() {
 		// The event was just an attribute change
 		return
 	}
 
+	if !insideRoots(event.Name, lib.libraryPaths()) {
+		// The directory was removed from the library or disabled.
+		return
+	}
+
+	if filepath.Base(event.Name) == IgnoreFileName {
+		lib.updateIgnoreFile(filepath.Dir(event.Name))
+		return
+	}
+
 	st, stErr := fs.Stat(lib.fs, event.Name)
 	if stErr != nil && !event.IsRename() && !event.IsDelete() {
 		log.Printf("Watch event stat received error: %s\n", stErr.Error())
 		return
 	}
 
+	if stErr == nil && lib.isExcluded(event.Name, st.IsDir()) {
+		return
+	}
+
 	if event.IsDelete() || event.IsRename() {
-		if lib.isSupportedFormat(event.Name) {
+		if isCueSheet(event.Name) {
+			lib.removeCueSheet(event.Name)
+		} else if isLyricsSidecar(event.Name) {
+			lib.updateSidecarLyrics(event.Name)
+		} else if ziparchive.IsArchive(event.Name) && (stErr != nil || !st.IsDir()) {
+			// The tracks in the archive are removed as if it was a directory.
+			lib.removeDirectory(event.Name)
+		} else if lib.isSupportedFormat(event.Name) {
 			// This is a file
 			lib.removeFile(event.Name)
 		} else {
 			// It was a directory... probably
-			lib.watchLock.Lock()
-			err := lib.watch.RemoveWatch(event.Name)
-			lib.watchLock.Unlock()
-			if err != nil {
-				fmt.Printf("error removing watcher for %s: %s\n", event.Name, err)
-			}
-
+			lib.removeWatch(event.Name)
 			lib.removeDirectory(event.Name)
 		}
 		return
 	}
 
 	if event.IsCreate() && st.IsDir() {
-		if err := lib.watch.Watch(event.Name); err != nil {
-			fmt.Printf("error starting a watcher for %s: %s\n", event.Name, err)
+		if lst, err := os.Lstat(event.Name); err == nil &&
+			lst.Mode()&fs.ModeSymlink != 0 && !lib.followsSymlinks(event.Name) {
+			return
 		}
 
+		lib.addWatch(event.Name)
+
 		lib.waitScanLock.Lock()
 		lib.walkWG.Add(1)
 		lib.waitScanLock.Unlock()
@@ -124,7 +153,17 @@ func (lib *LocalLibrary) handleWatchEvent(event *fsnotify.FileEvent) {
 	}
 
 	if event.IsCreate() && !st.IsDir() {
-		if lib.isSupportedFormat(event.Name) {
+		if isCueSheet(event.Name) {
+			if err := lib.AddCueSheet(event.Name); err != nil {
+				fmt.Printf("error adding newly created CUE sheet: %s\n", err)
+			}
+		} else if isLyricsSidecar(event.Name) {
+			lib.updateSidecarLyrics(event.Name)
+		} else if ziparchive.IsArchive(event.Name) && lib.scansArchives(event.Name) {
+			if err := lib.AddArchive(event.Name); err != nil {
+				fmt.Printf("error adding newly created archive: %s\n", err)
+			}
+		} else if lib.isSupportedFormat(event.Name) {
 			if err := lib.AddMedia(event.Name); err != nil {
 				fmt.Printf("error adding newly created file: %s\n", err)
 			}
@@ -133,16 +172,119 @@ func (lib *LocalLibrary) handleWatchEvent(event *fsnotify.FileEvent) {
 	}
 
 	if event.IsModify() && !st.IsDir() {
-		if lib.isSupportedFormat(event.Name) {
-			lib.removeFile(event.Name)
-			if err := lib.AddMedia(event.Name); err != nil {
-				fmt.Printf("error adding modified file: %s\n", err)
+		if isCueSheet(event.Name) {
+			if err := lib.AddCueSheet(event.Name); err != nil {
+				fmt.Printf("error adding modified CUE sheet: %s\n", err)
+			}
+		} else if isLyricsSidecar(event.Name) {
+			lib.updateSidecarLyrics(event.Name)
+		} else if ziparchive.IsArchive(event.Name) && lib.scansArchives(event.Name) {
+			if err := lib.updateArchive(event.Name); err != nil {
+				fmt.Printf("error updating modified archive: %s\n", err)
+			}
+		} else if sheets := lib.cueSheetsForMedia(event.Name); len(sheets) > 0 {
+			for _, sheet := range sheets {
+				if err := lib.AddCueSheet(sheet); err != nil {
+					fmt.Printf("error adding CUE sheet for modified file: %s\n", err)
+				}
+			}
+		} else if lib.isSupportedFormat(event.Name) {
+			if err := lib.updateMedia(event.Name); err != nil {
+				fmt.Printf("error updating modified file: %s\n", err)
 			}
 		}
 		return
 	}
 }
 
+// addWatch starts watching the directory at path with the file system watcher.
+// Directories in libraries which are polled for changes are not watched.
+func (lib *LocalLibrary) addWatch(path string) {
+	if lib.pollsChanges(path) {
+		return
+	}
+
+	lib.watchLock.RLock()
+	defer lib.watchLock.RUnlock()
+
+	if lib.watch == nil || lib.noWatch {
+		return
+	}
+
+	if err := lib.watch.Watch(path); err != nil {
+		fmt.Printf("error starting a watcher for %s: %s\n", path, err)
+	}
+}
+
+// removeWatch stops watching the directory at path with the file system watcher.
+func (lib *LocalLibrary) removeWatch(path string) {
+	if lib.pollsChanges(path) {
+		return
+	}
+
+	lib.watchLock.Lock()
+	defer lib.watchLock.Unlock()
+
+	if lib.watch == nil {
+		return
+	}
+
+	if err := lib.watch.RemoveWatch(path); err != nil {
+		fmt.Printf("error removing watcher for %s: %s\n", path, err)
+	}
+}
+
+// watchOp is the kind of change in a watchEvent.
+type watchOp int
+
+const (
+	watchCreate watchOp = iota + 1
+	watchModify
+	watchDelete
+	watchRename
+	watchAttrib
+)
+
+// watchEvent is a change of a file or directory in the library. They come from
+// the file system watcher or from polling the library for changes.
+type watchEvent struct {
+	Name string
+	Op   watchOp
+}
+
+// newWatchEvent converts an event of the file system watcher.
+func newWatchEvent(ev *fsnotify.FileEvent) watchEvent {
+	event := watchEvent{Name: ev.Name}
+	switch {
+	case ev.IsCreate():
+		event.Op = watchCreate
+	case ev.IsDelete():
+		event.Op = watchDelete
+	case ev.IsRename():
+		event.Op = watchRename
+	case ev.IsModify():
+		event.Op = watchModify
+	case ev.IsAttrib():
+		event.Op = watchAttrib
+	}
+	return event
+}
+
+// IsCreate returns true when the file or directory was created.
+func (ev watchEvent) IsCreate() bool { return ev.Op == watchCreate }
+
+// IsDelete returns true when the file or directory was deleted.
+func (ev watchEvent) IsDelete() bool { return ev.Op == watchDelete }
+
+// IsModify returns true when the file was modified.
+func (ev watchEvent) IsModify() bool { return ev.Op == watchModify }
+
+// IsRename returns true when the file or directory was moved away.
+func (ev watchEvent) IsRename() bool { return ev.Op == watchRename }
+
+// IsAttrib returns true when only the attributes of the file were changed.
+func (ev watchEvent) IsAttrib() bool { return ev.Op == watchAttrib }
+
 // DisableWatching makes it so that the library will no longer add file system
 // watching for new directories.
 func (lib *LocalLibrary) DisableWatching() {
@@ -1,6 +1,14 @@
 package library
 
-import "time"
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/tags"
+)
 
 // MediaFile is an interface which a media object should satisfy in order to be inserted
 // in the library database.
@@ -21,3 +29,90 @@ type MediaFile interface {
 	// Length returns the duration of this piece of media
 	Length() time.Duration
 }
+
+// TaggedMediaFile is a MediaFile which gives access to all of the tags of the file
+// and not only the basic ones. Such as MusicBrainz IDs.
+type TaggedMediaFile interface {
+	MediaFile
+
+	// Tags returns all tags found in the media file.
+	Tags() tags.Tags
+}
+
+// bitrateMediaFile is a MediaFile which knows the bit rate of its audio. Such as
+// the files read with taglib.
+type bitrateMediaFile interface {
+	MediaFile
+
+	// Bitrate returns the bit rate of the audio in kbps.
+	Bitrate() int
+}
+
+// mediaBitrate returns the bit rate of `file` in kbps. It is zero when unknown.
+func mediaBitrate(file MediaFile) int64 {
+	if withBitrate, ok := file.(bitrateMediaFile); ok {
+		return int64(withBitrate.Bitrate())
+	}
+	return 0
+}
+
+// taggedMediaFile adds the tags read with the tags package to a MediaFile.
+type taggedMediaFile struct {
+	MediaFile
+	tags tags.Tags
+}
+
+// Tags implements TaggedMediaFile.
+func (f taggedMediaFile) Tags() tags.Tags {
+	return f.tags
+}
+
+// Bitrate implements bitrateMediaFile for the files which know their bit rate.
+func (f taggedMediaFile) Bitrate() int {
+	return int(mediaBitrate(f.MediaFile))
+}
+
+// withAllTags reads all of the tags of the media file at `filename` and returns
+// `file` as a TaggedMediaFile. When the tags could not be read `file` is returned
+// as is.
+func withAllTags(file MediaFile, filename string) MediaFile {
+	found, err := tags.ReadFile(filename)
+	if errors.Is(err, tags.ErrUnsupportedFormat) {
+		return file
+	} else if err != nil {
+		log.Printf("Error reading tags of %s: %s", filename, err)
+		return file
+	}
+
+	return taggedMediaFile{
+		MediaFile: file,
+		tags:      found,
+	}
+}
+
+// firstMBID returns the first MusicBrainz ID for the tag `name`. Some taggers
+// store multiple IDs in a single value separated by slashes or semicolons.
+func firstMBID(file TaggedMediaFile, name string) string {
+	value := file.Tags().Get(name)
+	if ind := strings.IndexAny(value, "/;"); ind >= 0 {
+		value = value[:ind]
+	}
+	return strings.TrimSpace(value)
+}
+
+// yearAndGenreFromTags returns the release year and the genre found in the tags.
+// Dates are usually stored as "YYYY-MM-DD" or just "YYYY" and only the year is
+// used from them.
+func yearAndGenreFromTags(found tags.Tags) (int64, string) {
+	date := strings.TrimSpace(found.Get(tags.Date))
+	if len(date) > 4 {
+		date = date[:4]
+	}
+
+	year, err := strconv.ParseInt(date, 10, 64)
+	if err != nil || year < 0 {
+		year = 0
+	}
+
+	return year, strings.TrimSpace(found.Get(tags.Genre))
+}
@@ -0,0 +1,541 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+//counterfeiter:generate . OverridesManager
+
+// OverridesManager is an interface for correcting the metadata of tracks and
+// albums without changing their media files. Overrides are stored in the library
+// and are used instead of the tags of the files.
+type OverridesManager interface {
+	// GetTrackOverrides returns the overrides for a single track by its ID.
+	GetTrackOverrides(ctx context.Context, trackID int64) (MetadataOverrides, error)
+
+	// SetTrackOverrides replaces the overrides for a single track by its ID. Empty
+	// overrides remove all of them.
+	SetTrackOverrides(
+		ctx context.Context,
+		trackID int64,
+		overrides MetadataOverrides,
+	) error
+
+	// GetAlbumOverrides returns the overrides for all tracks of an album by its ID.
+	GetAlbumOverrides(ctx context.Context, albumID int64) (MetadataOverrides, error)
+
+	// SetAlbumOverrides replaces the overrides for all tracks of an album by its
+	// ID. Empty overrides remove all of them. Title and TrackNumber could not be
+	// overridden for whole albums.
+	SetAlbumOverrides(
+		ctx context.Context,
+		albumID int64,
+		overrides MetadataOverrides,
+	) error
+}
+
+// MetadataOverrides is metadata which is used instead of the tags of media files.
+// Only the non-nil fields are overridden.
+type MetadataOverrides struct {
+	Artist      *string `json:"artist,omitempty"`
+	Album       *string `json:"album,omitempty"`
+	Title       *string `json:"title,omitempty"`
+	TrackNumber *int64  `json:"track,omitempty"`
+	Year        *int64  `json:"year,omitempty"`
+	Genre       *string `json:"genre,omitempty"`
+}
+
+// IsEmpty returns true when nothing is overridden.
+func (o MetadataOverrides) IsEmpty() bool {
+	return o.Artist == nil && o.Album == nil && o.Title == nil &&
+		o.TrackNumber == nil && o.Year == nil && o.Genre == nil
+}
+
+// applyTo replaces the metadata in md with the overridden values.
+func (o MetadataOverrides) applyTo(md *trackMetadata) {
+	if o.Artist != nil {
+		md.artist = *o.Artist
+	}
+	if o.Album != nil {
+		md.album = *o.Album
+	}
+	if o.Title != nil {
+		md.title = *o.Title
+	}
+	if o.TrackNumber != nil {
+		md.number = *o.TrackNumber
+	}
+	if o.Year != nil {
+		md.year = *o.Year
+	}
+	if o.Genre != nil {
+		md.genre = *o.Genre
+	}
+}
+
+// trackMetadata is the metadata of a track as it is stored in the library.
+type trackMetadata struct {
+	artist string
+	album  string
+	title  string
+	number int64
+	year   int64
+	genre  string
+	disc   int64
+
+	// bitrate is the bit rate of the audio in kbps. It could not be overridden.
+	bitrate int64
+
+	// format is the format of the media file. It could not be overridden.
+	format string
+}
+
+// applyOverrides replaces the metadata in md, as read from the tags of the file at
+// fsPath, with the overrides for its album and then for the track itself.
+func (lib *LocalLibrary) applyOverrides(md *trackMetadata, fsPath string, cueStart int64) {
+	if md.album == "" {
+		md.album = UnknownLabel
+	}
+
+	var albumOverrides, trackOverrides MetadataOverrides
+
+	work := func(db *sql.DB) error {
+		err := db.QueryRow(`
+			SELECT
+				artist,
+				album,
+				year,
+				genre
+			FROM
+				albums_overrides
+			WHERE
+				fs_path = ? AND
+				name = ?
+		`, albumDir(fsPath), md.album).Scan(
+			&albumOverrides.Artist,
+			&albumOverrides.Album,
+			&albumOverrides.Year,
+			&albumOverrides.Genre,
+		)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		return scanTrackOverrides(db.QueryRow(`
+			SELECT
+				artist,
+				album,
+				title,
+				number,
+				year,
+				genre
+			FROM
+				tracks_overrides
+			WHERE
+				fs_path = ? AND
+				cue_start = ?
+		`, fsPath, cueStart), &trackOverrides)
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		log.Printf("Error getting metadata overrides for %s: %s", fsPath, err)
+		return
+	}
+
+	albumOverrides.applyTo(md)
+	trackOverrides.applyTo(md)
+}
+
+// GetTrackOverrides implements the OverridesManager interface.
+func (lib *LocalLibrary) GetTrackOverrides(
+	ctx context.Context,
+	trackID int64,
+) (MetadataOverrides, error) {
+	var overrides MetadataOverrides
+
+	work := func(db *sql.DB) error {
+		var count int
+		err := db.QueryRowContext(ctx, `
+			SELECT
+				COUNT(*)
+			FROM
+				tracks
+			WHERE
+				id = ?
+		`, trackID).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrTrackNotFound
+		}
+
+		return scanTrackOverrides(db.QueryRowContext(ctx, `
+			SELECT
+				o.artist,
+				o.album,
+				o.title,
+				o.number,
+				o.year,
+				o.genre
+			FROM
+				tracks_overrides o
+				JOIN tracks t ON t.fs_path = o.fs_path AND t.cue_start = o.cue_start
+			WHERE
+				t.id = ?
+		`, trackID), &overrides)
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return MetadataOverrides{}, err
+	}
+
+	return overrides, nil
+}
+
+// SetTrackOverrides implements the OverridesManager interface. The track keeps
+// its ID.
+func (lib *LocalLibrary) SetTrackOverrides(
+	ctx context.Context,
+	trackID int64,
+	overrides MetadataOverrides,
+) error {
+	if err := validateOverrides(&overrides); err != nil {
+		return err
+	}
+
+	var (
+		fsPath   string
+		cueStart int64
+		cueSheet sql.NullString
+	)
+
+	work := func(db *sql.DB) error {
+		err := db.QueryRowContext(ctx, `
+			SELECT
+				fs_path,
+				cue_start,
+				cue_sheet
+			FROM
+				tracks
+			WHERE
+				id = ?
+		`, trackID).Scan(&fsPath, &cueStart, &cueSheet)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTrackNotFound
+		} else if err != nil {
+			return err
+		}
+
+		if overrides.IsEmpty() {
+			_, err := db.ExecContext(ctx, `
+				DELETE FROM tracks_overrides
+				WHERE fs_path = ? AND cue_start = ?
+			`, fsPath, cueStart)
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			INSERT INTO tracks_overrides
+				(fs_path, cue_start, artist, album, title, number, year, genre,
+					updated_at)
+			VALUES
+				($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (fs_path, cue_start) DO
+			UPDATE SET
+				artist = $3,
+				album = $4,
+				title = $5,
+				number = $6,
+				year = $7,
+				genre = $8,
+				updated_at = $9
+		`, fsPath, cueStart, overrides.Artist, overrides.Album, overrides.Title,
+			overrides.TrackNumber, overrides.Year, overrides.Genre,
+			time.Now().Unix())
+		return err
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return err
+	}
+
+	albums := lib.albumsOfPaths(fsPath)
+	if err := lib.refreshTrack(fsPath, cueSheet); err != nil {
+		return fmt.Errorf("applying overrides: %w", err)
+	}
+	lib.groupEditionsOf(append(albums, lib.albumsOfPaths(fsPath)...))
+
+	return nil
+}
+
+// GetAlbumOverrides implements the OverridesManager interface.
+func (lib *LocalLibrary) GetAlbumOverrides(
+	ctx context.Context,
+	albumID int64,
+) (MetadataOverrides, error) {
+	var overrides MetadataOverrides
+
+	work := func(db *sql.DB) error {
+		_, err := findAlbumOverrides(ctx, db, albumID, &overrides)
+		return err
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return MetadataOverrides{}, err
+	}
+
+	return overrides, nil
+}
+
+// SetAlbumOverrides implements the OverridesManager interface. The album keeps
+// its ID unless its name is overridden with the name of another album in the
+// same directory. Then its tracks are moved into the other album.
+func (lib *LocalLibrary) SetAlbumOverrides(
+	ctx context.Context,
+	albumID int64,
+	overrides MetadataOverrides,
+) error {
+	if overrides.Title != nil || overrides.TrackNumber != nil {
+		return fmt.Errorf(
+			"%w: title and track number cannot be set for albums",
+			ErrInvalidTagValue,
+		)
+	}
+
+	if err := validateOverrides(&overrides); err != nil {
+		return err
+	}
+
+	var key albumOverridesKey
+	work := func(db *sql.DB) error {
+		var err error
+		key, err = findAlbumOverrides(ctx, db, albumID, &MetadataOverrides{})
+		return err
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return err
+	}
+
+	// The album is renamed before its tracks are added again so that they
+	// find it with its new name.
+	newName := key.name
+	if overrides.Album != nil {
+		newName = *overrides.Album
+	}
+	albums := []int64{albumID}
+	albumID, err := lib.renameAlbum(ctx, albumID, newName, key.fsPath)
+	if err != nil {
+		return fmt.Errorf("renaming album: %w", err)
+	}
+
+	work = func(db *sql.DB) error {
+		if overrides.IsEmpty() {
+			_, err := db.ExecContext(ctx, `
+				DELETE FROM albums_overrides
+				WHERE fs_path = ? AND name = ?
+			`, key.fsPath, key.name)
+			return err
+		}
+
+		_, err := db.ExecContext(ctx, `
+			INSERT INTO albums_overrides
+				(fs_path, name, artist, album, year, genre, updated_at)
+			VALUES
+				($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (fs_path, name) DO
+			UPDATE SET
+				artist = $3,
+				album = $4,
+				year = $5,
+				genre = $6,
+				updated_at = $7
+		`, key.fsPath, key.name, overrides.Artist, overrides.Album,
+			overrides.Year, overrides.Genre, time.Now().Unix())
+		return err
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return err
+	}
+
+	tracks, err := lib.editedTracks(ctx, "album_id", albumID)
+	if err != nil {
+		return err
+	}
+
+	var refreshErr error
+	refreshedSheets := make(map[string]struct{})
+	albums = append(albums, albumID)
+	for _, track := range tracks {
+		if track.cueSheet.Valid {
+			if _, ok := refreshedSheets[track.cueSheet.String]; ok {
+				continue
+			}
+			refreshedSheets[track.cueSheet.String] = struct{}{}
+		}
+
+		if err := lib.refreshTrack(track.fsPath, track.cueSheet); err != nil {
+			log.Printf("Error applying overrides for %s: %s", track.fsPath, err)
+			if refreshErr == nil {
+				refreshErr = fmt.Errorf("applying overrides: %w", err)
+			}
+		}
+		albums = append(albums, lib.albumsOfPaths(track.fsPath)...)
+	}
+	lib.groupEditionsOf(albums)
+
+	return refreshErr
+}
+
+// albumOverridesKey identifies the overrides for an album.
+type albumOverridesKey struct {
+	fsPath string
+
+	// name is the name of the album in the tags of its tracks.
+	name string
+}
+
+// findAlbumOverrides reads the overrides for the album with ID albumID into
+// overrides. The album may already have its name overridden. So the overrides
+// are found either by the current name of the album or by the name overriding
+// the one in the tags.
+func findAlbumOverrides(
+	ctx context.Context,
+	db *sql.DB,
+	albumID int64,
+	overrides *MetadataOverrides,
+) (albumOverridesKey, error) {
+	var key albumOverridesKey
+
+	err := db.QueryRowContext(ctx, `
+		SELECT
+			fs_path,
+			name
+		FROM
+			albums
+		WHERE
+			id = ?
+	`, albumID).Scan(&key.fsPath, &key.name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return key, ErrAlbumNotFound
+	} else if err != nil {
+		return key, err
+	}
+
+	err = db.QueryRowContext(ctx, `
+		SELECT
+			name,
+			artist,
+			album,
+			year,
+			genre
+		FROM
+			albums_overrides
+		WHERE
+			fs_path = ? AND
+			IFNULL(album, name) = ?
+		ORDER BY
+			name = ? DESC
+		LIMIT 1
+	`, key.fsPath, key.name, key.name).Scan(
+		&key.name,
+		&overrides.Artist,
+		&overrides.Album,
+		&overrides.Year,
+		&overrides.Genre,
+	)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return key, err
+	}
+
+	return key, nil
+}
+
+// scanTrackOverrides scans a tracks_overrides row into overrides. It is not an
+// error for the row to be missing.
+func scanTrackOverrides(row *sql.Row, overrides *MetadataOverrides) error {
+	err := row.Scan(
+		&overrides.Artist,
+		&overrides.Album,
+		&overrides.Title,
+		&overrides.TrackNumber,
+		&overrides.Year,
+		&overrides.Genre,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	return err
+}
+
+// refreshTrack reads the tags of a track again and stores them in the library
+// together with their overrides. Tracks from CUE sheets are refreshed by reading
+// their sheet.
+func (lib *LocalLibrary) refreshTrack(fsPath string, cueSheet sql.NullString) error {
+	if cueSheet.Valid {
+		return lib.AddCueSheet(cueSheet.String)
+	}
+	return lib.updateMedia(fsPath)
+}
+
+// saveTrackDetails stores the year, genre, disc, bit rate and format of a track. Zero
+// values are stored as NULL.
+func (lib *LocalLibrary) saveTrackDetails(trackID int64, md trackMetadata) error {
+	work := func(db *sql.DB) error {
+		_, err := db.Exec(`
+			UPDATE tracks
+			SET
+				year = ?,
+				genre = ?,
+				disc = ?,
+				bitrate = ?,
+				format = ?
+			WHERE
+				id = ?
+		`, sql.NullInt64{Int64: md.year, Valid: md.year > 0},
+			sql.NullString{String: md.genre, Valid: md.genre != ""},
+			sql.NullInt64{Int64: md.disc, Valid: md.disc > 0},
+			sql.NullInt64{Int64: md.bitrate, Valid: md.bitrate > 0},
+			sql.NullString{String: md.format, Valid: md.format != ""},
+			trackID,
+		)
+		return err
+	}
+
+	return lib.executeDBJobAndWait(work)
+}
+
+// validateOverrides trims the text fields of overrides and makes sure none of
+// them is left empty and the numbers are sensible.
+func validateOverrides(overrides *MetadataOverrides) error {
+	for _, field := range []*string{
+		overrides.Artist,
+		overrides.Album,
+		overrides.Title,
+		overrides.Genre,
+	} {
+		if field == nil {
+			continue
+		}
+		*field = strings.TrimSpace(*field)
+		if *field == "" {
+			return fmt.Errorf("%w: empty value", ErrInvalidTagValue)
+		}
+	}
+
+	if overrides.TrackNumber != nil && *overrides.TrackNumber < 0 {
+		return fmt.Errorf("%w: negative track number", ErrInvalidTagValue)
+	}
+
+	if overrides.Year != nil && *overrides.Year <= 0 {
+		return fmt.Errorf("%w: year must be positive", ErrInvalidTagValue)
+	}
+
+	return nil
+}
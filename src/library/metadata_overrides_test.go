@@ -0,0 +1,170 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/helpers"
+)
+
+// TestMetadataOverrides checks that overrides are used instead of the tags and
+// that they survive adding the media file again.
+func TestMetadataOverrides(t *testing.T) {
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	projRoot, err := helpers.ProjectRoot()
+	if err != nil {
+		t.Fatalf("Was not able to find test_files directory: %s", err)
+	}
+
+	filePath := filepath.Join(t.TempDir(), "added.mp3")
+	err = copyFile(
+		filepath.Join(projRoot, "test_files", "more_mp3s", "test_file_added.mp3"),
+		filePath,
+	)
+	if err != nil {
+		t.Fatalf("copying test file: %s", err)
+	}
+
+	if err := lib.AddMedia(filePath); err != nil {
+		t.Fatalf("adding media: %s", err)
+	}
+
+	found := lib.Search("Added Song")
+	if len(found) != 1 {
+		t.Fatalf("expected one track but found %d", len(found))
+	}
+	trackID, albumID := found[0].ID, found[0].AlbumID
+	tagsYear, tagsGenre := found[0].Year, found[0].Genre
+
+	title := "Fixed Song"
+	year := int64(1999)
+	genre := " Rock "
+	err = lib.SetTrackOverrides(ctx, trackID, MetadataOverrides{
+		Title: &title,
+		Year:  &year,
+		Genre: &genre,
+	})
+	if err != nil {
+		t.Fatalf("setting track overrides: %s", err)
+	}
+
+	assertTrack := func(desc, title string, year int64, genre string) {
+		t.Helper()
+
+		found := lib.Search(title)
+		if len(found) != 1 {
+			t.Fatalf("%s: expected one track `%s` but found %d", desc, title, len(found))
+		}
+		if found[0].ID != trackID {
+			t.Errorf("%s: track ID changed from %d to %d", desc, trackID, found[0].ID)
+		}
+		if found[0].Year != year || found[0].Genre != genre {
+			t.Errorf("%s: expected year %d and genre `%s` but got %d and `%s`",
+				desc, year, genre, found[0].Year, found[0].Genre)
+		}
+	}
+
+	assertTrack("after setting overrides", "Fixed Song", 1999, "Rock")
+
+	overrides, err := lib.GetTrackOverrides(ctx, trackID)
+	if err != nil {
+		t.Fatalf("getting track overrides: %s", err)
+	}
+	genre = "Rock"
+	expected := MetadataOverrides{Title: &title, Year: &year, Genre: &genre}
+	if !reflect.DeepEqual(overrides, expected) {
+		t.Errorf("expected overrides %+v but got %+v", expected, overrides)
+	}
+
+	if err := lib.Rescan(ctx); err != nil {
+		t.Fatalf("rescanning: %s", err)
+	}
+	assertTrack("after rescan", "Fixed Song", 1999, "Rock")
+
+	albumName := "Expected Album"
+	artist := "Old Artist"
+	err = lib.SetAlbumOverrides(ctx, albumID, MetadataOverrides{
+		Album:  &albumName,
+		Artist: &artist,
+	})
+	if err != nil {
+		t.Fatalf("setting album overrides: %s", err)
+	}
+
+	tracks := lib.GetAlbumFiles(albumID)
+	if len(tracks) != 1 {
+		t.Fatalf("expected one track in album %d but got %d", albumID, len(tracks))
+	}
+	if tracks[0].Album != albumName || tracks[0].Artist != artist {
+		t.Errorf("expected album `%s` by `%s` but got `%s` by `%s`",
+			albumName, artist, tracks[0].Album, tracks[0].Artist)
+	}
+	if tracks[0].Title != "Fixed Song" {
+		t.Errorf("track overrides were lost, title is `%s`", tracks[0].Title)
+	}
+
+	albums, _ := lib.BrowseAlbums(BrowseArgs{PerPage: 10})
+	if len(albums) != 1 || albums[0].Name != albumName || albums[0].ID != albumID {
+		t.Errorf("unexpected albums when browsing: %+v", albums)
+	}
+
+	overrides, err = lib.GetAlbumOverrides(ctx, albumID)
+	if err != nil {
+		t.Fatalf("getting album overrides: %s", err)
+	}
+	expected = MetadataOverrides{Album: &albumName, Artist: &artist}
+	if !reflect.DeepEqual(overrides, expected) {
+		t.Errorf("expected album overrides %+v but got %+v", expected, overrides)
+	}
+
+	// Adding the file anew gives it a new ID but the overrides are still used.
+	lib.removeFile(filePath)
+	if err := lib.AddMedia(filePath); err != nil {
+		t.Fatalf("adding media again: %s", err)
+	}
+	found = lib.Search("Fixed Song")
+	if len(found) != 1 || found[0].Album != albumName {
+		t.Fatalf("overrides not used after adding the file again: %+v", found)
+	}
+	trackID = found[0].ID
+
+	err = lib.SetAlbumOverrides(ctx, albumID, MetadataOverrides{Title: &title})
+	if !errors.Is(err, ErrInvalidTagValue) {
+		t.Errorf("expected ErrInvalidTagValue for album title but got %v", err)
+	}
+
+	if err := lib.SetAlbumOverrides(ctx, albumID, MetadataOverrides{}); err != nil {
+		t.Fatalf("removing album overrides: %s", err)
+	}
+	tracks = lib.GetAlbumFiles(albumID)
+	if len(tracks) != 1 || tracks[0].Album != "Unexpected Album" {
+		t.Errorf("album overrides were not removed in place: %+v", tracks)
+	}
+
+	if err := lib.SetTrackOverrides(ctx, trackID, MetadataOverrides{}); err != nil {
+		t.Fatalf("removing track overrides: %s", err)
+	}
+	assertTrack("after removing overrides", "Added Song", tagsYear, tagsGenre)
+
+	if _, err := lib.GetTrackOverrides(ctx, 9999); !errors.Is(err, ErrTrackNotFound) {
+		t.Errorf("expected ErrTrackNotFound but got %v", err)
+	}
+	if _, err := lib.GetAlbumOverrides(ctx, 9999); !errors.Is(err, ErrAlbumNotFound) {
+		t.Errorf("expected ErrAlbumNotFound but got %v", err)
+	}
+}
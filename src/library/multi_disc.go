@@ -0,0 +1,204 @@
+package library
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ironsmile/euterpe/src/tags"
+)
+
+// discDirRegexp matches the names of directories which hold a single disc of a
+// multi-disc album. Such as "CD1", "Disc 2" or "disk_03 - Bonus Tracks".
+var discDirRegexp = regexp.MustCompile(`(?i)^(?:cd|dis[ck])[\s._-]*(\d{1,3})(?:\D.*)?$`)
+
+// discFromDir returns the disc number if `dir` is a directory for a single disc
+// of an album. Zero is returned for all other directories.
+func discFromDir(dir string) int64 {
+	match := discDirRegexp.FindStringSubmatch(filepath.Base(dir))
+	if match == nil {
+		return 0
+	}
+
+	disc, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return disc
+}
+
+// albumDir returns the directory of the album for the media file at `filePath`.
+// It is the directory of the file unless it is a disc directory. Then the album
+// is in its parent directory together with the rest of the discs. Copies of an
+// album in different directories are still separate albums.
+func albumDir(filePath string) string {
+	dir := filepath.Dir(filePath)
+	if discFromDir(dir) > 0 {
+		return filepath.Dir(dir)
+	}
+
+	return dir
+}
+
+// discFromTags returns the disc number found in the tags. It is usually stored
+// as "1" or "1/2" where the second number is the number of discs.
+func discFromTags(found tags.Tags) int64 {
+	disc, _, _ := strings.Cut(found.Get(tags.DiscNumber), "/")
+
+	number, err := strconv.ParseInt(strings.TrimSpace(disc), 10, 64)
+	if err != nil || number < 0 {
+		return 0
+	}
+
+	return number
+}
+
+// mergeDiscAlbums moves the tracks of albums which are in disc directories into
+// the albums of their parent directories. Such albums are left from before the
+// discs of an album were put together. Files which are in the library already
+// are not read again by scans so their albums would never change otherwise.
+func (lib *LocalLibrary) mergeDiscAlbums() {
+	type discAlbum struct {
+		id     int64
+		name   string
+		fsPath string
+	}
+
+	var albums []discAlbum
+	work := func(db *sql.DB) error {
+		// LIKE is not case sensitive so this finds all candidates for
+		// discDirRegexp.
+		rows, err := db.Query(`
+			SELECT
+				id,
+				name,
+				fs_path
+			FROM
+				albums
+			WHERE
+				fs_path LIKE '%cd%' OR
+				fs_path LIKE '%dis%'
+		`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var album discAlbum
+			if err := rows.Scan(&album.id, &album.name, &album.fsPath); err != nil {
+				return err
+			}
+			if discFromDir(album.fsPath) > 0 {
+				albums = append(albums, album)
+			}
+		}
+		return rows.Err()
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		log.Printf("Error getting albums of discs: %s", err)
+		return
+	}
+	if len(albums) == 0 {
+		return
+	}
+
+	work = func(db *sql.DB) error {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		for _, album := range albums {
+			parent := filepath.Dir(album.fsPath)
+
+			var albumID int64
+			err := tx.QueryRow(`
+				SELECT id
+				FROM albums
+				WHERE name = ? AND fs_path = ?
+			`, album.name, parent).Scan(&albumID)
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				// The first disc found becomes the album.
+				albumID = album.id
+				_, err = tx.Exec(`
+					UPDATE albums
+					SET fs_path = ?
+					WHERE id = ?
+				`, parent, album.id)
+			case err == nil:
+				err = moveAlbumTracks(tx, album.id, albumID)
+			}
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(`
+				UPDATE tracks
+				SET disc = ?
+				WHERE
+					album_id = ? AND
+					disc IS NULL AND
+					fs_path >= ? AND
+					fs_path < ?
+			`, discFromDir(album.fsPath), albumID,
+				album.fsPath+string(filepath.Separator),
+				album.fsPath+string(filepath.Separator+1))
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(`
+				UPDATE OR IGNORE albums_overrides
+				SET fs_path = ?
+				WHERE fs_path = ? AND name = ?
+			`, parent, album.fsPath, album.name)
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Commit()
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		log.Printf("Error merging albums of discs: %s", err)
+		return
+	}
+
+	log.Printf("Merged %d albums of discs into their albums", len(albums))
+}
+
+// moveAlbumTracks moves the tracks of the album `fromID` into the album `toID`
+// and removes the first one together with its artwork.
+func moveAlbumTracks(tx *sql.Tx, fromID, toID int64) error {
+	if _, err := tx.Exec(`
+		UPDATE tracks
+		SET album_id = ?
+		WHERE album_id = ?
+	`, toID, fromID); err != nil {
+		return err
+	}
+
+	// The editions are grouped again after the scan.
+	if _, err := tx.Exec(`
+		UPDATE albums
+		SET edition_of = NULL
+		WHERE edition_of = ?
+	`, fromID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM albums WHERE id = ?`, fromID); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(`DELETE FROM albums_artworks WHERE album_id = ?`, fromID)
+	return err
+}
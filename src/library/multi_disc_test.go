@@ -0,0 +1,231 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/helpers"
+	"github.com/ironsmile/euterpe/src/tags"
+)
+
+// TestDiscNumbers checks that disc numbers are found in directory names and in
+// tags.
+func TestDiscNumbers(t *testing.T) {
+	dirs := map[string]int64{
+		"/music/Album/CD1":                   1,
+		"/music/Album/cd 2":                  2,
+		"/music/Album/Disc_03":               3,
+		"/music/Album/disk-4 - Bonus Tracks": 4,
+		"/music/Album/Disc 12 (Live)":        12,
+		"/music/Album":                       0,
+		"/music/Discography":                 0,
+		"/music/CDs":                         0,
+		"/music/Album/Cd1x":                  1,
+	}
+	for dir, expected := range dirs {
+		if found := discFromDir(dir); found != expected {
+			t.Errorf("expected disc %d for %s but got %d", expected, dir, found)
+		}
+	}
+
+	if found := albumDir("/music/Album/CD1/01.mp3"); found != "/music/Album" {
+		t.Errorf("expected album dir /music/Album but got %s", found)
+	}
+	if found := albumDir("/music/Album/01.mp3"); found != "/music/Album" {
+		t.Errorf("expected album dir /music/Album but got %s", found)
+	}
+
+	values := map[string]int64{
+		"2":     2,
+		"1/2":   1,
+		" 3 /4": 3,
+		"":      0,
+		"one":   0,
+	}
+	for value, expected := range values {
+		found := discFromTags(tags.Tags{tags.DiscNumber: []string{value}})
+		if found != expected {
+			t.Errorf("expected disc %d for tag `%s` but got %d", expected, value, found)
+		}
+	}
+}
+
+// TestMultiDiscAlbums checks that discs in sub-directories of an album are merged
+// into one album while copies of the album elsewhere are not.
+func TestMultiDiscAlbums(t *testing.T) {
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	projRoot, err := helpers.ProjectRoot()
+	if err != nil {
+		t.Fatalf("Was not able to find test_files directory: %s", err)
+	}
+	testMp3 := filepath.Join(projRoot, "test_files", "more_mp3s", "test_file_added.mp3")
+
+	tmpDir := t.TempDir()
+	files := []string{
+		filepath.Join(tmpDir, "Album", "CD2", "first.mp3"),
+		filepath.Join(tmpDir, "Album", "CD1", "first.mp3"),
+		filepath.Join(tmpDir, "Copy", "CD1", "first.mp3"),
+	}
+	for _, file := range files {
+		if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := copyFile(testMp3, file); err != nil {
+			t.Fatalf("copying test file: %s", err)
+		}
+		if err := lib.AddMedia(file); err != nil {
+			t.Fatalf("adding media: %s", err)
+		}
+	}
+
+	found := lib.Search("Added Song")
+	if len(found) != 3 {
+		t.Fatalf("expected three tracks but found %d", len(found))
+	}
+
+	albumID, err := lib.GetAlbumID("Unexpected Album", filepath.Join(tmpDir, "Album"))
+	if err != nil {
+		t.Fatalf("getting the multi-disc album: %s", err)
+	}
+
+	tracks := lib.GetAlbumFiles(albumID)
+	if len(tracks) != 2 {
+		t.Fatalf("expected two tracks in the multi-disc album but got %d", len(tracks))
+	}
+	for i, track := range tracks {
+		if track.Disc != int64(i+1) {
+			t.Errorf("expected track %d to be on disc %d but it is on %d",
+				i, i+1, track.Disc)
+		}
+		if path := lib.GetFilePath(track.ID); path != files[1-i] {
+			t.Errorf("expected track %d to be %s but it is %s", i, files[1-i], path)
+		}
+	}
+
+	copyID, err := lib.GetAlbumID("Unexpected Album", filepath.Join(tmpDir, "Copy"))
+	if err != nil {
+		t.Fatalf("getting the copy of the album: %s", err)
+	}
+	if copyID == albumID {
+		t.Errorf("the copy of the album was merged with the original")
+	}
+	if tracks := lib.GetAlbumFiles(copyID); len(tracks) != 1 {
+		t.Errorf("expected one track in the copy of the album but got %d", len(tracks))
+	}
+}
+
+// TestMergingDiscAlbums checks that albums of single discs from before the discs
+// of an album were put together are merged into the album of their parent
+// directory.
+func TestMergingDiscAlbums(t *testing.T) {
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	albumDir := filepath.FromSlash("/music/Album")
+	files := []string{
+		filepath.Join(albumDir, "CD1", "first.mp3"),
+		filepath.Join(albumDir, "CD2", "first.mp3"),
+	}
+	for _, file := range files {
+		media := MockMedia{
+			artist: "Testy Testov",
+			album:  "Two Discs",
+			title:  "First",
+			track:  1,
+			length: time.Minute,
+		}
+		if err := lib.insertMediaIntoDatabase(&media, file); err != nil {
+			t.Fatalf("inserting media: %s", err)
+		}
+	}
+
+	// Before the discs were put together every disc was an album of its own
+	// and the discs were not known.
+	albumID, err := lib.GetAlbumID("Two Discs", albumDir)
+	if err != nil {
+		t.Fatalf("getting album: %s", err)
+	}
+	err = lib.executeDBJobAndWait(func(db *sql.DB) error {
+		if _, err := db.Exec(`
+			UPDATE albums SET fs_path = ? WHERE id = ?
+		`, filepath.Dir(files[0]), albumID); err != nil {
+			return err
+		}
+		res, err := db.Exec(`
+			INSERT INTO albums (name, fs_path) VALUES (?, ?)
+		`, "Two Discs", filepath.Dir(files[1]))
+		if err != nil {
+			return err
+		}
+		secondID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		if _, err := db.Exec(`
+			UPDATE tracks SET album_id = ? WHERE fs_path = ?
+		`, secondID, files[1]); err != nil {
+			return err
+		}
+		_, err = db.Exec(`UPDATE tracks SET disc = NULL`)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("making albums of discs: %s", err)
+	}
+
+	lib.mergeDiscAlbums()
+
+	mergedID, err := lib.GetAlbumID("Two Discs", albumDir)
+	if err != nil {
+		t.Fatalf("getting the merged album: %s", err)
+	}
+	if mergedID != albumID {
+		t.Errorf("expected the album of the first disc to be kept")
+	}
+
+	tracks := lib.GetAlbumFiles(mergedID)
+	if len(tracks) != 2 {
+		t.Fatalf("expected two tracks in the merged album but got %d", len(tracks))
+	}
+	for i, track := range tracks {
+		if track.Disc != int64(i+1) {
+			t.Errorf("expected track %d to be on disc %d but it is on %d",
+				i, i+1, track.Disc)
+		}
+	}
+
+	for _, file := range files {
+		if _, err := lib.GetAlbumID("Two Discs", filepath.Dir(file)); err == nil {
+			t.Errorf("the album of %s was not merged", filepath.Dir(file))
+		}
+	}
+	if albums := lib.getTableSize("albums"); albums != 1 {
+		t.Errorf("expected one album but found %d", albums)
+	}
+}
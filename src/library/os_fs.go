@@ -3,18 +3,37 @@ package library
 import (
 	"io/fs"
 	"os"
+
+	"github.com/ironsmile/euterpe/src/ziparchive"
 )
 
 // osFS is a fs.FS implementation which uses the os package as the underlying file
-// opener.
+// opener. Files inside ZIP archives are opened as if the archives were
+// directories.
 type osFS struct{}
 
 var _ fs.StatFS = (*osFS)(nil)
 
 func (osfs *osFS) Open(name string) (fs.File, error) {
-	return os.Open(name)
+	file, err := os.Open(name)
+	if err == nil {
+		return file, nil
+	}
+
+	if archivePath, entry, ok := ziparchive.Split(name); ok {
+		return ziparchive.Open(archivePath, entry)
+	}
+	return nil, err
 }
 
 func (osfs *osFS) Stat(name string) (fs.FileInfo, error) {
-	return os.Stat(name)
+	st, err := os.Stat(name)
+	if err == nil {
+		return st, nil
+	}
+
+	if archivePath, entry, ok := ziparchive.Split(name); ok {
+		return ziparchive.Stat(archivePath, entry)
+	}
+	return nil, err
 }
@@ -0,0 +1,346 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/loudness"
+)
+
+// The possible values of the gain_source column of tracks.
+const (
+	gainSourceTags     = "tags"
+	gainSourceAnalysis = "analysis"
+	gainSourceFailed   = "failed"
+)
+
+// replayGainColumns is used for scanning the ReplayGain columns of tracks.
+type replayGainColumns struct {
+	trackGain sql.NullFloat64
+	trackPeak sql.NullFloat64
+	albumGain sql.NullFloat64
+	albumPeak sql.NullFloat64
+	source    sql.NullString
+}
+
+// replayGain returns the ReplayGain information from the columns or nil when
+// there is none.
+func (c replayGainColumns) replayGain() *ReplayGain {
+	if !c.trackGain.Valid ||
+		(c.source.String != gainSourceTags && c.source.String != gainSourceAnalysis) {
+		return nil
+	}
+
+	rg := &ReplayGain{
+		TrackGain: c.trackGain.Float64,
+		Source:    c.source.String,
+	}
+	if c.trackPeak.Valid {
+		rg.TrackPeak = &c.trackPeak.Float64
+	}
+	if c.albumGain.Valid {
+		rg.AlbumGain = &c.albumGain.Float64
+	}
+	if c.albumPeak.Valid {
+		rg.AlbumPeak = &c.albumPeak.Float64
+	}
+	return rg
+}
+
+// loudnessAnalysis holds the state of the loudness analysis job.
+type loudnessAnalysis struct {
+	sync.Mutex
+
+	analyzer *loudness.Analyzer
+	running  bool
+}
+
+// EnableLoudnessAnalysis makes the library measure the loudness of tracks which
+// have no ReplayGain information in their tags. The analysis runs in the
+// background after every scan.
+func (lib *LocalLibrary) EnableLoudnessAnalysis(analyzer *loudness.Analyzer) {
+	lib.loudnessJob.Lock()
+	defer lib.loudnessJob.Unlock()
+
+	lib.loudnessJob.analyzer = analyzer
+}
+
+// startLoudnessAnalysis starts the loudness analysis in the background when it is
+// enabled and not already running.
+func (lib *LocalLibrary) startLoudnessAnalysis() {
+	lib.loudnessJob.Lock()
+	defer lib.loudnessJob.Unlock()
+
+	if lib.loudnessJob.analyzer == nil || lib.loudnessJob.running {
+		return
+	}
+	lib.loudnessJob.running = true
+
+	go func() {
+		defer func() {
+			lib.loudnessJob.Lock()
+			lib.loudnessJob.running = false
+			lib.loudnessJob.Unlock()
+		}()
+
+		start := time.Now()
+		if err := lib.analyzeLoudness(lib.ctx); err != nil {
+			log.Printf("Loudness analysis stopped: %s", err)
+			return
+		}
+		log.Printf("Loudness analysis took %s", time.Since(start))
+	}()
+}
+
+// saveReplayGainFromTags stores the ReplayGain information from the tags of a
+// media file. When there is none then previously stored information from tags
+// is removed. Measured loudness is kept.
+func (lib *LocalLibrary) saveReplayGainFromTags(trackID int64, file MediaFile) error {
+	var (
+		gain  loudness.Gain
+		found bool
+	)
+	if tagged, ok := file.(TaggedMediaFile); ok {
+		gain, found = loudness.FromTags(tagged.Tags())
+	}
+
+	if !found {
+		return lib.executeDBJobAndWait(func(db *sql.DB) error {
+			_, err := db.Exec(`
+				UPDATE tracks
+				SET
+					track_gain = NULL,
+					track_peak = NULL,
+					album_gain = NULL,
+					album_peak = NULL,
+					gain_source = NULL
+				WHERE
+					id = ? AND
+					gain_source = ?
+			`, trackID, gainSourceTags)
+			return err
+		})
+	}
+
+	return lib.saveReplayGain(trackID, gain, gainSourceTags)
+}
+
+// saveReplayGain stores the ReplayGain information for a track.
+func (lib *LocalLibrary) saveReplayGain(
+	trackID int64,
+	gain loudness.Gain,
+	source string,
+) error {
+	var (
+		trackPeak sql.NullFloat64
+		albumGain sql.NullFloat64
+		albumPeak sql.NullFloat64
+	)
+	if gain.TrackPeak > 0 {
+		trackPeak = sql.NullFloat64{Float64: gain.TrackPeak, Valid: true}
+	}
+	if gain.HasAlbumGain {
+		albumGain = sql.NullFloat64{Float64: gain.AlbumGain, Valid: true}
+		if gain.AlbumPeak > 0 {
+			albumPeak = sql.NullFloat64{Float64: gain.AlbumPeak, Valid: true}
+		}
+	}
+
+	return lib.executeDBJobAndWait(func(db *sql.DB) error {
+		_, err := db.Exec(`
+			UPDATE tracks
+			SET
+				track_gain = ?,
+				track_peak = ?,
+				album_gain = ?,
+				album_peak = ?,
+				gain_source = ?
+			WHERE
+				id = ?
+		`, gain.TrackGain, trackPeak, albumGain, albumPeak, source, trackID)
+		return err
+	})
+}
+
+// markLoudnessAnalysisFailed records that the loudness of a track could not be
+// measured so that it is not tried again.
+func (lib *LocalLibrary) markLoudnessAnalysisFailed(trackID int64) error {
+	return lib.executeDBJobAndWait(func(db *sql.DB) error {
+		_, err := db.Exec(`
+			UPDATE tracks
+			SET gain_source = ?
+			WHERE id = ?
+		`, gainSourceFailed, trackID)
+		return err
+	})
+}
+
+// analyzeLoudness measures the loudness of all tracks without ReplayGain
+// information. It goes album by album. The album gain is stored only when none
+// of the tracks in the album had ReplayGain information beforehand since it must
+// be measured over all of them. Tracks from CUE sheets are not analyzed.
+func (lib *LocalLibrary) analyzeLoudness(ctx context.Context) error {
+	lib.loudnessJob.Lock()
+	analyzer := lib.loudnessJob.analyzer
+	lib.loudnessJob.Unlock()
+
+	if analyzer == nil {
+		return errors.New("loudness analysis is not enabled")
+	}
+
+	var albumIDs []int64
+	work := func(db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, `
+			SELECT DISTINCT
+				album_id
+			FROM
+				tracks
+			WHERE
+				gain_source IS NULL AND
+				cue_sheet IS NULL
+		`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var albumID int64
+			if err := rows.Scan(&albumID); err != nil {
+				return err
+			}
+			albumIDs = append(albumIDs, albumID)
+		}
+		return rows.Err()
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return err
+	}
+
+	for _, albumID := range albumIDs {
+		if err := lib.analyzeAlbumLoudness(ctx, analyzer, albumID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// analyzeAlbumLoudness measures the loudness of the tracks in an album which
+// have no ReplayGain information.
+func (lib *LocalLibrary) analyzeAlbumLoudness(
+	ctx context.Context,
+	analyzer *loudness.Analyzer,
+	albumID int64,
+) error {
+	type albumTrack struct {
+		id     int64
+		fsPath string
+	}
+
+	var (
+		tracks     []albumTrack
+		wholeAlbum = true
+	)
+	work := func(db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, `
+			SELECT
+				id,
+				fs_path,
+				gain_source,
+				cue_sheet
+			FROM
+				tracks
+			WHERE
+				album_id = ?
+		`, albumID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				track    albumTrack
+				source   sql.NullString
+				cueSheet sql.NullString
+			)
+			if err := rows.Scan(&track.id, &track.fsPath, &source, &cueSheet); err != nil {
+				return err
+			}
+
+			if source.Valid || cueSheet.Valid {
+				wholeAlbum = false
+				continue
+			}
+			tracks = append(tracks, track)
+		}
+		return rows.Err()
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return err
+	}
+
+	meters := make([]*loudness.Meter, len(tracks))
+	for ind, track := range tracks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var meter *loudness.Meter
+		err := withLocalFile(track.fsPath, func(localPath string) (err error) {
+			meter, err = analyzer.Analyze(ctx, localPath)
+			return err
+		})
+		if err == nil && math.IsInf(meter.Loudness(), -1) {
+			err = errors.New("the track is silent")
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Measuring loudness of %s: %s", track.fsPath, err)
+			wholeAlbum = false
+			if err := lib.markLoudnessAnalysisFailed(track.id); err != nil {
+				return err
+			}
+			continue
+		}
+
+		meters[ind] = meter
+	}
+
+	var albumGain loudness.Gain
+	if wholeAlbum && len(meters) > 0 {
+		albumGain.HasAlbumGain = true
+		albumGain.AlbumGain = loudness.GainForLoudness(
+			loudness.IntegratedLoudness(meters...),
+		)
+		for _, meter := range meters {
+			if meter.Peak() > albumGain.AlbumPeak {
+				albumGain.AlbumPeak = meter.Peak()
+			}
+		}
+	}
+
+	for ind, meter := range meters {
+		if meter == nil {
+			continue
+		}
+
+		gain := albumGain
+		gain.TrackGain = loudness.GainForLoudness(meter.Loudness())
+		gain.TrackPeak = meter.Peak()
+
+		if err := lib.saveReplayGain(tracks[ind].id, gain, gainSourceAnalysis); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/loudness"
+	"github.com/ironsmile/euterpe/src/tags"
+)
+
+// TestReplayGainFromTags checks that ReplayGain information is read from tags and
+// returned with the tracks.
+func TestReplayGainFromTags(t *testing.T) {
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	trackPath := filepath.FromSlash("/music/killers/wrathchild.mp3")
+	media := &MockMedia{artist: "Iron Maiden", album: "Killers", title: "Wrathchild"}
+
+	tagged := taggedMediaFile{
+		MediaFile: media,
+		tags: tags.Tags{
+			loudness.TagTrackGain: {"-6.5 dB"},
+			loudness.TagTrackPeak: {"0.95"},
+			loudness.TagAlbumGain: {"-7 dB"},
+		},
+	}
+	if err := lib.insertMediaIntoDatabase(tagged, trackPath); err != nil {
+		t.Fatalf("inserting media: %s", err)
+	}
+
+	found := lib.Search("Wrathchild")
+	if len(found) != 1 {
+		t.Fatalf("expected one track but got %d", len(found))
+	}
+
+	rg := found[0].ReplayGain
+	if rg == nil {
+		t.Fatalf("expected ReplayGain information for the track")
+	}
+	if rg.Source != gainSourceTags || rg.TrackGain != -6.5 ||
+		rg.TrackPeak == nil || *rg.TrackPeak != 0.95 ||
+		rg.AlbumGain == nil || *rg.AlbumGain != -7 || rg.AlbumPeak != nil {
+		t.Errorf("unexpected ReplayGain information: %+v", rg)
+	}
+
+	albumFiles := lib.GetAlbumFiles(found[0].AlbumID)
+	if len(albumFiles) != 1 || albumFiles[0].ReplayGain == nil {
+		t.Errorf("expected ReplayGain information with the album files")
+	}
+
+	// The tags are removed from the file.
+	if err := lib.insertMediaIntoDatabase(media, trackPath); err != nil {
+		t.Fatalf("inserting media again: %s", err)
+	}
+
+	found = lib.Search("Wrathchild")
+	if len(found) != 1 || found[0].ReplayGain != nil {
+		t.Errorf("expected no ReplayGain information after the tags are removed")
+	}
+}
+
+// TestLoudnessAnalysis checks that the loudness of tracks without ReplayGain tags
+// is measured and that the album gain is computed for whole albums.
+func TestLoudnessAnalysis(t *testing.T) {
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	albumDir := t.TempDir()
+	files := []struct {
+		title string
+		path  string
+		dbfs  float64
+	}{
+		{title: "Quiet", path: filepath.Join(albumDir, "quiet.wav"), dbfs: -23},
+		{title: "Loud", path: filepath.Join(albumDir, "loud.wav"), dbfs: -17},
+		{title: "Missing", path: filepath.Join(albumDir, "missing.flac")},
+	}
+
+	for _, file := range files {
+		if file.dbfs != 0 {
+			writeSineWAV(t, file.path, file.dbfs)
+		}
+
+		media := &MockMedia{artist: "Tester", album: "Sines", title: file.title}
+		if err := lib.insertMediaIntoDatabase(media, file.path); err != nil {
+			t.Fatalf("inserting %s: %s", file.title, err)
+		}
+	}
+
+	lib.EnableLoudnessAnalysis(loudness.NewAnalyzer(nil))
+	if err := lib.analyzeLoudness(ctx); err != nil {
+		t.Fatalf("analyzing loudness: %s", err)
+	}
+
+	// The energy mean of -23 and -17 LUFS for equal durations.
+	albumLoudness := 10 * math.Log10((math.Pow(10, -2.3)+math.Pow(10, -1.7))/2)
+	expectedAlbumGain := loudness.ReferenceLoudness - albumLoudness
+
+	for _, file := range files {
+		found := lib.Search(file.title)
+		if len(found) != 1 {
+			t.Fatalf("%s: expected one track but got %d", file.title, len(found))
+		}
+		rg := found[0].ReplayGain
+
+		if file.dbfs == 0 {
+			if rg != nil {
+				t.Errorf("%s: expected no ReplayGain but got %+v", file.title, rg)
+			}
+			continue
+		}
+
+		if rg == nil {
+			t.Fatalf("%s: expected ReplayGain information", file.title)
+		}
+
+		expectedGain := loudness.ReferenceLoudness - file.dbfs
+		if rg.Source != gainSourceAnalysis || math.Abs(rg.TrackGain-expectedGain) > 0.1 {
+			t.Errorf("%s: expected gain %.2f from analysis but got %+v",
+				file.title, expectedGain, rg)
+		}
+
+		if rg.TrackPeak == nil || math.Abs(*rg.TrackPeak-math.Pow(10, file.dbfs/20)) > 0.01 {
+			t.Errorf("%s: unexpected track peak", file.title)
+		}
+
+		// One of the tracks could not be analyzed so the album gain is not known.
+		if rg.AlbumGain != nil {
+			t.Errorf("%s: expected no album gain but got %.2f", file.title, *rg.AlbumGain)
+		}
+	}
+
+	// Without the missing file the album is whole.
+	lib.removeFile(files[2].path)
+	if err := lib.executeDBJobAndWait(func(db *sql.DB) error {
+		_, err := db.Exec(`UPDATE tracks SET gain_source = NULL`)
+		return err
+	}); err != nil {
+		t.Fatalf("resetting analysis: %s", err)
+	}
+
+	if err := lib.analyzeLoudness(ctx); err != nil {
+		t.Fatalf("analyzing loudness again: %s", err)
+	}
+
+	for _, file := range files[:2] {
+		found := lib.Search(file.title)
+		if len(found) != 1 || found[0].ReplayGain == nil ||
+			found[0].ReplayGain.AlbumGain == nil {
+			t.Fatalf("%s: expected album gain", file.title)
+		}
+
+		albumGain := *found[0].ReplayGain.AlbumGain
+		if math.Abs(albumGain-expectedAlbumGain) > 0.1 {
+			t.Errorf("%s: expected album gain %.2f but got %.2f",
+				file.title, expectedAlbumGain, albumGain)
+		}
+	}
+}
+
+// writeSineWAV writes a five seconds long stereo WAV file with 1kHz sine which
+// peaks at `dbfs`.
+func writeSineWAV(t *testing.T, path string, dbfs float64) {
+	const sampleRate = 48000
+
+	amplitude := math.Pow(10, dbfs/20)
+	var pcm []byte
+	for i := 0; i < sampleRate*5; i++ {
+		value := amplitude * math.Sin(2*math.Pi*1000*float64(i)/sampleRate)
+		sample := uint16(int16(value * (1<<15 - 1)))
+		pcm = binary.LittleEndian.AppendUint16(pcm, sample)
+		pcm = binary.LittleEndian.AppendUint16(pcm, sample)
+	}
+
+	wav := []byte("RIFF")
+	wav = binary.LittleEndian.AppendUint32(wav, uint32(36+len(pcm)))
+	wav = append(wav, "WAVEfmt "...)
+	wav = binary.LittleEndian.AppendUint32(wav, 16)
+	wav = binary.LittleEndian.AppendUint16(wav, 1)
+	wav = binary.LittleEndian.AppendUint16(wav, 2)
+	wav = binary.LittleEndian.AppendUint32(wav, sampleRate)
+	wav = binary.LittleEndian.AppendUint32(wav, sampleRate*4)
+	wav = binary.LittleEndian.AppendUint16(wav, 4)
+	wav = binary.LittleEndian.AppendUint16(wav, 16)
+	wav = append(wav, "data"...)
+	wav = binary.LittleEndian.AppendUint32(wav, uint32(len(pcm)))
+	wav = append(wav, pcm...)
+
+	if err := os.WriteFile(path, wav, 0o600); err != nil {
+		t.Fatalf("writing WAV file: %s", err)
+	}
+}
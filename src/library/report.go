@@ -0,0 +1,251 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+//counterfeiter:generate . Reporter
+
+// Reporter is an interface for generating reports about the state of the library
+// which could be used for finding and fixing problems with the media files.
+type Reporter interface {
+	// Report returns lists with albums and tracks which have problems with
+	// their artwork or tags.
+	Report(ctx context.Context) (Report, error)
+}
+
+// Report lists the problems found in the library.
+type Report struct {
+	// AlbumsWithoutArtwork contains all albums for which there is no artwork
+	// stored in the library.
+	AlbumsWithoutArtwork []ReportAlbum `json:"albums_without_artwork"`
+
+	// UnknownLabelTracks contains all tracks for which the artist or the album
+	// was missing from the tags. In the library they are under UnknownLabel.
+	UnknownLabelTracks []ReportTrack `json:"unknown_label_tracks"`
+
+	// AlbumsWithTrackGaps contains all albums in which some of the track
+	// numbers are missing.
+	AlbumsWithTrackGaps []ReportTrackGaps `json:"albums_with_track_gaps"`
+}
+
+// ReportAlbum is an album in a Report.
+type ReportAlbum struct {
+	ID     int64  `json:"album_id"`
+	Name   string `json:"album"`
+	FSPath string `json:"fs_path"`
+
+	// ArtworkSearchedAt is the unix timestamp of the last time artwork was
+	// searched for and not found. It is zero when it has never been searched.
+	ArtworkSearchedAt int64 `json:"artwork_searched_at,omitempty"`
+}
+
+// ReportTrack is a track in a Report.
+type ReportTrack struct {
+	ID     int64  `json:"track_id"`
+	Title  string `json:"title"`
+	Artist string `json:"artist"`
+	Album  string `json:"album"`
+	FSPath string `json:"fs_path"`
+}
+
+// ReportTrackGaps is an album with missing track numbers.
+type ReportTrackGaps struct {
+	ReportAlbum
+
+	// MissingNumbers are the track numbers between 1 and the largest track
+	// number in the album for which there is no track.
+	MissingNumbers []int64 `json:"missing_track_numbers"`
+}
+
+// Report implements the Reporter interface.
+func (lib *LocalLibrary) Report(ctx context.Context) (Report, error) {
+	report := Report{
+		AlbumsWithoutArtwork: []ReportAlbum{},
+		UnknownLabelTracks:   []ReportTrack{},
+		AlbumsWithTrackGaps:  []ReportTrackGaps{},
+	}
+
+	work := func(db *sql.DB) error {
+		var err error
+
+		report.AlbumsWithoutArtwork, err = reportAlbumsWithoutArtwork(ctx, db)
+		if err != nil {
+			return fmt.Errorf("albums without artwork: %w", err)
+		}
+
+		report.UnknownLabelTracks, err = reportUnknownLabelTracks(ctx, db)
+		if err != nil {
+			return fmt.Errorf("tracks with unknown labels: %w", err)
+		}
+
+		report.AlbumsWithTrackGaps, err = reportAlbumsWithTrackGaps(ctx, db)
+		if err != nil {
+			return fmt.Errorf("albums with track gaps: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return Report{}, err
+	}
+
+	return report, nil
+}
+
+func reportAlbumsWithoutArtwork(ctx context.Context, db *sql.DB) ([]ReportAlbum, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			al.id,
+			al.name,
+			al.fs_path,
+			aa.updated_at
+		FROM
+			albums al
+			LEFT JOIN
+				albums_artworks aa ON aa.album_id = al.id
+		WHERE
+			aa.artwork_cover_hash IS NULL
+		ORDER BY
+			al.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query database: %w", err)
+	}
+	defer rows.Close()
+
+	albums := []ReportAlbum{}
+	for rows.Next() {
+		var (
+			album      ReportAlbum
+			searchedAt sql.NullInt64
+		)
+		if err := rows.Scan(&album.ID, &album.Name, &album.FSPath, &searchedAt); err != nil {
+			return nil, fmt.Errorf("scanning db result: %w", err)
+		}
+		album.ArtworkSearchedAt = searchedAt.Int64
+		albums = append(albums, album)
+	}
+
+	return albums, rows.Err()
+}
+
+func reportUnknownLabelTracks(ctx context.Context, db *sql.DB) ([]ReportTrack, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			t.id,
+			t.name,
+			ar.name,
+			al.name,
+			t.fs_path
+		FROM
+			tracks t
+			LEFT JOIN
+				albums al ON al.id = t.album_id
+			LEFT JOIN
+				artists ar ON ar.id = t.artist_id
+		WHERE
+			ar.name = $1 OR al.name = $1
+		ORDER BY
+			t.id
+	`, UnknownLabel)
+	if err != nil {
+		return nil, fmt.Errorf("query database: %w", err)
+	}
+	defer rows.Close()
+
+	tracks := []ReportTrack{}
+	for rows.Next() {
+		var track ReportTrack
+		err := rows.Scan(
+			&track.ID,
+			&track.Title,
+			&track.Artist,
+			&track.Album,
+			&track.FSPath,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanning db result: %w", err)
+		}
+		tracks = append(tracks, track)
+	}
+
+	return tracks, rows.Err()
+}
+
+func reportAlbumsWithTrackGaps(
+	ctx context.Context,
+	db *sql.DB,
+) ([]ReportTrackGaps, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			al.id,
+			al.name,
+			al.fs_path,
+			IFNULL(t.disc, 0),
+			t.number
+		FROM
+			tracks t
+			JOIN
+				albums al ON al.id = t.album_id
+		WHERE
+			t.number > 0
+		ORDER BY
+			al.id, t.disc, t.number
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query database: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		albums  = []ReportTrackGaps{}
+		current ReportTrackGaps
+		disc    int64
+		last    int64
+	)
+
+	// flush adds the current album to the result if it has any gaps.
+	flush := func() {
+		if len(current.MissingNumbers) > 0 {
+			albums = append(albums, current)
+		}
+	}
+
+	for rows.Next() {
+		var (
+			album     ReportAlbum
+			trackDisc int64
+			number    int64
+		)
+		err := rows.Scan(&album.ID, &album.Name, &album.FSPath, &trackDisc, &number)
+		if err != nil {
+			return nil, fmt.Errorf("scanning db result: %w", err)
+		}
+
+		if album.ID != current.ID {
+			flush()
+			current = ReportTrackGaps{ReportAlbum: album}
+			disc = trackDisc
+			last = 0
+		} else if trackDisc != disc {
+			// Track numbers start from the beginning on every disc.
+			disc = trackDisc
+			last = 0
+		}
+
+		for missing := last + 1; missing < number; missing++ {
+			current.MissingNumbers = append(current.MissingNumbers, missing)
+		}
+		last = number
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	return albums, nil
+}
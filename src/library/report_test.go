@@ -0,0 +1,125 @@
+package library
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestLibraryReport checks that the library report lists the albums without
+// artwork, the tracks with unknown labels and the albums with gaps in their
+// track numbers.
+func TestLibraryReport(t *testing.T) {
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	gapsDir := filepath.FromSlash("/music/gaps")
+	unknownDir := filepath.FromSlash("/music/unknown")
+	coverDir := filepath.FromSlash("/music/cover")
+
+	files := []struct {
+		media MockMedia
+		path  string
+	}{
+		{MockMedia{artist: "Gappy", album: "Gaps", title: "One", track: 1}, "one.mp3"},
+		{MockMedia{artist: "Gappy", album: "Gaps", title: "Two", track: 2}, "two.mp3"},
+		{MockMedia{artist: "Gappy", album: "Gaps", title: "Four", track: 4}, "four.mp3"},
+		{MockMedia{artist: "", album: "Nameless", title: "Who", track: 1}, "who.mp3"},
+		{MockMedia{artist: "Covered", album: "Cover", title: "Art", track: 1}, "art.mp3"},
+	}
+
+	for ind, file := range files {
+		file := file
+		dir := gapsDir
+		switch file.media.album {
+		case "Nameless":
+			dir = unknownDir
+		case "Cover":
+			dir = coverDir
+		}
+
+		trackPath := filepath.Join(dir, file.path)
+		if err := lib.insertMediaIntoDatabase(&file.media, trackPath); err != nil {
+			t.Fatalf("inserting media file %d failed: %s", ind, err)
+		}
+	}
+
+	gapsID, _ := lib.GetAlbumID("Gaps", gapsDir)
+	unknownID, _ := lib.GetAlbumID("Nameless", unknownDir)
+	coverID, _ := lib.GetAlbumID("Cover", coverDir)
+
+	err = lib.SaveAlbumArtwork(ctx, coverID, bytes.NewReader([]byte("cover")))
+	if err != nil {
+		t.Fatalf("saving album artwork: %s", err)
+	}
+
+	if err := lib.saveAlbumArtworkNotFound(unknownID); err != nil {
+		t.Fatalf("saving artwork not found: %s", err)
+	}
+
+	report, err := lib.Report(ctx)
+	if err != nil {
+		t.Fatalf("generating report: %s", err)
+	}
+
+	if len(report.AlbumsWithoutArtwork) != 2 {
+		t.Fatalf("expected two albums without artwork but got %+v",
+			report.AlbumsWithoutArtwork)
+	}
+	for _, album := range report.AlbumsWithoutArtwork {
+		switch album.ID {
+		case gapsID:
+			if album.ArtworkSearchedAt != 0 {
+				t.Errorf("artwork for album `Gaps` has never been searched for")
+			}
+		case unknownID:
+			if album.ArtworkSearchedAt == 0 {
+				t.Errorf("expected search time for album `Nameless` artwork")
+			}
+			if album.FSPath != unknownDir {
+				t.Errorf("expected path `%s` but got `%s`", unknownDir, album.FSPath)
+			}
+		default:
+			t.Errorf("unexpected album without artwork: %+v", album)
+		}
+	}
+
+	if len(report.UnknownLabelTracks) != 1 {
+		t.Fatalf("expected one track with unknown label but got %+v",
+			report.UnknownLabelTracks)
+	}
+	unknownTrack := report.UnknownLabelTracks[0]
+	if unknownTrack.Artist != UnknownLabel || unknownTrack.Title != "Who" {
+		t.Errorf("unexpected track with unknown label: %+v", unknownTrack)
+	}
+	if expected := filepath.Join(unknownDir, "who.mp3"); unknownTrack.FSPath != expected {
+		t.Errorf("expected track path `%s` but got `%s`", expected, unknownTrack.FSPath)
+	}
+
+	expectedGaps := []ReportTrackGaps{
+		{
+			ReportAlbum: ReportAlbum{
+				ID:     gapsID,
+				Name:   "Gaps",
+				FSPath: gapsDir,
+			},
+			MissingNumbers: []int64{3},
+		},
+	}
+	if !reflect.DeepEqual(expectedGaps, report.AlbumsWithTrackGaps) {
+		t.Errorf("expected albums with gaps %+v but got %+v",
+			expectedGaps, report.AlbumsWithTrackGaps)
+	}
+}
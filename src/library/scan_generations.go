@@ -0,0 +1,153 @@
+package library
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// seenBatchSize is the number of files which are marked as seen by a scan with a
+// single database job.
+const seenBatchSize = 500
+
+// startScanGeneration starts a new scan generation for the library directory
+// root. It returns the new generation.
+func (lib *LocalLibrary) startScanGeneration(root string) (int64, error) {
+	var generation int64
+	work := func(db *sql.DB) error {
+		_, err := db.Exec(`
+			UPDATE libraries
+			SET scan_generation = scan_generation + 1
+			WHERE path = ?
+		`, root)
+		if err != nil {
+			return err
+		}
+
+		return db.QueryRow(`
+			SELECT scan_generation
+			FROM libraries
+			WHERE path = ?
+		`, root).Scan(&generation)
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return 0, fmt.Errorf("starting scan generation of %s: %w", root, err)
+	}
+	return generation, nil
+}
+
+// completeScanGeneration records that the scan with generation walked the whole
+// library directory root. Nothing is recorded when another scan of root was
+// started in the meantime.
+func (lib *LocalLibrary) completeScanGeneration(root string, generation int64) error {
+	work := func(db *sql.DB) error {
+		_, err := db.Exec(`
+			UPDATE libraries
+			SET completed_generation = ?
+			WHERE path = ? AND scan_generation = ?
+		`, generation, root, generation)
+		return err
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return fmt.Errorf("completing scan generation of %s: %w", root, err)
+	}
+	return nil
+}
+
+// completedScanGenerations returns the generations of the last completed scans
+// by library directory. Only enabled directories whose last started scan was
+// completed are returned. The rest were not scanned or their scans were
+// interrupted or are still running.
+func (lib *LocalLibrary) completedScanGenerations() (map[string]int64, error) {
+	generations := make(map[string]int64)
+	work := func(db *sql.DB) error {
+		rows, err := db.Query(`
+			SELECT path, completed_generation
+			FROM libraries
+			WHERE
+				disabled = 0 AND
+				completed_generation = scan_generation
+		`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				root       string
+				generation int64
+			)
+			if err := rows.Scan(&root, &generation); err != nil {
+				return err
+			}
+			generations[root] = generation
+		}
+		return rows.Err()
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return nil, fmt.Errorf("getting scan generations: %w", err)
+	}
+	return generations, nil
+}
+
+// markSeen sets the current scan generation of their library directory to the
+// tracks of the files at paths. It is for files which are in the library already
+// and were found by a scan. Tracks from CUE sheets are marked when their sheets
+// are added again.
+func (lib *LocalLibrary) markSeen(paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+
+	roots := make([]string, len(paths))
+	for ind, path := range paths {
+		roots[ind], _ = lib.libraryRootOf(path)
+	}
+
+	work := func(db *sql.DB) error {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		stmt, err := tx.Prepare(`
+			UPDATE tracks
+			SET scan_generation = (
+				SELECT scan_generation FROM libraries WHERE path = ?
+			)
+			WHERE fs_path = ? AND cue_sheet IS NULL
+		`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for ind, path := range paths {
+			if roots[ind] == "" {
+				continue
+			}
+			if _, err := stmt.Exec(roots[ind], path); err != nil {
+				return err
+			}
+		}
+
+		return tx.Commit()
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		log.Printf("Error marking %d files as seen: %s", len(paths), err)
+	}
+}
+
+// scanGenerationRoot returns the library directory whose scan generation is
+// stored in the track of the file at path. It is NULL for files which are in no
+// library directory.
+func (lib *LocalLibrary) scanGenerationRoot(path string) sql.NullString {
+	root, ok := lib.libraryRootOf(path)
+	return sql.NullString{String: root, Valid: ok}
+}
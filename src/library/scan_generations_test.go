@@ -0,0 +1,106 @@
+package library
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ironsmile/euterpe/src/helpers"
+)
+
+// TestScanGenerationsCleanup checks that the clean-up removes the tracks whose
+// files were not seen by the last completed scan without looking for them on the
+// disk and that it checks the files on the disk when the last scan of their
+// library directory was not completed.
+func TestScanGenerationsCleanup(t *testing.T) {
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+	defer func() { _ = lib.Truncate() }()
+	lib.DisableWatching()
+
+	projRoot, err := helpers.ProjectRoot()
+	if err != nil {
+		t.Fatalf("Was not able to find test_files directory: %s", err)
+	}
+	testMp3, err := os.ReadFile(
+		filepath.Join(projRoot, "test_files", "more_mp3s", "test_file_added.mp3"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	kept := filepath.Join(root, "kept.mp3")
+	removed := filepath.Join(root, "removed.mp3")
+	for _, file := range []string{kept, removed} {
+		if err := os.WriteFile(file, testMp3, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lib.AddLibraryPath(root)
+	lib.Scan()
+
+	generations, err := lib.completedScanGenerations()
+	if err != nil {
+		t.Fatalf("getting scan generations: %s", err)
+	}
+	if generations[root] != 1 {
+		t.Fatalf("expected completed scan generation 1 for %s but got %v",
+			root, generations)
+	}
+
+	// Files seen by the completed scan are not looked for on the disk.
+	lib.fs = fstest.MapFS{}
+	lib.cleanupTracks()
+	lib.fs = &osFS{}
+
+	for _, file := range []string{kept, removed} {
+		if !lib.MediaExistsInLibrary(file) {
+			t.Fatalf("%s seen by the last scan was removed by the clean-up", file)
+		}
+	}
+
+	if err := os.Remove(removed); err != nil {
+		t.Fatal(err)
+	}
+	lib.Scan()
+
+	if lib.MediaExistsInLibrary(removed) {
+		t.Errorf("the file not seen by the last scan is still in the library")
+	}
+	if !lib.MediaExistsInLibrary(kept) {
+		t.Errorf("the file seen by the last scan was removed from the library")
+	}
+
+	// Scans which were not completed leave the clean-up to the disk checks.
+	if _, err := lib.startScanGeneration(root); err != nil {
+		t.Fatalf("starting scan generation: %s", err)
+	}
+	generations, err = lib.completedScanGenerations()
+	if err != nil {
+		t.Fatalf("getting scan generations: %s", err)
+	}
+	if _, ok := generations[root]; ok {
+		t.Errorf("expected no completed scan generation for the interrupted scan")
+	}
+
+	if err := os.Remove(kept); err != nil {
+		t.Fatal(err)
+	}
+	lib.cleanupTracks()
+
+	if lib.MediaExistsInLibrary(kept) {
+		t.Errorf("the missing file was not removed after an interrupted scan")
+	}
+}
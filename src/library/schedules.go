@@ -0,0 +1,113 @@
+package library
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ScheduledJob is a library job which could be run periodically.
+type ScheduledJob string
+
+const (
+	// ScheduledScan scans the library directories for new files and then
+	// cleans up the database.
+	ScheduledScan ScheduledJob = "scan"
+
+	// ScheduledRescan reads again the metadata of all files in the library.
+	ScheduledRescan ScheduledJob = "rescan"
+
+	// ScheduledCleanup removes from the database everything which is no longer
+	// in the library directories.
+	ScheduledCleanup ScheduledJob = "cleanup"
+)
+
+// Schedule returns the times at which a scheduled job runs.
+type Schedule interface {
+	// Next returns the first time after `after` at which the job must run. The
+	// zero time means that it must not run any more.
+	Next(after time.Time) time.Time
+}
+
+// scheduledJobs makes sure only one scheduled job runs at a time.
+type scheduledJobs struct {
+	sync.Mutex
+}
+
+// ScheduleJob runs the job at the times of `when` until the library is closed.
+// Scheduled jobs do not run at the same time as other scheduled jobs. They also
+// wait for the changes in the library directories which are being processed at
+// the moment. New changes wait for them to finish. Scheduled scans do not wait
+// for ScanConfig.InitialWait which is only for the scan on start up.
+func (lib *LocalLibrary) ScheduleJob(job ScheduledJob, when Schedule) error {
+	var run func()
+
+	switch job {
+	case ScheduledScan:
+		run = func() {
+			lib.withoutWatchEvents(lib.scanAll)
+		}
+	case ScheduledRescan:
+		run = func() {
+			lib.withoutWatchEvents(func() {
+				if err := lib.Rescan(lib.ctx); err != nil {
+					log.Printf("Scheduled rescan error: %s\n", err)
+				}
+			})
+		}
+	case ScheduledCleanup:
+		run = func() {
+			lib.withoutWatchEvents(lib.cleanUpDatabase)
+		}
+	default:
+		return fmt.Errorf("unknown library job %q", job)
+	}
+
+	go lib.runScheduledJob(job, when, run)
+	return nil
+}
+
+// runScheduledJob runs the job every time `when` comes.
+func (lib *LocalLibrary) runScheduledJob(
+	job ScheduledJob,
+	when Schedule,
+	run func(),
+) {
+	for {
+		next := when.Next(time.Now())
+		if next.IsZero() {
+			log.Printf("Scheduled library %s will not run any more\n", job)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-lib.ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		lib.schedules.Lock()
+		log.Printf("Starting scheduled library %s\n", job)
+		start := time.Now()
+		run()
+		log.Printf("Scheduled library %s took %s\n", job, time.Since(start))
+		lib.schedules.Unlock()
+	}
+}
+
+// withoutWatchEvents runs `work` once the changes in the library directories
+// and the scans of directories which are processed at the moment are done.
+// Changes which come in the meantime are processed after `work` is done.
+func (lib *LocalLibrary) withoutWatchEvents(work func()) {
+	lib.watchEvents.busy.Lock()
+	defer lib.watchEvents.busy.Unlock()
+
+	lib.waitScanLock.RLock()
+	lib.walkWG.Wait()
+	lib.waitScanLock.RUnlock()
+
+	work()
+}
@@ -0,0 +1,147 @@
+package library
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/helpers"
+)
+
+// onceSchedule is a Schedule which comes once at a certain time.
+type onceSchedule struct {
+	sync.Mutex
+
+	at    time.Time
+	calls int
+}
+
+func (s *onceSchedule) Next(after time.Time) time.Time {
+	s.Lock()
+	defer s.Unlock()
+
+	s.calls++
+	if s.calls > 1 {
+		return time.Time{}
+	}
+	return s.at
+}
+
+func (s *onceSchedule) callsCount() int {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.calls
+}
+
+// TestScheduledCleanup checks that a scheduled clean-up removes tracks which are
+// no longer on disk at its time.
+func TestScheduledCleanup(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+	defer func() { _ = lib.Truncate() }()
+
+	missingFile := filepath.Join(t.TempDir(), "missing.mp3")
+	media := MockMedia{
+		artist: "Testy Testov",
+		album:  "The Test Strikes Back",
+		title:  "One Final Bug",
+		track:  1,
+		length: 334,
+	}
+	if err := lib.insertMediaIntoDatabase(&media, missingFile); err != nil {
+		t.Fatalf("inserting media: %s", err)
+	}
+
+	if err := lib.ScheduleJob("defragment", &onceSchedule{}); err == nil {
+		t.Errorf("expected an error for an unknown job")
+	}
+
+	when := &onceSchedule{at: time.Now().Add(50 * time.Millisecond)}
+	if err := lib.ScheduleJob(ScheduledCleanup, when); err != nil {
+		t.Fatalf("scheduling clean-up: %s", err)
+	}
+
+	if !lib.MediaExistsInLibrary(missingFile) {
+		t.Fatalf("expected the track to be there before the clean-up")
+	}
+
+	// The schedule is asked for the next time once the job is done.
+	for when.callsCount() < 2 {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("the scheduled clean-up did not run")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	if lib.MediaExistsInLibrary(missingFile) {
+		t.Errorf("expected the track to be removed by the scheduled clean-up")
+	}
+}
+
+// TestScheduledScan checks that a scheduled scan adds the new files to the library
+// without waiting for the initial wait of the scan on start up.
+func TestScheduledScan(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+	defer func() { _ = lib.Truncate() }()
+	lib.DisableWatching()
+
+	projRoot, err := helpers.ProjectRoot()
+	if err != nil {
+		t.Fatalf("Was not able to find test_files directory: %s", err)
+	}
+	testMp3, err := os.ReadFile(
+		filepath.Join(projRoot, "test_files", "more_mp3s", "test_file_added.mp3"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	newFile := filepath.Join(root, "new.mp3")
+	if err := os.WriteFile(newFile, testMp3, 0600); err != nil {
+		t.Fatal(err)
+	}
+	lib.AddLibraryPath(root)
+	lib.ScanConfig.InitialWait = time.Hour
+
+	when := &onceSchedule{at: time.Now().Add(50 * time.Millisecond)}
+	if err := lib.ScheduleJob(ScheduledScan, when); err != nil {
+		t.Fatalf("scheduling scan: %s", err)
+	}
+
+	for when.callsCount() < 2 {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("the scheduled scan did not finish")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	if !lib.MediaExistsInLibrary(newFile) {
+		t.Errorf("expected the new file to be added by the scheduled scan")
+	}
+}
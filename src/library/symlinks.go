@@ -0,0 +1,263 @@
+package library
+
+import (
+	"database/sql"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// followAllSymlinks is the value in the configuration for following symbolic
+// links in all library directories.
+const followAllSymlinks = "*"
+
+// fileIdentity identifies a file no matter by which path it is reached.
+type fileIdentity struct {
+	device uint64
+	inode  uint64
+
+	// path is used where there are no inodes.
+	path string
+}
+
+// walkedFiles remembers the files and directories which were scanned and the
+// paths by which they were reached. It is used for scanning every file only once
+// even when it could be reached by more than one path because of links. They
+// are remembered only while there are walks of the library directories. Files
+// added after that are checked against the library with sameFileInLibrary.
+type walkedFiles struct {
+	sync.Mutex
+
+	paths map[fileIdentity]string
+
+	// walks is the number of walks at the moment.
+	walks int
+}
+
+// begin records that a walk started.
+func (wf *walkedFiles) begin() {
+	wf.Lock()
+	defer wf.Unlock()
+
+	wf.walks++
+}
+
+// end records that a walk ended. The scanned files are forgotten once all walks
+// have ended.
+func (wf *walkedFiles) end() {
+	wf.Lock()
+	defer wf.Unlock()
+
+	wf.walks--
+	if wf.walks <= 0 {
+		wf.walks = 0
+		wf.paths = nil
+	}
+}
+
+// claim records that the file at path is scanned. It returns false when the
+// same file was already scanned by another path which still leads to it and is
+// before path lexically. Files are in the library by the first of their paths
+// no matter in which order the paths are walked.
+func (wf *walkedFiles) claim(path string, info fs.FileInfo) bool {
+	id, ok := fileIdentityOf(path, info)
+	if !ok {
+		return true
+	}
+
+	wf.Lock()
+	defer wf.Unlock()
+
+	if wf.paths == nil {
+		wf.paths = make(map[fileIdentity]string)
+	}
+
+	claimed, found := wf.paths[id]
+	if found && claimed < path {
+		st, err := os.Stat(claimed)
+		if err == nil && os.SameFile(st, info) {
+			return false
+		}
+	}
+
+	wf.paths[id] = path
+	return true
+}
+
+// sameFileInLibrary returns the track by which the file at filePath is in the
+// library already. This is the case for files which could be reached by more
+// than one path because of links. Only the files of tracks with the same
+// fingerprint fp are compared with it.
+func (lib *LocalLibrary) sameFileInLibrary(filePath, fp string) (removedTrack, bool) {
+	st, err := os.Stat(filePath)
+	if err != nil {
+		return removedTrack{}, false
+	}
+
+	var tracks []removedTrack
+	work := func(db *sql.DB) error {
+		rows, err := db.Query(`
+			SELECT
+				id,
+				fs_path
+			FROM
+				tracks
+			WHERE
+				fingerprint = ? AND
+				fs_path != ? AND
+				cue_sheet IS NULL
+		`, fp, filePath)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var track removedTrack
+			if err := rows.Scan(&track.id, &track.fsPath); err != nil {
+				return err
+			}
+			tracks = append(tracks, track)
+		}
+		return rows.Err()
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		log.Printf("Error finding the files with the fingerprint of %s: %s", filePath, err)
+		return removedTrack{}, false
+	}
+
+	for _, track := range tracks {
+		otherSt, err := os.Stat(track.fsPath)
+		if err == nil && os.SameFile(st, otherSt) {
+			return track, true
+		}
+	}
+	return removedTrack{}, false
+}
+
+// followsSymlinks returns true when symbolic links to directories are followed
+// while scanning the library directory in which path is.
+func (lib *LocalLibrary) followsSymlinks(path string) bool {
+	root, ok := lib.libraryRootOf(filepath.Clean(path))
+	if !ok {
+		return false
+	}
+
+	for _, dir := range lib.ScanConfig.FollowSymlinks {
+		if dir == followAllSymlinks || filepath.Clean(dir) == root {
+			return true
+		}
+	}
+	return false
+}
+
+// walkLibraryPath walks the file tree at root similarly to filepath.Walk. The
+// difference is that files behind symbolic links are walked with the info of
+// their targets. Links whose targets are missing are skipped. And symbolic links to directories are descended into when
+// followSymlinks is set. Links to directories which are being walked at the
+// moment are skipped so that link loops come to an end.
+func walkLibraryPath(
+	root string,
+	followSymlinks bool,
+	walkFn filepath.WalkFunc,
+) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		return walkFn(root, nil, err)
+	}
+
+	walker := libraryWalker{
+		followSymlinks: followSymlinks,
+		walkFn:         walkFn,
+	}
+
+	err = walker.walk(root, info, nil)
+	if errors.Is(err, filepath.SkipDir) {
+		return nil
+	}
+	return err
+}
+
+// libraryWalker walks file trees for walkLibraryPath.
+type libraryWalker struct {
+	followSymlinks bool
+	walkFn         filepath.WalkFunc
+}
+
+// walk walks the file tree at path. parents are the identities of the
+// directories above path.
+func (lw *libraryWalker) walk(
+	path string,
+	info fs.FileInfo,
+	parents []fileIdentity,
+) error {
+	if !info.IsDir() {
+		return lw.walkFn(path, info, nil)
+	}
+
+	if id, ok := fileIdentityOf(path, info); ok {
+		for _, parent := range parents {
+			if parent == id {
+				log.Printf("Skipping symbolic link loop at %s\n", path)
+				return nil
+			}
+		}
+		parents = append(parents[:len(parents):len(parents)], id)
+	}
+
+	if err := lw.walkFn(path, info, nil); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return lw.walkFn(path, info, err)
+	}
+
+	// Symbolic links are walked last so that files which are reachable by their
+	// own paths are scanned by them.
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Type()&fs.ModeSymlink == 0 &&
+			entries[j].Type()&fs.ModeSymlink != 0
+	})
+
+	for _, entry := range entries {
+		name := filepath.Join(path, entry.Name())
+
+		entryInfo, err := entry.Info()
+		if err == nil && entryInfo.Mode()&fs.ModeSymlink != 0 {
+			entryInfo, err = os.Stat(name)
+			if err != nil {
+				// Broken links are skipped as filepath.Walk does.
+				continue
+			}
+			if entryInfo.IsDir() && !lw.followSymlinks {
+				continue
+			}
+		}
+
+		if err != nil {
+			err = lw.walkFn(name, entryInfo, err)
+			if err != nil && !errors.Is(err, filepath.SkipDir) {
+				return err
+			}
+			continue
+		}
+
+		err = lw.walk(name, entryInfo, parents)
+		if err == nil || (entryInfo.IsDir() && errors.Is(err, filepath.SkipDir)) {
+			continue
+		}
+		if errors.Is(err, filepath.SkipDir) {
+			// Skipping the rest of the directory as filepath.Walk does.
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package library
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/helpers"
+)
+
+// TestScanFollowingSymlinks checks that symbolic links to directories are followed
+// only when enabled, that link loops and broken links do not stop the scan and
+// that files reachable by more than one path are added once by the first of
+// their paths. Even when they are found by the file system watcher.
+func TestScanFollowingSymlinks(t *testing.T) {
+	projRoot, err := helpers.ProjectRoot()
+	if err != nil {
+		t.Fatalf("Was not able to find test_files directory: %s", err)
+	}
+	testMp3 := filepath.Join(projRoot, "test_files", "more_mp3s", "test_file_added.mp3")
+
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "library")
+	other := filepath.Join(tmpDir, "other")
+
+	for _, file := range []string{
+		filepath.Join(root, "albums", "song.mp3"),
+		filepath.Join(other, "song.mp3"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := copyFile(testMp3, file); err != nil {
+			t.Fatalf("copying test file: %s", err)
+		}
+	}
+
+	links := map[string]string{
+		filepath.Join(root, "again"):  filepath.Join(root, "albums"),
+		filepath.Join(root, "linked"): other,
+		filepath.Join(root, "loop"):   root,
+		filepath.Join(root, "broken"): filepath.Join(tmpDir, "missing"),
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("creating symbolic links is not possible: %s", err)
+		}
+	}
+
+	tests := []struct {
+		desc           string
+		followSymlinks []string
+		expected       map[string]bool
+	}{
+		{
+			desc: "not following",
+			expected: map[string]bool{
+				filepath.Join("albums", "song.mp3"): true,
+				filepath.Join("linked", "song.mp3"): false,
+			},
+		},
+		{
+			desc:           "following",
+			followSymlinks: []string{root},
+			expected: map[string]bool{
+				filepath.Join("albums", "song.mp3"):         false,
+				filepath.Join("again", "song.mp3"):          true,
+				filepath.Join("linked", "song.mp3"):         true,
+				filepath.Join("loop", "albums", "song.mp3"): false,
+				filepath.Join("loop", "linked", "song.mp3"): false,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		lib, err := NewLocalLibrary(
+			context.Background(),
+			SQLiteMemoryFile,
+			getTestMigrationFiles(),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := lib.Initialize(); err != nil {
+			t.Fatalf("Initializing library: %s", err)
+		}
+		lib.DisableWatching()
+
+		lib.ScanConfig.FollowSymlinks = test.followSymlinks
+		lib.AddLibraryPath(root)
+		lib.Scan()
+
+		for file, inLibrary := range test.expected {
+			found := lib.MediaExistsInLibrary(filepath.Join(root, file))
+			if found != inLibrary {
+				t.Errorf("%s: expected %s in library to be %t but it was %t",
+					test.desc, file, inLibrary, found)
+			}
+		}
+
+		generations, err := lib.completedScanGenerations()
+		if err != nil {
+			t.Fatalf("getting scan generations: %s", err)
+		}
+		if _, ok := generations[root]; !ok {
+			t.Errorf("%s: expected the scan to be completed", test.desc)
+		}
+
+		lib.walked.Lock()
+		remembered := len(lib.walked.paths)
+		lib.walked.Unlock()
+		if remembered != 0 {
+			t.Errorf("%s: expected no remembered files after the scan but got %d",
+				test.desc, remembered)
+		}
+
+		// Files found by the watcher are not added by a second path either.
+		// And they are kept by the first of their paths.
+		if test.followSymlinks != nil {
+			albums := filepath.Join(root, "albums", "song.mp3")
+			lib.handleWatchEvent(watchEvent{Name: albums, Op: watchCreate})
+			if lib.MediaExistsInLibrary(albums) {
+				t.Errorf("%s: the file was added again by the watcher", test.desc)
+			}
+		} else {
+			again := filepath.Join(root, "again", "song.mp3")
+			lib.handleWatchEvent(watchEvent{Name: again, Op: watchCreate})
+			if !lib.MediaExistsInLibrary(again) ||
+				lib.MediaExistsInLibrary(filepath.Join(root, "albums", "song.mp3")) {
+				t.Errorf("%s: the file was not moved to its first path", test.desc)
+			}
+			if tracks := lib.getTableSize("tracks"); tracks != 1 {
+				t.Errorf("%s: expected one track but found %d", test.desc, tracks)
+			}
+		}
+
+		_ = lib.Truncate()
+	}
+}
@@ -0,0 +1,404 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	taglib "github.com/wtolson/go-taglib"
+)
+
+var (
+	// ErrInvalidTagValue is returned when a metadata update has an empty or
+	// otherwise unusable value for some of its fields.
+	ErrInvalidTagValue = errors.New("invalid tag value")
+
+	// ErrTagsNotWritable is returned when the metadata of a track does not come
+	// from the tags of its media file. Such is the case for tracks described by
+	// CUE sheets.
+	ErrTagsNotWritable = errors.New("tags are not stored in the media file")
+)
+
+//counterfeiter:generate . TagEditor
+
+// TagEditor is an interface for changing the metadata of tracks and albums. The
+// changes are written in the tags of the media files and the library keeps the
+// IDs of the changed tracks.
+type TagEditor interface {
+	// UpdateTrack changes the metadata of a single track by its ID. It returns the
+	// track with its new metadata.
+	UpdateTrack(
+		ctx context.Context,
+		trackID int64,
+		update TrackUpdate,
+	) (SearchResult, error)
+
+	// UpdateAlbum changes the metadata of all tracks in an album by its ID. It
+	// returns the tracks of the album after the change.
+	UpdateAlbum(
+		ctx context.Context,
+		albumID int64,
+		update AlbumUpdate,
+	) ([]SearchResult, error)
+}
+
+// TrackUpdate describes a change in the metadata of a track. Only the non-nil
+// fields are changed.
+type TrackUpdate struct {
+	Title       *string `json:"title,omitempty"`
+	Artist      *string `json:"artist,omitempty"`
+	Album       *string `json:"album,omitempty"`
+	TrackNumber *int64  `json:"track,omitempty"`
+}
+
+// AlbumUpdate describes a change in the metadata of all tracks in an album. Only
+// the non-nil fields are changed.
+type AlbumUpdate struct {
+	Name   *string `json:"album,omitempty"`
+	Artist *string `json:"artist,omitempty"`
+}
+
+// editedTrack is a track which is about to have its tags changed.
+type editedTrack struct {
+	id       int64
+	fsPath   string
+	cueSheet sql.NullString
+}
+
+// UpdateTrack implements the TagEditor interface. The database is changed before
+// the media file so that the file system watcher finds the track already up to
+// date when it notices the file was modified.
+func (lib *LocalLibrary) UpdateTrack(
+	ctx context.Context,
+	trackID int64,
+	update TrackUpdate,
+) (SearchResult, error) {
+	if err := validateTrackUpdate(&update); err != nil {
+		return SearchResult{}, err
+	}
+
+	tracks, err := lib.editedTracks(ctx, "id", trackID)
+	if err != nil {
+		return SearchResult{}, err
+	}
+	if len(tracks) == 0 {
+		return SearchResult{}, ErrTrackNotFound
+	}
+	track := tracks[0]
+	if track.cueSheet.Valid {
+		return SearchResult{}, ErrTagsNotWritable
+	}
+
+	var (
+		setters       []string
+		args          []interface{}
+		trackAlbumDir = albumDir(track.fsPath)
+	)
+
+	if update.Title != nil {
+		setters = append(setters, "name = ?")
+		args = append(args, *update.Title)
+	}
+	if update.TrackNumber != nil {
+		setters = append(setters, "number = ?")
+		args = append(args, *update.TrackNumber)
+	}
+	if update.Artist != nil {
+		artistID, err := lib.setArtistID(*update.Artist)
+		if err != nil {
+			return SearchResult{}, fmt.Errorf("setting artist: %w", err)
+		}
+		setters = append(setters, "artist_id = ?")
+		args = append(args, artistID)
+	}
+	if update.Album != nil {
+		albumID, err := lib.setAlbumID(*update.Album, trackAlbumDir)
+		if err != nil {
+			return SearchResult{}, fmt.Errorf("setting album: %w", err)
+		}
+		setters = append(setters, "album_id = ?")
+		args = append(args, albumID)
+	}
+
+	if len(setters) > 0 {
+		albums := lib.albumsOfPaths(track.fsPath)
+		work := func(db *sql.DB) error {
+			query := fmt.Sprintf(
+				"UPDATE tracks SET %s WHERE id = ?",
+				strings.Join(setters, ", "),
+			)
+			_, err := db.ExecContext(ctx, query, append(args, trackID)...)
+			return err
+		}
+		if err := lib.executeDBJobAndWait(work); err != nil {
+			return SearchResult{}, fmt.Errorf("updating track: %w", err)
+		}
+
+		if err := writeTags(track.fsPath, update); err != nil {
+			lib.refreshMedia(track.fsPath)
+			return SearchResult{}, err
+		}
+		lib.groupEditionsOf(append(albums, lib.albumsOfPaths(track.fsPath)...))
+	}
+
+	return lib.getTrack(trackID)
+}
+
+// UpdateAlbum implements the TagEditor interface. The album keeps its ID unless
+// it is renamed to an album which already exists in the same directory. Then its
+// tracks are moved into the other album.
+func (lib *LocalLibrary) UpdateAlbum(
+	ctx context.Context,
+	albumID int64,
+	update AlbumUpdate,
+) ([]SearchResult, error) {
+	if err := validateTrackUpdate(&TrackUpdate{
+		Album:  update.Name,
+		Artist: update.Artist,
+	}); err != nil {
+		return nil, err
+	}
+
+	albumPath, err := lib.GetAlbumFSPathByID(albumID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name == nil && update.Artist == nil {
+		return lib.GetAlbumFiles(albumID), nil
+	}
+
+	tracks, err := lib.editedTracks(ctx, "album_id", albumID)
+	if err != nil {
+		return nil, err
+	}
+	for _, track := range tracks {
+		if track.cueSheet.Valid {
+			return nil, ErrTagsNotWritable
+		}
+	}
+
+	if update.Artist != nil {
+		artistID, err := lib.setArtistID(*update.Artist)
+		if err != nil {
+			return nil, fmt.Errorf("setting artist: %w", err)
+		}
+
+		work := func(db *sql.DB) error {
+			_, err := db.ExecContext(ctx, `
+				UPDATE tracks
+				SET artist_id = ?
+				WHERE album_id = ?
+			`, artistID, albumID)
+			return err
+		}
+		if err := lib.executeDBJobAndWait(work); err != nil {
+			return nil, fmt.Errorf("updating album artist: %w", err)
+		}
+	}
+
+	albums := []int64{albumID}
+	if update.Name != nil {
+		albumID, err = lib.renameAlbum(ctx, albumID, *update.Name, albumPath)
+		if err != nil {
+			return nil, fmt.Errorf("renaming album: %w", err)
+		}
+	}
+
+	trackUpdate := TrackUpdate{
+		Album:  update.Name,
+		Artist: update.Artist,
+	}
+	var writeErr error
+	for _, track := range tracks {
+		if err := writeTags(track.fsPath, trackUpdate); err != nil {
+			lib.refreshMedia(track.fsPath)
+			albums = append(albums, lib.albumsOfPaths(track.fsPath)...)
+			if writeErr == nil {
+				writeErr = err
+			}
+		}
+	}
+	lib.groupEditionsOf(append(albums, albumID))
+	if writeErr != nil {
+		return nil, writeErr
+	}
+
+	return lib.GetAlbumFiles(albumID), nil
+}
+
+// renameAlbum changes the name of an album in place. When there is another
+// album with this name in the same directory then the tracks are moved into it
+// instead. Returns the ID of the album which has the tracks after the rename.
+func (lib *LocalLibrary) renameAlbum(
+	ctx context.Context,
+	albumID int64,
+	name, fsPath string,
+) (int64, error) {
+	existingID, err := lib.GetAlbumID(name, fsPath)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+	merge := err == nil
+
+	if merge && existingID == albumID {
+		return albumID, nil
+	}
+
+	work := func(db *sql.DB) error {
+		if merge {
+			_, err := db.ExecContext(ctx, `
+				UPDATE tracks
+				SET album_id = ?
+				WHERE album_id = ?
+			`, existingID, albumID)
+			return err
+		}
+
+		_, err := db.ExecContext(ctx, `
+			UPDATE albums
+			SET name = ?
+			WHERE id = ?
+		`, name, albumID)
+		return err
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return 0, err
+	}
+
+	if merge {
+		return existingID, nil
+	}
+	return albumID, nil
+}
+
+// editedTracks returns the tracks for which `column` (either "id" or
+// "album_id") is equal to id.
+func (lib *LocalLibrary) editedTracks(
+	ctx context.Context,
+	column string,
+	id int64,
+) ([]editedTrack, error) {
+	var tracks []editedTrack
+
+	work := func(db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+			SELECT
+				id,
+				fs_path,
+				cue_sheet
+			FROM
+				tracks
+			WHERE
+				%s = ?
+		`, column), id)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var track editedTrack
+			if err := rows.Scan(&track.id, &track.fsPath, &track.cueSheet); err != nil {
+				return err
+			}
+			tracks = append(tracks, track)
+		}
+
+		return rows.Err()
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return nil, fmt.Errorf("getting tracks: %w", err)
+	}
+
+	return tracks, nil
+}
+
+// getTrack returns a single track by its ID.
+func (lib *LocalLibrary) getTrack(trackID int64) (SearchResult, error) {
+	var albumID int64
+
+	work := func(db *sql.DB) error {
+		return db.QueryRow(`
+			SELECT album_id
+			FROM tracks
+			WHERE id = ?
+		`, trackID).Scan(&albumID)
+	}
+	err := lib.executeDBJobAndWait(work)
+	if errors.Is(err, sql.ErrNoRows) {
+		return SearchResult{}, ErrTrackNotFound
+	} else if err != nil {
+		return SearchResult{}, err
+	}
+
+	for _, track := range lib.GetAlbumFiles(albumID) {
+		if track.ID == trackID {
+			return track, nil
+		}
+	}
+
+	return SearchResult{}, ErrTrackNotFound
+}
+
+// refreshMedia makes the database record of a media file match its tags again.
+// It is used when writing tags in the file failed after the database has been
+// changed already.
+func (lib *LocalLibrary) refreshMedia(fsPath string) {
+	if err := lib.updateMedia(fsPath); err != nil {
+		log.Printf("Error restoring the metadata of %s: %s", fsPath, err)
+	}
+}
+
+// validateTrackUpdate trims the text fields of update and makes sure none of
+// them is left empty.
+func validateTrackUpdate(update *TrackUpdate) error {
+	for _, field := range []*string{update.Title, update.Artist, update.Album} {
+		if field == nil {
+			continue
+		}
+		*field = strings.TrimSpace(*field)
+		if *field == "" {
+			return fmt.Errorf("%w: empty value", ErrInvalidTagValue)
+		}
+	}
+
+	if update.TrackNumber != nil && *update.TrackNumber < 0 {
+		return fmt.Errorf("%w: negative track number", ErrInvalidTagValue)
+	}
+
+	return nil
+}
+
+// writeTags saves the metadata from update in the tags of the media file at
+// fsPath.
+func writeTags(fsPath string, update TrackUpdate) error {
+	file, err := taglib.Read(fsPath)
+	if err != nil {
+		return fmt.Errorf("reading tags of %s: %w", fsPath, err)
+	}
+	defer file.Close()
+
+	if update.Title != nil {
+		file.SetTitle(*update.Title)
+	}
+	if update.Artist != nil {
+		file.SetArtist(*update.Artist)
+	}
+	if update.Album != nil {
+		file.SetAlbum(*update.Album)
+	}
+	if update.TrackNumber != nil {
+		file.SetTrack(int(*update.TrackNumber))
+	}
+
+	if err := file.Save(); err != nil {
+		return fmt.Errorf("writing tags of %s: %w", fsPath, err)
+	}
+
+	return nil
+}
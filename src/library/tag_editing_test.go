@@ -0,0 +1,166 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/cue"
+	"github.com/ironsmile/euterpe/src/helpers"
+)
+
+// TestTagEditing checks that editing the metadata of tracks and albums changes
+// the library in place and keeps the IDs.
+func TestTagEditing(t *testing.T) {
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	projRoot, err := helpers.ProjectRoot()
+	if err != nil {
+		t.Fatalf("Was not able to find test_files directory: %s", err)
+	}
+
+	albumDir := t.TempDir()
+	firstPath := filepath.Join(albumDir, "first.mp3")
+	secondPath := filepath.Join(albumDir, "second.mp3")
+
+	for src, dst := range map[string]string{
+		"test_file_one.mp3": firstPath,
+		"test_file_two.mp3": secondPath,
+	} {
+		err := copyFile(filepath.Join(projRoot, "test_files", "library", src), dst)
+		if err != nil {
+			t.Fatalf("copying test file: %s", err)
+		}
+	}
+
+	for path, media := range map[string]*MockMedia{
+		firstPath:  {artist: "Artist", album: "Abey Road", title: "Come Togther", track: 1},
+		secondPath: {artist: "Artist", album: "Abey Road", title: "Something", track: 2},
+	} {
+		if err := lib.insertMediaIntoDatabase(media, path); err != nil {
+			t.Fatalf("inserting media: %s", err)
+		}
+	}
+
+	albumID, err := lib.GetAlbumID("Abey Road", albumDir)
+	if err != nil {
+		t.Fatalf("getting album ID: %s", err)
+	}
+
+	tracks := lib.GetAlbumFiles(albumID)
+	if len(tracks) != 2 {
+		t.Fatalf("expected 2 tracks in the album but got %d", len(tracks))
+	}
+	trackID := tracks[0].ID
+
+	title := " Come Together "
+	number := int64(7)
+	artist := "The Beatles"
+	track, err := lib.UpdateTrack(ctx, trackID, TrackUpdate{
+		Title:       &title,
+		TrackNumber: &number,
+		Artist:      &artist,
+	})
+	if err != nil {
+		t.Fatalf("updating track: %s", err)
+	}
+
+	if track.ID != trackID {
+		t.Errorf("track ID changed from %d to %d", trackID, track.ID)
+	}
+	if track.Title != "Come Together" {
+		t.Errorf("expected title `Come Together` but got `%s`", track.Title)
+	}
+	if track.TrackNumber != number {
+		t.Errorf("expected track number %d but got %d", number, track.TrackNumber)
+	}
+	if track.Artist != artist {
+		t.Errorf("expected artist `%s` but got `%s`", artist, track.Artist)
+	}
+	if track.AlbumID != albumID {
+		t.Errorf("expected album ID %d but got %d", albumID, track.AlbumID)
+	}
+
+	albumName := "Abbey Road"
+	tracks, err = lib.UpdateAlbum(ctx, albumID, AlbumUpdate{Name: &albumName})
+	if err != nil {
+		t.Fatalf("updating album: %s", err)
+	}
+	if len(tracks) != 2 {
+		t.Fatalf("expected 2 tracks after the update but got %d", len(tracks))
+	}
+	for _, track := range tracks {
+		if track.AlbumID != albumID {
+			t.Errorf("album ID changed from %d to %d", albumID, track.AlbumID)
+		}
+		if track.Album != albumName {
+			t.Errorf("expected album `%s` but got `%s`", albumName, track.Album)
+		}
+	}
+
+	// This is what the file system watcher does once it notices the change.
+	// Whatever the tags are the track must be updated in place.
+	if err := lib.updateMedia(firstPath); err != nil {
+		t.Fatalf("updating media: %s", err)
+	}
+	if path := lib.GetFilePath(trackID); path != firstPath {
+		t.Errorf("track %d has path `%s` after the update", trackID, path)
+	}
+
+	empty := " "
+	_, err = lib.UpdateTrack(ctx, trackID, TrackUpdate{Title: &empty})
+	if !errors.Is(err, ErrInvalidTagValue) {
+		t.Errorf("expected ErrInvalidTagValue for empty title but got %v", err)
+	}
+
+	_, err = lib.UpdateTrack(ctx, 9999, TrackUpdate{Title: &title})
+	if !errors.Is(err, ErrTrackNotFound) {
+		t.Errorf("expected ErrTrackNotFound but got %v", err)
+	}
+
+	_, err = lib.UpdateAlbum(ctx, 9999, AlbumUpdate{Name: &albumName})
+	if !errors.Is(err, ErrAlbumNotFound) {
+		t.Errorf("expected ErrAlbumNotFound but got %v", err)
+	}
+
+	// Tracks from CUE sheets have their metadata in the sheet.
+	liveDir := filepath.FromSlash("/music/live")
+	sheet := cue.Sheet{
+		Title: "Live",
+		Files: []cue.File{
+			{
+				Name:   "live.flac",
+				Tracks: []cue.Track{{Number: 1, Title: "Intro"}},
+			},
+		},
+	}
+	audio := MockMedia{artist: "Artist", title: "Live", length: time.Minute}
+	cueIDs, err := lib.insertCueFileIntoDatabase(
+		filepath.Join(liveDir, "live.cue"),
+		sheet,
+		sheet.Files[0],
+		&audio,
+		filepath.Join(liveDir, "live.flac"),
+	)
+	if err != nil {
+		t.Fatalf("inserting CUE sheet: %s", err)
+	}
+
+	_, err = lib.UpdateTrack(ctx, cueIDs[0], TrackUpdate{Title: &title})
+	if !errors.Is(err, ErrTagsNotWritable) {
+		t.Errorf("expected ErrTagsNotWritable for CUE track but got %v", err)
+	}
+}
@@ -0,0 +1,322 @@
+package library
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/fingerprint"
+	"github.com/ironsmile/euterpe/src/ziparchive"
+)
+
+// removedTrackTimeout is for how long the IDs of removed tracks are remembered.
+// A file with the same fingerprint found during this time is considered to be
+// the removed one which was moved or renamed and it gets its old ID back.
+const removedTrackTimeout = time.Minute
+
+// removedTrack is a track which was removed from the library recently.
+type removedTrack struct {
+	id          int64
+	fsPath      string
+	fingerprint string
+	removedAt   time.Time
+}
+
+// removedTracks keeps the recently removed tracks by their fingerprint. It is
+// used for keeping the IDs of tracks stable when their files are moved. The file
+// watcher sees moves as removing a file followed by creating another one.
+type removedTracks struct {
+	sync.Mutex
+
+	byFingerprint map[string][]removedTrack
+}
+
+// add remembers that the tracks were removed.
+func (rt *removedTracks) add(tracks []removedTrack) {
+	if len(tracks) == 0 {
+		return
+	}
+
+	rt.Lock()
+	defer rt.Unlock()
+
+	rt.expire()
+	if rt.byFingerprint == nil {
+		rt.byFingerprint = make(map[string][]removedTrack)
+	}
+
+	for _, track := range tracks {
+		fp := track.fingerprint
+		rt.byFingerprint[fp] = append(rt.byFingerprint[fp], track)
+	}
+}
+
+// take returns and forgets a removed track with fingerprint `fp`. The second
+// result is false when no such track was removed recently.
+func (rt *removedTracks) take(fp string) (removedTrack, bool) {
+	rt.Lock()
+	defer rt.Unlock()
+
+	rt.expire()
+
+	tracks := rt.byFingerprint[fp]
+	if len(tracks) == 0 {
+		return removedTrack{}, false
+	}
+
+	track := tracks[0]
+	if len(tracks) == 1 {
+		delete(rt.byFingerprint, fp)
+	} else {
+		rt.byFingerprint[fp] = tracks[1:]
+	}
+
+	return track, true
+}
+
+// expire forgets the tracks which were removed too long ago. Must be called
+// with the lock held.
+func (rt *removedTracks) expire() {
+	for fp, tracks := range rt.byFingerprint {
+		for len(tracks) > 0 && time.Since(tracks[0].removedAt) > removedTrackTimeout {
+			tracks = tracks[1:]
+		}
+
+		if len(tracks) == 0 {
+			delete(rt.byFingerprint, fp)
+		} else {
+			rt.byFingerprint[fp] = tracks
+		}
+	}
+}
+
+// fileFingerprint returns the fingerprint of the media file at `filePath`. An
+// empty string is returned when it could not be computed.
+func (lib *LocalLibrary) fileFingerprint(filePath string) string {
+	fp, err := lib.computeFingerprint(filePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Error computing fingerprint of %s: %s", filePath, err)
+	}
+
+	return fp
+}
+
+func (lib *LocalLibrary) computeFingerprint(filePath string) (string, error) {
+	var (
+		fh  fs.File
+		err error
+	)
+	if archivePath, name, ok := ziparchive.Split(filePath); ok {
+		// Files in archives are read from the archives themselves. They could
+		// not be opened as files in directories.
+		fh, err = ziparchive.Open(archivePath, name)
+	} else {
+		fh, err = lib.fs.Open(filePath)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer fh.Close()
+
+	st, err := fh.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	ra, ok := fh.(io.ReaderAt)
+	if !ok {
+		return "", errors.New("file does not support random access")
+	}
+
+	return fingerprint.Compute(ra, st.Size())
+}
+
+// claimMovedTrack is called before a file which is not in the library yet is
+// added to it. It finds out whether the file is one which was already in the
+// library but was moved or renamed.
+//
+// When there is a track with the same fingerprint whose file is missing it is
+// moved to `filePath` in place. Adding the file will then update it. Otherwise
+// when a track with this fingerprint was removed recently its ID is returned.
+// The new track must get this ID once it is added.
+func (lib *LocalLibrary) claimMovedTrack(filePath, fp string) (int64, error) {
+	var (
+		exists     bool
+		candidates []removedTrack
+	)
+	work := func(db *sql.DB) error {
+		var id int64
+		err := db.QueryRow(`
+			SELECT id FROM tracks WHERE fs_path = ? AND cue_start = 0
+		`, filePath).Scan(&id)
+		if err == nil {
+			exists = true
+			return nil
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		rows, err := db.Query(`
+			SELECT
+				id,
+				fs_path
+			FROM
+				tracks
+			WHERE
+				fingerprint = ? AND
+				cue_sheet IS NULL
+		`, fp)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var track removedTrack
+			if err := rows.Scan(&track.id, &track.fsPath); err != nil {
+				return err
+			}
+			candidates = append(candidates, track)
+		}
+
+		return rows.Err()
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return 0, err
+	}
+
+	if exists {
+		return 0, nil
+	}
+
+	for _, track := range candidates {
+		_, err := fs.Stat(lib.fs, track.fsPath)
+		if err == nil || !errors.Is(err, fs.ErrNotExist) {
+			// This is a copy of an existing file and not a moved one.
+			continue
+		}
+
+		if err := lib.moveTrack(track.id, track.fsPath, filePath); err != nil {
+			return 0, err
+		}
+
+		log.Printf("Track %d moved from %s to %s", track.id, track.fsPath, filePath)
+		return 0, nil
+	}
+
+	if track, ok := lib.removed.take(fp); ok {
+		if err := lib.moveTrackOverrides(track.fsPath, filePath); err != nil {
+			return 0, err
+		}
+
+		log.Printf("Track %d moved from %s to %s", track.id, track.fsPath, filePath)
+		return track.id, nil
+	}
+
+	return 0, nil
+}
+
+// moveTrack changes the file of the track with `trackID` from `oldPath` to
+// `newPath` together with its overrides.
+func (lib *LocalLibrary) moveTrack(trackID int64, oldPath, newPath string) error {
+	work := func(db *sql.DB) error {
+		_, err := db.Exec(`
+			UPDATE tracks SET fs_path = ? WHERE id = ?
+		`, newPath, trackID)
+		return err
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return err
+	}
+
+	return lib.moveTrackOverrides(oldPath, newPath)
+}
+
+// moveTrackOverrides makes the overrides for the file at `oldPath` apply to the
+// file at `newPath`. Overrides which the new file already has are kept.
+func (lib *LocalLibrary) moveTrackOverrides(oldPath, newPath string) error {
+	work := func(db *sql.DB) error {
+		_, err := db.Exec(`
+			UPDATE OR IGNORE tracks_overrides
+			SET fs_path = ?
+			WHERE fs_path = ? AND cue_start = 0
+		`, newPath, oldPath)
+		return err
+	}
+
+	return lib.executeDBJobAndWait(work)
+}
+
+// setTrackFingerprint stores the fingerprint of a track. When `reuseID` is not
+// zero the track is given this ID instead of its current one.
+func (lib *LocalLibrary) setTrackFingerprint(trackID, reuseID int64, fp string) (int64, error) {
+	work := func(db *sql.DB) error {
+		if reuseID != 0 && reuseID != trackID {
+			_, err := db.Exec(`
+				UPDATE tracks SET id = ? WHERE id = ?
+			`, reuseID, trackID)
+			if err != nil {
+				// Some other track got this ID in the meantime.
+				log.Printf("Error reusing ID %d for track %d: %s", reuseID, trackID, err)
+			} else {
+				trackID = reuseID
+			}
+		}
+
+		var fpValue sql.NullString
+		if fp != "" {
+			fpValue = sql.NullString{String: fp, Valid: true}
+		}
+
+		_, err := db.Exec(`
+			UPDATE tracks SET fingerprint = ? WHERE id = ?
+		`, fpValue, trackID)
+		return err
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return trackID, err
+	}
+
+	return trackID, nil
+}
+
+// rememberRemovedTracks is called with a database query which selects tracks
+// which are about to be removed. The ones with fingerprints are remembered so
+// that they keep their IDs if they turn out to be moved.
+func (lib *LocalLibrary) rememberRemovedTracks(db *sql.DB, query string, args ...interface{}) {
+	rows, err := db.Query(`
+		SELECT
+			id,
+			fs_path,
+			fingerprint
+		FROM
+			tracks
+		WHERE
+			fingerprint IS NOT NULL AND
+			cue_sheet IS NULL AND
+	`+query, args...)
+	if err != nil {
+		log.Printf("Error querying removed tracks: %s", err)
+		return
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	var removed []removedTrack
+	for rows.Next() {
+		track := removedTrack{removedAt: now}
+		err := rows.Scan(&track.id, &track.fsPath, &track.fingerprint)
+		if err != nil {
+			log.Printf("Error scanning removed track: %s", err)
+			return
+		}
+		removed = append(removed, track)
+	}
+
+	lib.removed.add(removed)
+}
@@ -0,0 +1,136 @@
+package library
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/helpers"
+)
+
+// TestMovedTracksKeepTheirIDs checks that tracks whose files were moved or renamed
+// keep their IDs and overrides while copies of files get new IDs.
+func TestMovedTracksKeepTheirIDs(t *testing.T) {
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	projRoot, err := helpers.ProjectRoot()
+	if err != nil {
+		t.Fatalf("Was not able to find test_files directory: %s", err)
+	}
+
+	tmpDir := t.TempDir()
+	firstPath := filepath.Join(tmpDir, "first.mp3")
+	err = copyFile(
+		filepath.Join(projRoot, "test_files", "more_mp3s", "test_file_added.mp3"),
+		firstPath,
+	)
+	if err != nil {
+		t.Fatalf("copying test file: %s", err)
+	}
+
+	if err := lib.AddMedia(firstPath); err != nil {
+		t.Fatalf("adding media: %s", err)
+	}
+
+	found := lib.Search("Added Song")
+	if len(found) != 1 {
+		t.Fatalf("expected one track but found %d", len(found))
+	}
+	trackID := found[0].ID
+
+	// Another track is added so that the moved one is not the last inserted.
+	// Otherwise it may get its ID back by accident.
+	other := MockMedia{
+		artist: "Other Artist",
+		album:  "Other Album",
+		title:  "Other Song",
+		track:  1,
+		length: 1000,
+	}
+	if err := lib.insertMediaIntoDatabase(&other, "/music/other.mp3"); err != nil {
+		t.Fatalf("adding other media: %s", err)
+	}
+
+	title := "Moved Song"
+	err = lib.SetTrackOverrides(ctx, trackID, MetadataOverrides{Title: &title})
+	if err != nil {
+		t.Fatalf("setting track overrides: %s", err)
+	}
+
+	assertMoved := func(desc, expectedPath string) {
+		t.Helper()
+
+		found := lib.Search(title)
+		if len(found) != 1 {
+			t.Fatalf("%s: expected one track but found %d", desc, len(found))
+		}
+		if found[0].ID != trackID {
+			t.Errorf("%s: track ID changed from %d to %d", desc, trackID, found[0].ID)
+		}
+		if found[0].Title != title {
+			t.Errorf("%s: overrides were lost, title is `%s`", desc, found[0].Title)
+		}
+		if fsPath := lib.GetFilePath(trackID); fsPath != expectedPath {
+			t.Errorf("%s: expected track in %s but it is in %s",
+				desc, expectedPath, fsPath)
+		}
+	}
+
+	// Renaming as seen by the file watcher. The track is removed and then
+	// the file is found under another name.
+	secondPath := filepath.Join(tmpDir, "second.mp3")
+	if err := os.Rename(firstPath, secondPath); err != nil {
+		t.Fatal(err)
+	}
+	lib.removeFile(firstPath)
+	if err := lib.AddMedia(secondPath); err != nil {
+		t.Fatalf("adding renamed media: %s", err)
+	}
+	assertMoved("after renaming", secondPath)
+
+	// Moving while nobody was looking. The file is found during scanning
+	// before the missing one is cleaned up.
+	if err := os.Mkdir(filepath.Join(tmpDir, "moved"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	thirdPath := filepath.Join(tmpDir, "moved", "third.mp3")
+	if err := os.Rename(secondPath, thirdPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := lib.AddMedia(thirdPath); err != nil {
+		t.Fatalf("adding moved media: %s", err)
+	}
+	assertMoved("after moving", thirdPath)
+
+	// A copy of the file is a different track.
+	copyPath := filepath.Join(tmpDir, "copy.mp3")
+	if err := copyFile(thirdPath, copyPath); err != nil {
+		t.Fatalf("copying media file: %s", err)
+	}
+	if err := lib.AddMedia(copyPath); err != nil {
+		t.Fatalf("adding copied media: %s", err)
+	}
+
+	found = lib.Search("Added Song")
+	if len(found) != 1 {
+		t.Fatalf("expected to find the copy but found %d tracks", len(found))
+	}
+	if found[0].ID == trackID {
+		t.Errorf("the copy has the same ID %d as the original", trackID)
+	}
+	if fsPath := lib.GetFilePath(trackID); fsPath != thirdPath {
+		t.Errorf("original track moved to %s after copying", fsPath)
+	}
+}
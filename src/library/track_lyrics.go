@@ -0,0 +1,312 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/lyrics"
+	"github.com/ironsmile/euterpe/src/tags"
+)
+
+// ErrLyricsNotFound is returned when no lyrics could be found for particular track.
+var ErrLyricsNotFound = errors.New("lyrics not found")
+
+// The possible sources of track lyrics.
+const (
+	// LyricsSourceTags is for lyrics found in the tags of the media file.
+	LyricsSourceTags = "tags"
+
+	// LyricsSourceLRC is for lyrics found in a .lrc file next to the media file.
+	LyricsSourceLRC = "lrc"
+
+	// LyricsSourceOnline is for lyrics found with the lyrics.Finder.
+	LyricsSourceOnline = "online"
+)
+
+//counterfeiter:generate . LyricsManager
+
+// LyricsManager is an interface for getting the lyrics of tracks.
+type LyricsManager interface {
+	// GetLyrics returns the lyrics for a particular track by its ID.
+	GetLyrics(ctx context.Context, trackID int64) (TrackLyrics, error)
+}
+
+// TrackLyrics are the lyrics of a track.
+type TrackLyrics struct {
+	TrackID int64 `json:"track_id"`
+
+	// Synced is true when every line has the time at which it is sung.
+	Synced bool `json:"synced"`
+
+	// Source is where the lyrics were found. One of the LyricsSource* constants.
+	Source string `json:"source"`
+
+	Lines []LyricsLine `json:"lines"`
+}
+
+// LyricsLine is a single line of lyrics.
+type LyricsLine struct {
+	// Time is the offset from the start of the track in milliseconds at which
+	// the line is sung. It is always 0 when the lyrics are not synced.
+	Time int64  `json:"time"`
+	Text string `json:"text"`
+}
+
+// SetLyricsFinder binds a particular lyrics.Finder to this library. It will be
+// used for finding lyrics for tracks which have none in their files.
+func (lib *LocalLibrary) SetLyricsFinder(lf lyrics.Finder) {
+	lib.lyricsFinder = lf
+}
+
+// GetLyrics implements the LyricsManager interface. Lyrics from the media files
+// are always preferred. When there are none they are searched for online once.
+// When nothing is found then no new search will be made for notFoundCacheTTL.
+func (lib *LocalLibrary) GetLyrics(
+	ctx context.Context,
+	trackID int64,
+) (TrackLyrics, error) {
+	var (
+		title      string
+		artistName sql.NullString
+		albumName  sql.NullString
+		duration   sql.NullInt64
+		text       sql.NullString
+		source     sql.NullString
+		updatedAt  sql.NullInt64
+	)
+
+	work := func(db *sql.DB) error {
+		err := db.QueryRowContext(ctx, `
+			SELECT
+				t.name,
+				ar.name,
+				al.name,
+				t.duration,
+				tl.lyrics,
+				tl.source,
+				tl.updated_at
+			FROM
+				tracks t
+				LEFT JOIN artists ar ON ar.id = t.artist_id
+				LEFT JOIN albums al ON al.id = t.album_id
+				LEFT JOIN tracks_lyrics tl ON tl.track_id = t.id
+			WHERE
+				t.id = ?
+		`, trackID).Scan(
+			&title,
+			&artistName,
+			&albumName,
+			&duration,
+			&text,
+			&source,
+			&updatedAt,
+		)
+		if err == sql.ErrNoRows {
+			return ErrTrackNotFound
+		}
+		return err
+	}
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		return TrackLyrics{}, err
+	}
+
+	if text.Valid && strings.TrimSpace(text.String) != "" {
+		return trackLyricsFromText(trackID, text.String, source.String), nil
+	}
+
+	lastUpdate := time.Unix(updatedAt.Int64, 0)
+	if updatedAt.Valid && time.Now().Before(lastUpdate.Add(notFoundCacheTTL)) {
+		return TrackLyrics{}, ErrLyricsNotFound
+	}
+
+	if lib.lyricsFinder == nil {
+		return TrackLyrics{}, ErrLyricsNotFound
+	}
+
+	found, err := lib.lyricsFinder.GetLyrics(
+		ctx,
+		artistName.String,
+		albumName.String,
+		title,
+		time.Duration(duration.Int64)*time.Millisecond,
+	)
+	if errors.Is(err, lyrics.ErrNotFound) {
+		if err := lib.saveTrackLyrics(trackID, "", LyricsSourceOnline); err != nil {
+			return TrackLyrics{}, err
+		}
+		return TrackLyrics{}, ErrLyricsNotFound
+	} else if err != nil {
+		return TrackLyrics{}, err
+	}
+
+	if err := lib.saveTrackLyrics(trackID, found, LyricsSourceOnline); err != nil {
+		return TrackLyrics{}, err
+	}
+
+	return trackLyricsFromText(trackID, found, LyricsSourceOnline), nil
+}
+
+// trackLyricsFromText parses the stored lyrics text of a track.
+func trackLyricsFromText(trackID int64, text, source string) TrackLyrics {
+	parsed := lyrics.Parse(text)
+
+	tl := TrackLyrics{
+		TrackID: trackID,
+		Synced:  parsed.Synced,
+		Source:  source,
+		Lines:   make([]LyricsLine, 0, len(parsed.Lines)),
+	}
+	for _, line := range parsed.Lines {
+		tl.Lines = append(tl.Lines, LyricsLine{
+			Time: line.Time.Milliseconds(),
+			Text: line.Text,
+		})
+	}
+
+	return tl
+}
+
+// saveTrackLyrics stores the lyrics for a track. An empty `text` means that no
+// lyrics were found.
+func (lib *LocalLibrary) saveTrackLyrics(trackID int64, text, source string) error {
+	var storedText sql.NullString
+	if text != "" {
+		storedText = sql.NullString{String: text, Valid: true}
+	}
+
+	return lib.executeDBJobAndWait(func(db *sql.DB) error {
+		_, err := db.Exec(`
+			INSERT INTO
+				tracks_lyrics (track_id, lyrics, source, updated_at)
+			VALUES
+				($1, $2, $3, $4)
+			ON CONFLICT (track_id) DO
+			UPDATE SET
+				lyrics = $2,
+				source = $3,
+				updated_at = $4
+		`, trackID, storedText, source, time.Now().Unix())
+		return err
+	})
+}
+
+// saveLocalLyrics stores the lyrics for a track from its .lrc sidecar file or
+// from `tagLyrics` which are the ones found in the tags of its media file. The
+// .lrc file is preferred since its lyrics are usually synchronised. When there
+// are neither then previously stored local lyrics are removed.
+func (lib *LocalLibrary) saveLocalLyrics(trackID int64, fsPath, tagLyrics string) error {
+	lrc, err := fs.ReadFile(lib.fs, lyricsSidecarPath(fsPath))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Error reading lyrics for %s: %s", fsPath, err)
+	}
+
+	if strings.TrimSpace(string(lrc)) != "" {
+		return lib.saveTrackLyrics(trackID, string(lrc), LyricsSourceLRC)
+	}
+
+	if strings.TrimSpace(tagLyrics) != "" {
+		return lib.saveTrackLyrics(trackID, tagLyrics, LyricsSourceTags)
+	}
+
+	return lib.executeDBJobAndWait(func(db *sql.DB) error {
+		_, err := db.Exec(`
+			DELETE FROM tracks_lyrics
+			WHERE
+				track_id = ? AND
+				source IN (?, ?)
+		`, trackID, LyricsSourceTags, LyricsSourceLRC)
+		return err
+	})
+}
+
+// lyricsFromTags returns the lyrics found in the tags of a media file.
+func lyricsFromTags(file MediaFile) string {
+	tagged, ok := file.(TaggedMediaFile)
+	if !ok {
+		return ""
+	}
+	return tagged.Tags().Get(tags.Lyrics)
+}
+
+// lyricsSidecarPath returns the path of the .lrc file with lyrics for the media
+// file at fsPath.
+func lyricsSidecarPath(fsPath string) string {
+	return strings.TrimSuffix(fsPath, filepath.Ext(fsPath)) + ".lrc"
+}
+
+// isLyricsSidecar returns true when the file at path is a .lrc file with lyrics.
+func isLyricsSidecar(path string) bool {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	return base != ext && strings.EqualFold(ext, ".lrc")
+}
+
+// updateSidecarLyrics updates the lyrics of the tracks for which the .lrc
+// file at path is. It is used when the file is created, changed or removed.
+func (lib *LocalLibrary) updateSidecarLyrics(path string) {
+	path = filepath.Clean(path)
+	stem := strings.TrimSuffix(path, filepath.Ext(path))
+
+	type sidecarTrack struct {
+		id     int64
+		fsPath string
+	}
+	var tracks []sidecarTrack
+
+	// All paths which start with "stem." are between "stem." and "stem/"
+	// since '/' comes right after '.'.
+	work := func(db *sql.DB) error {
+		rows, err := db.Query(`
+			SELECT
+				id,
+				fs_path
+			FROM
+				tracks
+			WHERE
+				fs_path >= ? AND
+				fs_path < ? AND
+				cue_sheet IS NULL
+		`, stem+".", stem+"/")
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var track sidecarTrack
+			if err := rows.Scan(&track.id, &track.fsPath); err != nil {
+				return err
+			}
+			if lyricsSidecarPath(track.fsPath) == path {
+				tracks = append(tracks, track)
+			}
+		}
+
+		return rows.Err()
+	}
+
+	if err := lib.executeDBJobAndWait(work); err != nil {
+		log.Printf("Error finding tracks for lyrics %s: %s", path, err)
+		return
+	}
+
+	for _, track := range tracks {
+		var tagLyrics string
+		found, err := tags.ReadFile(track.fsPath)
+		if err == nil {
+			tagLyrics = found.Get(tags.Lyrics)
+		} else if !errors.Is(err, tags.ErrUnsupportedFormat) {
+			log.Printf("Error reading tags of %s: %s", track.fsPath, err)
+		}
+
+		if err := lib.saveLocalLyrics(track.id, track.fsPath, tagLyrics); err != nil {
+			log.Printf("Error saving lyrics for %s: %s", track.fsPath, err)
+		}
+	}
+}
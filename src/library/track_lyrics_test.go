@@ -0,0 +1,170 @@
+package library
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/lyrics"
+	"github.com/ironsmile/euterpe/src/lyrics/lyricsfakes"
+	"github.com/ironsmile/euterpe/src/tags"
+)
+
+// TestLyrics checks that lyrics are found in the tags of media files, in .lrc files
+// next to them and online.
+func TestLyrics(t *testing.T) {
+	ctx := context.Background()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+
+	defer func() { _ = lib.Truncate() }()
+
+	albumDir := t.TempDir()
+	lrcPath := filepath.Join(albumDir, "synced.lrc")
+	lrc := "[ti:Synced]\n[00:01.00]First line\n[00:02.50]Second line\n"
+	if err := os.WriteFile(lrcPath, []byte(lrc), 0o600); err != nil {
+		t.Fatalf("writing lrc file: %s", err)
+	}
+
+	files := []struct {
+		media MediaFile
+		path  string
+	}{
+		{
+			media: taggedMediaFile{
+				MediaFile: &MockMedia{artist: "Maiden", album: "Killers", title: "Tagged"},
+				tags:      tags.Tags{tags.Lyrics: {"Plain line\nAnother line"}},
+			},
+			path: "tagged.mp3",
+		},
+		{
+			media: taggedMediaFile{
+				MediaFile: &MockMedia{artist: "Maiden", album: "Killers", title: "Synced"},
+				tags:      tags.Tags{tags.Lyrics: {"Lyrics from the tags"}},
+			},
+			path: "synced.flac",
+		},
+		{
+			media: &MockMedia{
+				artist: "Maiden",
+				album:  "Killers",
+				title:  "Online",
+				length: 3 * time.Minute,
+			},
+			path: "online.ogg",
+		},
+	}
+
+	var trackIDs []int64
+	for _, file := range files {
+		trackPath := filepath.Join(albumDir, file.path)
+		if err := lib.insertMediaIntoDatabase(file.media, trackPath); err != nil {
+			t.Fatalf("inserting %s: %s", file.path, err)
+		}
+
+		found := lib.Search(file.media.Title())
+		if len(found) != 1 {
+			t.Fatalf("expected to find one %s track but got %d", file.path, len(found))
+		}
+		trackIDs = append(trackIDs, found[0].ID)
+	}
+
+	tagged, err := lib.GetLyrics(ctx, trackIDs[0])
+	if err != nil {
+		t.Fatalf("getting lyrics from tags: %s", err)
+	}
+
+	expected := TrackLyrics{
+		TrackID: trackIDs[0],
+		Source:  LyricsSourceTags,
+		Lines: []LyricsLine{
+			{Text: "Plain line"},
+			{Text: "Another line"},
+		},
+	}
+	if !reflect.DeepEqual(expected, tagged) {
+		t.Errorf("expected lyrics from tags %+v but got %+v", expected, tagged)
+	}
+
+	synced, err := lib.GetLyrics(ctx, trackIDs[1])
+	if err != nil {
+		t.Fatalf("getting lyrics from lrc file: %s", err)
+	}
+
+	expected = TrackLyrics{
+		TrackID: trackIDs[1],
+		Synced:  true,
+		Source:  LyricsSourceLRC,
+		Lines: []LyricsLine{
+			{Time: 1000, Text: "First line"},
+			{Time: 2500, Text: "Second line"},
+		},
+	}
+	if !reflect.DeepEqual(expected, synced) {
+		t.Errorf("expected lyrics from lrc %+v but got %+v", expected, synced)
+	}
+
+	// There is no finder so no lyrics are expected.
+	if _, err := lib.GetLyrics(ctx, trackIDs[2]); err != ErrLyricsNotFound {
+		t.Errorf("expected ErrLyricsNotFound without a finder but got %v", err)
+	}
+
+	finder := &lyricsfakes.FakeFinder{}
+	finder.GetLyricsReturns("[00:10.00]Found online", nil)
+	lib.SetLyricsFinder(finder)
+
+	for i := 0; i < 2; i++ {
+		online, err := lib.GetLyrics(ctx, trackIDs[2])
+		if err != nil {
+			t.Fatalf("getting online lyrics: %s", err)
+		}
+		if online.Source != LyricsSourceOnline || !online.Synced ||
+			len(online.Lines) != 1 || online.Lines[0].Text != "Found online" {
+			t.Errorf("unexpected online lyrics: %+v", online)
+		}
+	}
+
+	if finder.GetLyricsCallCount() != 1 {
+		t.Fatalf("expected one search for lyrics but there were %d",
+			finder.GetLyricsCallCount())
+	}
+
+	_, artist, album, title, duration := finder.GetLyricsArgsForCall(0)
+	if artist != "Maiden" || album != "Killers" || title != "Online" ||
+		duration != 3*time.Minute {
+		t.Errorf("unexpected search for lyrics: %s, %s, %s, %s",
+			artist, album, title, duration)
+	}
+
+	// Removing the lrc file makes the tags the only local source. The media file
+	// does not really exist so there are no lyrics at all.
+	finder.GetLyricsReturns("", lyrics.ErrNotFound)
+	if err := os.Remove(lrcPath); err != nil {
+		t.Fatalf("removing lrc file: %s", err)
+	}
+	lib.updateSidecarLyrics(lrcPath)
+
+	for i := 0; i < 2; i++ {
+		if _, err := lib.GetLyrics(ctx, trackIDs[1]); err != ErrLyricsNotFound {
+			t.Errorf("expected ErrLyricsNotFound after removing the lrc but got %v", err)
+		}
+	}
+
+	if finder.GetLyricsCallCount() != 2 {
+		t.Errorf("expected not found lyrics not to be searched for again")
+	}
+
+	if _, err := lib.GetLyrics(ctx, 9999); err != ErrTrackNotFound {
+		t.Errorf("expected ErrTrackNotFound but got %v", err)
+	}
+}
@@ -0,0 +1,277 @@
+package library
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultWatchQuietPeriod is for how long there must be no events for a
+	// path before its events are processed.
+	defaultWatchQuietPeriod = 2 * time.Second
+
+	// defaultWatchWorkers is the number of goroutines which process the events
+	// by default.
+	defaultWatchWorkers = 4
+)
+
+//counterfeiter:generate . WatchMonitor
+
+// WatchMonitor is an interface for getting information about the processing of
+// the changes in the library directories.
+type WatchMonitor interface {
+	// WatchQueueMetrics returns the current state of the queue with changes.
+	WatchQueueMetrics() WatchQueueMetrics
+}
+
+// WatchQueueMetrics describes the queue with changes in the library directories.
+// The changes are buffered by path until there are no new changes for it for a
+// while. Then they are processed.
+type WatchQueueMetrics struct {
+	// Queued is the number of paths with changes waiting to be processed.
+	Queued int `json:"queued"`
+
+	// Processing is the number of paths with changes being processed at the
+	// moment.
+	Processing int `json:"processing"`
+
+	// Workers is the number of goroutines which process the changes.
+	Workers int `json:"workers"`
+
+	// Received is the number of all received changes.
+	Received uint64 `json:"received"`
+
+	// Coalesced is the number of changes which were merged into the changes
+	// already queued for the same path.
+	Coalesced uint64 `json:"coalesced"`
+
+	// Processed is the number of all processed paths with changes.
+	Processed uint64 `json:"processed"`
+}
+
+// queuedEvent are the settled changes of a path which are ready for processing.
+type queuedEvent struct {
+	watchEvent
+
+	// replaced is set when the path must be removed before the event.
+	replaced bool
+}
+
+// pendingEvent are the coalesced changes of a single path.
+type pendingEvent struct {
+	op watchOp
+
+	// replaced is set when the path was deleted and then created again. It
+	// must be removed from the library before it is added again.
+	replaced bool
+
+	// lastSeen is the time of the last change.
+	lastSeen time.Time
+}
+
+// add merges op into the pending changes. A file which was created and then
+// modified only has to be added. And one which was created and deleted only
+// has to be removed, in case it was added in the meantime.
+func (pe *pendingEvent) add(op watchOp) {
+	switch {
+	case pe.op == watchCreate && op == watchModify:
+	case (pe.op == watchDelete || pe.op == watchRename) && op == watchCreate:
+		pe.op = watchCreate
+		pe.replaced = true
+	default:
+		pe.op = op
+	}
+}
+
+// watchQueue buffers the changes in the library directories by path until they
+// settle and then hands them to a pool of workers.
+type watchQueue struct {
+	sync.Mutex
+
+	started    bool
+	pending    map[string]*pendingEvent
+	processing map[string]struct{}
+	work       chan queuedEvent
+	metrics    WatchQueueMetrics
+
+	// albums are the albums changed by the processed events since the queue
+	// was last settled.
+	albums []int64
+
+	// busy is held for reading while events are processed. Holding it for
+	// writing stops their processing.
+	busy sync.RWMutex
+}
+
+// queueWatchEvent queues a change in the library directories for processing by
+// handleWatchEvent. Changes are processed after there were no new changes for
+// their path for the configured quiet period. Changes for the same path are
+// never processed at the same time.
+func (lib *LocalLibrary) queueWatchEvent(event watchEvent) {
+	if event.IsAttrib() {
+		// The event was just an attribute change
+		return
+	}
+
+	lib.watchEvents.Lock()
+	defer lib.watchEvents.Unlock()
+
+	if !lib.watchEvents.started {
+		lib.startWatchQueue()
+	}
+
+	lib.watchEvents.metrics.Received++
+
+	pending, ok := lib.watchEvents.pending[event.Name]
+	if ok {
+		lib.watchEvents.metrics.Coalesced++
+	} else {
+		pending = &pendingEvent{}
+		lib.watchEvents.pending[event.Name] = pending
+	}
+
+	pending.add(event.Op)
+	pending.lastSeen = time.Now()
+}
+
+// WatchQueueMetrics implements the WatchMonitor interface.
+func (lib *LocalLibrary) WatchQueueMetrics() WatchQueueMetrics {
+	lib.watchEvents.Lock()
+	defer lib.watchEvents.Unlock()
+
+	metrics := lib.watchEvents.metrics
+	metrics.Queued = len(lib.watchEvents.pending)
+	metrics.Processing = len(lib.watchEvents.processing)
+	metrics.Workers = lib.watchWorkers()
+
+	return metrics
+}
+
+// startWatchQueue starts the goroutines which process the queued changes. They
+// stop when the library is closed. Must be called with the queue locked.
+func (lib *LocalLibrary) startWatchQueue() {
+	lib.watchEvents.started = true
+	lib.watchEvents.pending = make(map[string]*pendingEvent)
+	lib.watchEvents.processing = make(map[string]struct{})
+	lib.watchEvents.work = make(chan queuedEvent)
+
+	for i := 0; i < lib.watchWorkers(); i++ {
+		go lib.watchEventsWorker()
+	}
+	go lib.dispatchWatchEvents()
+}
+
+// dispatchWatchEvents sends the changes which have settled to the workers.
+// Deletions are sent first so that moved files keep their tracks.
+func (lib *LocalLibrary) dispatchWatchEvents() {
+	quietPeriod := lib.ScanConfig.WatchQuietPeriod
+	if quietPeriod <= 0 {
+		quietPeriod = defaultWatchQuietPeriod
+	}
+
+	tick := quietPeriod / 2
+	if tick <= 0 {
+		tick = quietPeriod
+	}
+
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-lib.ctx.Done():
+			return
+		}
+
+		var ready []queuedEvent
+
+		lib.watchEvents.Lock()
+		for path, pending := range lib.watchEvents.pending {
+			if _, ok := lib.watchEvents.processing[path]; ok {
+				continue
+			}
+			if time.Since(pending.lastSeen) < quietPeriod {
+				continue
+			}
+
+			ready = append(ready, queuedEvent{
+				watchEvent: watchEvent{Name: path, Op: pending.op},
+				replaced:   pending.replaced && pending.op == watchCreate,
+			})
+
+			delete(lib.watchEvents.pending, path)
+			lib.watchEvents.processing[path] = struct{}{}
+		}
+		lib.watchEvents.Unlock()
+
+		sort.SliceStable(ready, func(i, j int) bool {
+			return isRemoval(ready[i].watchEvent) && !isRemoval(ready[j].watchEvent)
+		})
+
+		for _, event := range ready {
+			select {
+			case lib.watchEvents.work <- event:
+			case <-lib.ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// watchEventsWorker processes the changes sent by the dispatcher.
+func (lib *LocalLibrary) watchEventsWorker() {
+	for {
+		var event queuedEvent
+
+		select {
+		case event = <-lib.watchEvents.work:
+		case <-lib.ctx.Done():
+			return
+		}
+
+		// Scheduled jobs wait for the events which are processed at the moment.
+		lib.watchEvents.busy.RLock()
+		albums := lib.albumsOfPaths(event.Name)
+		if event.replaced {
+			lib.handleWatchEvent(watchEvent{Name: event.Name, Op: watchDelete})
+		}
+		lib.handleWatchEvent(event.watchEvent)
+		albums = append(albums, lib.albumsOfPaths(event.Name)...)
+		lib.watchEvents.busy.RUnlock()
+
+		lib.watchEvents.Lock()
+		delete(lib.watchEvents.processing, event.Name)
+		lib.watchEvents.albums = append(lib.watchEvents.albums, albums...)
+		var changed []int64
+		if len(lib.watchEvents.pending) == 0 && len(lib.watchEvents.processing) == 0 {
+			changed = lib.watchEvents.albums
+			lib.watchEvents.albums = nil
+		}
+		lib.watchEvents.Unlock()
+
+		// The added and removed albums may be editions of other albums. They
+		// are grouped once all changes are processed.
+		if len(changed) > 0 {
+			lib.groupEditionsOf(changed)
+		}
+
+		lib.watchEvents.Lock()
+		lib.watchEvents.metrics.Processed++
+		lib.watchEvents.Unlock()
+	}
+}
+
+// watchWorkers returns the number of goroutines which process changes.
+func (lib *LocalLibrary) watchWorkers() int {
+	if lib.ScanConfig.WatchWorkers > 0 {
+		return lib.ScanConfig.WatchWorkers
+	}
+	return defaultWatchWorkers
+}
+
+// isRemoval returns true for events which remove a path from the library.
+func isRemoval(event watchEvent) bool {
+	return event.IsDelete() || event.IsRename()
+}
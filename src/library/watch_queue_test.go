@@ -0,0 +1,124 @@
+package library
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/helpers"
+)
+
+// TestPendingEventCoalescing checks how the changes of a single path are merged.
+func TestPendingEventCoalescing(t *testing.T) {
+	tests := []struct {
+		ops      []watchOp
+		expected watchOp
+		replaced bool
+	}{
+		{ops: []watchOp{watchCreate, watchModify, watchModify}, expected: watchCreate},
+		{ops: []watchOp{watchModify, watchModify}, expected: watchModify},
+		{ops: []watchOp{watchCreate, watchModify, watchDelete}, expected: watchDelete},
+		{ops: []watchOp{watchModify, watchRename}, expected: watchRename},
+		{
+			ops:      []watchOp{watchDelete, watchCreate, watchModify},
+			expected: watchCreate,
+			replaced: true,
+		},
+	}
+
+	for _, test := range tests {
+		var pending pendingEvent
+		for _, op := range test.ops {
+			pending.add(op)
+		}
+
+		if pending.op != test.expected || pending.replaced != test.replaced {
+			t.Errorf("%v: expected op %d (replaced %t) but got %d (replaced %t)",
+				test.ops, test.expected, test.replaced, pending.op, pending.replaced)
+		}
+	}
+}
+
+// TestWatchQueue checks that queued changes are coalesced and processed once
+// they settle.
+func TestWatchQueue(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	lib, err := NewLocalLibrary(ctx, SQLiteMemoryFile, getTestMigrationFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Initialize(); err != nil {
+		t.Fatalf("Initializing library: %s", err)
+	}
+	defer func() { _ = lib.Truncate() }()
+
+	projRoot, err := helpers.ProjectRoot()
+	if err != nil {
+		t.Fatalf("Was not able to find test_files directory: %s", err)
+	}
+	testMp3 := filepath.Join(projRoot, "test_files", "more_mp3s", "test_file_added.mp3")
+
+	root := t.TempDir()
+	lib.ScanConfig.WatchQuietPeriod = 50 * time.Millisecond
+	lib.ScanConfig.WatchWorkers = 2
+	lib.AddLibraryPath(root)
+
+	newFile := filepath.Join(root, "song.mp3")
+	if err := copyFile(testMp3, newFile); err != nil {
+		t.Fatalf("copying test file: %s", err)
+	}
+
+	lib.queueWatchEvent(watchEvent{Name: newFile, Op: watchCreate})
+	lib.queueWatchEvent(watchEvent{Name: newFile, Op: watchModify})
+	lib.queueWatchEvent(watchEvent{Name: newFile, Op: watchAttrib})
+	lib.queueWatchEvent(watchEvent{Name: newFile, Op: watchModify})
+
+	metrics := lib.WatchQueueMetrics()
+	expected := WatchQueueMetrics{
+		Queued:    1,
+		Workers:   2,
+		Received:  3,
+		Coalesced: 2,
+	}
+	if metrics != expected {
+		t.Errorf("expected metrics %+v but got %+v", expected, metrics)
+	}
+
+	if lib.MediaExistsInLibrary(newFile) {
+		t.Errorf("expected the file not to be added before its changes settle")
+	}
+
+	for lib.WatchQueueMetrics().Processed < 1 {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("the queued changes were not processed")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	if !lib.MediaExistsInLibrary(newFile) {
+		t.Errorf("expected the file to be added once its changes settle")
+	}
+
+	if err := os.Remove(newFile); err != nil {
+		t.Fatal(err)
+	}
+	lib.queueWatchEvent(watchEvent{Name: newFile, Op: watchDelete})
+
+	for lib.WatchQueueMetrics().Processed < 2 {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("the removal was not processed")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	if lib.MediaExistsInLibrary(newFile) {
+		t.Errorf("expected the removed file not to be in the library")
+	}
+}
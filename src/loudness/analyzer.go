@@ -0,0 +1,232 @@
+package loudness
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsupportedFormat is returned when a media file could not be decoded. That
+// is for all files but WAV ones when there is no external decoder.
+var ErrUnsupportedFormat = errors.New("unsupported media format")
+
+// FilePlaceholder is replaced with the path to the media file in the arguments
+// of the external decoder.
+const FilePlaceholder = "{file}"
+
+// The format in which the external decoder must write the decoded audio to its
+// standard output. It is signed 16 bit little endian samples, interleaved.
+const (
+	DecoderSampleRate = 48000
+	DecoderChannels   = 2
+)
+
+// Analyzer measures the loudness of media files. It is safe for concurrent use.
+type Analyzer struct {
+	decoder []string
+}
+
+// NewAnalyzer returns an Analyzer which uses the `decoder` command for all media
+// files but WAV ones. The command must write the decoded audio in the format
+// described by DecoderSampleRate and DecoderChannels to its standard output.
+// FilePlaceholder in its arguments is replaced with the path to the media file.
+// For example with ffmpeg:
+//
+//	ffmpeg -v quiet -i {file} -f s16le -ac 2 -ar 48000 -
+//
+// When `decoder` is empty only WAV files could be analyzed.
+func NewAnalyzer(decoder []string) *Analyzer {
+	return &Analyzer{
+		decoder: decoder,
+	}
+}
+
+// Analyze decodes the media file at path and returns a Meter with all of its
+// audio.
+func (a *Analyzer) Analyze(ctx context.Context, path string) (*Meter, error) {
+	if strings.EqualFold(filepath.Ext(path), ".wav") {
+		fh, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer fh.Close()
+
+		return readWAV(ctx, bufio.NewReader(fh))
+	}
+
+	if len(a.decoder) == 0 {
+		return nil, ErrUnsupportedFormat
+	}
+
+	return a.runDecoder(ctx, path)
+}
+
+// runDecoder runs the external decoder for the file at path and measures its
+// output.
+func (a *Analyzer) runDecoder(ctx context.Context, path string) (*Meter, error) {
+	args := make([]string, 0, len(a.decoder)-1)
+	for _, arg := range a.decoder[1:] {
+		args = append(args, strings.ReplaceAll(arg, FilePlaceholder, path))
+	}
+
+	cmd := exec.CommandContext(ctx, a.decoder[0], args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting decoder: %w", err)
+	}
+
+	meter := NewMeter(DecoderSampleRate, DecoderChannels)
+	readErr := readPCM(ctx, bufio.NewReader(stdout), meter, 16, false)
+
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("decoder failed: %w", err)
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	return meter, nil
+}
+
+// WAV format codes.
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xfffe
+)
+
+// readWAV measures the audio of a WAV file.
+func readWAV(ctx context.Context, r io.Reader) (*Meter, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("reading WAV header: %w", err)
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, ErrUnsupportedFormat
+	}
+
+	var (
+		meter         *Meter
+		bitsPerSample int
+		isFloat       bool
+	)
+
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return nil, fmt.Errorf("reading WAV chunk: %w", err)
+		}
+		size := int64(binary.LittleEndian.Uint32(chunk[4:]))
+
+		switch string(chunk[:4]) {
+		case "fmt ":
+			if size < 16 || size > 1024 {
+				return nil, fmt.Errorf("malformed WAV fmt chunk")
+			}
+			format := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, format); err != nil {
+				return nil, fmt.Errorf("reading WAV fmt chunk: %w", err)
+			}
+
+			formatCode := binary.LittleEndian.Uint16(format[0:])
+			channels := int(binary.LittleEndian.Uint16(format[2:]))
+			sampleRate := int(binary.LittleEndian.Uint32(format[4:]))
+			bitsPerSample = int(binary.LittleEndian.Uint16(format[14:]))
+
+			if formatCode == wavFormatExtensible && size >= 26 {
+				formatCode = binary.LittleEndian.Uint16(format[24:])
+			}
+
+			switch {
+			case formatCode == wavFormatPCM &&
+				(bitsPerSample == 8 || bitsPerSample == 16 ||
+					bitsPerSample == 24 || bitsPerSample == 32):
+			case formatCode == wavFormatFloat && bitsPerSample == 32:
+				isFloat = true
+			default:
+				return nil, ErrUnsupportedFormat
+			}
+
+			if channels < 1 || sampleRate < 1 {
+				return nil, fmt.Errorf("malformed WAV fmt chunk")
+			}
+			meter = NewMeter(sampleRate, channels)
+		case "data":
+			if meter == nil {
+				return nil, fmt.Errorf("WAV data chunk before fmt chunk")
+			}
+			err := readPCM(ctx, io.LimitReader(r, size), meter, bitsPerSample, isFloat)
+			if err != nil {
+				return nil, err
+			}
+			return meter, nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, size+size%2); err != nil {
+				return nil, fmt.Errorf("skipping WAV chunk: %w", err)
+			}
+		}
+	}
+}
+
+// readPCM writes all little endian PCM samples from r into the meter. 8 bit
+// samples are unsigned, all others are signed.
+func readPCM(
+	ctx context.Context,
+	r io.Reader,
+	meter *Meter,
+	bitsPerSample int,
+	isFloat bool,
+) error {
+	sampleSize := bitsPerSample / 8
+	buf := make([]byte, 4096*sampleSize*meter.channels)
+	samples := make([]float64, 0, 4096*meter.channels)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n, err := io.ReadFull(r, buf)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return fmt.Errorf("reading audio: %w", err)
+		}
+
+		samples = samples[:0]
+		for i := 0; i+sampleSize <= n; i += sampleSize {
+			samples = append(samples, decodeSample(buf[i:], bitsPerSample, isFloat))
+		}
+		meter.Write(samples)
+
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+func decodeSample(b []byte, bitsPerSample int, isFloat bool) float64 {
+	switch {
+	case isFloat:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	case bitsPerSample == 8:
+		return (float64(b[0]) - 128) / 128
+	case bitsPerSample == 16:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15)
+	case bitsPerSample == 24:
+		v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+		return float64(v) / (1 << 23)
+	default:
+		return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+	}
+}
@@ -0,0 +1,98 @@
+package loudness_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/loudness"
+)
+
+// TestAnalyzeWAV checks that WAV files are analyzed without an external decoder.
+func TestAnalyzeWAV(t *testing.T) {
+	const sampleRate = 44100
+
+	pcm := pcm16(sine(sampleRate, 2, 1000, -23, 10))
+
+	wav := []byte("RIFF")
+	wav = binary.LittleEndian.AppendUint32(wav, uint32(4+8+16+8+9+8+len(pcm)))
+	wav = append(wav, "WAVEfmt "...)
+	wav = binary.LittleEndian.AppendUint32(wav, 16)
+	wav = binary.LittleEndian.AppendUint16(wav, 1)
+	wav = binary.LittleEndian.AppendUint16(wav, 2)
+	wav = binary.LittleEndian.AppendUint32(wav, sampleRate)
+	wav = binary.LittleEndian.AppendUint32(wav, sampleRate*4)
+	wav = binary.LittleEndian.AppendUint16(wav, 4)
+	wav = binary.LittleEndian.AppendUint16(wav, 16)
+
+	// A chunk with odd size which must be skipped together with its padding.
+	wav = append(wav, "LIST"...)
+	wav = binary.LittleEndian.AppendUint32(wav, 9)
+	wav = append(wav, "some info\x00"...)
+
+	wav = append(wav, "data"...)
+	wav = binary.LittleEndian.AppendUint32(wav, uint32(len(pcm)))
+	wav = append(wav, pcm...)
+
+	wavPath := filepath.Join(t.TempDir(), "sine.wav")
+	if err := os.WriteFile(wavPath, wav, 0o600); err != nil {
+		t.Fatalf("writing WAV file: %s", err)
+	}
+
+	meter, err := loudness.NewAnalyzer(nil).Analyze(context.Background(), wavPath)
+	if err != nil {
+		t.Fatalf("analyzing WAV file: %s", err)
+	}
+
+	if found := meter.Loudness(); math.Abs(found+23) > 0.1 {
+		t.Errorf("expected -23 LUFS but got %.2f", found)
+	}
+
+	_, err = loudness.NewAnalyzer(nil).Analyze(context.Background(), "song.flac")
+	if !errors.Is(err, loudness.ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat without decoder but got %v", err)
+	}
+}
+
+// TestAnalyzeWithDecoder checks that the external decoder output is analyzed.
+// `cat` is used as a decoder for files which are already raw PCM.
+func TestAnalyzeWithDecoder(t *testing.T) {
+	catPath, err := exec.LookPath("cat")
+	if err != nil {
+		t.Skip("cat is not available")
+	}
+
+	pcm := pcm16(sine(loudness.DecoderSampleRate, loudness.DecoderChannels, 1000, -20, 10))
+	rawPath := filepath.Join(t.TempDir(), "sine.raw")
+	if err := os.WriteFile(rawPath, pcm, 0o600); err != nil {
+		t.Fatalf("writing raw file: %s", err)
+	}
+
+	analyzer := loudness.NewAnalyzer([]string{catPath, loudness.FilePlaceholder})
+	meter, err := analyzer.Analyze(context.Background(), rawPath)
+	if err != nil {
+		t.Fatalf("analyzing: %s", err)
+	}
+
+	if found := meter.Loudness(); math.Abs(found+20) > 0.1 {
+		t.Errorf("expected -20 LUFS but got %.2f", found)
+	}
+
+	failing := loudness.NewAnalyzer([]string{catPath, "/does/not/exist"})
+	if _, err := failing.Analyze(context.Background(), rawPath); err == nil {
+		t.Errorf("expected an error when the decoder fails")
+	}
+}
+
+func pcm16(samples []float64) []byte {
+	out := make([]byte, 0, len(samples)*2)
+	for _, sample := range samples {
+		out = binary.LittleEndian.AppendUint16(out, uint16(int16(sample*(1<<15-1))))
+	}
+	return out
+}
@@ -0,0 +1,11 @@
+/*
+Package loudness deals with loudness normalisation of songs. It reads ReplayGain
+information from tags and measures loudness of media files for which there is none.
+
+Loudness is measured as described in ITU-R BS.1770-4 and EBU R 128. The gains are
+relative to the ReplayGain 2.0 reference loudness of -18 LUFS.
+
+Only WAV files are decoded by the package itself. All other formats are decoded by
+an external program such as ffmpeg when one is configured.
+*/
+package loudness
@@ -0,0 +1,248 @@
+package loudness
+
+import (
+	"math"
+)
+
+const (
+	// absoluteGate is the loudness in LUFS under which blocks are ignored.
+	absoluteGate = -70.0
+
+	// relativeGate is how many LU under the loudness of the blocks above
+	// the absolute gate a block must be in order to be ignored.
+	relativeGate = -10.0
+
+	// subBlocksPerBlock is the number of 100ms steps in a 400ms gating block.
+	subBlocksPerBlock = 4
+)
+
+// Meter measures the integrated loudness and the sample peak of audio. It is
+// not safe for concurrent use.
+type Meter struct {
+	channels int
+	weights  []float64
+	filters  []kFilter
+
+	// subBlockSize is the number of frames in 100ms.
+	subBlockSize int
+	subBlockFill int
+
+	// squares holds the sums of squared K-weighted samples for every channel
+	// in the current 100ms sub-block.
+	squares []float64
+
+	// recent holds the energy of the last sub-blocks. Every gating block is
+	// made of four consecutive sub-blocks.
+	recent []float64
+
+	// blocks holds the energy of every 400ms gating block.
+	blocks []float64
+
+	peak float64
+}
+
+// NewMeter returns a Meter for audio with the given sample rate and number of
+// channels. For five or more channels they are expected to be in the order
+// L, R, C, LFE, Ls, Rs. The LFE channel is ignored then and the surround ones are
+// weighted as described in BS.1770.
+func NewMeter(sampleRate, channels int) *Meter {
+	m := &Meter{
+		channels:     channels,
+		weights:      make([]float64, channels),
+		filters:      make([]kFilter, channels),
+		squares:      make([]float64, channels),
+		subBlockSize: sampleRate / 10,
+	}
+
+	for ch := range m.weights {
+		m.weights[ch] = 1
+		if channels >= 5 {
+			switch ch {
+			case 3:
+				m.weights[ch] = 0
+			case 4, 5:
+				m.weights[ch] = 1.41
+			}
+		}
+		m.filters[ch] = newKFilter(float64(sampleRate))
+	}
+
+	if m.subBlockSize < 1 {
+		m.subBlockSize = 1
+	}
+
+	return m
+}
+
+// Write adds interleaved samples to the measurement. Full scale is [-1, 1].
+// The number of samples should be a multiple of the number of channels.
+func (m *Meter) Write(samples []float64) {
+	for ind, sample := range samples {
+		ch := ind % m.channels
+
+		if abs := math.Abs(sample); abs > m.peak {
+			m.peak = abs
+		}
+
+		filtered := m.filters[ch].process(sample)
+		m.squares[ch] += filtered * filtered
+
+		if ch == m.channels-1 {
+			m.subBlockFill++
+			if m.subBlockFill == m.subBlockSize {
+				m.finishSubBlock()
+			}
+		}
+	}
+}
+
+// finishSubBlock records the energy of the current 100ms sub-block and of the
+// gating block which ends with it.
+func (m *Meter) finishSubBlock() {
+	var energy float64
+	for ch, sum := range m.squares {
+		energy += m.weights[ch] * sum / float64(m.subBlockSize)
+		m.squares[ch] = 0
+	}
+	m.subBlockFill = 0
+
+	m.recent = append(m.recent, energy)
+	if len(m.recent) > subBlocksPerBlock {
+		m.recent = m.recent[1:]
+	}
+	if len(m.recent) < subBlocksPerBlock {
+		return
+	}
+
+	var block float64
+	for _, e := range m.recent {
+		block += e
+	}
+	m.blocks = append(m.blocks, block/subBlocksPerBlock)
+}
+
+// Peak returns the highest absolute sample value so far.
+func (m *Meter) Peak() float64 {
+	return m.peak
+}
+
+// Loudness returns the integrated loudness in LUFS of the audio written so far.
+// It is negative infinity for silence or audio shorter than 400ms.
+func (m *Meter) Loudness() float64 {
+	return IntegratedLoudness(m)
+}
+
+// IntegratedLoudness returns the integrated loudness in LUFS of the audio from all
+// meters together. This is the loudness of an album when every meter is one of
+// its songs.
+func IntegratedLoudness(meters ...*Meter) float64 {
+	absThreshold := energyForLoudness(absoluteGate)
+
+	var (
+		sum   float64
+		count int
+	)
+	for _, m := range meters {
+		for _, block := range m.blocks {
+			if block > absThreshold {
+				sum += block
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		return math.Inf(-1)
+	}
+
+	relThreshold := energyForLoudness(loudnessForEnergy(sum/float64(count)) + relativeGate)
+
+	sum, count = 0, 0
+	for _, m := range meters {
+		for _, block := range m.blocks {
+			if block > absThreshold && block > relThreshold {
+				sum += block
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		return math.Inf(-1)
+	}
+
+	return loudnessForEnergy(sum / float64(count))
+}
+
+func loudnessForEnergy(energy float64) float64 {
+	return -0.691 + 10*math.Log10(energy)
+}
+
+func energyForLoudness(lufs float64) float64 {
+	return math.Pow(10, (lufs+0.691)/10)
+}
+
+// kFilter is the K-weighting filter from BS.1770. It is a high shelf filter
+// followed by a high pass one.
+type kFilter struct {
+	shelf    biquad
+	highPass biquad
+}
+
+// newKFilter returns the K-weighting filter for a sample rate. The coefficients
+// are calculated for any sample rate with the filter parameters used by
+// libebur128.
+func newKFilter(sampleRate float64) kFilter {
+	var kf kFilter
+
+	const (
+		shelfFreq = 1681.974450955533
+		shelfGain = 3.999843853973347
+		shelfQ    = 0.7071752369554196
+	)
+
+	k := math.Tan(math.Pi * shelfFreq / sampleRate)
+	vh := math.Pow(10, shelfGain/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/shelfQ + k*k
+	kf.shelf = biquad{
+		b0: (vh + vb*k/shelfQ + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/shelfQ + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/shelfQ + k*k) / a0,
+	}
+
+	const (
+		highPassFreq = 38.13547087602444
+		highPassQ    = 0.5003270373238773
+	)
+
+	k = math.Tan(math.Pi * highPassFreq / sampleRate)
+	a0 = 1 + k/highPassQ + k*k
+	kf.highPass = biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/highPassQ + k*k) / a0,
+	}
+
+	return kf
+}
+
+func (kf *kFilter) process(sample float64) float64 {
+	return kf.highPass.process(kf.shelf.process(sample))
+}
+
+// biquad is a second order IIR filter in direct form II transposed.
+type biquad struct {
+	b0, b1, b2 float64
+	a1, a2     float64
+	z1, z2     float64
+}
+
+func (bq *biquad) process(in float64) float64 {
+	out := bq.b0*in + bq.z1
+	bq.z1 = bq.b1*in - bq.a1*out + bq.z2
+	bq.z2 = bq.b2*in - bq.a2*out
+	return out
+}
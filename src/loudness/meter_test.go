@@ -0,0 +1,81 @@
+package loudness_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/loudness"
+)
+
+// TestMeterSine checks the loudness of a stereo 1kHz sine at -23 dBFS which is
+// -23 LUFS by definition.
+func TestMeterSine(t *testing.T) {
+	for _, sampleRate := range []int{44100, 48000} {
+		meter := loudness.NewMeter(sampleRate, 2)
+		meter.Write(sine(sampleRate, 2, 1000, -23, 20))
+
+		if found := meter.Loudness(); math.Abs(found+23) > 0.1 {
+			t.Errorf("%dHz: expected -23 LUFS but got %.2f", sampleRate, found)
+		}
+
+		expectedPeak := math.Pow(10, -23.0/20)
+		if math.Abs(meter.Peak()-expectedPeak) > 0.001 {
+			t.Errorf("%dHz: expected peak %.4f but got %.4f",
+				sampleRate, expectedPeak, meter.Peak())
+		}
+	}
+}
+
+// TestMeterGating checks that silence and quiet parts do not change the
+// integrated loudness.
+func TestMeterGating(t *testing.T) {
+	const sampleRate = 48000
+
+	meter := loudness.NewMeter(sampleRate, 2)
+	meter.Write(sine(sampleRate, 2, 1000, -23, 10))
+	meter.Write(make([]float64, sampleRate*2*10))
+	meter.Write(sine(sampleRate, 2, 1000, -50, 10))
+
+	if found := meter.Loudness(); math.Abs(found+23) > 0.1 {
+		t.Errorf("expected -23 LUFS but got %.2f", found)
+	}
+
+	silent := loudness.NewMeter(sampleRate, 2)
+	silent.Write(make([]float64, sampleRate*2*5))
+	if found := silent.Loudness(); !math.IsInf(found, -1) {
+		t.Errorf("expected -Inf for silence but got %.2f", found)
+	}
+}
+
+// TestIntegratedLoudnessAlbum checks that the loudness of many meters is
+// measured as if their audio was one.
+func TestIntegratedLoudnessAlbum(t *testing.T) {
+	const sampleRate = 48000
+
+	loud := loudness.NewMeter(sampleRate, 2)
+	loud.Write(sine(sampleRate, 2, 1000, -20, 10))
+
+	quiet := loudness.NewMeter(sampleRate, 2)
+	quiet.Write(sine(sampleRate, 2, 1000, -26, 10))
+
+	// The energy mean of -20 and -26 LUFS for equal durations.
+	expected := 10 * math.Log10((math.Pow(10, -2)+math.Pow(10, -2.6))/2)
+
+	if found := loudness.IntegratedLoudness(loud, quiet); math.Abs(found-expected) > 0.1 {
+		t.Errorf("expected %.2f LUFS but got %.2f", expected, found)
+	}
+}
+
+// sine returns interleaved samples of a sine with `freq` Hz and peak at `dbfs`
+// in all channels which lasts `seconds`.
+func sine(sampleRate, channels int, freq, dbfs float64, seconds int) []float64 {
+	amplitude := math.Pow(10, dbfs/20)
+	samples := make([]float64, 0, sampleRate*channels*seconds)
+	for i := 0; i < sampleRate*seconds; i++ {
+		value := amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate))
+		for ch := 0; ch < channels; ch++ {
+			samples = append(samples, value)
+		}
+	}
+	return samples
+}
@@ -0,0 +1,120 @@
+package loudness
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ironsmile/euterpe/src/tags"
+)
+
+// ReferenceLoudness is the loudness in LUFS to which ReplayGain 2.0 gains bring
+// songs.
+const ReferenceLoudness = -18.0
+
+// r128Reference is the loudness in LUFS to which the R128_*_GAIN tags of Opus
+// files bring songs.
+const r128Reference = -23.0
+
+// Names of the ReplayGain tags.
+const (
+	TagTrackGain     = "REPLAYGAIN_TRACK_GAIN"
+	TagTrackPeak     = "REPLAYGAIN_TRACK_PEAK"
+	TagAlbumGain     = "REPLAYGAIN_ALBUM_GAIN"
+	TagAlbumPeak     = "REPLAYGAIN_ALBUM_PEAK"
+	TagR128TrackGain = "R128_TRACK_GAIN"
+	TagR128AlbumGain = "R128_ALBUM_GAIN"
+)
+
+// Gain is the ReplayGain information for a song.
+type Gain struct {
+	// TrackGain is the gain in dB which brings the song to ReferenceLoudness.
+	TrackGain float64
+
+	// TrackPeak is the highest absolute sample value in the song where 1.0
+	// is full scale. It is zero when unknown.
+	TrackPeak float64
+
+	// HasAlbumGain is true when AlbumGain and AlbumPeak are known.
+	HasAlbumGain bool
+
+	// AlbumGain is the gain in dB which brings the whole album of the song to
+	// ReferenceLoudness.
+	AlbumGain float64
+
+	// AlbumPeak is the highest absolute sample value in the album. It is zero
+	// when unknown.
+	AlbumPeak float64
+}
+
+// FromTags returns the ReplayGain information found in the tags of a song. The
+// second returned value is false when there is no track gain in the tags. The
+// REPLAYGAIN_* tags are preferred over the R128_* ones of Opus files.
+func FromTags(t tags.Tags) (Gain, bool) {
+	var gain Gain
+
+	trackGain, ok := parseGain(t.Get(TagTrackGain))
+	if !ok {
+		trackGain, ok = parseR128Gain(t.Get(TagR128TrackGain))
+	}
+	if !ok {
+		return gain, false
+	}
+
+	gain.TrackGain = trackGain
+	gain.TrackPeak = parsePeak(t.Get(TagTrackPeak))
+
+	albumGain, ok := parseGain(t.Get(TagAlbumGain))
+	if !ok {
+		albumGain, ok = parseR128Gain(t.Get(TagR128AlbumGain))
+	}
+	if ok {
+		gain.HasAlbumGain = true
+		gain.AlbumGain = albumGain
+		gain.AlbumPeak = parsePeak(t.Get(TagAlbumPeak))
+	}
+
+	return gain, true
+}
+
+// GainForLoudness returns the gain in dB which brings a song with integrated
+// loudness `lufs` to ReferenceLoudness.
+func GainForLoudness(lufs float64) float64 {
+	return ReferenceLoudness - lufs
+}
+
+// parseGain parses values such as "-6.54 dB".
+func parseGain(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) > 2 && strings.EqualFold(value[len(value)-2:], "db") {
+		value = strings.TrimSpace(value[:len(value)-2])
+	}
+	if value == "" {
+		return 0, false
+	}
+
+	gain, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	return gain, true
+}
+
+// parseR128Gain parses the Q7.8 fixed point values of the R128_*_GAIN tags and
+// converts them to ReplayGain 2.0 gains.
+func parseR128Gain(value string) (float64, bool) {
+	q78, err := strconv.ParseInt(strings.TrimSpace(value), 10, 16)
+	if err != nil {
+		return 0, false
+	}
+	return float64(q78)/256 + (ReferenceLoudness - r128Reference), true
+}
+
+// parsePeak parses peak values such as "0.988525". Zero is returned for values
+// which could not be parsed.
+func parsePeak(value string) float64 {
+	peak, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || peak < 0 {
+		return 0
+	}
+	return peak
+}
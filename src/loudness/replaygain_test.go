@@ -0,0 +1,77 @@
+package loudness_test
+
+import (
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/loudness"
+	"github.com/ironsmile/euterpe/src/tags"
+)
+
+// TestFromTags checks reading ReplayGain information from tags.
+func TestFromTags(t *testing.T) {
+	tests := []struct {
+		desc     string
+		tags     tags.Tags
+		expected loudness.Gain
+		found    bool
+	}{
+		{
+			desc: "ReplayGain tags",
+			tags: tags.Tags{
+				loudness.TagTrackGain: {"-6.54 dB"},
+				loudness.TagTrackPeak: {"0.988525"},
+				loudness.TagAlbumGain: {"-7.00 db"},
+				loudness.TagAlbumPeak: {"1.000000"},
+			},
+			expected: loudness.Gain{
+				TrackGain:    -6.54,
+				TrackPeak:    0.988525,
+				HasAlbumGain: true,
+				AlbumGain:    -7,
+				AlbumPeak:    1,
+			},
+			found: true,
+		},
+		{
+			desc: "track gain only",
+			tags: tags.Tags{
+				loudness.TagTrackGain: {"+1.5 dB"},
+			},
+			expected: loudness.Gain{TrackGain: 1.5},
+			found:    true,
+		},
+		{
+			desc: "Opus R128 tags",
+			tags: tags.Tags{
+				loudness.TagR128TrackGain: {"-512"},
+				loudness.TagR128AlbumGain: {"256"},
+			},
+			expected: loudness.Gain{
+				TrackGain:    3,
+				HasAlbumGain: true,
+				AlbumGain:    6,
+			},
+			found: true,
+		},
+		{
+			desc: "malformed",
+			tags: tags.Tags{
+				loudness.TagTrackGain: {"loud"},
+			},
+		},
+		{
+			desc: "no tags",
+			tags: tags.Tags{},
+		},
+	}
+
+	for _, test := range tests {
+		gain, found := loudness.FromTags(test.tags)
+		if found != test.found {
+			t.Errorf("%s: expected found to be %t", test.desc, test.found)
+		}
+		if gain != test.expected {
+			t.Errorf("%s: expected %+v but got %+v", test.desc, test.expected, gain)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package lyrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client finds lyrics using the LRCLIB API. It is safe for concurrent use.
+//
+// It implements Finder.
+type Client struct {
+	useragent  string
+	apiHost    string
+	httpClient *http.Client
+}
+
+// NewClient returns a fully configured Client. The `useragent` is used for
+// representing itself when contacting the LRCLIB API as they kindly ask.
+func NewClient(useragent string) *Client {
+	return &Client{
+		useragent:  useragent,
+		apiHost:    "https://lrclib.net",
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// lrclibTrack is the response of the LRCLIB API for a single track.
+type lrclibTrack struct {
+	Instrumental bool   `json:"instrumental"`
+	PlainLyrics  string `json:"plainLyrics"`
+	SyncedLyrics string `json:"syncedLyrics"`
+}
+
+// GetLyrics implements the Finder interface. Synchronised lyrics are preferred
+// over plain ones.
+func (c *Client) GetLyrics(
+	ctx context.Context,
+	artist, album, title string,
+	duration time.Duration,
+) (string, error) {
+	if artist == "" || title == "" {
+		return "", ErrNotFound
+	}
+
+	query := url.Values{}
+	query.Set("artist_name", artist)
+	query.Set("track_name", title)
+	if album != "" {
+		query.Set("album_name", album)
+	}
+	if duration > 0 {
+		query.Set("duration", strconv.FormatInt(int64(duration.Round(time.Second).Seconds()), 10))
+	}
+
+	endpointURL := fmt.Sprintf("%s/api/get?%s", c.apiHost, query.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating LRCLIB API req: %w", err)
+	}
+	req.Header.Set("User-Agent", c.useragent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request to LRCLIB API failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", ErrNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("LRCLIB API returned HTTP %d", resp.StatusCode)
+	}
+
+	var track lrclibTrack
+	dec := json.NewDecoder(resp.Body)
+	if err := dec.Decode(&track); err != nil {
+		return "", fmt.Errorf("unrecognised JSON returned by LRCLIB: %w", err)
+	}
+
+	if strings.TrimSpace(track.SyncedLyrics) != "" {
+		return track.SyncedLyrics, nil
+	}
+
+	if strings.TrimSpace(track.PlainLyrics) != "" {
+		return track.PlainLyrics, nil
+	}
+
+	return "", ErrNotFound
+}
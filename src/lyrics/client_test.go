@@ -0,0 +1,93 @@
+package lyrics_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/lyrics"
+)
+
+// TestClientGetLyrics checks that the lyrics.Client finds lyrics using the
+// LRCLIB API and prefers synchronised ones.
+func TestClientGetLyrics(t *testing.T) {
+	var serverErrors []string
+
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/api/get" {
+			serverErrors = append(serverErrors,
+				fmt.Sprintf("unexpected path %s", req.URL.Path))
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if ua := req.Header.Get("User-Agent"); ua != "test-agent" {
+			serverErrors = append(serverErrors,
+				fmt.Sprintf("unexpected user agent `%s`", ua))
+		}
+
+		query := req.URL.Query()
+		switch query.Get("track_name") {
+		case "Wrathchild":
+			if query.Get("artist_name") != "Iron Maiden" ||
+				query.Get("album_name") != "Killers" ||
+				query.Get("duration") != "175" {
+				serverErrors = append(serverErrors,
+					fmt.Sprintf("unexpected query %s", req.URL.RawQuery))
+			}
+			fmt.Fprint(w, `{
+				"id": 1,
+				"instrumental": false,
+				"plainLyrics": "I was born in a world",
+				"syncedLyrics": "[00:12.50] I was born in a world"
+			}`)
+		case "Plain":
+			fmt.Fprint(w, `{"plainLyrics": "Only plain", "syncedLyrics": null}`)
+		case "Instrumental":
+			fmt.Fprint(w, `{"instrumental": true, "plainLyrics": null}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"code": 404, "name": "TrackNotFound"}`)
+		}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(handler))
+	defer srv.Close()
+
+	client := lyrics.NewClient("test-agent")
+	client.SetAPIURL(srv.URL)
+
+	ctx := context.Background()
+
+	found, err := client.GetLyrics(ctx, "Iron Maiden", "Killers", "Wrathchild",
+		175*time.Second+200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("getting lyrics: %s", err)
+	}
+	if found != "[00:12.50] I was born in a world" {
+		t.Errorf("expected the synced lyrics but got `%s`", found)
+	}
+
+	found, err = client.GetLyrics(ctx, "Iron Maiden", "", "Plain", 0)
+	if err != nil {
+		t.Fatalf("getting plain lyrics: %s", err)
+	}
+	if found != "Only plain" {
+		t.Errorf("expected the plain lyrics but got `%s`", found)
+	}
+
+	for _, title := range []string{"Instrumental", "Missing"} {
+		_, err := client.GetLyrics(ctx, "Iron Maiden", "", title, 0)
+		if !errors.Is(err, lyrics.ErrNotFound) {
+			t.Errorf("%s: expected ErrNotFound but got %v", title, err)
+		}
+	}
+
+	for _, serverErr := range serverErrors {
+		t.Error(serverErr)
+	}
+}
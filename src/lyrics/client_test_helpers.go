@@ -0,0 +1,6 @@
+package lyrics
+
+// SetAPIURL sets the LRCLIB API URL. Only useful for tests.
+func (c *Client) SetAPIURL(apiURL string) {
+	c.apiHost = apiURL
+}
@@ -0,0 +1,14 @@
+/*
+Package lyrics is responsible for parsing song lyrics and for finding them over the
+internet.
+
+Lyrics could be plain text or synchronised in the LRC format where every line
+starts with the time at which it is sung:
+
+	[00:12.50]Walking through the city, looking oh so pretty
+	[00:17.20]I've just got to find my way
+
+Lyrics for songs which have none in their tags or next to them are found using the
+LRCLIB API: https://lrclib.net/docs
+*/
+package lyrics
@@ -0,0 +1,194 @@
+package lyrics
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by the Finder when no lyrics could be found for a song.
+var ErrNotFound = errors.New("lyrics not found")
+
+//counterfeiter:generate . Finder
+
+// Finder defines a type which is capable of finding lyrics for songs.
+type Finder interface {
+	// GetLyrics returns the lyrics for a song. They are in the LRC format
+	// when synchronised lyrics were found and in plain text otherwise. The
+	// duration of the song is used for telling apart different versions of
+	// it and may be zero when unknown.
+	GetLyrics(
+		ctx context.Context,
+		artist, album, title string,
+		duration time.Duration,
+	) (string, error)
+}
+
+// Lyrics are the parsed lyrics of a song.
+type Lyrics struct {
+	// Synced is true when every line has the time at which it is sung.
+	Synced bool
+
+	// Lines are the lines of the lyrics. For synchronised lyrics they are
+	// sorted by their time.
+	Lines []Line
+}
+
+// Line is a single line of lyrics.
+type Line struct {
+	// Time is the offset from the start of the song at which the line is sung.
+	// It is always zero for lyrics which are not synchronised.
+	Time time.Duration
+	Text string
+}
+
+// Parse parses lyrics in plain text or the LRC format. The text is considered
+// LRC when at least one of its lines has a time tag. Lines without time tags are
+// dropped then. So are the LRC metadata tags such as [ar:Artist]. Apart from the
+// [offset:] tag which is applied to all times.
+func Parse(text string) Lyrics {
+	var (
+		plain  []Line
+		synced []Line
+		offset time.Duration
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(strings.TrimPrefix(text, "\ufeff")))
+	scanner.Buffer(nil, len(text)+1)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		times, rest, isMeta := parseTags(line)
+		if isMeta {
+			if ms, ok := offsetTag(line); ok {
+				offset = time.Duration(ms) * time.Millisecond
+			}
+			continue
+		}
+
+		plain = append(plain, Line{Text: line})
+		for _, lineTime := range times {
+			synced = append(synced, Line{
+				Time: lineTime,
+				Text: strings.TrimSpace(rest),
+			})
+		}
+	}
+
+	if len(synced) == 0 {
+		return Lyrics{Lines: trimEmptyLines(plain)}
+	}
+
+	// A positive offset means the lyrics are shown earlier.
+	for ind := range synced {
+		synced[ind].Time -= offset
+		if synced[ind].Time < 0 {
+			synced[ind].Time = 0
+		}
+	}
+
+	sort.SliceStable(synced, func(i, j int) bool {
+		return synced[i].Time < synced[j].Time
+	})
+
+	return Lyrics{
+		Synced: true,
+		Lines:  synced,
+	}
+}
+
+// parseTags parses the time tags at the start of a LRC line. A line may have
+// more than one when it is repeated in the song. isMeta is true when the line
+// is a metadata tag instead.
+func parseTags(line string) (times []time.Duration, rest string, isMeta bool) {
+	rest = line
+	for strings.HasPrefix(rest, "[") {
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
+			break
+		}
+
+		tag := rest[1:end]
+		lineTime, ok := parseTime(tag)
+		if !ok {
+			if len(times) == 0 && isMetaTag(tag) {
+				return nil, "", true
+			}
+			break
+		}
+
+		times = append(times, lineTime)
+		rest = rest[end+1:]
+	}
+
+	return times, rest, false
+}
+
+// parseTime parses LRC time tags in the mm:ss, mm:ss.xx or mm:ss.xxx formats.
+func parseTime(tag string) (time.Duration, bool) {
+	minutes, seconds, ok := strings.Cut(tag, ":")
+	if !ok || minutes == "" || seconds == "" {
+		return 0, false
+	}
+
+	mm, err := strconv.Atoi(minutes)
+	if err != nil || mm < 0 {
+		return 0, false
+	}
+
+	seconds = strings.Replace(seconds, ":", ".", 1)
+	ss, err := strconv.ParseFloat(seconds, 64)
+	if err != nil || ss < 0 || ss >= 60 || strings.ContainsAny(seconds, "eE+-") {
+		return 0, false
+	}
+
+	return time.Duration(mm)*time.Minute +
+		time.Duration(ss*float64(time.Second)).Round(time.Millisecond), true
+}
+
+// isMetaTag returns true for LRC metadata tags such as "ar:Artist" or
+// "length: 3:20".
+func isMetaTag(tag string) bool {
+	name, _, ok := strings.Cut(tag, ":")
+	if !ok || name == "" {
+		return false
+	}
+
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && r != '#' {
+			return false
+		}
+	}
+	return true
+}
+
+// offsetTag returns the value of the [offset:+/-ms] tag if the line is one.
+func offsetTag(line string) (int64, bool) {
+	value, ok := strings.CutPrefix(strings.TrimSpace(line), "[offset:")
+	if !ok {
+		return 0, false
+	}
+
+	value = strings.TrimSpace(strings.TrimSuffix(value, "]"))
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return ms, true
+}
+
+// trimEmptyLines removes the empty lines at the start and at the end of plain
+// lyrics.
+func trimEmptyLines(lines []Line) []Line {
+	for len(lines) > 0 && strings.TrimSpace(lines[0].Text) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1].Text) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,58 @@
+package lyrics_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/lyrics"
+)
+
+// TestParseSynced checks parsing lyrics in the LRC format.
+func TestParseSynced(t *testing.T) {
+	const lrc = "\ufeff[ar:Iron Maiden]\r\n" +
+		"[ti:Wrathchild]\r\n" +
+		"[offset:+500]\r\n" +
+		"[00:12.50]I was born in a world\r\n" +
+		"Line without a time\r\n" +
+		"[00:20.00][01:40.123]Wrathchild\r\n" +
+		"[00:15]  Where I was searching  \r\n" +
+		"[00:30.00]\r\n"
+
+	found := lyrics.Parse(lrc)
+
+	expected := lyrics.Lyrics{
+		Synced: true,
+		Lines: []lyrics.Line{
+			{Time: 12 * time.Second, Text: "I was born in a world"},
+			{Time: 14500 * time.Millisecond, Text: "Where I was searching"},
+			{Time: 19500 * time.Millisecond, Text: "Wrathchild"},
+			{Time: 29500 * time.Millisecond, Text: ""},
+			{Time: 99623 * time.Millisecond, Text: "Wrathchild"},
+		},
+	}
+
+	if !reflect.DeepEqual(expected, found) {
+		t.Errorf("expected\n%+v\nbut got\n%+v", expected, found)
+	}
+}
+
+// TestParsePlain checks that lyrics without time tags are returned line by line.
+func TestParsePlain(t *testing.T) {
+	const text = "\n[Chorus]\nWalking through the city\n\nLooking oh so pretty\n\n"
+
+	found := lyrics.Parse(text)
+
+	expected := lyrics.Lyrics{
+		Lines: []lyrics.Line{
+			{Text: "[Chorus]"},
+			{Text: "Walking through the city"},
+			{Text: ""},
+			{Text: "Looking oh so pretty"},
+		},
+	}
+
+	if !reflect.DeepEqual(expected, found) {
+		t.Errorf("expected\n%+v\nbut got\n%+v", expected, found)
+	}
+}
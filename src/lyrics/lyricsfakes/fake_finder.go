@@ -0,0 +1,126 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package lyricsfakes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/lyrics"
+)
+
+type FakeFinder struct {
+	GetLyricsStub        func(context.Context, string, string, string, time.Duration) (string, error)
+	getLyricsMutex       sync.RWMutex
+	getLyricsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 time.Duration
+	}
+	getLyricsReturns struct {
+		result1 string
+		result2 error
+	}
+	getLyricsReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeFinder) GetLyrics(arg1 context.Context, arg2 string, arg3 string, arg4 string, arg5 time.Duration) (string, error) {
+	fake.getLyricsMutex.Lock()
+	ret, specificReturn := fake.getLyricsReturnsOnCall[len(fake.getLyricsArgsForCall)]
+	fake.getLyricsArgsForCall = append(fake.getLyricsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 time.Duration
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.GetLyricsStub
+	fakeReturns := fake.getLyricsReturns
+	fake.recordInvocation("GetLyrics", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.getLyricsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeFinder) GetLyricsCallCount() int {
+	fake.getLyricsMutex.RLock()
+	defer fake.getLyricsMutex.RUnlock()
+	return len(fake.getLyricsArgsForCall)
+}
+
+func (fake *FakeFinder) GetLyricsCalls(stub func(context.Context, string, string, string, time.Duration) (string, error)) {
+	fake.getLyricsMutex.Lock()
+	defer fake.getLyricsMutex.Unlock()
+	fake.GetLyricsStub = stub
+}
+
+func (fake *FakeFinder) GetLyricsArgsForCall(i int) (context.Context, string, string, string, time.Duration) {
+	fake.getLyricsMutex.RLock()
+	defer fake.getLyricsMutex.RUnlock()
+	argsForCall := fake.getLyricsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeFinder) GetLyricsReturns(result1 string, result2 error) {
+	fake.getLyricsMutex.Lock()
+	defer fake.getLyricsMutex.Unlock()
+	fake.GetLyricsStub = nil
+	fake.getLyricsReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFinder) GetLyricsReturnsOnCall(i int, result1 string, result2 error) {
+	fake.getLyricsMutex.Lock()
+	defer fake.getLyricsMutex.Unlock()
+	fake.GetLyricsStub = nil
+	if fake.getLyricsReturnsOnCall == nil {
+		fake.getLyricsReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.getLyricsReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFinder) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getLyricsMutex.RLock()
+	defer fake.getLyricsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeFinder) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ lyrics.Finder = new(FakeFinder)
@@ -7,6 +7,7 @@ package src
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io/fs"
@@ -17,11 +18,15 @@ import (
 	"time"
 
 	"github.com/ironsmile/euterpe/src/art"
+	"github.com/ironsmile/euterpe/src/chromaprint"
 	"github.com/ironsmile/euterpe/src/config"
 	"github.com/ironsmile/euterpe/src/daemon"
 	"github.com/ironsmile/euterpe/src/helpers"
 	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/loudness"
+	"github.com/ironsmile/euterpe/src/lyrics"
 	"github.com/ironsmile/euterpe/src/scaler"
+	"github.com/ironsmile/euterpe/src/schedule"
 	"github.com/ironsmile/euterpe/src/version"
 	"github.com/ironsmile/euterpe/src/webserver"
 	"github.com/spf13/afero"
@@ -54,6 +59,10 @@ var (
 	// doNotWatchDirs is controlled by the -dont-watch flag and will cause
 	// the program to cease watching music library directories for changes.
 	doNotWatchDirs bool
+
+	// printReport is controlled by the -report flag and will cause the program
+	// to print a report with the problems found in the library and then exit.
+	printReport bool
 )
 
 const userAgentFormat = "Euterpe Media Server/%s (github.com/ironsmile/euterpe)"
@@ -80,6 +89,10 @@ func init() {
 			"Alternatively one could use the -rescan flag.\n\n"+
 			"This option is useful for systems with low open files limit such\n"+
 			"MacOS by default.")
+	flag.BoolVar(&printReport, "report", false,
+		"Prints a JSON report with albums without artwork, tracks with missing\n"+
+			"artist or album tags and albums with gaps in their track numbers.\n"+
+			"Without starting the server proper.")
 }
 
 // Main is the only thing run in the project's root main.go file.
@@ -101,6 +114,14 @@ func Main(httpRootFS, htmlTemplatesFS, sqlFilesFS fs.FS) {
 		os.Exit(0)
 	}
 
+	if printReport {
+		if err := runLibraryReport(appfs, sqlFilesFS); err != nil {
+			log.Println(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	if localFiles {
 		httpRootFS = os.DirFS("http_root")
 		htmlTemplatesFS = os.DirFS("templates")
@@ -179,12 +200,36 @@ func getLibrary(
 		lib.AddLibraryPath(path)
 	}
 
+	if err := lib.RestoreLibraries(ctx); err != nil {
+		return nil, fmt.Errorf("restoring libraries: %w", err)
+	}
+
 	if cfg.DownloadArtwork {
 		useragent := fmt.Sprintf(userAgentFormat, version.Version)
 		caf := art.NewClient(useragent, time.Second, cfg.DiscogsAuthToken)
 		lib.SetArtFinder(caf)
+		lib.SetInfoFinder(caf)
+	}
+
+	if cfg.DownloadLyrics {
+		useragent := fmt.Sprintf(userAgentFormat, version.Version)
+		lib.SetLyricsFinder(lyrics.NewClient(useragent))
+	}
+
+	if cfg.LoudnessAnalysis.Enable {
+		lib.EnableLoudnessAnalysis(loudness.NewAnalyzer(cfg.LoudnessAnalysis.Decoder))
 	}
 
+	if cfg.Fpcalc != "" {
+		lib.EnableChromaprints(chromaprint.NewCalculator(cfg.Fpcalc))
+	}
+
+	if cfg.PrefetchArtwork {
+		lib.EnableArtworkPrefetch()
+	}
+
+	lib.SetPreferredFormats(cfg.PreferredFormats)
+
 	return lib, nil
 }
 
@@ -223,6 +268,12 @@ func runServer(appfs afero.Fs, httpRootFS, htmlTemplatesFS, sqlFilesFS fs.FS) er
 		return err
 	}
 
+	// Only the server removes the libraries which are no longer in the
+	// configuration. Reports and rescans leave the database as it is.
+	if err := lib.RemoveStaleLibraries(ctx); err != nil {
+		return fmt.Errorf("removing stale libraries: %w", err)
+	}
+
 	scl := scaler.New(ctx)
 	defer scl.Cancel()
 
@@ -236,6 +287,10 @@ func runServer(appfs afero.Fs, httpRootFS, htmlTemplatesFS, sqlFilesFS fs.FS) er
 		go lib.Scan()
 	}
 
+	if err := scheduleLibraryJobs(lib, cfg.Schedule); err != nil {
+		return err
+	}
+
 	log.Printf("Release %s\n", version.Version)
 	srv := webserver.NewServer(ctx, cfg, lib, httpRootFS, htmlTemplatesFS)
 	srv.Serve()
@@ -243,6 +298,36 @@ func runServer(appfs afero.Fs, httpRootFS, htmlTemplatesFS, sqlFilesFS fs.FS) er
 	return nil
 }
 
+// scheduleLibraryJobs starts running periodically the library jobs which have
+// schedules in the configuration.
+func scheduleLibraryJobs(lib *library.LocalLibrary, cfg config.Schedule) error {
+	jobs := []struct {
+		job  library.ScheduledJob
+		spec string
+	}{
+		{library.ScheduledScan, cfg.Scan},
+		{library.ScheduledRescan, cfg.Rescan},
+		{library.ScheduledCleanup, cfg.Cleanup},
+	}
+
+	for _, job := range jobs {
+		if job.spec == "" {
+			continue
+		}
+
+		when, err := schedule.Parse(job.spec)
+		if err != nil {
+			return fmt.Errorf("parsing the %s schedule: %w", job.job, err)
+		}
+
+		if err := lib.ScheduleJob(job.job, when); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func runLibraryRescan(appfs afero.Fs, sqlFilesFS fs.FS) error {
 	ctx, cancelContext := context.WithCancel(context.Background())
 
@@ -271,3 +356,29 @@ func runLibraryRescan(appfs afero.Fs, sqlFilesFS fs.FS) error {
 
 	return lib.Rescan(ctx)
 }
+
+func runLibraryReport(appfs afero.Fs, sqlFilesFS fs.FS) error {
+	ctx, cancelContext := context.WithCancel(context.Background())
+	defer cancelContext()
+
+	cfg, err := config.FindAndParse(appfs)
+	if err != nil {
+		return fmt.Errorf("parsing configuration: %s", err)
+	}
+
+	userPath := filepath.Dir(config.UserConfigPath(appfs))
+	lib, err := getLibrary(ctx, userPath, cfg, sqlFilesFS)
+	if err != nil {
+		return fmt.Errorf("creating library object: %w", err)
+	}
+	defer lib.Close()
+
+	report, err := lib.Report(ctx)
+	if err != nil {
+		return fmt.Errorf("generating report: %w", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
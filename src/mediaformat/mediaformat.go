@@ -0,0 +1,112 @@
+// Package mediaformat knows about the formats of media files which could be in
+// the library. It finds out the format of a file by looking at its contents and
+// knows the MIME type with which every format is served.
+//
+// Formats are named by their most common file extension, e.g. "flac" or "m4a".
+// The exception is "alac" which is Apple Lossless audio in an MP4 container. Its
+// files usually have the ".m4a" extension.
+package mediaformat
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Format describes a single media format.
+type Format struct {
+	// Name is the name of the format. It is also its most common extension.
+	Name string
+
+	// MIMEType is the value for the Content-Type HTTP header when serving files
+	// in this format.
+	MIMEType string
+
+	// Extensions are the file extensions, without the dot, which are used for
+	// files in this format.
+	Extensions []string
+}
+
+// DefaultMIMEType is the MIME type of media files in unknown formats.
+const DefaultMIMEType = "application/octet-stream"
+
+var formats = []Format{
+	{Name: "mp3", MIMEType: "audio/mpeg", Extensions: []string{"mp3"}},
+	{Name: "aac", MIMEType: "audio/aac", Extensions: []string{"aac"}},
+	{Name: "flac", MIMEType: "audio/flac", Extensions: []string{"flac", "fla"}},
+	{Name: "ogg", MIMEType: "audio/ogg", Extensions: []string{"ogg"}},
+	{Name: "oga", MIMEType: "audio/ogg", Extensions: []string{"oga"}},
+	{Name: "opus", MIMEType: "audio/ogg; codecs=opus", Extensions: []string{"opus"}},
+	{Name: "spx", MIMEType: "audio/ogg; codecs=speex", Extensions: []string{"spx"}},
+	{Name: "wav", MIMEType: "audio/wav", Extensions: []string{"wav", "wave"}},
+	{Name: "aiff", MIMEType: "audio/aiff", Extensions: []string{"aiff", "aif", "aifc"}},
+	{Name: "wv", MIMEType: "audio/x-wavpack", Extensions: []string{"wv"}},
+	{Name: "ape", MIMEType: "audio/x-ape", Extensions: []string{"ape"}},
+	{Name: "mpc", MIMEType: "audio/x-musepack", Extensions: []string{"mpc"}},
+	{Name: "m4a", MIMEType: "audio/mp4", Extensions: []string{"m4a", "m4b"}},
+	{Name: "alac", MIMEType: "audio/mp4"},
+	{Name: "mp4", MIMEType: "video/mp4", Extensions: []string{"mp4"}},
+	{Name: "webm", MIMEType: "audio/webm", Extensions: []string{"webm"}},
+	{Name: "mka", MIMEType: "audio/x-matroska", Extensions: []string{"mka"}},
+	{Name: "dsf", MIMEType: "audio/x-dsf", Extensions: []string{"dsf"}},
+	{Name: "dff", MIMEType: "audio/x-dff", Extensions: []string{"dff"}},
+	{Name: "wma", MIMEType: "audio/x-ms-wma", Extensions: []string{"wma"}},
+}
+
+// All returns all known formats.
+func All() []Format {
+	return append([]Format(nil), formats...)
+}
+
+// DefaultExtensions returns the extensions, without the dot, of all known
+// formats.
+func DefaultExtensions() []string {
+	var extensions []string
+	for _, format := range formats {
+		extensions = append(extensions, format.Extensions...)
+	}
+	return extensions
+}
+
+// ByName returns the format with this name.
+func ByName(name string) (Format, bool) {
+	for _, format := range formats {
+		if strings.EqualFold(format.Name, name) {
+			return format, true
+		}
+	}
+	return Format{}, false
+}
+
+// ByExtension returns the format of files with the extension ext. It could be
+// with or without the leading dot.
+func ByExtension(ext string) (Format, bool) {
+	ext = strings.TrimPrefix(ext, ".")
+	for _, format := range formats {
+		for _, formatExt := range format.Extensions {
+			if strings.EqualFold(formatExt, ext) {
+				return format, true
+			}
+		}
+	}
+	return Format{}, false
+}
+
+// FromFileName returns the name of the format of a file judging only by its
+// extension. For unknown extensions the extension itself in lower case is
+// returned. And an empty string for files without one.
+func FromFileName(path string) string {
+	ext := filepath.Ext(path)
+	if format, ok := ByExtension(ext); ok {
+		return format.Name
+	}
+	return strings.ToLower(strings.TrimPrefix(ext, "."))
+}
+
+// MIMEType returns the MIME type for files in the format with this name. It is
+// DefaultMIMEType for unknown formats.
+func MIMEType(name string) string {
+	if format, ok := ByName(name); ok {
+		return format.MIMEType
+	}
+	return DefaultMIMEType
+}
@@ -0,0 +1,306 @@
+package mediaformat
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrUnknownFormat is returned when the format of a file could not be found out
+// from its contents.
+var ErrUnknownFormat = errors.New("unknown media format")
+
+var asfHeaderGUID = []byte{
+	0x30, 0x26, 0xb2, 0x75, 0x8e, 0x66, 0xcf, 0x11,
+	0xa6, 0xd9, 0x00, 0xaa, 0x00, 0x62, 0xce, 0x6c,
+}
+
+// SniffFile returns the name of the format of the file at path by looking at
+// its contents.
+func SniffFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	st, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	return Sniff(file, st.Size())
+}
+
+// Sniff returns the name of the format of the media file in r by looking at its
+// container. Size is the size of the file. ErrUnknownFormat is returned when the
+// container is not recognized.
+func Sniff(r io.ReaderAt, size int64) (string, error) {
+	header, err := readHeader(r, 0, 128)
+	if err != nil {
+		return "", err
+	}
+
+	var offset int64
+	if bytes.HasPrefix(header, []byte("ID3")) && len(header) >= 10 {
+		// Skipping the ID3v2 tag at the beginning of the file. These could be
+		// found in front of MP3, AAC and even FLAC files.
+		offset = 10 + (int64(header[6]&0x7f)<<21 | int64(header[7]&0x7f)<<14 |
+			int64(header[8]&0x7f)<<7 | int64(header[9]&0x7f))
+		if header[5]&0x10 != 0 {
+			// There is a footer as well.
+			offset += 10
+		}
+
+		header, err = readHeader(r, offset, 128)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if format := sniffHeader(header); format != "" {
+		return format, nil
+	}
+
+	if offset == 0 && len(header) >= 8 && string(header[4:8]) == "ftyp" {
+		return sniffMP4(r, size)
+	}
+
+	if offset > 0 {
+		// ID3v2 tags are used almost only with MP3 files. Their first frame is
+		// sometimes not right after the tag because of padding.
+		return "mp3", nil
+	}
+
+	return "", ErrUnknownFormat
+}
+
+// readHeader reads up to n bytes from r at offset.
+func readHeader(r io.ReaderAt, offset int64, n int) ([]byte, error) {
+	header := make([]byte, n)
+	read, err := r.ReadAt(header, offset)
+	if read == 0 && err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrUnknownFormat
+		}
+		return nil, fmt.Errorf("reading file header: %w", err)
+	}
+	return header[:read], nil
+}
+
+// sniffHeader returns the format of the file which starts with header. The
+// format is an empty string when it is not recognized.
+func sniffHeader(header []byte) string {
+	hasMagic := func(at int, magic string) bool {
+		return len(header) >= at+len(magic) &&
+			string(header[at:at+len(magic)]) == magic
+	}
+
+	switch {
+	case hasMagic(0, "fLaC"):
+		return "flac"
+	case (hasMagic(0, "RIFF") || hasMagic(0, "RF64")) && hasMagic(8, "WAVE"):
+		return "wav"
+	case hasMagic(0, "FORM") && (hasMagic(8, "AIFF") || hasMagic(8, "AIFC")):
+		return "aiff"
+	case hasMagic(0, "OggS"):
+		return sniffOgg(header)
+	case hasMagic(0, "wvpk"):
+		return "wv"
+	case hasMagic(0, "MAC "):
+		return "ape"
+	case hasMagic(0, "MPCK") || hasMagic(0, "MP+"):
+		return "mpc"
+	case hasMagic(0, "DSD "):
+		return "dsf"
+	case hasMagic(0, "FRM8"):
+		return "dff"
+	case hasMagic(0, "ADIF"):
+		return "aac"
+	case bytes.HasPrefix(header, asfHeaderGUID):
+		return "wma"
+	case hasMagic(0, "\x1a\x45\xdf\xa3"):
+		// The EBML header of Matroska files has their document type.
+		if bytes.Contains(header, []byte("webm")) {
+			return "webm"
+		}
+		return "mka"
+	case len(header) >= 4 && header[0] == 0xff && header[1]&0xe0 == 0xe0:
+		return sniffMPEG(header)
+	}
+
+	return ""
+}
+
+// sniffOgg returns the format of an Ogg file by the codec in its first page.
+func sniffOgg(header []byte) string {
+	const pageHeaderSize = 27
+	if len(header) < pageHeaderSize {
+		return "ogg"
+	}
+
+	payload := header[pageHeaderSize:]
+	segments := int(header[pageHeaderSize-1])
+	if len(payload) < segments {
+		return "ogg"
+	}
+	payload = payload[segments:]
+
+	switch {
+	case bytes.HasPrefix(payload, []byte("OpusHead")):
+		return "opus"
+	case bytes.HasPrefix(payload, []byte("\x7fFLAC")):
+		return "oga"
+	case bytes.HasPrefix(payload, []byte("Speex   ")):
+		return "spx"
+	default:
+		return "ogg"
+	}
+}
+
+// sniffMPEG returns the format of a file which starts with an MPEG audio frame.
+// The format is an empty string when the frame header is not valid.
+func sniffMPEG(header []byte) string {
+	layer := (header[1] >> 1) & 0x03
+	if layer == 0 {
+		// Layer 0 is used by the ADTS headers of AAC streams.
+		return "aac"
+	}
+
+	bitrateIndex := header[2] >> 4
+	sampleRateIndex := (header[2] >> 2) & 0x03
+	if bitrateIndex == 0x0f || sampleRateIndex == 0x03 {
+		return ""
+	}
+	return "mp3"
+}
+
+// mp4Info is what is learned about an MP4 file from its boxes.
+type mp4Info struct {
+	brand       string
+	hasVideo    bool
+	audioCodecs []string
+
+	// handler is the type of the media of the current track.
+	handler string
+}
+
+// sniffMP4 returns the format of a file in the MP4 container. Files with Apple
+// Lossless audio are "alac", the rest of the audio-only files are "m4a" and
+// files with video are "mp4".
+func sniffMP4(r io.ReaderAt, size int64) (string, error) {
+	var info mp4Info
+	err := readMP4Boxes(r, 0, size, info.read(r))
+	if err != nil && info.brand == "" {
+		return "", fmt.Errorf("reading MP4 boxes: %w", err)
+	}
+
+	for _, codec := range info.audioCodecs {
+		if codec == "alac" {
+			return "alac", nil
+		}
+	}
+
+	switch {
+	case info.hasVideo:
+		return "mp4", nil
+	case len(info.audioCodecs) > 0:
+		return "m4a", nil
+	case info.brand == "M4A " || info.brand == "M4B " || info.brand == "M4P ":
+		return "m4a", nil
+	default:
+		return "mp4", nil
+	}
+}
+
+// read returns a function which reads the boxes of an MP4 file into info.
+func (info *mp4Info) read(r io.ReaderAt) func(typ string, start, end int64) error {
+	var readBox func(typ string, start, end int64) error
+	readBox = func(typ string, start, end int64) error {
+		switch typ {
+		case "moov", "mdia", "minf", "stbl":
+			return readMP4Boxes(r, start, end, readBox)
+		case "trak":
+			info.handler = ""
+			return readMP4Boxes(r, start, end, readBox)
+		case "ftyp":
+			brand, err := readHeader(r, start, 4)
+			if err != nil {
+				return err
+			}
+			info.brand = string(brand)
+		case "hdlr":
+			// Version, flags and pre-defined come before the handler type.
+			handler, err := readHeader(r, start+8, 4)
+			if err != nil {
+				return err
+			}
+			info.handler = string(handler)
+		case "stsd":
+			// Version, flags and the number of entries come before the first
+			// entry. Its type is the codec.
+			entry, err := readHeader(r, start+8, 8)
+			if err != nil {
+				return err
+			}
+			if len(entry) < 8 {
+				return nil
+			}
+
+			switch info.handler {
+			case "soun":
+				info.audioCodecs = append(info.audioCodecs, string(entry[4:8]))
+			case "vide":
+				info.hasVideo = true
+			}
+		}
+		return nil
+	}
+	return readBox
+}
+
+// readMP4Boxes calls readBox for every MP4 box between start and end in r with
+// the type of the box and where its contents start and end.
+func readMP4Boxes(
+	r io.ReaderAt,
+	start, end int64,
+	readBox func(typ string, start, end int64) error,
+) error {
+	for pos := start; pos+8 <= end; {
+		header, err := readHeader(r, pos, 16)
+		if err != nil {
+			return err
+		}
+		if len(header) < 8 {
+			return io.ErrUnexpectedEOF
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		headerSize := int64(8)
+		switch size {
+		case 0:
+			// The box lasts until the end of the file.
+			size = end - pos
+		case 1:
+			if len(header) < 16 {
+				return io.ErrUnexpectedEOF
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+
+		if size < headerSize || pos+size > end {
+			return fmt.Errorf("invalid size of box %q", header[4:8])
+		}
+
+		if err := readBox(string(header[4:8]), pos+headerSize, pos+size); err != nil {
+			return err
+		}
+		pos += size
+	}
+
+	return nil
+}
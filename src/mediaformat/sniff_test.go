@@ -0,0 +1,147 @@
+package mediaformat
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/helpers"
+)
+
+// TestSniff checks that formats are recognized by the headers of their
+// containers.
+func TestSniff(t *testing.T) {
+	oggPage := func(payload string) []byte {
+		page := make([]byte, 27)
+		copy(page, "OggS")
+		page[26] = 1
+		page = append(page, byte(len(payload)))
+		return append(page, payload...)
+	}
+
+	tests := []struct {
+		desc     string
+		content  []byte
+		expected string
+	}{
+		{"flac", []byte("fLaC\x00\x00\x00\x22"), "flac"},
+		{"wav", []byte("RIFF\x24\x00\x00\x00WAVEfmt "), "wav"},
+		{"aiff", []byte("FORM\x00\x00\x00\x00AIFFCOMM"), "aiff"},
+		{"aiff-c", []byte("FORM\x00\x00\x00\x00AIFCFVER"), "aiff"},
+		{"ogg vorbis", oggPage("\x01vorbis"), "ogg"},
+		{"ogg opus", oggPage("OpusHead"), "opus"},
+		{"ogg flac", oggPage("\x7fFLAC\x01\x00"), "oga"},
+		{"wavpack", []byte("wvpk\x00\x00\x00\x00"), "wv"},
+		{"monkey's audio", []byte("MAC \x96\x0f\x00\x00"), "ape"},
+		{"musepack", []byte("MPCKSH"), "mpc"},
+		{"dsf", []byte("DSD \x1c\x00\x00\x00"), "dsf"},
+		{"dff", []byte("FRM8\x00\x00\x00\x00"), "dff"},
+		{"wma", append(append([]byte(nil), asfHeaderGUID...), 0, 0), "wma"},
+		{"webm", []byte("\x1a\x45\xdf\xa3\x9f\x42\x82\x84webm"), "webm"},
+		{"matroska", []byte("\x1a\x45\xdf\xa3\xa3\x42\x82\x88matroska"), "mka"},
+		{"mp3 frame", []byte{0xff, 0xfb, 0x90, 0x64}, "mp3"},
+		{"adts", []byte{0xff, 0xf1, 0x50, 0x80}, "aac"},
+		{"id3 with flac", append(id3Tag(4), "fLaC"...), "flac"},
+		{"id3 with padding", append(id3Tag(4), 0, 0, 0, 0, 0, 0), "mp3"},
+		{"alac", mp4File("M4A ", "soun", "alac"), "alac"},
+		{"aac in m4a", mp4File("isom", "soun", "mp4a"), "m4a"},
+		{"video", mp4File("isom", "vide", "avc1"), "mp4"},
+	}
+
+	for _, test := range tests {
+		format, err := Sniff(bytes.NewReader(test.content), int64(len(test.content)))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.desc, err)
+			continue
+		}
+		if format != test.expected {
+			t.Errorf("%s: expected format %s but got %s",
+				test.desc, test.expected, format)
+		}
+	}
+
+	_, err := Sniff(bytes.NewReader([]byte("not media")), 9)
+	if !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat for a text file but got %v", err)
+	}
+}
+
+// TestSniffFile checks sniffing real files from the test files.
+func TestSniffFile(t *testing.T) {
+	projRoot, err := helpers.ProjectRoot()
+	if err != nil {
+		t.Fatalf("Was not able to find test_files directory: %s", err)
+	}
+
+	mp3 := filepath.Join(projRoot, "test_files", "more_mp3s", "test_file_added.mp3")
+	format, err := SniffFile(mp3)
+	if err != nil {
+		t.Fatalf("sniffing %s: %s", mp3, err)
+	}
+	if format != "mp3" {
+		t.Errorf("expected mp3 but got %s", format)
+	}
+
+	notMP3 := filepath.Join(projRoot, "test_files", "library", "folder_one", "not_an_mp3")
+	if _, err := SniffFile(notMP3); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat for %s but got %v", notMP3, err)
+	}
+}
+
+// TestFromFileName checks finding out formats by file extensions.
+func TestFromFileName(t *testing.T) {
+	tests := map[string]string{
+		"/music/song.mp3":  "mp3",
+		"/music/song.FLA":  "flac",
+		"/music/song.aif":  "aiff",
+		"/music/song.m4b":  "m4a",
+		"/music/song.xyz":  "xyz",
+		"/music/song":      "",
+		"/music/song.opus": "opus",
+	}
+
+	for path, expected := range tests {
+		if format := FromFileName(path); format != expected {
+			t.Errorf("expected format %q for %s but got %q", expected, path, format)
+		}
+	}
+
+	if mimeType := MIMEType("alac"); mimeType != "audio/mp4" {
+		t.Errorf("expected audio/mp4 for alac but got %s", mimeType)
+	}
+	if mimeType := MIMEType("xyz"); mimeType != DefaultMIMEType {
+		t.Errorf("expected %s for unknown format but got %s", DefaultMIMEType, mimeType)
+	}
+}
+
+// id3Tag returns an ID3v2 tag with size bytes of padding.
+func id3Tag(size int) []byte {
+	tag := []byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, byte(size)}
+	return append(tag, make([]byte, size)...)
+}
+
+// mp4File returns the boxes of an MP4 file with a single track.
+func mp4File(brand, handler, codec string) []byte {
+	box := func(typ string, contents ...[]byte) []byte {
+		payload := bytes.Join(contents, nil)
+		out := make([]byte, 8, 8+len(payload))
+		binary.BigEndian.PutUint32(out, uint32(8+len(payload)))
+		copy(out[4:], typ)
+		return append(out, payload...)
+	}
+
+	hdlr := append(make([]byte, 8), handler...)
+	hdlr = append(hdlr, make([]byte, 12)...)
+	stsd := append([]byte{0, 0, 0, 0, 0, 0, 0, 1}, box(codec, make([]byte, 28))...)
+
+	return bytes.Join([][]byte{
+		box("ftyp", []byte(brand), make([]byte, 4)),
+		box("moov", box("trak", box("mdia",
+			box("hdlr", hdlr),
+			box("minf", box("stbl", box("stsd", stsd))),
+		))),
+		box("mdat", make([]byte, 16)),
+	}, nil)
+}
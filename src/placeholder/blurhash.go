@@ -0,0 +1,151 @@
+package placeholder
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// The number of horizontal and vertical components used for the BlurHash of
+// images. Album artwork and artist images are roughly square so the same number
+// is used for both.
+const (
+	blurHashXComponents = 4
+	blurHashYComponents = 4
+)
+
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// BlurHash encodes `img` as a BlurHash string with `xComponents` by `yComponents`
+// components. Both must be between 1 and 9.
+//
+// The algorithm is described at https://github.com/woltapp/blurhash
+func BlurHash(img image.Image, xComponents, yComponents int) string {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// Converting every pixel to linear RGB only once.
+	linear := make([][3]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBAModel.Convert(
+				img.At(bounds.Min.X+x, bounds.Min.Y+y),
+			).(color.NRGBA)
+			linear[y*width+x] = [3]float64{
+				sRGBToLinear(c.R),
+				sRGBToLinear(c.G),
+				sRGBToLinear(c.B),
+			}
+		}
+	}
+
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1.0
+			}
+
+			var factor [3]float64
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					basis := normalisation *
+						math.Cos(math.Pi*float64(i)*float64(x)/float64(width)) *
+						math.Cos(math.Pi*float64(j)*float64(y)/float64(height))
+
+					pixel := linear[y*width+x]
+					factor[0] += basis * pixel[0]
+					factor[1] += basis * pixel[1]
+					factor[2] += basis * pixel[2]
+				}
+			}
+
+			scale := 1.0 / float64(width*height)
+			factor[0] *= scale
+			factor[1] *= scale
+			factor[2] *= scale
+			factors = append(factors, factor)
+		}
+	}
+
+	var hash strings.Builder
+	encodeBase83(&hash, (xComponents-1)+(yComponents-1)*9, 1)
+
+	dc, ac := factors[0], factors[1:]
+
+	maximumValue := 1.0
+	if len(ac) > 0 {
+		var actualMaximum float64
+		for _, factor := range ac {
+			for _, v := range factor {
+				actualMaximum = math.Max(actualMaximum, math.Abs(v))
+			}
+		}
+
+		quantisedMaximum := clamp(int(math.Floor(actualMaximum*166-0.5)), 0, 82)
+		maximumValue = float64(quantisedMaximum+1) / 166
+		encodeBase83(&hash, quantisedMaximum, 1)
+	} else {
+		encodeBase83(&hash, 0, 1)
+	}
+
+	encodeBase83(&hash, encodeDC(dc), 4)
+	for _, factor := range ac {
+		encodeBase83(&hash, encodeAC(factor, maximumValue), 2)
+	}
+
+	return hash.String()
+}
+
+func encodeDC(value [3]float64) int {
+	return linearToSRGB(value[0])<<16 |
+		linearToSRGB(value[1])<<8 |
+		linearToSRGB(value[2])
+}
+
+func encodeAC(value [3]float64, maximumValue float64) int {
+	quant := func(v float64) int {
+		return clamp(int(math.Floor(signPow(v/maximumValue, 0.5)*9+9.5)), 0, 18)
+	}
+
+	return quant(value[0])*19*19 + quant(value[1])*19 + quant(value[2])
+}
+
+func encodeBase83(out *strings.Builder, value, length int) {
+	for i := 1; i <= length; i++ {
+		digit := (value / int(math.Pow(83, float64(length-i)))) % 83
+		out.WriteByte(base83Chars[digit])
+	}
+}
+
+func sRGBToLinear(value uint8) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
+
+func clamp(value, min, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
@@ -0,0 +1,6 @@
+/*
+Package placeholder computes small representations of images which clients could
+show while the actual image is still loading. These are the dominant colour of the
+image and its BlurHash. See https://blurha.sh for the latter.
+*/
+package placeholder
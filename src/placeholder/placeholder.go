@@ -0,0 +1,113 @@
+package placeholder
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+
+	// The following are all image formats supported for computing
+	// placeholders. They are the same as the ones supported by the scaler.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/vp8"
+	_ "golang.org/x/image/webp"
+
+	"golang.org/x/image/draw"
+)
+
+// sampleSize is the width and height of the image from which placeholders are
+// computed. Images are scaled down to it first since neither the dominant colour
+// nor the BlurHash need more detail and this makes computing them fast.
+const sampleSize = 32
+
+// Placeholder describes an image in a way which is small enough to be sent to
+// clients together with the rest of the album or artist information.
+type Placeholder struct {
+	// Color is the dominant colour of the image in the "#rrggbb" format.
+	Color string
+
+	// BlurHash is a compact representation of a blurred version of the image.
+	BlurHash string
+}
+
+// Compute decodes the image `img` and returns its placeholder.
+func Compute(img []byte) (Placeholder, error) {
+	decoded, _, err := image.Decode(bytes.NewReader(img))
+	if err != nil {
+		return Placeholder{}, fmt.Errorf("error decoding image: %w", err)
+	}
+
+	if decoded.Bounds().Empty() {
+		return Placeholder{}, fmt.Errorf("image is empty")
+	}
+
+	sample := image.NewRGBA(image.Rect(0, 0, sampleSize, sampleSize))
+	draw.ApproxBiLinear.Scale(
+		sample,
+		sample.Bounds(),
+		decoded,
+		decoded.Bounds(),
+		draw.Src,
+		nil,
+	)
+
+	dominant := DominantColor(sample)
+
+	return Placeholder{
+		Color:    fmt.Sprintf("#%02x%02x%02x", dominant.R, dominant.G, dominant.B),
+		BlurHash: BlurHash(sample, blurHashXComponents, blurHashYComponents),
+	}, nil
+}
+
+// DominantColor returns the colour which is most common in `img`. Similar colours
+// are counted together and the result is their average. Transparent pixels are
+// ignored.
+func DominantColor(img image.Image) color.RGBA {
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+
+	// Colours are put in buckets by their 4 most significant bits for every
+	// channel.
+	var buckets [1 << 12]bucket
+
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A == 0 {
+				continue
+			}
+
+			ind := int(c.R>>4)<<8 | int(c.G>>4)<<4 | int(c.B>>4)
+			buckets[ind].count++
+			buckets[ind].r += int(c.R)
+			buckets[ind].g += int(c.G)
+			buckets[ind].b += int(c.B)
+		}
+	}
+
+	var best *bucket
+	for ind := range buckets {
+		if best == nil || buckets[ind].count > best.count {
+			best = &buckets[ind]
+		}
+	}
+
+	if best.count == 0 {
+		return color.RGBA{A: 0xff}
+	}
+
+	return color.RGBA{
+		R: uint8(best.r / best.count),
+		G: uint8(best.g / best.count),
+		B: uint8(best.b / best.count),
+		A: 0xff,
+	}
+}
@@ -0,0 +1,132 @@
+package placeholder_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/placeholder"
+)
+
+// TestCompute checks that placeholders are computed for encoded images.
+func TestCompute(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			c := color.RGBA{R: 0xcc, G: 0x22, B: 0x11, A: 0xff}
+			if x >= 150 {
+				c = color.RGBA{R: 0x10, G: 0x20, B: 0xf0, A: 0xff}
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	var buff bytes.Buffer
+	if err := png.Encode(&buff, img); err != nil {
+		t.Fatalf("encoding test image: %s", err)
+	}
+
+	found, err := placeholder.Compute(buff.Bytes())
+	if err != nil {
+		t.Fatalf("computing placeholder: %s", err)
+	}
+
+	if found.Color != "#cc2211" {
+		t.Errorf("expected dominant colour #cc2211 but got %s", found.Color)
+	}
+
+	// One character for the size, one for the maximum AC value, four for the DC
+	// component and two for every of the 15 AC components.
+	if len(found.BlurHash) != 36 {
+		t.Errorf("expected BlurHash with 36 characters but got `%s`", found.BlurHash)
+	}
+
+	if _, err := placeholder.Compute([]byte("not an image")); err == nil {
+		t.Errorf("expected an error for data which is not an image")
+	}
+}
+
+// TestBlurHashSolidColor checks the BlurHash of an image with a single colour.
+// It must have the colour as its DC component.
+func TestBlurHashSolidColor(t *testing.T) {
+	img := image.NewUniform(color.RGBA{R: 0xff, G: 0x80, B: 0x00, A: 0xff})
+	hash := placeholder.BlurHash(&boundedImage{img, image.Rect(0, 0, 16, 16)}, 4, 3)
+
+	if len(hash) != 28 {
+		t.Fatalf("expected BlurHash with 28 characters but got `%s`", hash)
+	}
+
+	// Size flag for 4x3 components is (4-1) + (3-1)*9 = 21.
+	if hash[0] != 'L' {
+		t.Errorf("wrong size flag in `%s`", hash)
+	}
+
+	if dc := decodeBase83(hash[2:6]); dc != 0xff8000 {
+		t.Errorf("expected DC component 0xff8000 but got %#06x", dc)
+	}
+}
+
+// TestBlurHashHorizontalGradient checks that an image which is bright on the left
+// and dark on the right has a positive first horizontal AC component.
+func TestBlurHashHorizontalGradient(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 20, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			if x < 10 {
+				img.SetGray(x, y, color.Gray{Y: 0xff})
+			}
+		}
+	}
+
+	hash := placeholder.BlurHash(img, 2, 1)
+	if len(hash) != 8 {
+		t.Fatalf("expected BlurHash with 8 characters but got `%s`", hash)
+	}
+
+	ac := decodeBase83(hash[6:8])
+	r, g, b := ac/(19*19), (ac/19)%19, ac%19
+	if r <= 9 || g <= 9 || b <= 9 {
+		t.Errorf("expected positive AC component but got %d, %d, %d", r, g, b)
+	}
+}
+
+// TestDominantColor checks that transparent pixels are ignored when looking for
+// the dominant colour.
+func TestDominantColor(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			if x < 3 {
+				img.SetNRGBA(x, y, color.NRGBA{G: 0xff, A: 0xff})
+			}
+		}
+	}
+
+	found := placeholder.DominantColor(img)
+	expected := color.RGBA{G: 0xff, A: 0xff}
+	if found != expected {
+		t.Errorf("expected dominant colour %v but got %v", expected, found)
+	}
+}
+
+type boundedImage struct {
+	image.Image
+	bounds image.Rectangle
+}
+
+func (i *boundedImage) Bounds() image.Rectangle {
+	return i.bounds
+}
+
+func decodeBase83(s string) int {
+	const chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+	var value int
+	for _, c := range s {
+		value = value*83 + strings.IndexRune(chars, c)
+	}
+	return value
+}
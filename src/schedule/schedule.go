@@ -0,0 +1,229 @@
+// Package schedule parses schedules in the cron format and finds the times at
+// which they come.
+//
+// A schedule has five fields separated by spaces: minute, hour, day of month,
+// month and day of week. Every field is "*" or a comma separated list of numbers
+// and ranges such as "1-5". Both could have steps such as "*/15" or "1-30/2".
+// Months and days of week could be written with their first three letters, e.g.
+// "jan" or "mon". Sunday is both 0 and 7. When both the day of month and the day
+// of week are restricted then matching either of them is enough, as in cron.
+//
+// The descriptors "@yearly", "@annually", "@monthly", "@weekly", "@daily",
+// "@midnight" and "@hourly" could be used instead of the fields.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// searchYears is for how many years ahead Next searches for matching times.
+// Schedules such as "0 0 30 2 *" never come.
+const searchYears = 5
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = []string{
+		"jan", "feb", "mar", "apr", "may", "jun",
+		"jul", "aug", "sep", "oct", "nov", "dec",
+	}
+	dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// Schedule is a parsed cron schedule. Every field is a bit set of the values
+// which match it.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// anyDOM and anyDOW are set when the day of month and the day of week are
+	// not restricted.
+	anyDOM, anyDOW bool
+}
+
+// field describes the possible values of a schedule field.
+type field struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: monthNames}
+	dowField    = field{name: "day of week", min: 0, max: 7, names: dayNames}
+)
+
+// Parse parses a schedule in the cron format.
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(strings.ToLower(spec))
+	if descriptor, ok := descriptors[spec]; ok {
+		spec = descriptor
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf(
+			"expected 5 fields in schedule %q but found %d", spec, len(fields),
+		)
+	}
+
+	var (
+		sched Schedule
+		err   error
+	)
+	for ind, target := range []struct {
+		field field
+		bits  *uint64
+	}{
+		{minuteField, &sched.minute},
+		{hourField, &sched.hour},
+		{domField, &sched.dom},
+		{monthField, &sched.month},
+		{dowField, &sched.dow},
+	} {
+		*target.bits, err = parseField(fields[ind], target.field)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Sunday is both 0 and 7.
+	if sched.dow&(1<<7) != 0 {
+		sched.dow |= 1
+	}
+
+	sched.anyDOM = fields[2] == "*"
+	sched.anyDOW = fields[4] == "*"
+
+	return &sched, nil
+}
+
+// parseField returns the bit set of the values of a single field.
+func parseField(text string, f field) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(text, ",") {
+		rangeText, stepText, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepText)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q for %s", stepText, f.name)
+			}
+		}
+
+		var start, end int
+		switch {
+		case rangeText == "*":
+			start, end = f.min, f.max
+		case strings.Contains(rangeText, "-"):
+			startText, endText, _ := strings.Cut(rangeText, "-")
+			var err error
+			if start, err = f.value(startText); err != nil {
+				return 0, err
+			}
+			if end, err = f.value(endText); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range %q for %s", rangeText, f.name)
+			}
+		default:
+			var err error
+			if start, err = f.value(rangeText); err != nil {
+				return 0, err
+			}
+			end = start
+			if hasStep {
+				end = f.max
+			}
+		}
+
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+
+	return bits, nil
+}
+
+// value parses a single value of the field.
+func (f field) value(text string) (int, error) {
+	for ind, name := range f.names {
+		if text == name {
+			return ind + f.min, nil
+		}
+	}
+
+	value, err := strconv.Atoi(text)
+	if err != nil || value < f.min || value > f.max {
+		return 0, fmt.Errorf("invalid value %q for %s", text, f.name)
+	}
+	return value, nil
+}
+
+// Next returns the first time after `after` which matches the schedule. It is
+// in the location of `after`. The zero time is returned when there is no such
+// time in the next few years.
+func (s *Schedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	loc := t.Location()
+	limit := t.Year() + searchYears
+
+	for t.Year() <= limit {
+		if !has(s.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if !has(s.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+
+		if !has(s.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// matchesDay returns true when the day of t matches the schedule.
+func (s *Schedule) matchesDay(t time.Time) bool {
+	domMatch := has(s.dom, t.Day())
+	dowMatch := has(s.dow, int(t.Weekday()))
+
+	if s.anyDOM || s.anyDOW {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// has returns true when value is in the bit set.
+func has(bits uint64, value int) bool {
+	return bits&(1<<uint(value)) != 0
+}
@@ -0,0 +1,65 @@
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ironsmile/euterpe/src/schedule"
+)
+
+// TestNext checks finding the next times of schedules.
+func TestNext(t *testing.T) {
+	// It is a Wednesday.
+	now := time.Date(2024, time.May, 15, 10, 42, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2024, time.May, 15, 10, 43, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, time.May, 15, 10, 45, 0, 0, time.UTC)},
+		{"30 3 * * *", time.Date(2024, time.May, 16, 3, 30, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, time.May, 16, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, time.May, 15, 11, 0, 0, 0, time.UTC)},
+		{"0 4 * * sun", time.Date(2024, time.May, 19, 4, 0, 0, 0, time.UTC)},
+		{"0 4 * * 7", time.Date(2024, time.May, 19, 4, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1-5 * 1,3", time.Date(2024, time.May, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 12 20 * 1", time.Date(2024, time.May, 20, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, test := range tests {
+		sched, err := schedule.Parse(test.spec)
+		if err != nil {
+			t.Errorf("parsing %q: %s", test.spec, err)
+			continue
+		}
+
+		if next := sched.Next(now); !next.Equal(test.expected) {
+			t.Errorf("%q: expected next time %s but got %s", test.spec, test.expected, next)
+		}
+	}
+}
+
+// TestParseErrors checks that invalid schedules are rejected.
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"@sometimes",
+		"* * * foo *",
+	} {
+		if _, err := schedule.Parse(spec); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
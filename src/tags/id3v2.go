@@ -0,0 +1,297 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// id3Frames maps ID3v2.3 and ID3v2.4 text frames to Vorbis comment names. Only
+// the ones which are used by the rest of the program are here.
+var id3Frames = map[string]string{
+	"TIT2": Title,
+	"TPE1": Artist,
+	"TALB": Album,
+	"TPE2": AlbumArtist,
+	"TRCK": TrackNumber,
+	"TPOS": DiscNumber,
+	"TCON": Genre,
+	"TYER": Date,
+	"TDRC": Date,
+}
+
+// id3v22Frames are the ID3v2.2 equivalents of the frames in id3Frames.
+var id3v22Frames = map[string]string{
+	"TT2": Title,
+	"TP1": Artist,
+	"TAL": Album,
+	"TP2": AlbumArtist,
+	"TRK": TrackNumber,
+	"TPA": DiscNumber,
+	"TCO": Genre,
+	"TYE": Date,
+	"TXX": "TXXX",
+	"UFI": "UFID",
+	"ULT": "USLT",
+}
+
+// readID3v2 reads the ID3v2 tag at the current position of `r` into `tags`. On
+// return `r` is positioned right after the tag.
+func readID3v2(r io.Reader, tags Tags) error {
+	var header [10]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return fmt.Errorf("reading ID3v2 header: %w", err)
+	}
+
+	version := header[3]
+	flags := header[5]
+	size := syncsafe(header[6:10])
+
+	if size > maxTagSize {
+		return fmt.Errorf("ID3v2 tag is too big: %d bytes", size)
+	}
+
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return fmt.Errorf("reading ID3v2 tag: %w", err)
+	}
+
+	if version < 2 || version > 4 {
+		// Unknown versions are skipped but the rest of the file could
+		// still be read.
+		return nil
+	}
+
+	// In ID3v2.4 the unsynchronisation is done per frame.
+	if flags&0x80 != 0 && version < 4 {
+		body = removeUnsync(body)
+	}
+
+	if flags&0x40 != 0 && version >= 3 {
+		if len(body) < 4 {
+			return nil
+		}
+		extSize := int(binary.BigEndian.Uint32(body[:4]))
+		if version == 3 {
+			extSize += 4
+		} else {
+			extSize = int(syncsafe(body[:4]))
+		}
+		if extSize > len(body) {
+			return nil
+		}
+		body = body[extSize:]
+	}
+
+	idLen, headerLen := 4, 10
+	if version == 2 {
+		idLen, headerLen = 3, 6
+	}
+
+	for len(body) >= headerLen {
+		id := string(body[:idLen])
+		if id[0] == 0 {
+			// Reached the padding.
+			break
+		}
+
+		var (
+			frameSize  int
+			frameFlags uint16
+		)
+		switch version {
+		case 2:
+			frameSize = int(body[3])<<16 | int(body[4])<<8 | int(body[5])
+		case 3:
+			frameSize = int(binary.BigEndian.Uint32(body[4:8]))
+			frameFlags = binary.BigEndian.Uint16(body[8:10])
+		case 4:
+			frameSize = int(syncsafe(body[4:8]))
+			frameFlags = binary.BigEndian.Uint16(body[8:10])
+		}
+
+		body = body[headerLen:]
+		if frameSize > len(body) {
+			break
+		}
+		frame := body[:frameSize]
+		body = body[frameSize:]
+
+		if version == 2 {
+			mapped, ok := id3v22Frames[id]
+			if !ok {
+				continue
+			}
+			id = mapped
+		}
+
+		frame, ok := id3FrameData(frame, version, frameFlags)
+		if !ok {
+			continue
+		}
+
+		readID3Frame(id, frame, tags)
+	}
+
+	return nil
+}
+
+// id3FrameData returns the actual contents of a frame taking into account its
+// flags. When the frame could not be decoded it returns false.
+func id3FrameData(frame []byte, version byte, flags uint16) ([]byte, bool) {
+	switch version {
+	case 3:
+		// Compressed and encrypted frames are not supported.
+		if flags&0x00c0 != 0 {
+			return nil, false
+		}
+		if flags&0x0020 != 0 {
+			if len(frame) < 1 {
+				return nil, false
+			}
+			frame = frame[1:]
+		}
+	case 4:
+		if flags&0x000c != 0 {
+			return nil, false
+		}
+		if flags&0x0040 != 0 {
+			if len(frame) < 1 {
+				return nil, false
+			}
+			frame = frame[1:]
+		}
+		if flags&0x0001 != 0 {
+			if len(frame) < 4 {
+				return nil, false
+			}
+			frame = frame[4:]
+		}
+		if flags&0x0002 != 0 {
+			frame = removeUnsync(frame)
+		}
+	}
+
+	return frame, true
+}
+
+func readID3Frame(id string, frame []byte, tags Tags) {
+	if len(frame) < 1 {
+		return
+	}
+
+	switch {
+	case id == "TXXX":
+		enc := frame[0]
+		desc, rest := splitID3String(frame[1:], enc)
+		for _, value := range id3TextValues(rest, enc) {
+			tags.add(normaliseName(desc), value)
+		}
+	case id == "UFID":
+		owner, rest := splitID3String(frame, 0)
+		if owner == "http://musicbrainz.org" {
+			tags.add(MusicBrainzTrackID, string(rest))
+		}
+	case id == "USLT":
+		if len(frame) < 4 {
+			return
+		}
+		enc := frame[0]
+		_, rest := splitID3String(frame[4:], enc)
+		tags.add(Lyrics, decodeID3String(rest, enc))
+	case id[0] == 'T':
+		name, ok := id3Frames[id]
+		if !ok {
+			return
+		}
+		for _, value := range id3TextValues(frame[1:], frame[0]) {
+			tags.add(name, value)
+		}
+	}
+}
+
+// id3TextValues decodes the values of a text frame. ID3v2.4 allows more than one
+// value separated by null characters.
+func id3TextValues(data []byte, enc byte) []string {
+	text := decodeID3String(data, enc)
+	text = strings.TrimRight(text, "\x00")
+	return strings.Split(text, "\x00")
+}
+
+// splitID3String splits `data` at the first null terminator for the encoding
+// `enc`. It returns the decoded string before it and the rest of the data.
+func splitID3String(data []byte, enc byte) (string, []byte) {
+	if enc == 1 || enc == 2 {
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				return decodeID3String(data[:i], enc), data[i+2:]
+			}
+		}
+		return decodeID3String(data, enc), nil
+	}
+
+	ind := bytes.IndexByte(data, 0)
+	if ind < 0 {
+		return decodeID3String(data, enc), nil
+	}
+	return decodeID3String(data[:ind], enc), data[ind+1:]
+}
+
+// decodeID3String decodes the text `data` encoded with the ID3v2 encoding `enc`.
+func decodeID3String(data []byte, enc byte) string {
+	switch enc {
+	case 0:
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	case 1, 2:
+		bigEndian := enc == 2
+		if len(data) >= 2 {
+			if data[0] == 0xfe && data[1] == 0xff {
+				bigEndian, data = true, data[2:]
+			} else if data[0] == 0xff && data[1] == 0xfe {
+				bigEndian, data = false, data[2:]
+			}
+		}
+
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			if bigEndian {
+				units[i] = binary.BigEndian.Uint16(data[i*2:])
+			} else {
+				units[i] = binary.LittleEndian.Uint16(data[i*2:])
+			}
+		}
+		return string(utf16.Decode(units))
+	default:
+		return string(data)
+	}
+}
+
+// syncsafe decodes a syncsafe integer where only the lower 7 bits of every
+// byte are used.
+func syncsafe(b []byte) uint32 {
+	var n uint32
+	for _, c := range b {
+		n = n<<7 | uint32(c&0x7f)
+	}
+	return n
+}
+
+// removeUnsync reverses the ID3v2 unsynchronisation scheme in which every 0xFF
+// byte is followed by an additional zero byte.
+func removeUnsync(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		out = append(out, data[i])
+		if data[i] == 0xff && i+1 < len(data) && data[i+1] == 0 {
+			i++
+		}
+	}
+	return out
+}
@@ -0,0 +1,190 @@
+package tags
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// mp4Items maps the iTunes metadata items to Vorbis comment names.
+var mp4Items = map[string]string{
+	"\xa9nam": Title,
+	"\xa9ART": Artist,
+	"\xa9alb": Album,
+	"aART":    AlbumArtist,
+	"\xa9gen": Genre,
+	"\xa9day": Date,
+	"\xa9lyr": Lyrics,
+}
+
+// mp4DataTypeUTF8 is the "well-known type" of data atoms which contain UTF-8 text.
+const mp4DataTypeUTF8 = 1
+
+// readMP4 finds the moov.udta.meta.ilst atom in a MP4 file and reads all of the
+// metadata items in it.
+func readMP4(r io.ReadSeeker, tags Tags) error {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	path := []string{"moov", "udta", "meta", "ilst"}
+	var start int64
+
+	for depth, name := range path {
+		atomStart, atomEnd, err := findMP4Atom(r, start, end, name)
+		if errors.Is(err, errMP4AtomNotFound) {
+			// There are no tags in this file.
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		start, end = atomStart, atomEnd
+
+		// The "meta" atom is a "full box" which has a version and flags
+		// before its children.
+		if path[depth] == "meta" {
+			start += 4
+		}
+	}
+
+	if end-start > maxTagSize {
+		return fmt.Errorf("MP4 metadata is too big: %d bytes", end-start)
+	}
+
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return err
+	}
+	ilst := make([]byte, end-start)
+	if _, err := io.ReadFull(r, ilst); err != nil {
+		return fmt.Errorf("reading MP4 metadata: %w", err)
+	}
+
+	forEachMP4Atom(ilst, func(name string, item []byte) {
+		readMP4Item(name, item, tags)
+	})
+
+	return nil
+}
+
+var errMP4AtomNotFound = errors.New("atom not found")
+
+// findMP4Atom searches for the atom `name` among the atoms between `start` and
+// `end` in `r`. It returns the start and end of its contents.
+func findMP4Atom(r io.ReadSeeker, start, end int64, name string) (int64, int64, error) {
+	for pos := start; pos+8 <= end; {
+		if _, err := r.Seek(pos, io.SeekStart); err != nil {
+			return 0, 0, err
+		}
+
+		var header [16]byte
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return 0, 0, fmt.Errorf("reading MP4 atom header: %w", err)
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		atomType := string(header[4:8])
+		headerSize := int64(8)
+
+		switch size {
+		case 0:
+			size = end - pos
+		case 1:
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return 0, 0, fmt.Errorf("reading MP4 atom size: %w", err)
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+
+		if size < headerSize || pos+size > end {
+			return 0, 0, errors.New("malformed MP4 atom")
+		}
+
+		if atomType == name {
+			return pos + headerSize, pos + size, nil
+		}
+
+		pos += size
+	}
+
+	return 0, 0, errMP4AtomNotFound
+}
+
+// forEachMP4Atom calls `fn` for every atom in `data` with its name and contents.
+func forEachMP4Atom(data []byte, fn func(name string, contents []byte)) {
+	for len(data) >= 8 {
+		size := int(binary.BigEndian.Uint32(data[:4]))
+		if size < 8 || size > len(data) {
+			return
+		}
+
+		fn(string(data[4:8]), data[8:size])
+		data = data[size:]
+	}
+}
+
+// readMP4Item reads a single item from the "ilst" atom.
+func readMP4Item(name string, item []byte, tags Tags) {
+	var (
+		freeformName string
+		values       [][]byte
+		dataTypes    []uint32
+	)
+
+	forEachMP4Atom(item, func(child string, contents []byte) {
+		switch child {
+		case "name":
+			if len(contents) > 4 {
+				freeformName = string(contents[4:])
+			}
+		case "data":
+			if len(contents) < 8 {
+				return
+			}
+			dataTypes = append(dataTypes, binary.BigEndian.Uint32(contents[:4])&0xffffff)
+			values = append(values, contents[8:])
+		}
+	})
+
+	switch name {
+	case "----":
+		if freeformName == "" {
+			return
+		}
+		for _, value := range values {
+			tags.add(normaliseName(freeformName), string(value))
+		}
+	case "trkn", "disk":
+		// These are binary: two reserved bytes, the number and the total.
+		for _, value := range values {
+			if len(value) < 4 {
+				continue
+			}
+
+			number := binary.BigEndian.Uint16(value[2:4])
+			if number == 0 {
+				continue
+			}
+
+			tagName := TrackNumber
+			if name == "disk" {
+				tagName = DiscNumber
+			}
+			tags.add(tagName, strconv.Itoa(int(number)))
+		}
+	default:
+		tagName, ok := mp4Items[name]
+		if !ok {
+			return
+		}
+		for ind, value := range values {
+			if dataTypes[ind] == mp4DataTypeUTF8 {
+				tags.add(tagName, string(value))
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Package tags reads the tags of media files which are not accessible through
+// taglib. Such as MusicBrainz IDs, lyrics and ReplayGain information.
+//
+// Supported are ID3v2 tags (MP3 files), Vorbis comments in FLAC and Ogg files and
+// the iTunes style metadata of MP4 files. Tags are returned with the names used in
+// Vorbis comments by MusicBrainz Picard regardless of the format of the file. So
+// for example the "MusicBrainz Album Id" ID3v2 frame is returned as
+// MUSICBRAINZ_ALBUMID.
+//
+// See https://picard-docs.musicbrainz.org/en/appendices/tag_mapping.html
+package tags
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Names of the tags which are of interest for the rest of the program.
+const (
+	Title                   = "TITLE"
+	Artist                  = "ARTIST"
+	Album                   = "ALBUM"
+	AlbumArtist             = "ALBUMARTIST"
+	TrackNumber             = "TRACKNUMBER"
+	DiscNumber              = "DISCNUMBER"
+	Genre                   = "GENRE"
+	Date                    = "DATE"
+	Lyrics                  = "LYRICS"
+	MusicBrainzAlbumID      = "MUSICBRAINZ_ALBUMID"
+	MusicBrainzArtistID     = "MUSICBRAINZ_ARTISTID"
+	MusicBrainzAlbumArtist  = "MUSICBRAINZ_ALBUMARTISTID"
+	MusicBrainzReleaseGroup = "MUSICBRAINZ_RELEASEGROUPID"
+	MusicBrainzTrackID      = "MUSICBRAINZ_TRACKID"
+)
+
+// ErrUnsupportedFormat is returned when the media file is not in any of the
+// supported formats.
+var ErrUnsupportedFormat = errors.New("unsupported media format")
+
+// maxTagSize limits how big a single tag block could be. Anything bigger is
+// considered malformed. It is generous since tags may contain images.
+const maxTagSize = 64 * 1024 * 1024
+
+// Tags holds the tags of a media file. Keys are upper case tag names. A tag may
+// have more than one value.
+type Tags map[string][]string
+
+// Get returns the first value for the tag `name` or an empty string if there is
+// no such tag.
+func (t Tags) Get(name string) string {
+	values := t[strings.ToUpper(name)]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (t Tags) add(name, value string) {
+	value = strings.TrimRight(value, "\x00")
+	if name == "" || value == "" {
+		return
+	}
+
+	name = strings.ToUpper(name)
+	t[name] = append(t[name], value)
+}
+
+// ReadFile reads the tags of the media file at `path`.
+func ReadFile(path string) (Tags, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	return Read(fh)
+}
+
+// Read reads the tags from a media file. The format is detected from its
+// contents. ErrUnsupportedFormat is returned when the format is not recognised.
+func Read(r io.ReadSeeker) (Tags, error) {
+	magic := make([]byte, 12)
+	n, err := io.ReadFull(r, magic)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("reading file header: %w", err)
+	}
+	magic = magic[:n]
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	tags := make(Tags)
+
+	switch {
+	case bytes.HasPrefix(magic, []byte("ID3")):
+		err = readID3v2(r, tags)
+		if err != nil {
+			break
+		}
+
+		// FLAC files sometimes have an ID3v2 tag in front of them.
+		var flacMagic [4]byte
+		if _, err := io.ReadFull(r, flacMagic[:]); err == nil &&
+			string(flacMagic[:]) == "fLaC" {
+			err = readFLACBlocks(r, tags)
+		}
+	case bytes.HasPrefix(magic, []byte("fLaC")):
+		_, _ = r.Seek(4, io.SeekStart)
+		err = readFLACBlocks(r, tags)
+	case bytes.HasPrefix(magic, []byte("OggS")):
+		err = readOgg(r, tags)
+	case len(magic) >= 8 && string(magic[4:8]) == "ftyp":
+		err = readMP4(r, tags)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// picardNames maps the names which MusicBrainz Picard uses for ID3v2 TXXX frames
+// and MP4 freeform atoms to the Vorbis comment names.
+var picardNames = map[string]string{
+	"MUSICBRAINZ ALBUM ID":              MusicBrainzAlbumID,
+	"MUSICBRAINZ ARTIST ID":             MusicBrainzArtistID,
+	"MUSICBRAINZ ALBUM ARTIST ID":       MusicBrainzAlbumArtist,
+	"MUSICBRAINZ RELEASE GROUP ID":      MusicBrainzReleaseGroup,
+	"MUSICBRAINZ RELEASE TRACK ID":      "MUSICBRAINZ_RELEASETRACKID",
+	"MUSICBRAINZ TRACK ID":              MusicBrainzTrackID,
+	"MUSICBRAINZ WORK ID":               "MUSICBRAINZ_WORKID",
+	"MUSICBRAINZ DISC ID":               "MUSICBRAINZ_DISCID",
+	"MUSICBRAINZ ALBUM TYPE":            "RELEASETYPE",
+	"MUSICBRAINZ ALBUM STATUS":          "RELEASESTATUS",
+	"MUSICBRAINZ ALBUM RELEASE COUNTRY": "RELEASECOUNTRY",
+}
+
+// normaliseName converts a free form tag name into a Vorbis comment name.
+func normaliseName(name string) string {
+	upper := strings.ToUpper(strings.TrimSpace(name))
+	if mapped, ok := picardNames[upper]; ok {
+		return mapped
+	}
+	return strings.ReplaceAll(upper, " ", "_")
+}
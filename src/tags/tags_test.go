@@ -0,0 +1,277 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestReadID3v2 checks that ID3v2.3 and ID3v2.4 tags are read and that the
+// Picard frame names are converted into Vorbis comment names.
+func TestReadID3v2(t *testing.T) {
+	for _, version := range []byte{3, 4} {
+		var frames []byte
+		frames = append(frames, id3Frame(version, "TIT2", append([]byte{3}, "Wrathchild"...))...)
+		frames = append(frames, id3Frame(version, "TXXX", append(
+			[]byte{0}, "MusicBrainz Album Id\x00album-mbid"...,
+		))...)
+		frames = append(frames, id3Frame(version, "TXXX", utf16Text(
+			"REPLAYGAIN_TRACK_GAIN", "-6.5 dB",
+		))...)
+		frames = append(frames, id3Frame(version, "UFID", []byte(
+			"http://musicbrainz.org\x00track-mbid",
+		))...)
+		frames = append(frames, id3Frame(version, "USLT", append(
+			[]byte{3}, "eng\x00Walking through the city"...,
+		))...)
+		frames = append(frames, id3Frame(version, "TPOS", append([]byte{3}, "2/2"...))...)
+
+		// Some padding at the end.
+		frames = append(frames, make([]byte, 20)...)
+
+		file := id3Tag(version, frames)
+		file = append(file, "mpeg frames follow"...)
+
+		found, err := Read(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("v2.%d: reading tags: %s", version, err)
+		}
+
+		expected := Tags{
+			Title:                   {"Wrathchild"},
+			MusicBrainzAlbumID:      {"album-mbid"},
+			"REPLAYGAIN_TRACK_GAIN": {"-6.5 dB"},
+			MusicBrainzTrackID:      {"track-mbid"},
+			Lyrics:                  {"Walking through the city"},
+			DiscNumber:              {"2/2"},
+		}
+
+		if !reflect.DeepEqual(expected, found) {
+			t.Errorf("v2.%d: expected tags\n%v\nbut got\n%v", version, expected, found)
+		}
+	}
+}
+
+// TestReadFLAC checks that the Vorbis comments in FLAC files are read.
+func TestReadFLAC(t *testing.T) {
+	var file []byte
+	file = append(file, "fLaC"...)
+
+	// STREAMINFO block which must be skipped.
+	file = append(file, 0, 0, 0, 34)
+	file = append(file, make([]byte, 34)...)
+
+	comment := vorbisComment(
+		"ARTIST=Iron Maiden",
+		"MUSICBRAINZ_ARTISTID=artist-mbid",
+		"musicbrainz_albumid=album-mbid",
+		"UNSYNCEDLYRICS=Lyrics here",
+		"malformed",
+	)
+	file = append(file, 0x80|flacBlockVorbisComment)
+	file = append(file, byte(len(comment)>>16), byte(len(comment)>>8), byte(len(comment)))
+	file = append(file, comment...)
+
+	found, err := Read(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("reading tags: %s", err)
+	}
+
+	expected := Tags{
+		Artist:              {"Iron Maiden"},
+		MusicBrainzArtistID: {"artist-mbid"},
+		MusicBrainzAlbumID:  {"album-mbid"},
+		Lyrics:              {"Lyrics here"},
+	}
+
+	if !reflect.DeepEqual(expected, found) {
+		t.Errorf("expected tags\n%v\nbut got\n%v", expected, found)
+	}
+
+	if found.Get("musicbrainz_artistid") != "artist-mbid" {
+		t.Errorf("Get is expected to be case insensitive")
+	}
+}
+
+// TestReadOgg checks that comments are read from Ogg Vorbis files even when
+// the comment packet spans more than one page.
+func TestReadOgg(t *testing.T) {
+	longValue := string(bytes.Repeat([]byte("a"), 600))
+	comment := append([]byte("\x03vorbis"), vorbisComment(
+		"TITLE=Killers",
+		"COMMENT="+longValue,
+	)...)
+
+	var file []byte
+	file = append(file, oggPage(1, [][]byte{[]byte("\x01vorbis identification")}, false)...)
+
+	// Split the comment packet in two pages.
+	first, second := comment[:510], comment[510:]
+	file = append(file, oggPage(1, [][]byte{first}, true)...)
+	file = append(file, oggPage(1, [][]byte{second}, false)...)
+
+	found, err := Read(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("reading tags: %s", err)
+	}
+
+	expected := Tags{
+		Title:     {"Killers"},
+		"COMMENT": {longValue},
+	}
+
+	if !reflect.DeepEqual(expected, found) {
+		t.Errorf("expected tags\n%v\nbut got\n%v", expected, found)
+	}
+}
+
+// TestReadMP4 checks that iTunes style metadata is read from MP4 files.
+func TestReadMP4(t *testing.T) {
+	ilst := append(
+		mp4Atom("\xa9nam", mp4Data(1, []byte("Purgatory"))),
+		mp4Atom("trkn", mp4Data(0, []byte{0, 0, 0, 3, 0, 10}))...,
+	)
+	ilst = append(ilst, mp4Atom("----", concat(
+		mp4Atom("mean", append([]byte{0, 0, 0, 0}, "com.apple.iTunes"...)),
+		mp4Atom("name", append([]byte{0, 0, 0, 0}, "MusicBrainz Album Id"...)),
+		mp4Data(1, []byte("album-mbid")),
+	))...)
+
+	meta := append([]byte{0, 0, 0, 0}, mp4Atom("hdlr", make([]byte, 25))...)
+	meta = append(meta, mp4Atom("ilst", ilst)...)
+
+	moov := concat(
+		mp4Atom("mvhd", make([]byte, 100)),
+		mp4Atom("udta", mp4Atom("meta", meta)),
+	)
+
+	file := concat(
+		mp4Atom("ftyp", []byte("M4A \x00\x00\x00\x00")),
+		mp4Atom("mdat", make([]byte, 300)),
+		mp4Atom("moov", moov),
+	)
+
+	found, err := Read(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("reading tags: %s", err)
+	}
+
+	expected := Tags{
+		Title:              {"Purgatory"},
+		TrackNumber:        {"3"},
+		MusicBrainzAlbumID: {"album-mbid"},
+	}
+
+	if !reflect.DeepEqual(expected, found) {
+		t.Errorf("expected tags\n%v\nbut got\n%v", expected, found)
+	}
+}
+
+// TestReadUnsupported checks the error for unsupported formats.
+func TestReadUnsupported(t *testing.T) {
+	_, err := Read(bytes.NewReader([]byte("RIFF....WAVEfmt ")))
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat but got %v", err)
+	}
+}
+
+func id3Tag(version byte, frames []byte) []byte {
+	tag := []byte{'I', 'D', '3', version, 0, 0}
+	tag = append(tag, syncsafeBytes(len(frames))...)
+	return append(tag, frames...)
+}
+
+func id3Frame(version byte, id string, data []byte) []byte {
+	frame := []byte(id)
+	if version == 4 {
+		frame = append(frame, syncsafeBytes(len(data))...)
+	} else {
+		frame = binary.BigEndian.AppendUint32(frame, uint32(len(data)))
+	}
+	frame = append(frame, 0, 0)
+	return append(frame, data...)
+}
+
+// utf16Text returns the data of a TXXX frame with UTF-16 encoding and BOM.
+func utf16Text(desc, value string) []byte {
+	data := []byte{1}
+	for ind, str := range []string{desc, value} {
+		data = append(data, 0xff, 0xfe)
+		for _, r := range str {
+			data = append(data, byte(r), 0)
+		}
+		if ind == 0 {
+			data = append(data, 0, 0)
+		}
+	}
+	return data
+}
+
+func syncsafeBytes(n int) []byte {
+	return []byte{
+		byte(n >> 21 & 0x7f),
+		byte(n >> 14 & 0x7f),
+		byte(n >> 7 & 0x7f),
+		byte(n & 0x7f),
+	}
+}
+
+func vorbisComment(comments ...string) []byte {
+	data := binary.LittleEndian.AppendUint32(nil, 6)
+	data = append(data, "vendor"...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(comments)))
+	for _, comment := range comments {
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(comment)))
+		data = append(data, comment...)
+	}
+	return data
+}
+
+// oggPage creates an Ogg page with `packets`. When `continued` is true the last
+// packet does not end in this page. Its length must be a multiple of 255 then.
+func oggPage(serial uint32, packets [][]byte, continued bool) []byte {
+	var segments []byte
+	var data []byte
+	for ind, packet := range packets {
+		n := len(packet)
+		for n >= 255 {
+			segments = append(segments, 255)
+			n -= 255
+		}
+		if !continued || ind < len(packets)-1 {
+			segments = append(segments, byte(n))
+		}
+		data = append(data, packet...)
+	}
+
+	page := []byte("OggS")
+	page = append(page, 0, 0)
+	page = append(page, make([]byte, 8)...)
+	page = binary.LittleEndian.AppendUint32(page, serial)
+	page = append(page, make([]byte, 8)...)
+	page = append(page, byte(len(segments)))
+	page = append(page, segments...)
+	return append(page, data...)
+}
+
+func mp4Atom(name string, contents []byte) []byte {
+	atom := binary.BigEndian.AppendUint32(nil, uint32(len(contents)+8))
+	atom = append(atom, name...)
+	return append(atom, contents...)
+}
+
+func mp4Data(dataType uint32, value []byte) []byte {
+	contents := binary.BigEndian.AppendUint32(nil, dataType)
+	contents = append(contents, 0, 0, 0, 0)
+	return mp4Atom("data", append(contents, value...))
+}
+
+func concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, part := range parts {
+		out = append(out, part...)
+	}
+	return out
+}
@@ -0,0 +1,175 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	flacBlockVorbisComment = 4
+
+	// maxOggPages limits how many pages are read while searching for the
+	// comments packet in an Ogg file.
+	maxOggPages = 256
+)
+
+// readFLACBlocks reads the FLAC metadata blocks which follow the "fLaC" marker at
+// the current position of `r`.
+func readFLACBlocks(r io.Reader, tags Tags) error {
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return fmt.Errorf("reading FLAC metadata block header: %w", err)
+		}
+
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7f
+		size := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+
+		if blockType == flacBlockVorbisComment {
+			block := make([]byte, size)
+			if _, err := io.ReadFull(r, block); err != nil {
+				return fmt.Errorf("reading FLAC Vorbis comment: %w", err)
+			}
+			if err := readVorbisComment(block, tags); err != nil {
+				return err
+			}
+		} else if _, err := io.CopyN(io.Discard, r, size); err != nil {
+			return fmt.Errorf("skipping FLAC metadata block: %w", err)
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+// readOgg reads the comments of the first logical stream in an Ogg file. Both
+// Vorbis and Opus streams are supported.
+func readOgg(r io.Reader, tags Tags) error {
+	var (
+		serial  uint32
+		packets [][]byte
+		current []byte
+	)
+
+	for page := 0; page < maxOggPages; page++ {
+		var header [27]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return fmt.Errorf("reading Ogg page header: %w", err)
+		}
+		if string(header[:4]) != "OggS" {
+			return errors.New("malformed Ogg page")
+		}
+
+		pageSerial := binary.LittleEndian.Uint32(header[14:18])
+		if page == 0 {
+			serial = pageSerial
+		}
+
+		segments := make([]byte, header[26])
+		if _, err := io.ReadFull(r, segments); err != nil {
+			return fmt.Errorf("reading Ogg segment table: %w", err)
+		}
+
+		var pageSize int
+		for _, s := range segments {
+			pageSize += int(s)
+		}
+		data := make([]byte, pageSize)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return fmt.Errorf("reading Ogg page: %w", err)
+		}
+
+		if pageSerial != serial {
+			continue
+		}
+
+		for _, s := range segments {
+			current = append(current, data[:s]...)
+			data = data[s:]
+
+			if len(current) > maxTagSize {
+				return errors.New("Ogg comment packet is too big")
+			}
+
+			// A segment shorter than 255 bytes ends the packet.
+			if s < 255 {
+				packets = append(packets, current)
+				current = nil
+			}
+		}
+
+		if len(packets) >= 2 {
+			return readOggCommentPacket(packets[1], tags)
+		}
+	}
+
+	return errors.New("Ogg comments packet not found")
+}
+
+func readOggCommentPacket(packet []byte, tags Tags) error {
+	switch {
+	case bytes.HasPrefix(packet, []byte("\x03vorbis")):
+		return readVorbisComment(packet[7:], tags)
+	case bytes.HasPrefix(packet, []byte("OpusTags")):
+		return readVorbisComment(packet[8:], tags)
+	default:
+		return ErrUnsupportedFormat
+	}
+}
+
+// readVorbisComment parses a Vorbis comment structure. It is the same in Ogg and
+// FLAC files.
+func readVorbisComment(data []byte, tags Tags) error {
+	errMalformed := errors.New("malformed Vorbis comment")
+
+	readLength := func() (int, bool) {
+		if len(data) < 4 {
+			return 0, false
+		}
+		length := int(binary.LittleEndian.Uint32(data[:4]))
+		data = data[4:]
+		return length, length <= len(data)
+	}
+
+	vendorLength, ok := readLength()
+	if !ok {
+		return errMalformed
+	}
+	data = data[vendorLength:]
+
+	if len(data) < 4 {
+		return errMalformed
+	}
+	count := int(binary.LittleEndian.Uint32(data[:4]))
+	data = data[4:]
+
+	for i := 0; i < count; i++ {
+		length, ok := readLength()
+		if !ok {
+			return errMalformed
+		}
+
+		comment := string(data[:length])
+		data = data[length:]
+
+		name, value, found := strings.Cut(comment, "=")
+		if !found {
+			continue
+		}
+
+		// Some taggers use UNSYNCEDLYRICS instead of LYRICS.
+		if strings.EqualFold(name, "UNSYNCEDLYRICS") {
+			name = Lyrics
+		}
+
+		tags.add(name, value)
+	}
+
+	return nil
+}
@@ -5,26 +5,52 @@ import "net/http"
 // The following are URL Path endpoints for certain API calls.
 const (
 	APIv1EndpointFile           = "/v1/file/{fileID}"
+	APIv1EndpointFileLyrics     = "/v1/file/{fileID}/lyrics"
+	APIv1EndpointFileOverrides  = "/v1/file/{fileID}/overrides"
 	APIv1EndpointAlbumArtwork   = "/v1/album/{albumID}/artwork"
 	APIv1EndpointDownloadAlbum  = "/v1/album/{albumID}"
+	APIv1EndpointAlbumOverrides = "/v1/album/{albumID}/overrides"
+	APIv1EndpointAlbumEditions  = "/v1/album/{albumID}/editions"
 	APIv1EndpointArtistImage    = "/v1/artist/{artistID}/image"
+	APIv1EndpointArtistInfo     = "/v1/artist/{artistID}/info"
 	APIv1EndpointBrowse         = "/v1/browse"
+	APIv1EndpointFolders        = "/v1/folders"
+	APIv1EndpointFolder         = "/v1/folders/{folderID}"
 	APIv1EndpointSearchWithPath = "/v1/search/{searchQuery}"
 	APIv1EndpointSearch         = "/v1/search/"
 	APIv1EndpointLoginToken     = "/v1/login/token/"
 	APIv1EndpointRegisterToken  = "/v1/register/token/"
+	APIv1EndpointPrefetch       = "/v1/artwork/prefetch"
+	APIv1EndpointLibraryReport  = "/v1/library/report"
+	APIv1EndpointDuplicates     = "/v1/library/duplicates"
+	APIv1EndpointWatchQueue     = "/v1/library/watcher"
+	APIv1EndpointLibraries      = "/v1/libraries"
+	APIv1EndpointLibrary        = "/v1/libraries/{libraryID}"
 )
 
 // APIv1Methods defines on which HTTP methods APIv1 endpoints will respond to.
 // It is an uri_path => list of HTTP methods map.
 var APIv1Methods map[string][]string = map[string][]string{
-	APIv1EndpointFile:           {http.MethodGet},
+	APIv1EndpointFile:           {http.MethodGet, http.MethodPatch},
+	APIv1EndpointFileLyrics:     {http.MethodGet},
+	APIv1EndpointFileOverrides:  {http.MethodGet, http.MethodPut, http.MethodDelete},
 	APIv1EndpointAlbumArtwork:   {http.MethodGet, http.MethodPut, http.MethodDelete},
-	APIv1EndpointDownloadAlbum:  {http.MethodGet},
+	APIv1EndpointDownloadAlbum:  {http.MethodGet, http.MethodPatch},
+	APIv1EndpointAlbumOverrides: {http.MethodGet, http.MethodPut, http.MethodDelete},
+	APIv1EndpointAlbumEditions:  {http.MethodGet},
 	APIv1EndpointArtistImage:    {http.MethodGet, http.MethodPut, http.MethodDelete},
+	APIv1EndpointArtistInfo:     {http.MethodGet},
 	APIv1EndpointBrowse:         {http.MethodGet},
+	APIv1EndpointFolders:        {http.MethodGet},
+	APIv1EndpointFolder:         {http.MethodGet},
 	APIv1EndpointSearchWithPath: {http.MethodGet},
 	APIv1EndpointSearch:         {http.MethodGet},
 	APIv1EndpointLoginToken:     {http.MethodPost},
 	APIv1EndpointRegisterToken:  {http.MethodPost},
+	APIv1EndpointPrefetch:       {http.MethodGet, http.MethodPost},
+	APIv1EndpointLibraryReport:  {http.MethodGet},
+	APIv1EndpointDuplicates:     {http.MethodGet},
+	APIv1EndpointWatchQueue:     {http.MethodGet},
+	APIv1EndpointLibraries:      {http.MethodGet, http.MethodPost},
+	APIv1EndpointLibrary:        {http.MethodPatch, http.MethodDelete},
 }
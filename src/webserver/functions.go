@@ -2,10 +2,13 @@ package webserver
 
 import (
 	"crypto/subtle"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 
 	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/library"
 )
 
 // HandlerFuncWithError is similar to http.HandlerFunc but returns an error when
@@ -44,3 +47,25 @@ func checkLoginCreds(user, pass string, auth config.Auth) bool {
 
 	return userCheck&passCheck == 1
 }
+
+// serveImage writes the image in `img` into the response. When the image is a
+// library.StoredImage its hash and modification time are used for the ETag and
+// Last-Modified headers. This way clients may revalidate their cached copies
+// and receive "304 Not Modified" when the image has not changed.
+func serveImage(writer http.ResponseWriter, req *http.Request, img io.Reader) error {
+	stored, ok := img.(library.StoredImage)
+	if !ok {
+		writer.Header().Set("Cache-Control", "max-age=604800")
+		_, err := io.Copy(writer, img)
+		return err
+	}
+
+	// The same URL may point to a different image once the artwork is changed.
+	// So clients are asked to revalidate after the max-age. This is cheap since
+	// the ETag is the image hash.
+	writer.Header().Set("Cache-Control", "max-age=604800, must-revalidate")
+	writer.Header().Set("ETag", fmt.Sprintf(`"%s"`, stored.Hash()))
+	http.ServeContent(writer, req, "", stored.ModTime(), stored)
+
+	return nil
+}
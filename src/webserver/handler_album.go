@@ -63,9 +63,17 @@ func (fh AlbumHandler) find(writer http.ResponseWriter, req *http.Request) error
 		fmt.Sprintf(`filename="%s.zip"`, albumFiles[0].Album))
 
 	var files []string
+	seen := make(map[string]struct{})
 
 	for _, track := range albumFiles {
-		files = append(files, fh.library.GetFilePath(track.ID))
+		// Tracks from CUE sheets share the same file. It is included only once.
+		filePath := fh.library.GetFilePath(track.ID)
+		if _, ok := seen[filePath]; ok {
+			continue
+		}
+		seen[filePath] = struct{}{}
+
+		files = append(files, filePath)
 	}
 
 	written, err := fh.writeZipContents(writer, files)
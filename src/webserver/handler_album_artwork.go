@@ -95,10 +95,7 @@ func (aah AlbumArtworkHandler) find(
 
 	defer imgReader.Close()
 
-	writer.Header().Set("Cache-Control", "max-age=604800")
-	_, err = io.Copy(writer, imgReader)
-
-	if err != nil {
+	if err := serveImage(writer, req, imgReader); err != nil {
 		log.Printf("еrror sending HTTP data for artwork %d: %s", id, err)
 	}
 
@@ -156,6 +156,88 @@ func TestAlbumArtworkHandlerGET(t *testing.T) {
 	}
 }
 
+// TestAlbumArtworkHandlerCaching makes sure that artwork which comes from the
+// library's image store is served with ETag and Last-Modified headers and that
+// conditional requests are answered with "304 Not Modified".
+func TestAlbumArtworkHandlerCaching(t *testing.T) {
+	var (
+		imgBytes = []byte("stored album image")
+		imgHash  = "3c8a3fca6a9c30f4c5e7fcaa65a4e79d8c0c4d0b6b8a4fdc1d7d3c7f8a2b1e01"
+		modTime  = time.Date(2023, 4, 2, 12, 0, 0, 0, time.UTC)
+	)
+
+	fakeAM := &libraryfakes.FakeArtworkManager{
+		FindAndSaveAlbumArtworkStub: func(
+			ctx context.Context,
+			albumID int64,
+			size library.ImageSize,
+		) (io.ReadCloser, error) {
+			return &fakeStoredImage{
+				Reader:  bytes.NewReader(imgBytes),
+				hash:    imgHash,
+				modTime: modTime,
+			}, nil
+		},
+	}
+
+	handler := routeAlbumArtworkHandler(webserver.NewAlbumArtworkHandler(
+		fakeAM,
+		fstest.MapFS{},
+		"images/notfound.png",
+	))
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/album/321/artwork", nil)
+	handler.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected code %d but got %d", http.StatusOK, resp.Code)
+	}
+
+	if !bytes.Equal(imgBytes, resp.Body.Bytes()) {
+		t.Errorf("expected image `%s` but got `%s`", imgBytes, resp.Body.Bytes())
+	}
+
+	etag := resp.Header().Get("ETag")
+	if etag != `"`+imgHash+`"` {
+		t.Errorf("expected ETag with the image hash but got `%s`", etag)
+	}
+
+	lastModified := resp.Header().Get("Last-Modified")
+	if lastModified != modTime.Format(http.TimeFormat) {
+		t.Errorf("unexpected Last-Modified header: `%s`", lastModified)
+	}
+
+	if resp.Header().Get("Cache-Control") == "" {
+		t.Errorf("expected Cache-Control header to be set")
+	}
+
+	// Revalidating with the same ETag should not return the image again.
+	resp = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/album/321/artwork", nil)
+	req.Header.Set("If-None-Match", etag)
+	handler.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match: expected code %d but got %d",
+			http.StatusNotModified, resp.Code)
+	}
+
+	if resp.Body.Len() != 0 {
+		t.Errorf("If-None-Match: expected empty body but got `%s`", resp.Body.Bytes())
+	}
+
+	// A different ETag means the client has an outdated image.
+	resp = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/album/321/artwork", nil)
+	req.Header.Set("If-None-Match", `"some-other-hash"`)
+	handler.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("outdated ETag: expected code %d but got %d", http.StatusOK, resp.Code)
+	}
+}
+
 // TestAlbumArtworkHandlerDELETE tests what happens when artwork is removed.
 func TestAlbumArtworkHandlerDELETE(t *testing.T) {
 	fakeAM := &libraryfakes.FakeArtworkManager{
@@ -340,3 +422,15 @@ func routeAlbumArtworkHandler(h http.Handler) http.Handler {
 
 	return router
 }
+
+// fakeStoredImage implements library.StoredImage for tests.
+type fakeStoredImage struct {
+	*bytes.Reader
+
+	hash    string
+	modTime time.Time
+}
+
+func (f *fakeStoredImage) Close() error       { return nil }
+func (f *fakeStoredImage) Hash() string       { return f.hash }
+func (f *fakeStoredImage) ModTime() time.Time { return f.modTime }
@@ -0,0 +1,73 @@
+package webserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+// AlbumEditionsHandler is a http.Handler which returns the editions of an album.
+// Such as the same album as FLAC and as MP3 files.
+type AlbumEditionsHandler struct {
+	resolver library.EditionsResolver
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (eh AlbumEditionsHandler) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+
+	albumID, err := strconv.ParseInt(vars["albumID"], 10, 64)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(writer, "Bad request. Parsing albumID: %s\n", err)
+		return
+	}
+
+	editions, err := eh.resolver.GetAlbumEditions(req.Context(), albumID)
+	if errors.Is(err, library.ErrAlbumNotFound) {
+		writer.WriteHeader(http.StatusNotFound)
+		fmt.Fprintln(writer, err)
+		return
+	} else if err != nil {
+		log.Printf("Error getting album editions: %s\n", err)
+		writer.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintln(writer, err)
+		return
+	}
+
+	writer.Header().Add("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(writer)
+	if err := enc.Encode(editions); err != nil {
+		log.Printf("error writing body in AlbumEditionsHandler: %s", err)
+	}
+}
+
+// NewAlbumEditionsHandler returns a new AlbumEditions handler. It needs an
+// implementation of the EditionsResolver.
+func NewAlbumEditionsHandler(resolver library.EditionsResolver) *AlbumEditionsHandler {
+	return &AlbumEditionsHandler{
+		resolver: resolver,
+	}
+}
+
+// preferredFormats returns the formats of album editions which the client
+// prefers. They are in the "formats" query parameter separated by commas. For
+// example "flac,mp3".
+func preferredFormats(req *http.Request) []string {
+	var formats []string
+	for _, format := range strings.Split(req.URL.Query().Get("formats"), ",") {
+		format = strings.TrimSpace(format)
+		if format != "" {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
@@ -0,0 +1,81 @@
+package webserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestAlbumEditionsHandler checks that the editions handler returns the editions
+// of albums from its library.EditionsResolver.
+func TestAlbumEditionsHandler(t *testing.T) {
+	editions := []library.AlbumEdition{
+		{AlbumID: 12, Name: "Album", Format: "flac", Tracks: 10},
+		{AlbumID: 13, Name: "Album", Format: "mp3", Tracks: 10},
+	}
+
+	fakeResolver := &libraryfakes.FakeEditionsResolver{
+		GetAlbumEditionsStub: func(
+			_ context.Context,
+			albumID int64,
+		) ([]library.AlbumEdition, error) {
+			switch albumID {
+			case 12, 13:
+				return editions, nil
+			case 42:
+				return nil, fmt.Errorf("database is gone")
+			}
+			return nil, library.ErrAlbumNotFound
+		},
+	}
+
+	router := mux.NewRouter()
+	router.Handle(
+		webserver.APIv1EndpointAlbumEditions,
+		webserver.NewAlbumEditionsHandler(fakeResolver),
+	).Methods(webserver.APIv1Methods[webserver.APIv1EndpointAlbumEditions]...)
+
+	tests := []struct {
+		url          string
+		expectedCode int
+	}{
+		{"/v1/album/13/editions", http.StatusOK},
+		{"/v1/album/5/editions", http.StatusNotFound},
+		{"/v1/album/42/editions", http.StatusInternalServerError},
+		{"/v1/album/baba/editions", http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, test.url, nil)
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != test.expectedCode {
+			t.Errorf("%s: expected code %d but got %d",
+				test.url, test.expectedCode, resp.Code)
+		}
+	}
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/album/12/editions", nil)
+	router.ServeHTTP(resp, req)
+
+	var found []library.AlbumEdition
+	if err := json.NewDecoder(resp.Body).Decode(&found); err != nil {
+		t.Fatalf("decoding response: %s", err)
+	}
+
+	if !reflect.DeepEqual(found, editions) {
+		t.Errorf("expected editions %+v but got %+v", editions, found)
+	}
+}
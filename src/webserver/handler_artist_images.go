@@ -3,7 +3,6 @@ package webserver
 import (
 	"context"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"os"
@@ -85,10 +84,7 @@ func (aih ArtstImageHandler) find(
 
 	defer imgReader.Close()
 
-	writer.Header().Set("Cache-Control", "max-age=604800")
-	_, err = io.Copy(writer, imgReader)
-
-	if err != nil {
+	if err := serveImage(writer, req, imgReader); err != nil {
 		log.Printf("еrror sending HTTP data for artwork %d: %s", id, err)
 	}
 
@@ -0,0 +1,72 @@
+package webserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+// ArtistInfoHandler is a http.Handler which returns information about artists
+// such as their biographies and links.
+type ArtistInfoHandler struct {
+	infoManager library.ArtistInfoManager
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (aih ArtistInfoHandler) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+
+	idString, ok := vars["artistID"]
+	if !ok {
+		http.NotFoundHandler().ServeHTTP(writer, req)
+		return
+	}
+
+	id, err := strconv.ParseInt(idString, 10, 64)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(writer, "Bad request. Parsing artistID: %s\n", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), 5*time.Minute)
+	defer cancel()
+
+	info, err := aih.infoManager.GetArtistInfo(ctx, id)
+	if err == library.ErrArtistNotFound || err == library.ErrArtistInfoNotFound {
+		writer.WriteHeader(http.StatusNotFound)
+		fmt.Fprintln(writer, "404 artist info not found")
+		return
+	}
+
+	if err != nil {
+		log.Printf("Error getting artist %d info: %s\n", id, err)
+		writer.WriteHeader(http.StatusInternalServerError)
+		if _, err := writer.Write([]byte(err.Error())); err != nil {
+			log.Printf("error writing body in ArtistInfoHandler: %s", err)
+		}
+		return
+	}
+
+	writer.Header().Add("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(writer)
+	if err := enc.Encode(info); err != nil {
+		log.Printf("error writing body in ArtistInfoHandler: %s", err)
+	}
+}
+
+// NewArtistInfoHandler returns a new Artist info handler.
+// It needs an implementation of the ArtistInfoManager.
+func NewArtistInfoHandler(aim library.ArtistInfoManager) *ArtistInfoHandler {
+	return &ArtistInfoHandler{
+		infoManager: aim,
+	}
+}
@@ -0,0 +1,88 @@
+package webserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestArtistInfoHandler checks that the artist info handler returns the info
+// from its library.ArtistInfoManager and the appropriate errors.
+func TestArtistInfoHandler(t *testing.T) {
+	expected := library.ArtistInfo{
+		ArtistID: 73,
+		MBID:     "maiden-mbid",
+		Name:     "Iron Maiden",
+		Country:  "GB",
+		Links: []library.ArtistLink{
+			{Type: "official homepage", URL: "https://www.ironmaiden.com/"},
+		},
+		Related:   []library.RelatedArtist{},
+		UpdatedAt: 1700000000,
+	}
+
+	fakeIM := &libraryfakes.FakeArtistInfoManager{
+		GetArtistInfoStub: func(
+			_ context.Context,
+			artistID int64,
+		) (library.ArtistInfo, error) {
+			switch artistID {
+			case 73:
+				return expected, nil
+			case 42:
+				return library.ArtistInfo{}, fmt.Errorf("database is gone")
+			case 5:
+				return library.ArtistInfo{}, library.ErrArtistInfoNotFound
+			default:
+				return library.ArtistInfo{}, library.ErrArtistNotFound
+			}
+		},
+	}
+
+	router := mux.NewRouter()
+	router.Handle(webserver.APIv1EndpointArtistInfo, webserver.NewArtistInfoHandler(fakeIM))
+
+	tests := []struct {
+		url          string
+		expectedCode int
+	}{
+		{"/v1/artist/73/info", http.StatusOK},
+		{"/v1/artist/42/info", http.StatusInternalServerError},
+		{"/v1/artist/5/info", http.StatusNotFound},
+		{"/v1/artist/6/info", http.StatusNotFound},
+		{"/v1/artist/baba/info", http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, test.url, nil)
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != test.expectedCode {
+			t.Errorf("%s: expected code %d but got %d",
+				test.url, test.expectedCode, resp.Code)
+		}
+	}
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/artist/73/info", nil)
+	router.ServeHTTP(resp, req)
+
+	var info library.ArtistInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		t.Fatalf("decoding response: %s", err)
+	}
+
+	if !reflect.DeepEqual(expected, info) {
+		t.Errorf("expected info %+v but got %+v", expected, info)
+	}
+}
@@ -0,0 +1,62 @@
+package webserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+// ArtworkPrefetchHandler is a http.Handler which shows the progress of the artwork
+// prefetch job and controls it.
+type ArtworkPrefetchHandler struct {
+	prefetcher library.ArtworkPrefetcher
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (aph ArtworkPrefetchHandler) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	InternalErrorOnErrorHandler(writer, req, aph.serve)
+}
+
+func (aph ArtworkPrefetchHandler) serve(writer http.ResponseWriter, req *http.Request) error {
+	if req.Method == http.MethodPost {
+		if err := req.ParseForm(); err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(writer, "Bad request. Parsing form: %s\n", err)
+			return nil
+		}
+
+		switch action := req.Form.Get("action"); action {
+		case "start":
+			aph.prefetcher.StartArtworkPrefetch()
+		case "pause":
+			aph.prefetcher.PauseArtworkPrefetch()
+		case "resume":
+			aph.prefetcher.ResumeArtworkPrefetch()
+		default:
+			writer.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(writer, "Bad request. Unknown action `%s`\n", action)
+			return nil
+		}
+	}
+
+	writer.Header().Add("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(writer)
+	if err := enc.Encode(aph.prefetcher.ArtworkPrefetchProgress()); err != nil {
+		log.Printf("error writing body in artwork prefetch handler: %s", err)
+	}
+
+	return nil
+}
+
+// NewArtworkPrefetchHandler returns a new handler for the artwork prefetch job.
+// It needs an implementation of the library.ArtworkPrefetcher.
+func NewArtworkPrefetchHandler(
+	prefetcher library.ArtworkPrefetcher,
+) *ArtworkPrefetchHandler {
+	return &ArtworkPrefetchHandler{
+		prefetcher: prefetcher,
+	}
+}
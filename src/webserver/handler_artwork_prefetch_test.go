@@ -0,0 +1,116 @@
+package webserver_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestArtworkPrefetchHandler checks that the artwork prefetch handler returns the
+// progress of the job and dispatches the actions to its prefetcher.
+func TestArtworkPrefetchHandler(t *testing.T) {
+	expectedProgress := library.PrefetchProgress{
+		Running: true,
+		Albums: library.PrefetchCounters{
+			Total:   10,
+			Checked: 4,
+			Found:   3,
+		},
+		StartedAt: 1700000000,
+	}
+
+	fakePrefetcher := &libraryfakes.FakeArtworkPrefetcher{}
+	fakePrefetcher.ArtworkPrefetchProgressReturns(expectedProgress)
+
+	handler := webserver.NewArtworkPrefetchHandler(fakePrefetcher)
+
+	tests := []struct {
+		desc         string
+		method       string
+		url          string
+		expectedCode int
+		startCalls   int
+		pauseCalls   int
+		resumeCalls  int
+	}{
+		{
+			desc:         "progress",
+			method:       http.MethodGet,
+			url:          "/v1/artwork/prefetch",
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "start",
+			method:       http.MethodPost,
+			url:          "/v1/artwork/prefetch?action=start",
+			expectedCode: http.StatusOK,
+			startCalls:   1,
+		},
+		{
+			desc:         "pause",
+			method:       http.MethodPost,
+			url:          "/v1/artwork/prefetch?action=pause",
+			expectedCode: http.StatusOK,
+			startCalls:   1,
+			pauseCalls:   1,
+		},
+		{
+			desc:         "resume",
+			method:       http.MethodPost,
+			url:          "/v1/artwork/prefetch?action=resume",
+			expectedCode: http.StatusOK,
+			startCalls:   1,
+			pauseCalls:   1,
+			resumeCalls:  1,
+		},
+		{
+			desc:         "unknown action",
+			method:       http.MethodPost,
+			url:          "/v1/artwork/prefetch?action=stop",
+			expectedCode: http.StatusBadRequest,
+			startCalls:   1,
+			pauseCalls:   1,
+			resumeCalls:  1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			resp := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, test.url, nil)
+			handler.ServeHTTP(resp, req)
+
+			if resp.Code != test.expectedCode {
+				t.Fatalf("expected code %d but got %d", test.expectedCode, resp.Code)
+			}
+
+			if calls := fakePrefetcher.StartArtworkPrefetchCallCount(); calls != test.startCalls {
+				t.Errorf("expected %d start calls but got %d", test.startCalls, calls)
+			}
+			if calls := fakePrefetcher.PauseArtworkPrefetchCallCount(); calls != test.pauseCalls {
+				t.Errorf("expected %d pause calls but got %d", test.pauseCalls, calls)
+			}
+			if calls := fakePrefetcher.ResumeArtworkPrefetchCallCount(); calls != test.resumeCalls {
+				t.Errorf("expected %d resume calls but got %d", test.resumeCalls, calls)
+			}
+
+			if test.expectedCode != http.StatusOK {
+				return
+			}
+
+			var progress library.PrefetchProgress
+			if err := json.NewDecoder(resp.Body).Decode(&progress); err != nil {
+				t.Fatalf("decoding response: %s", err)
+			}
+
+			if progress != expectedProgress {
+				t.Errorf("expected progress %+v but got %+v", expectedProgress, progress)
+			}
+		})
+	}
+}
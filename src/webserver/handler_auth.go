@@ -1,6 +1,7 @@
 package webserver
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"net/http"
@@ -13,17 +14,31 @@ import (
 )
 
 const (
-	authRequiredJSON = `{"error": "authentication required"}`
+	authRequiredJSON  = `{"error": "authentication required"}`
+	adminRequiredJSON = `{"error": "admin credentials required"}`
 )
 
+// adminCtxKey is the request context key under which the AuthHandler stores
+// whether the request was made with an admin-capable credential.
+type adminCtxKey struct{}
+
+// tokenPayload is the payload of the JWTs issued by the server.
+type tokenPayload struct {
+	jwt.Payload
+
+	// Admin is set for tokens received in exchange of the username and password.
+	// Only such tokens could be used for changing the media files.
+	Admin bool `json:"adm,omitempty"`
+}
+
 // AuthHandler is a handler wrapper used for authentication. Its only job is
 // to do the authentication and then pass the work to the Handler it wraps around.
 // Possible methods for authentication:
 //
-//  * Basic Auth with the username and password
-//  * Authorization Bearer JWT token
-//  * JWT token in a session cookie
-//  * JWT token as a query string
+//   - Basic Auth with the username and password
+//   - Authorization Bearer JWT token
+//   - JWT token in a session cookie
+//   - JWT token as a query string
 //
 // Basic auth is preserved for backward compatibility. Needless to say, it so not
 // a preferred method for authentication.
@@ -58,12 +73,14 @@ func NewAuthHandler(
 // ServeHTTP implements the http.Handler interface and does the actual basic authenticate
 // check for every request
 func (hl *AuthHandler) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
-	if !hl.authenticated(req) {
+	authenticated, admin := hl.authenticated(req)
+	if !authenticated {
 		InternalErrorOnErrorHandler(writer, req, hl.challengeAuthentication)
 		return
 	}
 
-	hl.wrapped.ServeHTTP(writer, req)
+	ctx := context.WithValue(req.Context(), adminCtxKey{}, admin)
+	hl.wrapped.ServeHTTP(writer, req.WithContext(ctx))
 }
 
 // Sends 401 and authentication challenge in the writer
@@ -115,11 +132,12 @@ func (hl *AuthHandler) redirectToLogin(w http.ResponseWriter, r *http.Request) e
 }
 
 // Compares the authentication header with the stored user and passwords
-// and returns true if they pass.
-func (hl *AuthHandler) authenticated(r *http.Request) bool {
+// and returns true if they pass. The second returned value shows whether the
+// credential is admin-capable.
+func (hl *AuthHandler) authenticated(r *http.Request) (bool, bool) {
 	for _, path := range hl.exceptions {
 		if strings.HasPrefix(r.URL.Path, path) {
-			return true
+			return true, false
 		}
 	}
 
@@ -130,7 +148,8 @@ func (hl *AuthHandler) authenticated(r *http.Request) bool {
 	}
 
 	if strings.HasPrefix(authHeader, "Basic ") {
-		return hl.withBasicAuth(strings.TrimPrefix(authHeader, "Basic "))
+		ok := hl.withBasicAuth(strings.TrimPrefix(authHeader, "Basic "))
+		return ok, ok
 	}
 
 	if cookie, err := r.Cookie(sessionCookieName); err == nil {
@@ -141,7 +160,7 @@ func (hl *AuthHandler) authenticated(r *http.Request) bool {
 		return hl.withJWT(queryToken)
 	}
 
-	return false
+	return false, false
 }
 
 func (hl *AuthHandler) withBasicAuth(encoded string) bool {
@@ -164,15 +183,47 @@ func (hl *AuthHandler) withBasicAuth(encoded string) bool {
 	return checkLoginCreds(pair[0], pair[1], cfg)
 }
 
-func (hl *AuthHandler) withJWT(token string) bool {
-	var jot jwt.Payload
+func (hl *AuthHandler) withJWT(token string) (bool, bool) {
+	var jot tokenPayload
 
 	alg := jwt.NewHS256([]byte(hl.secret))
 	exp := jwt.ExpirationTimeValidator(time.Now())
-	validatePayload := jwt.ValidatePayload(&jot, exp)
+	validatePayload := jwt.ValidatePayload(&jot.Payload, exp)
 
 	_, err := jwt.Verify([]byte(token), alg, &jot, validatePayload)
-	return err == nil
+	if err != nil {
+		return false, false
+	}
+
+	return true, jot.Admin
+}
+
+// AdminOnlyHandler is a handler wrapper which lets through only requests made
+// with an admin-capable credential. Such are HTTP Basic authentication and
+// tokens received in exchange of the username and password. Tokens for devices
+// registered with a QR code are not admin-capable. It must be wrapped by the
+// AuthHandler which finds out what credential was used.
+type AdminOnlyHandler struct {
+	wrapped http.Handler
+}
+
+// NewAdminOnlyHandler returns a new AdminOnlyHandler which wraps around h.
+func NewAdminOnlyHandler(h http.Handler) *AdminOnlyHandler {
+	return &AdminOnlyHandler{
+		wrapped: h,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *AdminOnlyHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if admin, _ := req.Context().Value(adminCtxKey{}).(bool); !admin {
+		w.Header().Set("Content-Type", "application/json; charset=utf8")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(adminRequiredJSON))
+		return
+	}
+
+	h.wrapped.ServeHTTP(w, req)
 }
 
 func contains(haystack []string, needle string) bool {
@@ -0,0 +1,38 @@
+package webserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+// DuplicatesHandler is a http.Handler which returns the tracks which are in the
+// library more than once.
+type DuplicatesHandler struct {
+	finder library.DuplicatesFinder
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (dh DuplicatesHandler) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	InternalErrorOnErrorHandler(writer, req, dh.find)
+}
+
+func (dh DuplicatesHandler) find(writer http.ResponseWriter, req *http.Request) error {
+	duplicates, err := dh.finder.FindDuplicates(req.Context())
+	if err != nil {
+		return err
+	}
+
+	writer.Header().Add("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(writer)
+	return enc.Encode(duplicates)
+}
+
+// NewDuplicatesHandler returns a new duplicates handler. It needs an
+// implementation of the library.DuplicatesFinder.
+func NewDuplicatesHandler(finder library.DuplicatesFinder) *DuplicatesHandler {
+	return &DuplicatesHandler{
+		finder: finder,
+	}
+}
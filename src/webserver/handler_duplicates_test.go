@@ -0,0 +1,81 @@
+package webserver_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestDuplicatesHandler checks that the duplicates handler returns the groups of
+// duplicate tracks found by the library as JSON.
+func TestDuplicatesHandler(t *testing.T) {
+	expected := []library.DuplicateTracks{
+		{
+			MatchedBy: library.MatchedByTags,
+			Tracks: []library.DuplicateTrack{
+				{
+					ReportTrack: library.ReportTrack{
+						ID:     18,
+						Title:  "White Rabbit",
+						Artist: "Jefferson Airplane",
+						Album:  "Surrealistic Pillow",
+						FSPath: "/music/wr.flac",
+					},
+					Format:   "flac",
+					Bitrate:  912,
+					Duration: 151000,
+				},
+				{
+					ReportTrack: library.ReportTrack{
+						ID:     73,
+						Title:  "White rabbit",
+						Artist: "Jefferson Airplane",
+						Album:  "The Best Of",
+						FSPath: "/music/best/wr.mp3",
+					},
+					Format:   "mp3",
+					Bitrate:  320,
+					Duration: 152000,
+				},
+			},
+		},
+	}
+
+	fakeFinder := &libraryfakes.FakeDuplicatesFinder{}
+	fakeFinder.FindDuplicatesReturns(expected, nil)
+
+	handler := webserver.NewDuplicatesHandler(fakeFinder)
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/library/duplicates", nil)
+	handler.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected code %d but got %d", http.StatusOK, resp.Code)
+	}
+
+	var found []library.DuplicateTracks
+	if err := json.NewDecoder(resp.Body).Decode(&found); err != nil {
+		t.Fatalf("decoding response: %s", err)
+	}
+
+	if !reflect.DeepEqual(expected, found) {
+		t.Errorf("expected duplicates %+v but got %+v", expected, found)
+	}
+
+	fakeFinder.FindDuplicatesReturns(nil, fmt.Errorf("database is gone"))
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusInternalServerError {
+		t.Errorf("expected code %d but got %d", http.StatusInternalServerError, resp.Code)
+	}
+}
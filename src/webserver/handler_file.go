@@ -1,14 +1,21 @@
 package webserver
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/ironsmile/euterpe/src/cue"
 	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/mediaformat"
+	"github.com/ironsmile/euterpe/src/ziparchive"
 )
 
 // FileHandler will find and serve a media file by its ID
@@ -39,11 +46,29 @@ func (fh FileHandler) find(writer http.ResponseWriter, req *http.Request) error
 		return fmt.Errorf("Library for FileHandler is nil")
 	}
 
+	if formats := preferredFormats(req); len(formats) > 0 {
+		if er, ok := fh.library.(library.EditionsResolver); ok {
+			preferredID, err := er.PreferredTrack(req.Context(), int64(id), formats)
+			if err != nil && !errors.Is(err, library.ErrTrackNotFound) {
+				return fmt.Errorf("choosing album edition: %w", err)
+			} else if err == nil {
+				id = int(preferredID)
+			}
+		}
+	}
+
 	filePath := fh.library.GetFilePath(int64(id))
 
 	_, err = os.Stat(filePath)
 
+	// Tracks could be files inside ZIP archives.
+	var archivePath, archivedName string
+	archived := false
 	if err != nil {
+		archivePath, archivedName, archived = ziparchive.Split(filePath)
+	}
+
+	if err != nil && !archived {
 		http.NotFoundHandler().ServeHTTP(writer, req)
 		return nil
 	}
@@ -53,12 +78,124 @@ func (fh FileHandler) find(writer http.ResponseWriter, req *http.Request) error
 	writer.Header().Add("Content-Disposition",
 		fmt.Sprintf("filename=\"%s\"", baseName))
 
+	if err := fh.setContentType(writer, req, int64(id), filePath); err != nil {
+		return err
+	}
+
+	if archived {
+		return fh.serveArchived(writer, req, archivePath, archivedName)
+	}
+
+	if sf, ok := fh.library.(library.TrackSegmentFinder); ok {
+		segment, err := sf.GetTrackSegment(req.Context(), int64(id))
+		if err != nil && !errors.Is(err, library.ErrTrackNotFound) {
+			return fmt.Errorf("getting track segment: %w", err)
+		}
+
+		if err == nil && !segment.WholeFile() {
+			return fh.serveSegment(writer, req, filePath, segment)
+		}
+	}
+
 	req.URL.Path = "/" + baseName
 	http.FileServer(http.Dir(filepath.Dir(filePath))).ServeHTTP(writer, req)
 
 	return nil
 }
 
+// setContentType sets the Content-Type header for the media file at filePath of
+// the track with this ID. The type is by the format of the file as found out by
+// the library. Files in unknown formats are left for http.FileServer to figure
+// out their type.
+func (fh FileHandler) setContentType(
+	writer http.ResponseWriter,
+	req *http.Request,
+	id int64,
+	filePath string,
+) error {
+	formatName := mediaformat.FromFileName(filePath)
+	if ff, ok := fh.library.(library.TrackFormatFinder); ok {
+		trackFormat, err := ff.GetTrackFormat(req.Context(), id)
+		if err != nil && !errors.Is(err, library.ErrTrackNotFound) {
+			return fmt.Errorf("getting track format: %w", err)
+		} else if err == nil {
+			formatName = trackFormat
+		}
+	}
+
+	if format, ok := mediaformat.ByName(formatName); ok {
+		writer.Header().Set("Content-Type", format.MIMEType)
+	}
+	return nil
+}
+
+// serveArchived serves the file `name` from the ZIP archive at archivePath.
+// Range requests are supported even for compressed files.
+func (fh FileHandler) serveArchived(
+	writer http.ResponseWriter,
+	req *http.Request,
+	archivePath string,
+	name string,
+) error {
+	file, err := ziparchive.Open(archivePath, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFoundHandler().ServeHTTP(writer, req)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("opening archived file: %w", err)
+	}
+	defer file.Close()
+
+	st, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	content, ok := file.(io.ReadSeeker)
+	if !ok || st.IsDir() {
+		http.NotFoundHandler().ServeHTTP(writer, req)
+		return nil
+	}
+
+	http.ServeContent(writer, req, path.Base(name), st.ModTime(), content)
+	return nil
+}
+
+// serveSegment serves only the part of the media file at filePath which is the
+// track. Such are the tracks described by CUE sheets.
+func (fh FileHandler) serveSegment(
+	writer http.ResponseWriter,
+	req *http.Request,
+	filePath string,
+	segment library.TrackSegment,
+) error {
+	mediaFile, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer mediaFile.Close()
+
+	st, err := mediaFile.Stat()
+	if err != nil {
+		return err
+	}
+
+	slice, err := cue.Slice(mediaFile, st.Size(), segment.Start, segment.End)
+	if errors.Is(err, cue.ErrUnsupportedFormat) {
+		http.Error(
+			writer,
+			"Serving parts of this media format is not supported",
+			http.StatusNotImplemented,
+		)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("slicing media file: %w", err)
+	}
+
+	http.ServeContent(writer, req, filepath.Base(filePath), st.ModTime(), slice)
+	return nil
+}
+
 // NewFileHandler returns a new File handler will will be resposible for serving a file
 // from the library identified from its ID.
 func NewFileHandler(lib library.Library) *FileHandler {
@@ -1,11 +1,20 @@
 package webserver_test
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
 	"github.com/ironsmile/euterpe/src/webserver"
 )
 
@@ -50,6 +59,231 @@ func TestFileHandlerWithWrongPathVars(t *testing.T) {
 	}
 }
 
+// TestFileHandlerTrackSegments checks that only the part of the file which is the
+// track is served for tracks from CUE sheets.
+func TestFileHandlerTrackSegments(t *testing.T) {
+	const sampleRate = 8000
+
+	// Mono 16 bit WAV file which is three seconds long.
+	samples := make([]byte, sampleRate*2*3)
+	for ind := range samples {
+		samples[ind] = byte(ind / (sampleRate * 2))
+	}
+
+	wav := []byte("RIFF")
+	wav = binary.LittleEndian.AppendUint32(wav, uint32(36+len(samples)))
+	wav = append(wav, "WAVEfmt "...)
+	wav = binary.LittleEndian.AppendUint32(wav, 16)
+	wav = binary.LittleEndian.AppendUint16(wav, 1)
+	wav = binary.LittleEndian.AppendUint16(wav, 1)
+	wav = binary.LittleEndian.AppendUint32(wav, sampleRate)
+	wav = binary.LittleEndian.AppendUint32(wav, sampleRate*2)
+	wav = binary.LittleEndian.AppendUint16(wav, 2)
+	wav = binary.LittleEndian.AppendUint16(wav, 16)
+	wav = append(wav, "data"...)
+	wav = binary.LittleEndian.AppendUint32(wav, uint32(len(samples)))
+	wav = append(wav, samples...)
+
+	tmpDir := t.TempDir()
+	wavPath := filepath.Join(tmpDir, "album.wav")
+	if err := os.WriteFile(wavPath, wav, 0o600); err != nil {
+		t.Fatalf("writing WAV file: %s", err)
+	}
+
+	lib := &segmentLibrary{filePath: wavPath}
+	lib.GetTrackSegmentReturns(library.TrackSegment{
+		Start: time.Second,
+		End:   2 * time.Second,
+	}, nil)
+
+	h := routeFileHandler(webserver.NewFileHandler(lib))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/file/2", nil)
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected status %d but got %d", http.StatusOK, resp.Code)
+	}
+
+	if lib.GetTrackSegmentCallCount() != 1 {
+		t.Fatalf("expected the track segment to be queried once")
+	}
+	if _, trackID := lib.GetTrackSegmentArgsForCall(0); trackID != 2 {
+		t.Errorf("expected segment for track 2 but got %d", trackID)
+	}
+
+	body, _ := io.ReadAll(resp.Result().Body)
+	if len(body) != 44+sampleRate*2 {
+		t.Fatalf("expected %d bytes but got %d", 44+sampleRate*2, len(body))
+	}
+	if !bytes.Equal(body[44:], samples[sampleRate*2:sampleRate*4]) {
+		t.Errorf("the response does not contain the second second of audio")
+	}
+
+	// Range requests are supported for seeking in the track.
+	req = httptest.NewRequest(http.MethodGet, "/v1/file/2", nil)
+	req.Header.Set("Range", "bytes=44-")
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusPartialContent {
+		t.Errorf("expected status %d but got %d", http.StatusPartialContent, resp.Code)
+	}
+	if resp.Body.Len() != sampleRate*2 {
+		t.Errorf("expected %d bytes for range but got %d", sampleRate*2, resp.Body.Len())
+	}
+
+	// Segments of formats which could not be cut are not implemented.
+	oggPath := filepath.Join(tmpDir, "album.ogg")
+	if err := os.WriteFile(oggPath, []byte("OggS not really"), 0o600); err != nil {
+		t.Fatalf("writing Ogg file: %s", err)
+	}
+	lib.filePath = oggPath
+
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/v1/file/2", nil))
+
+	if resp.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d but got %d", http.StatusNotImplemented, resp.Code)
+	}
+}
+
+// TestFileHandlerContentType checks that media files are served with the MIME
+// type of their format.
+func TestFileHandlerContentType(t *testing.T) {
+	tmpDir := t.TempDir()
+	m4aPath := filepath.Join(tmpDir, "song.m4a")
+	if err := os.WriteFile(m4aPath, []byte("not really m4a"), 0o600); err != nil {
+		t.Fatalf("writing m4a file: %s", err)
+	}
+
+	lib := &formatLibrary{filePath: m4aPath}
+	lib.GetTrackFormatReturns("alac", nil)
+
+	h := routeFileHandler(webserver.NewFileHandler(lib))
+
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/v1/file/3", nil))
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected status %d but got %d", http.StatusOK, resp.Code)
+	}
+	if contentType := resp.Header().Get("Content-Type"); contentType != "audio/mp4" {
+		t.Errorf("expected Content-Type audio/mp4 but got %s", contentType)
+	}
+	if _, trackID := lib.GetTrackFormatArgsForCall(0); trackID != 3 {
+		t.Errorf("expected format of track 3 but got %d", trackID)
+	}
+
+	// Without a stored format the type is by the file extension.
+	flacPath := filepath.Join(tmpDir, "song.flac")
+	if err := os.WriteFile(flacPath, []byte("not really flac"), 0o600); err != nil {
+		t.Fatalf("writing flac file: %s", err)
+	}
+	lib.filePath = flacPath
+	lib.GetTrackFormatReturns("", library.ErrTrackNotFound)
+
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/v1/file/3", nil))
+
+	if contentType := resp.Header().Get("Content-Type"); contentType != "audio/flac" {
+		t.Errorf("expected Content-Type audio/flac but got %s", contentType)
+	}
+}
+
+// formatLibrary is a library which returns the same file for every track and
+// uses a fake for finding track formats.
+type formatLibrary struct {
+	library.Library
+	libraryfakes.FakeTrackFormatFinder
+
+	filePath string
+}
+
+func (l *formatLibrary) GetFilePath(int64) string {
+	return l.filePath
+}
+
+// TestFileHandlerArchivedFiles checks serving files from inside ZIP archives,
+// including range requests for compressed files.
+func TestFileHandlerArchivedFiles(t *testing.T) {
+	content := make([]byte, 50000)
+	for ind := range content {
+		content[ind] = byte(ind % 253)
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "album.zip")
+	out, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(out)
+	w, err := zw.Create("disc/01 Song.flac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+
+	lib := &segmentLibrary{
+		filePath: filepath.Join(archivePath, "disc", "01 Song.flac"),
+	}
+	h := routeFileHandler(webserver.NewFileHandler(lib))
+
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/v1/file/4", nil))
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected status %d but got %d", http.StatusOK, resp.Code)
+	}
+	if !bytes.Equal(resp.Body.Bytes(), content) {
+		t.Errorf("the response is not the archived file")
+	}
+	if contentType := resp.Header().Get("Content-Type"); contentType != "audio/flac" {
+		t.Errorf("expected Content-Type audio/flac but got %s", contentType)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/file/4", nil)
+	req.Header.Set("Range", "bytes=40000-40099")
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusPartialContent {
+		t.Errorf("expected status %d but got %d", http.StatusPartialContent, resp.Code)
+	}
+	if !bytes.Equal(resp.Body.Bytes(), content[40000:40100]) {
+		t.Errorf("the response is not the requested range of the archived file")
+	}
+
+	// Files which are not in the archive are not found.
+	lib.filePath = filepath.Join(archivePath, "02 Missing.flac")
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/v1/file/4", nil))
+
+	if resp.Code != http.StatusNotFound {
+		t.Errorf("expected status %d but got %d", http.StatusNotFound, resp.Code)
+	}
+}
+
+// segmentLibrary is a library which returns the same file for every track and
+// uses a fake for finding track segments.
+type segmentLibrary struct {
+	library.Library
+	libraryfakes.FakeTrackSegmentFinder
+
+	filePath string
+}
+
+func (l *segmentLibrary) GetFilePath(int64) string {
+	return l.filePath
+}
+
 // routeFileHandler wraps a handler the same way the web server will do when
 // constructing the main application router. This is needed for tests so that the
 // Gorilla mux variables will be parsed.
@@ -0,0 +1,143 @@
+package webserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+// FoldersHandler is a http.Handler which allows browsing the library by its
+// directories with the help of pagination. Without a folder ID in the URL it
+// returns the directories of the library.
+type FoldersHandler struct {
+	browser library.FolderBrowser
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (fh FoldersHandler) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	InternalErrorOnErrorHandler(writer, req, fh.browse)
+}
+
+func (fh FoldersHandler) browse(writer http.ResponseWriter, req *http.Request) error {
+	folderID, ok := mux.Vars(req)["folderID"]
+	if !ok {
+		return fh.roots(writer, req)
+	}
+
+	query := req.URL.Query()
+	page, perPage := 1, 10
+	order := strings.TrimSpace(strings.ToLower(query.Get("order")))
+
+	if order != "" && order != "asc" && order != "desc" {
+		fh.badRequest(writer, "Wrong 'order' parameter. Must be 'asc' or 'desc'")
+		return nil
+	}
+
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{
+		{"page", &page},
+		{"per-page", &perPage},
+	} {
+		str := query.Get(param.name)
+		if str == "" {
+			continue
+		}
+
+		var err error
+		*param.value, err = strconv.Atoi(str)
+		if err != nil {
+			fh.badRequest(writer, fmt.Sprintf(`Wrong "%s" parameter: %s`, param.name, err))
+			return nil
+		}
+	}
+
+	if page < 1 || perPage < 1 {
+		fh.badRequest(writer, `"page" and "per-page" must be integers greater than one`)
+		return nil
+	}
+
+	browseArgs := getBrowseArgs(page, perPage, "name", order)
+	contents, count, err := fh.browser.BrowseFolder(req.Context(), folderID, browseArgs)
+	if errors.Is(err, library.ErrFolderNotFound) {
+		http.NotFoundHandler().ServeHTTP(writer, req)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	pageURI := func(page int) string {
+		values := url.Values{}
+		values.Set("page", strconv.Itoa(page))
+		values.Set("per-page", strconv.Itoa(perPage))
+		if order != "" {
+			values.Set("order", order)
+		}
+		return fmt.Sprintf("/v1/folders/%s?%s", url.PathEscape(folderID), values.Encode())
+	}
+
+	retData := struct {
+		library.FolderContents
+		Next       string `json:"next"`
+		Previous   string `json:"previous"`
+		PagesCount int    `json:"pages_count"`
+	}{
+		FolderContents: contents,
+		PagesCount:     int(math.Ceil(float64(count) / float64(perPage))),
+	}
+	if page > 1 {
+		retData.Previous = pageURI(page - 1)
+	}
+	if page*perPage < count {
+		retData.Next = pageURI(page + 1)
+	}
+
+	writer.Header().Add("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(writer)
+	return enc.Encode(retData)
+}
+
+func (fh FoldersHandler) roots(writer http.ResponseWriter, req *http.Request) error {
+	roots, err := fh.browser.RootFolders(req.Context())
+	if err != nil {
+		return err
+	}
+
+	retData := struct {
+		Folders []library.Folder `json:"folders"`
+	}{
+		Folders: roots,
+	}
+
+	writer.Header().Add("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(writer)
+	return enc.Encode(retData)
+}
+
+func (fh FoldersHandler) badRequest(writer http.ResponseWriter, message string) {
+	writer.Header().Add("Content-Type", "application/json; charset=utf-8")
+	writer.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(writer).Encode(struct {
+		Error string `json:"error"`
+	}{
+		Error: message,
+	})
+}
+
+// NewFoldersHandler returns a new Folders handler. It needs a
+// library.FolderBrowser to browse through.
+func NewFoldersHandler(browser library.FolderBrowser) *FoldersHandler {
+	return &FoldersHandler{
+		browser: browser,
+	}
+}
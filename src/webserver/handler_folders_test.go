@@ -0,0 +1,102 @@
+package webserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestFoldersHandler checks that the folders handler returns the directories of
+// the library and pages of the contents of folders.
+func TestFoldersHandler(t *testing.T) {
+	fakeBrowser := &libraryfakes.FakeFolderBrowser{
+		BrowseFolderStub: func(
+			_ context.Context,
+			folderID string,
+			_ library.BrowseArgs,
+		) (library.FolderContents, int, error) {
+			if folderID != "abc" {
+				return library.FolderContents{}, 0, library.ErrFolderNotFound
+			}
+			return library.FolderContents{
+				Folder:   library.Folder{ID: "abc", Name: "Jefferson Airplane"},
+				ParentID: "root",
+				Folders: []library.Folder{
+					{ID: "def", Name: "Surrealistic Pillow"},
+				},
+				Tracks: []library.SearchResult{},
+			}, 25, nil
+		},
+	}
+	fakeBrowser.RootFoldersReturns([]library.Folder{{ID: "root", Name: "Music"}}, nil)
+
+	handler := webserver.NewFoldersHandler(fakeBrowser)
+	router := mux.NewRouter()
+	router.Handle(webserver.APIv1EndpointFolders, handler)
+	router.Handle(webserver.APIv1EndpointFolder, handler)
+
+	request := func(url string, expectedCode int) *httptest.ResponseRecorder {
+		t.Helper()
+
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != expectedCode {
+			t.Errorf("%s: expected code %d but got %d", url, expectedCode, resp.Code)
+		}
+		return resp
+	}
+
+	var roots struct {
+		Folders []library.Folder `json:"folders"`
+	}
+	resp := request("/v1/folders", http.StatusOK)
+	if err := json.NewDecoder(resp.Body).Decode(&roots); err != nil {
+		t.Fatalf("decoding roots: %s", err)
+	}
+	if len(roots.Folders) != 1 || roots.Folders[0].ID != "root" {
+		t.Errorf("unexpected root folders: %+v", roots.Folders)
+	}
+
+	var folder struct {
+		library.FolderContents
+		Next       string `json:"next"`
+		Previous   string `json:"previous"`
+		PagesCount int    `json:"pages_count"`
+	}
+	resp = request("/v1/folders/abc?page=2&per-page=10&order=desc", http.StatusOK)
+	if err := json.NewDecoder(resp.Body).Decode(&folder); err != nil {
+		t.Fatalf("decoding folder: %s", err)
+	}
+	if folder.ID != "abc" || folder.ParentID != "root" || len(folder.Folders) != 1 {
+		t.Errorf("unexpected folder contents: %+v", folder.FolderContents)
+	}
+	if folder.PagesCount != 3 {
+		t.Errorf("expected 3 pages but got %d", folder.PagesCount)
+	}
+	if expected := "/v1/folders/abc?order=desc&page=3&per-page=10"; folder.Next != expected {
+		t.Errorf("expected next page %s but got %s", expected, folder.Next)
+	}
+	if expected := "/v1/folders/abc?order=desc&page=1&per-page=10"; folder.Previous != expected {
+		t.Errorf("expected previous page %s but got %s", expected, folder.Previous)
+	}
+
+	_, _, args := fakeBrowser.BrowseFolderArgsForCall(0)
+	if args.Page != 1 || args.PerPage != 10 || args.Order != library.OrderDesc {
+		t.Errorf("unexpected browse arguments: %+v", args)
+	}
+
+	request("/v1/folders/nope", http.StatusNotFound)
+	request("/v1/folders/abc?page=0", http.StatusBadRequest)
+	request("/v1/folders/abc?per-page=many", http.StatusBadRequest)
+	request("/v1/folders/abc?order=random", http.StatusBadRequest)
+}
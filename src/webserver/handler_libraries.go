@@ -0,0 +1,137 @@
+package webserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+// LibrariesHandler is a http.Handler which manages the directories of the library
+// while the server is running. Without a library ID in the URL it lists and adds
+// directories. With one it changes and removes them.
+type LibrariesHandler struct {
+	manager library.LibrariesManager
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (lh LibrariesHandler) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	idVar, ok := mux.Vars(req)["libraryID"]
+	if !ok {
+		lh.serveLibraries(writer, req)
+		return
+	}
+
+	libraryID, err := strconv.ParseInt(idVar, 10, 64)
+	if err != nil {
+		lh.respondError(writer, &badRequestError{
+			fmt.Errorf("parsing libraryID: %w", err),
+		})
+		return
+	}
+
+	switch req.Method {
+	case http.MethodPatch:
+		var changes library.LibraryChanges
+		if err := json.NewDecoder(req.Body).Decode(&changes); err != nil {
+			lh.respondError(writer, &badRequestError{
+				fmt.Errorf("parsing JSON body: %w", err),
+			})
+			return
+		}
+
+		dir, err := lh.manager.UpdateLibrary(req.Context(), libraryID, changes)
+		if err != nil {
+			lh.respondError(writer, err)
+			return
+		}
+		lh.respondJSON(writer, http.StatusOK, dir)
+	case http.MethodDelete:
+		if err := lh.manager.RemoveLibrary(req.Context(), libraryID); err != nil {
+			lh.respondError(writer, err)
+			return
+		}
+		writer.WriteHeader(http.StatusNoContent)
+	default:
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (lh LibrariesHandler) serveLibraries(writer http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		dirs, err := lh.manager.Libraries(req.Context())
+		if err != nil {
+			lh.respondError(writer, err)
+			return
+		}
+		lh.respondJSON(writer, http.StatusOK, dirs)
+		return
+	}
+
+	var newDir struct {
+		Path string `json:"path"`
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&newDir); err != nil {
+		lh.respondError(writer, &badRequestError{
+			fmt.Errorf("parsing JSON body: %w", err),
+		})
+		return
+	}
+
+	dir, err := lh.manager.AddLibrary(req.Context(), newDir.Path, newDir.Name)
+	if err != nil {
+		lh.respondError(writer, err)
+		return
+	}
+	lh.respondJSON(writer, http.StatusCreated, dir)
+}
+
+func (lh LibrariesHandler) respondJSON(
+	writer http.ResponseWriter,
+	status int,
+	body interface{},
+) {
+	writer.Header().Add("Content-Type", "application/json; charset=utf-8")
+	writer.WriteHeader(status)
+	if err := json.NewEncoder(writer).Encode(body); err != nil {
+		log.Printf("error writing body in LibrariesHandler: %s", err)
+	}
+}
+
+func (lh LibrariesHandler) respondError(writer http.ResponseWriter, err error) {
+	var badRequest *badRequestError
+
+	switch {
+	case errors.As(err, &badRequest), errors.Is(err, library.ErrInvalidLibrary):
+		writer.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(writer, "Bad request. %s\n", err)
+	case errors.Is(err, library.ErrLibraryNotFound):
+		writer.WriteHeader(http.StatusNotFound)
+		fmt.Fprintln(writer, err)
+	case errors.Is(err, library.ErrLibraryExists),
+		errors.Is(err, library.ErrLibraryFromConfig):
+		writer.WriteHeader(http.StatusConflict)
+		fmt.Fprintln(writer, err)
+	default:
+		log.Printf("Error managing libraries: %s\n", err)
+		writer.WriteHeader(http.StatusInternalServerError)
+		if _, err := writer.Write([]byte(err.Error())); err != nil {
+			log.Printf("error writing body in LibrariesHandler: %s", err)
+		}
+	}
+}
+
+// NewLibrariesHandler returns a new Libraries handler. It needs an
+// implementation of the library.LibrariesManager.
+func NewLibrariesHandler(manager library.LibrariesManager) *LibrariesHandler {
+	return &LibrariesHandler{
+		manager: manager,
+	}
+}
@@ -0,0 +1,120 @@
+package webserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestLibrariesHandler checks that the libraries handler lists, adds, changes and
+// removes library directories and maps the library errors to status codes.
+func TestLibrariesHandler(t *testing.T) {
+	fakeManager := &libraryfakes.FakeLibrariesManager{
+		AddLibraryStub: func(
+			_ context.Context,
+			path, name string,
+		) (library.LibraryDir, error) {
+			if path == "/music" {
+				return library.LibraryDir{}, library.ErrLibraryExists
+			}
+			if !strings.HasPrefix(path, "/") {
+				return library.LibraryDir{}, fmt.Errorf("%w: relative", library.ErrInvalidLibrary)
+			}
+			return library.LibraryDir{ID: 2, Path: path, Name: name}, nil
+		},
+		UpdateLibraryStub: func(
+			_ context.Context,
+			id int64,
+			changes library.LibraryChanges,
+		) (library.LibraryDir, error) {
+			if id != 2 {
+				return library.LibraryDir{}, library.ErrLibraryNotFound
+			}
+			return library.LibraryDir{ID: 2, Name: *changes.Name}, nil
+		},
+		RemoveLibraryStub: func(_ context.Context, id int64) error {
+			if id == 1 {
+				return library.ErrLibraryFromConfig
+			}
+			return nil
+		},
+	}
+	fakeManager.LibrariesReturns([]library.LibraryDir{
+		{ID: 1, Path: "/music", Name: "music", FromConfig: true},
+	}, nil)
+
+	handler := webserver.NewLibrariesHandler(fakeManager)
+	router := mux.NewRouter()
+	router.Handle(webserver.APIv1EndpointLibraries, handler).Methods(
+		webserver.APIv1Methods[webserver.APIv1EndpointLibraries]...,
+	)
+	router.Handle(webserver.APIv1EndpointLibrary, handler).Methods(
+		webserver.APIv1Methods[webserver.APIv1EndpointLibrary]...,
+	)
+
+	request := func(
+		method, url, body string,
+		expectedCode int,
+	) *httptest.ResponseRecorder {
+		t.Helper()
+
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != expectedCode {
+			t.Errorf("%s %s: expected code %d but got %d",
+				method, url, expectedCode, resp.Code)
+		}
+		return resp
+	}
+
+	var dirs []library.LibraryDir
+	resp := request(http.MethodGet, "/v1/libraries", "", http.StatusOK)
+	if err := json.NewDecoder(resp.Body).Decode(&dirs); err != nil {
+		t.Fatalf("decoding libraries: %s", err)
+	}
+	if len(dirs) != 1 || !dirs[0].FromConfig || dirs[0].Path != "/music" {
+		t.Errorf("unexpected libraries: %+v", dirs)
+	}
+
+	var added library.LibraryDir
+	resp = request(
+		http.MethodPost,
+		"/v1/libraries",
+		`{"path": "/podcasts", "name": "Podcasts"}`,
+		http.StatusCreated,
+	)
+	if err := json.NewDecoder(resp.Body).Decode(&added); err != nil {
+		t.Fatalf("decoding added library: %s", err)
+	}
+	if added.ID != 2 || added.Name != "Podcasts" {
+		t.Errorf("unexpected added library: %+v", added)
+	}
+
+	request(http.MethodPost, "/v1/libraries", `{"path": "/music"}`, http.StatusConflict)
+	request(http.MethodPost, "/v1/libraries", `{"path": "music"}`, http.StatusBadRequest)
+	request(http.MethodPost, "/v1/libraries", `not json`, http.StatusBadRequest)
+
+	request(http.MethodPatch, "/v1/libraries/2", `{"name": "Shows"}`, http.StatusOK)
+	_, id, changes := fakeManager.UpdateLibraryArgsForCall(0)
+	if id != 2 || changes.Name == nil || *changes.Name != "Shows" || changes.Disabled != nil {
+		t.Errorf("unexpected update arguments: %d, %+v", id, changes)
+	}
+	request(http.MethodPatch, "/v1/libraries/3", `{"name": "Shows"}`, http.StatusNotFound)
+	request(http.MethodPatch, "/v1/libraries/two", `{}`, http.StatusBadRequest)
+
+	request(http.MethodDelete, "/v1/libraries/2", "", http.StatusNoContent)
+	request(http.MethodDelete, "/v1/libraries/1", "", http.StatusConflict)
+	request(http.MethodGet, "/v1/libraries/1", "", http.StatusMethodNotAllowed)
+}
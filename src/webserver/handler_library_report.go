@@ -0,0 +1,38 @@
+package webserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+// LibraryReportHandler is a http.Handler which returns a report with the problems
+// found in the library.
+type LibraryReportHandler struct {
+	reporter library.Reporter
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (lrh LibraryReportHandler) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	InternalErrorOnErrorHandler(writer, req, lrh.report)
+}
+
+func (lrh LibraryReportHandler) report(writer http.ResponseWriter, req *http.Request) error {
+	report, err := lrh.reporter.Report(req.Context())
+	if err != nil {
+		return err
+	}
+
+	writer.Header().Add("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(writer)
+	return enc.Encode(report)
+}
+
+// NewLibraryReportHandler returns a new library report handler. It needs an
+// implementation of the library.Reporter.
+func NewLibraryReportHandler(reporter library.Reporter) *LibraryReportHandler {
+	return &LibraryReportHandler{
+		reporter: reporter,
+	}
+}
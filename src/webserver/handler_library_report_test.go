@@ -0,0 +1,71 @@
+package webserver_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestLibraryReportHandler checks that the library report handler returns the
+// report generated by its reporter as JSON.
+func TestLibraryReportHandler(t *testing.T) {
+	expected := library.Report{
+		AlbumsWithoutArtwork: []library.ReportAlbum{
+			{ID: 2, Name: "Battlefield Vietnam", FSPath: "/music/bv"},
+		},
+		UnknownLabelTracks: []library.ReportTrack{
+			{
+				ID:     18,
+				Title:  "White Rabbit",
+				Artist: library.UnknownLabel,
+				Album:  library.UnknownLabel,
+				FSPath: "/music/wr.mp3",
+			},
+		},
+		AlbumsWithTrackGaps: []library.ReportTrackGaps{
+			{
+				ReportAlbum:    library.ReportAlbum{ID: 5, Name: "Pillow"},
+				MissingNumbers: []int64{3, 7},
+			},
+		},
+	}
+
+	fakeReporter := &libraryfakes.FakeReporter{}
+	fakeReporter.ReportReturns(expected, nil)
+
+	handler := webserver.NewLibraryReportHandler(fakeReporter)
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/library/report", nil)
+	handler.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected code %d but got %d", http.StatusOK, resp.Code)
+	}
+
+	var report library.Report
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatalf("decoding response: %s", err)
+	}
+
+	if !reflect.DeepEqual(expected, report) {
+		t.Errorf("expected report %+v but got %+v", expected, report)
+	}
+
+	// Errors from the reporter are internal server errors.
+	fakeReporter.ReportReturns(library.Report{}, fmt.Errorf("database is gone"))
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusInternalServerError {
+		t.Errorf("expected code %d but got %d", http.StatusInternalServerError, resp.Code)
+	}
+}
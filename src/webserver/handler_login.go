@@ -85,9 +85,12 @@ func (h *loginHandler) respondCorrect(
 		expiresAt = now.Add(rememberMeDuration)
 	}
 
-	pl := jwt.Payload{
-		IssuedAt:       jwt.NumericDate(now),
-		ExpirationTime: jwt.NumericDate(expiresAt),
+	pl := tokenPayload{
+		Payload: jwt.Payload{
+			IssuedAt:       jwt.NumericDate(now),
+			ExpirationTime: jwt.NumericDate(expiresAt),
+		},
+		Admin: true,
 	}
 
 	if len(h.auth.Secret) == 0 {
@@ -53,9 +53,12 @@ func (h *loginTokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	now := time.Now()
-	pl := jwt.Payload{
-		IssuedAt:       jwt.NumericDate(now),
-		ExpirationTime: jwt.NumericDate(time.Now().Add(rememberMeDuration)),
+	pl := tokenPayload{
+		Payload: jwt.Payload{
+			IssuedAt:       jwt.NumericDate(now),
+			ExpirationTime: jwt.NumericDate(time.Now().Add(rememberMeDuration)),
+		},
+		Admin: true,
 	}
 
 	if len(h.auth.Secret) == 0 {
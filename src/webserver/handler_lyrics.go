@@ -0,0 +1,71 @@
+package webserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+// LyricsHandler is a http.Handler which returns the lyrics of tracks.
+type LyricsHandler struct {
+	lyricsManager library.LyricsManager
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (lh LyricsHandler) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+
+	idString, ok := vars["fileID"]
+	if !ok {
+		http.NotFoundHandler().ServeHTTP(writer, req)
+		return
+	}
+
+	id, err := strconv.ParseInt(idString, 10, 64)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(writer, "Bad request. Parsing fileID: %s\n", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), time.Minute)
+	defer cancel()
+
+	trackLyrics, err := lh.lyricsManager.GetLyrics(ctx, id)
+	if err == library.ErrTrackNotFound || err == library.ErrLyricsNotFound {
+		writer.WriteHeader(http.StatusNotFound)
+		fmt.Fprintln(writer, "404 lyrics not found")
+		return
+	}
+
+	if err != nil {
+		log.Printf("Error getting track %d lyrics: %s\n", id, err)
+		writer.WriteHeader(http.StatusInternalServerError)
+		if _, err := writer.Write([]byte(err.Error())); err != nil {
+			log.Printf("error writing body in LyricsHandler: %s", err)
+		}
+		return
+	}
+
+	writer.Header().Add("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(writer)
+	if err := enc.Encode(trackLyrics); err != nil {
+		log.Printf("error writing body in LyricsHandler: %s", err)
+	}
+}
+
+// NewLyricsHandler returns a new Lyrics handler.
+// It needs an implementation of the LyricsManager.
+func NewLyricsHandler(lm library.LyricsManager) *LyricsHandler {
+	return &LyricsHandler{
+		lyricsManager: lm,
+	}
+}
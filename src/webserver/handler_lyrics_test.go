@@ -0,0 +1,86 @@
+package webserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestLyricsHandler checks that the lyrics handler returns the lyrics from its
+// library.LyricsManager and the appropriate errors.
+func TestLyricsHandler(t *testing.T) {
+	expected := library.TrackLyrics{
+		TrackID: 73,
+		Synced:  true,
+		Source:  library.LyricsSourceLRC,
+		Lines: []library.LyricsLine{
+			{Time: 0, Text: "I was born in a world"},
+			{Time: 12500, Text: "Where I was searching"},
+		},
+	}
+
+	fakeLM := &libraryfakes.FakeLyricsManager{
+		GetLyricsStub: func(
+			_ context.Context,
+			trackID int64,
+		) (library.TrackLyrics, error) {
+			switch trackID {
+			case 73:
+				return expected, nil
+			case 42:
+				return library.TrackLyrics{}, fmt.Errorf("database is gone")
+			case 5:
+				return library.TrackLyrics{}, library.ErrLyricsNotFound
+			default:
+				return library.TrackLyrics{}, library.ErrTrackNotFound
+			}
+		},
+	}
+
+	router := mux.NewRouter()
+	router.Handle(webserver.APIv1EndpointFileLyrics, webserver.NewLyricsHandler(fakeLM))
+
+	tests := []struct {
+		url          string
+		expectedCode int
+	}{
+		{"/v1/file/73/lyrics", http.StatusOK},
+		{"/v1/file/42/lyrics", http.StatusInternalServerError},
+		{"/v1/file/5/lyrics", http.StatusNotFound},
+		{"/v1/file/6/lyrics", http.StatusNotFound},
+		{"/v1/file/baba/lyrics", http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, test.url, nil)
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != test.expectedCode {
+			t.Errorf("%s: expected code %d but got %d",
+				test.url, test.expectedCode, resp.Code)
+		}
+	}
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/file/73/lyrics", nil)
+	router.ServeHTTP(resp, req)
+
+	var found library.TrackLyrics
+	if err := json.NewDecoder(resp.Body).Decode(&found); err != nil {
+		t.Fatalf("decoding response: %s", err)
+	}
+
+	if !reflect.DeepEqual(expected, found) {
+		t.Errorf("expected lyrics %+v but got %+v", expected, found)
+	}
+}
@@ -0,0 +1,116 @@
+package webserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+// OverridesHandler is a http.Handler which manages the metadata overrides of
+// tracks and albums. Overrides are stored in the library and are used instead of
+// the tags of the media files.
+type OverridesHandler struct {
+	overridesManager library.OverridesManager
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (oh OverridesHandler) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+
+	var (
+		idVar string
+		get   func(context.Context, int64) (library.MetadataOverrides, error)
+		set   func(context.Context, int64, library.MetadataOverrides) error
+	)
+
+	if _, ok := vars["fileID"]; ok {
+		idVar = "fileID"
+		get = oh.overridesManager.GetTrackOverrides
+		set = oh.overridesManager.SetTrackOverrides
+	} else if _, ok := vars["albumID"]; ok {
+		idVar = "albumID"
+		get = oh.overridesManager.GetAlbumOverrides
+		set = oh.overridesManager.SetAlbumOverrides
+	} else {
+		http.NotFoundHandler().ServeHTTP(writer, req)
+		return
+	}
+
+	id, err := strconv.ParseInt(vars[idVar], 10, 64)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(writer, "Bad request. Parsing %s: %s\n", idVar, err)
+		return
+	}
+
+	switch req.Method {
+	case http.MethodPut:
+		var overrides library.MetadataOverrides
+		if err := json.NewDecoder(req.Body).Decode(&overrides); err != nil {
+			err = &badRequestError{fmt.Errorf("parsing JSON body: %w", err)}
+			oh.respondError(writer, err)
+			return
+		}
+		err = set(req.Context(), id, overrides)
+	case http.MethodDelete:
+		err = set(req.Context(), id, library.MetadataOverrides{})
+	}
+
+	if err != nil {
+		oh.respondError(writer, err)
+		return
+	}
+
+	if req.Method == http.MethodDelete {
+		writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	overrides, err := get(req.Context(), id)
+	if err != nil {
+		oh.respondError(writer, err)
+		return
+	}
+
+	writer.Header().Add("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(writer)
+	if err := enc.Encode(overrides); err != nil {
+		log.Printf("error writing body in OverridesHandler: %s", err)
+	}
+}
+
+func (oh OverridesHandler) respondError(writer http.ResponseWriter, err error) {
+	var badRequest *badRequestError
+
+	switch {
+	case errors.As(err, &badRequest), errors.Is(err, library.ErrInvalidTagValue):
+		writer.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(writer, "Bad request. %s\n", err)
+	case errors.Is(err, library.ErrTrackNotFound),
+		errors.Is(err, library.ErrAlbumNotFound):
+		writer.WriteHeader(http.StatusNotFound)
+		fmt.Fprintln(writer, err)
+	default:
+		log.Printf("Error managing metadata overrides: %s\n", err)
+		writer.WriteHeader(http.StatusInternalServerError)
+		if _, err := writer.Write([]byte(err.Error())); err != nil {
+			log.Printf("error writing body in OverridesHandler: %s", err)
+		}
+	}
+}
+
+// NewOverridesHandler returns a new Overrides handler. It needs an implementation
+// of the OverridesManager.
+func NewOverridesHandler(om library.OverridesManager) *OverridesHandler {
+	return &OverridesHandler{
+		overridesManager: om,
+	}
+}
@@ -0,0 +1,105 @@
+package webserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestOverridesHandler checks that the overrides handler gets, sets and removes
+// overrides using its library.OverridesManager.
+func TestOverridesHandler(t *testing.T) {
+	stored := make(map[int64]library.MetadataOverrides)
+
+	getStub := func(_ context.Context, id int64) (library.MetadataOverrides, error) {
+		switch id {
+		case 42:
+			return library.MetadataOverrides{}, fmt.Errorf("database is gone")
+		case 5:
+			return library.MetadataOverrides{}, library.ErrTrackNotFound
+		}
+		return stored[id], nil
+	}
+	setStub := func(_ context.Context, id int64, o library.MetadataOverrides) error {
+		if o.Year != nil && *o.Year < 0 {
+			return library.ErrInvalidTagValue
+		}
+		if id == 5 {
+			return library.ErrAlbumNotFound
+		}
+		stored[id] = o
+		return nil
+	}
+
+	fakeOM := &libraryfakes.FakeOverridesManager{
+		GetTrackOverridesStub: getStub,
+		SetTrackOverridesStub: setStub,
+		GetAlbumOverridesStub: getStub,
+		SetAlbumOverridesStub: setStub,
+	}
+
+	router := mux.NewRouter()
+	handler := webserver.NewOverridesHandler(fakeOM)
+	router.Handle(webserver.APIv1EndpointFileOverrides, handler).Methods(
+		webserver.APIv1Methods[webserver.APIv1EndpointFileOverrides]...,
+	)
+	router.Handle(webserver.APIv1EndpointAlbumOverrides, handler).Methods(
+		webserver.APIv1Methods[webserver.APIv1EndpointAlbumOverrides]...,
+	)
+
+	tests := []struct {
+		method       string
+		url          string
+		body         string
+		expectedCode int
+	}{
+		{http.MethodPut, "/v1/file/73/overrides", `{"title": "Song"}`, http.StatusOK},
+		{http.MethodGet, "/v1/file/73/overrides", "", http.StatusOK},
+		{http.MethodGet, "/v1/file/42/overrides", "", http.StatusInternalServerError},
+		{http.MethodGet, "/v1/file/5/overrides", "", http.StatusNotFound},
+		{http.MethodGet, "/v1/file/baba/overrides", "", http.StatusBadRequest},
+		{http.MethodPut, "/v1/file/73/overrides", `{"year": -1}`, http.StatusBadRequest},
+		{http.MethodPut, "/v1/file/73/overrides", `{"year": `, http.StatusBadRequest},
+		{http.MethodPut, "/v1/album/12/overrides", `{"genre": "Rock"}`, http.StatusOK},
+		{http.MethodPut, "/v1/album/5/overrides", `{"genre": "Rock"}`, http.StatusNotFound},
+		{http.MethodDelete, "/v1/album/12/overrides", "", http.StatusNoContent},
+	}
+
+	for _, test := range tests {
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest(test.method, test.url, strings.NewReader(test.body))
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != test.expectedCode {
+			t.Errorf("%s %s: expected code %d but got %d",
+				test.method, test.url, test.expectedCode, resp.Code)
+		}
+	}
+
+	if _, ok := stored[12]; !ok || !stored[12].IsEmpty() {
+		t.Errorf("album overrides were not removed: %+v", stored[12])
+	}
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/file/73/overrides", nil)
+	router.ServeHTTP(resp, req)
+
+	var found library.MetadataOverrides
+	if err := json.NewDecoder(resp.Body).Decode(&found); err != nil {
+		t.Fatalf("decoding response: %s", err)
+	}
+
+	if found.Title == nil || *found.Title != "Song" || found.Album != nil {
+		t.Errorf("unexpected overrides in response: %+v", found)
+	}
+}
@@ -2,6 +2,7 @@ package webserver
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"net/url"
@@ -48,6 +49,16 @@ func (sh SearchHandler) search(writer http.ResponseWriter, req *http.Request) er
 
 	results := sh.library.Search(query)
 
+	if formats := preferredFormats(req); len(formats) > 0 {
+		if er, ok := sh.library.(library.EditionsResolver); ok {
+			var err error
+			results, err = er.PreferEditions(req.Context(), results, formats)
+			if err != nil {
+				return fmt.Errorf("choosing album editions: %w", err)
+			}
+		}
+	}
+
 	if len(results) == 0 {
 		_, err := writer.Write([]byte("[]"))
 		return err
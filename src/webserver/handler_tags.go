@@ -0,0 +1,125 @@
+package webserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+// TagsHandler is a http.Handler which changes the metadata of tracks and albums.
+// The new metadata is written in the tags of the media files.
+type TagsHandler struct {
+	editor library.TagEditor
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (th TagsHandler) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+
+	var (
+		result interface{}
+		err    error
+	)
+
+	if idString, ok := vars["fileID"]; ok {
+		result, err = th.updateTrack(req, idString)
+	} else if idString, ok := vars["albumID"]; ok {
+		result, err = th.updateAlbum(req, idString)
+	} else {
+		http.NotFoundHandler().ServeHTTP(writer, req)
+		return
+	}
+
+	var badRequest *badRequestError
+	switch {
+	case errors.As(err, &badRequest), errors.Is(err, library.ErrInvalidTagValue):
+		writer.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(writer, "Bad request. %s\n", err)
+		return
+	case errors.Is(err, library.ErrTrackNotFound),
+		errors.Is(err, library.ErrAlbumNotFound):
+		writer.WriteHeader(http.StatusNotFound)
+		fmt.Fprintln(writer, err)
+		return
+	case errors.Is(err, library.ErrTagsNotWritable):
+		writer.WriteHeader(http.StatusConflict)
+		fmt.Fprintln(writer, err)
+		return
+	case err != nil:
+		log.Printf("Error updating tags: %s\n", err)
+		writer.WriteHeader(http.StatusInternalServerError)
+		if _, err := writer.Write([]byte(err.Error())); err != nil {
+			log.Printf("error writing body in TagsHandler: %s", err)
+		}
+		return
+	}
+
+	writer.Header().Add("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(writer)
+	if err := enc.Encode(result); err != nil {
+		log.Printf("error writing body in TagsHandler: %s", err)
+	}
+}
+
+func (th TagsHandler) updateTrack(
+	req *http.Request,
+	idString string,
+) (interface{}, error) {
+	id, err := strconv.ParseInt(idString, 10, 64)
+	if err != nil {
+		return nil, &badRequestError{fmt.Errorf("parsing fileID: %w", err)}
+	}
+
+	var update library.TrackUpdate
+	if err := json.NewDecoder(req.Body).Decode(&update); err != nil {
+		return nil, &badRequestError{fmt.Errorf("parsing JSON body: %w", err)}
+	}
+
+	return th.editor.UpdateTrack(req.Context(), id, update)
+}
+
+func (th TagsHandler) updateAlbum(
+	req *http.Request,
+	idString string,
+) (interface{}, error) {
+	id, err := strconv.ParseInt(idString, 10, 64)
+	if err != nil {
+		return nil, &badRequestError{fmt.Errorf("parsing albumID: %w", err)}
+	}
+
+	var update library.AlbumUpdate
+	if err := json.NewDecoder(req.Body).Decode(&update); err != nil {
+		return nil, &badRequestError{fmt.Errorf("parsing JSON body: %w", err)}
+	}
+
+	return th.editor.UpdateAlbum(req.Context(), id, update)
+}
+
+// badRequestError is an error caused by a malformed request.
+type badRequestError struct {
+	err error
+}
+
+func (e *badRequestError) Error() string {
+	return e.err.Error()
+}
+
+func (e *badRequestError) Unwrap() error {
+	return e.err
+}
+
+// NewTagsHandler returns a new Tags handler. It needs an implementation of the
+// TagEditor. It must be wrapped by the AdminOnlyHandler when authentication
+// is enabled.
+func NewTagsHandler(editor library.TagEditor) *TagsHandler {
+	return &TagsHandler{
+		editor: editor,
+	}
+}
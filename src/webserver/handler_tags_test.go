@@ -0,0 +1,200 @@
+package webserver_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/gorilla/mux"
+
+	"github.com/ironsmile/euterpe/src/config"
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestTagsHandler checks that the tags handler passes the updates to its
+// library.TagEditor and returns the appropriate errors.
+func TestTagsHandler(t *testing.T) {
+	fakeEditor := &libraryfakes.FakeTagEditor{
+		UpdateTrackStub: func(
+			_ context.Context,
+			trackID int64,
+			update library.TrackUpdate,
+		) (library.SearchResult, error) {
+			switch trackID {
+			case 73:
+				return library.SearchResult{ID: 73, Title: *update.Title}, nil
+			case 42:
+				return library.SearchResult{}, fmt.Errorf("database is gone")
+			case 5:
+				return library.SearchResult{}, library.ErrTagsNotWritable
+			case 6:
+				return library.SearchResult{}, library.ErrInvalidTagValue
+			default:
+				return library.SearchResult{}, library.ErrTrackNotFound
+			}
+		},
+		UpdateAlbumStub: func(
+			_ context.Context,
+			albumID int64,
+			update library.AlbumUpdate,
+		) ([]library.SearchResult, error) {
+			if albumID != 12 {
+				return nil, library.ErrAlbumNotFound
+			}
+			return []library.SearchResult{{ID: 1, AlbumID: 12, Album: *update.Name}}, nil
+		},
+	}
+
+	router := mux.NewRouter()
+	tagsHandler := webserver.NewTagsHandler(fakeEditor)
+	router.Handle(webserver.APIv1EndpointFile, tagsHandler).Methods(http.MethodPatch)
+	router.Handle(webserver.APIv1EndpointDownloadAlbum, tagsHandler).Methods(
+		http.MethodPatch,
+	)
+
+	tests := []struct {
+		url          string
+		body         string
+		expectedCode int
+	}{
+		{"/v1/file/73", `{"title": "Something"}`, http.StatusOK},
+		{"/v1/file/42", `{"title": "Something"}`, http.StatusInternalServerError},
+		{"/v1/file/5", `{"title": "Something"}`, http.StatusConflict},
+		{"/v1/file/6", `{"title": ""}`, http.StatusBadRequest},
+		{"/v1/file/7", `{"title": "Something"}`, http.StatusNotFound},
+		{"/v1/file/73", `{"title": `, http.StatusBadRequest},
+		{"/v1/file/baba", `{"title": "Something"}`, http.StatusBadRequest},
+		{"/v1/album/12", `{"album": "Abbey Road"}`, http.StatusOK},
+		{"/v1/album/13", `{"album": "Abbey Road"}`, http.StatusNotFound},
+		{"/v1/album/baba", `{"album": "Abbey Road"}`, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPatch, test.url, strings.NewReader(test.body))
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != test.expectedCode {
+			t.Errorf("%s: expected code %d but got %d",
+				test.url, test.expectedCode, resp.Code)
+		}
+	}
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(
+		http.MethodPatch,
+		"/v1/album/12",
+		strings.NewReader(`{"album": "Abbey Road"}`),
+	)
+	router.ServeHTTP(resp, req)
+
+	var found []library.SearchResult
+	if err := json.NewDecoder(resp.Body).Decode(&found); err != nil {
+		t.Fatalf("decoding response: %s", err)
+	}
+
+	if len(found) != 1 || found[0].Album != "Abbey Road" {
+		t.Errorf("unexpected album tracks in response: %+v", found)
+	}
+}
+
+// TestAdminOnlyHandler checks that only admin-capable credentials are allowed
+// through the AdminOnlyHandler.
+func TestAdminOnlyHandler(t *testing.T) {
+	auth := config.Auth{
+		User:     "admin_user",
+		Password: "admin_pass",
+		Secret:   "admin_secret_which_is_completely_unknown_to_anyone",
+	}
+
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	handler := webserver.NewAuthHandler(
+		webserver.NewAdminOnlyHandler(okHandler),
+		auth.User,
+		auth.Password,
+		nil,
+		auth.Secret,
+		nil,
+	)
+
+	loginBody, _ := json.Marshal(map[string]string{
+		"username": auth.User,
+		"password": auth.Password,
+	})
+	loginResp := httptest.NewRecorder()
+	webserver.NewLoginTokenHandler(auth).ServeHTTP(
+		loginResp,
+		httptest.NewRequest(http.MethodPost, "/v1/login/token/", bytes.NewReader(loginBody)),
+	)
+
+	var loginToken struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(loginResp.Body).Decode(&loginToken); err != nil {
+		t.Fatalf("decoding login token: %s", err)
+	}
+
+	now := time.Now()
+	deviceToken, err := jwt.Sign(jwt.Payload{
+		IssuedAt:       jwt.NumericDate(now),
+		ExpirationTime: jwt.NumericDate(now.Add(10 * time.Minute)),
+	}, jwt.NewHS256([]byte(auth.Secret)))
+	if err != nil {
+		t.Fatalf("signing device token: %s", err)
+	}
+
+	tests := []struct {
+		desc         string
+		authorize    func(*http.Request)
+		expectedCode int
+	}{
+		{
+			desc: "basic authenticate",
+			authorize: func(req *http.Request) {
+				req.SetBasicAuth(auth.User, auth.Password)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc: "token from username and password",
+			authorize: func(req *http.Request) {
+				req.Header.Set("Authorization", "Bearer "+loginToken.Token)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc: "device token",
+			authorize: func(req *http.Request) {
+				req.Header.Set("Authorization", "Bearer "+string(deviceToken))
+			},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/v1/file/1", nil)
+			req.Header.Set("Accept", "application/json")
+			test.authorize(req)
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+
+			if resp.Code != test.expectedCode {
+				t.Errorf("expected code %d but got %d", test.expectedCode, resp.Code)
+			}
+		})
+	}
+}
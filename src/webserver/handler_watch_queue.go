@@ -0,0 +1,33 @@
+package webserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ironsmile/euterpe/src/library"
+)
+
+// WatchQueueHandler is a http.Handler which returns metrics about the queue with
+// changes in the library directories.
+type WatchQueueHandler struct {
+	monitor library.WatchMonitor
+}
+
+// ServeHTTP is required by the http.Handler's interface
+func (wqh WatchQueueHandler) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	InternalErrorOnErrorHandler(writer, req, wqh.metrics)
+}
+
+func (wqh WatchQueueHandler) metrics(writer http.ResponseWriter, _ *http.Request) error {
+	writer.Header().Add("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(writer)
+	return enc.Encode(wqh.monitor.WatchQueueMetrics())
+}
+
+// NewWatchQueueHandler returns a new watch queue handler. It needs an
+// implementation of the library.WatchMonitor.
+func NewWatchQueueHandler(monitor library.WatchMonitor) *WatchQueueHandler {
+	return &WatchQueueHandler{
+		monitor: monitor,
+	}
+}
@@ -0,0 +1,47 @@
+package webserver_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ironsmile/euterpe/src/library"
+	"github.com/ironsmile/euterpe/src/library/libraryfakes"
+	"github.com/ironsmile/euterpe/src/webserver"
+)
+
+// TestWatchQueueHandler checks that the watch queue handler returns the metrics
+// of its monitor as JSON.
+func TestWatchQueueHandler(t *testing.T) {
+	expected := library.WatchQueueMetrics{
+		Queued:     12,
+		Processing: 3,
+		Workers:    4,
+		Received:   120,
+		Coalesced:  80,
+		Processed:  25,
+	}
+
+	fakeMonitor := &libraryfakes.FakeWatchMonitor{}
+	fakeMonitor.WatchQueueMetricsReturns(expected)
+
+	handler := webserver.NewWatchQueueHandler(fakeMonitor)
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/library/watcher", nil)
+	handler.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected code %d but got %d", http.StatusOK, resp.Code)
+	}
+
+	var metrics library.WatchQueueMetrics
+	if err := json.NewDecoder(resp.Body).Decode(&metrics); err != nil {
+		t.Fatalf("decoding response: %s", err)
+	}
+
+	if metrics != expected {
+		t.Errorf("expected metrics %+v but got %+v", expected, metrics)
+	}
+}
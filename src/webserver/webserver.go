@@ -92,8 +92,27 @@ func (srv *Server) serveGoroutine() {
 		notFoundAlbumImage,
 	)
 	artistImageHandler := NewArtistImagesHandler(srv.library)
+	artistInfoHandler := NewArtistInfoHandler(srv.library)
 	browseHandler := NewBrowseHandler(srv.library)
+	foldersHandler := NewFoldersHandler(srv.library)
+	prefetchHandler := NewArtworkPrefetchHandler(srv.library)
+	watchQueueHandler := NewWatchQueueHandler(srv.library)
 	mediaFileHandler := NewFileHandler(srv.library)
+	lyricsHandler := NewLyricsHandler(srv.library)
+	overridesHandler := NewOverridesHandler(srv.library)
+	editionsHandler := NewAlbumEditionsHandler(srv.library)
+	var tagsHandler http.Handler = NewTagsHandler(srv.library)
+	var librariesHandler http.Handler = NewLibrariesHandler(srv.library)
+	var reportHandler http.Handler = NewLibraryReportHandler(srv.library)
+	var duplicatesHandler http.Handler = NewDuplicatesHandler(srv.library)
+	var overridesEditHandler http.Handler = overridesHandler
+	if srv.cfg.Auth {
+		tagsHandler = NewAdminOnlyHandler(tagsHandler)
+		librariesHandler = NewAdminOnlyHandler(librariesHandler)
+		reportHandler = NewAdminOnlyHandler(reportHandler)
+		duplicatesHandler = NewAdminOnlyHandler(duplicatesHandler)
+		overridesEditHandler = NewAdminOnlyHandler(overridesEditHandler)
+	}
 	loginHandler := NewLoginHandler(srv.cfg.Authenticate)
 	loginTokenHandler := NewLoginTokenHandler(srv.cfg.Authenticate)
 	logoutHandler := NewLogoutHandler()
@@ -106,10 +125,32 @@ func (srv *Server) serveGoroutine() {
 	router.StrictSlash(true)
 	router.UseEncodedPath()
 
-	// API v1 methods.
+	// API v1 methods. The tag editing handler is registered first so that it
+	// receives the PATCH requests for files and albums. The same goes for the
+	// requests which change overrides.
+	router.Handle(APIv1EndpointFile, tagsHandler).Methods(http.MethodPatch)
+	router.Handle(APIv1EndpointDownloadAlbum, tagsHandler).Methods(http.MethodPatch)
+	router.Handle(APIv1EndpointFileOverrides, overridesEditHandler).Methods(
+		http.MethodPut, http.MethodDelete,
+	)
+	router.Handle(APIv1EndpointAlbumOverrides, overridesEditHandler).Methods(
+		http.MethodPut, http.MethodDelete,
+	)
 	router.Handle(APIv1EndpointFile, mediaFileHandler).Methods(
 		APIv1Methods[APIv1EndpointFile]...,
 	)
+	router.Handle(APIv1EndpointFileLyrics, lyricsHandler).Methods(
+		APIv1Methods[APIv1EndpointFileLyrics]...,
+	)
+	router.Handle(APIv1EndpointFileOverrides, overridesHandler).Methods(
+		APIv1Methods[APIv1EndpointFileOverrides]...,
+	)
+	router.Handle(APIv1EndpointAlbumOverrides, overridesHandler).Methods(
+		APIv1Methods[APIv1EndpointAlbumOverrides]...,
+	)
+	router.Handle(APIv1EndpointAlbumEditions, editionsHandler).Methods(
+		APIv1Methods[APIv1EndpointAlbumEditions]...,
+	)
 	router.Handle(APIv1EndpointAlbumArtwork, artoworkHandler).Methods(
 		APIv1Methods[APIv1EndpointAlbumArtwork]...,
 	)
@@ -119,9 +160,18 @@ func (srv *Server) serveGoroutine() {
 	router.Handle(APIv1EndpointArtistImage, artistImageHandler).Methods(
 		APIv1Methods[APIv1EndpointArtistImage]...,
 	)
+	router.Handle(APIv1EndpointArtistInfo, artistInfoHandler).Methods(
+		APIv1Methods[APIv1EndpointArtistInfo]...,
+	)
 	router.Handle(APIv1EndpointBrowse, browseHandler).Methods(
 		APIv1Methods[APIv1EndpointBrowse]...,
 	)
+	router.Handle(APIv1EndpointFolders, foldersHandler).Methods(
+		APIv1Methods[APIv1EndpointFolders]...,
+	)
+	router.Handle(APIv1EndpointFolder, foldersHandler).Methods(
+		APIv1Methods[APIv1EndpointFolder]...,
+	)
 	router.Handle(APIv1EndpointSearchWithPath, searchHandler).Methods(
 		APIv1Methods[APIv1EndpointSearchWithPath]...,
 	)
@@ -134,6 +184,24 @@ func (srv *Server) serveGoroutine() {
 	router.Handle(APIv1EndpointRegisterToken, registerTokenHandler).Methods(
 		APIv1Methods[APIv1EndpointRegisterToken]...,
 	)
+	router.Handle(APIv1EndpointPrefetch, prefetchHandler).Methods(
+		APIv1Methods[APIv1EndpointPrefetch]...,
+	)
+	router.Handle(APIv1EndpointLibraryReport, reportHandler).Methods(
+		APIv1Methods[APIv1EndpointLibraryReport]...,
+	)
+	router.Handle(APIv1EndpointDuplicates, duplicatesHandler).Methods(
+		APIv1Methods[APIv1EndpointDuplicates]...,
+	)
+	router.Handle(APIv1EndpointWatchQueue, watchQueueHandler).Methods(
+		APIv1Methods[APIv1EndpointWatchQueue]...,
+	)
+	router.Handle(APIv1EndpointLibraries, librariesHandler).Methods(
+		APIv1Methods[APIv1EndpointLibraries]...,
+	)
+	router.Handle(APIv1EndpointLibrary, librariesHandler).Methods(
+		APIv1Methods[APIv1EndpointLibrary]...,
+	)
 
 	// Kept for backward compatibility with older clients created before the
 	// API v1 compatibility promise. Although no promise has been made for
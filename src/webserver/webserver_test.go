@@ -17,6 +17,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gbrlsnchs/jwt/v3"
+
 	"github.com/ironsmile/euterpe/src/config"
 	"github.com/ironsmile/euterpe/src/helpers"
 	"github.com/ironsmile/euterpe/src/library"
@@ -276,6 +278,112 @@ func TestUserAuthentication(t *testing.T) {
 	}
 }
 
+// TestAdminOnlyEndpoints checks that changing overrides and reading the library
+// and duplicates reports, which reveal the paths of files, require an admin-capable credential
+// while reading overrides does not.
+func TestAdminOnlyEndpoints(t *testing.T) {
+	projRoot, _ := getProjectRoot()
+
+	lib, err := library.NewLocalLibrary(
+		context.TODO(),
+		library.SQLiteMemoryFile,
+		os.DirFS("../../sqls"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lib.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lib.Truncate() }()
+
+	lib.AddLibraryPath(filepath.Join(projRoot, "test_files", "library"))
+
+	ch := testErrorAfter(5, "Library in TestAdminOnlyEndpoints did not finish scaning on time")
+	lib.Scan()
+	ch <- 42
+
+	tracks := lib.Search("")
+	if len(tracks) == 0 {
+		t.Fatalf("no tracks found in the test library")
+	}
+
+	var wsCfg config.Config
+	wsCfg.Listen = fmt.Sprintf("127.0.0.1:%d", testPort)
+	wsCfg.Auth = true
+	wsCfg.Authenticate = config.Auth{
+		User:     "testuser",
+		Password: "testpass",
+		Secret:   "overrides_secret_which_is_completely_unknown_to_anyone",
+	}
+
+	httpFS, templatesFS := getTestFileSystems()
+	srv := NewServer(context.Background(), wsCfg, lib, httpFS, templatesFS)
+	srv.Serve()
+	defer tearDownServer(srv)
+
+	now := time.Now()
+	deviceToken, err := jwt.Sign(jwt.Payload{
+		IssuedAt:       jwt.NumericDate(now),
+		ExpirationTime: jwt.NumericDate(now.Add(10 * time.Minute)),
+	}, jwt.NewHS256([]byte(wsCfg.Authenticate.Secret)))
+	if err != nil {
+		t.Fatalf("signing device token: %s", err)
+	}
+
+	tests := []struct {
+		method       string
+		path         string
+		admin        bool
+		expectedCode int
+	}{
+		{http.MethodPut, "/v1/file/%d/overrides", false, http.StatusForbidden},
+		{http.MethodDelete, "/v1/file/%d/overrides", false, http.StatusForbidden},
+		{http.MethodPut, "/v1/album/%d/overrides", false, http.StatusForbidden},
+		{http.MethodDelete, "/v1/album/%d/overrides", false, http.StatusForbidden},
+		{http.MethodGet, "/v1/file/%d/overrides", false, http.StatusOK},
+		{http.MethodPut, "/v1/file/%d/overrides", true, http.StatusOK},
+		{http.MethodGet, "/v1/library/report", false, http.StatusForbidden},
+		{http.MethodGet, "/v1/library/report", true, http.StatusOK},
+		{http.MethodGet, "/v1/library/duplicates", false, http.StatusForbidden},
+		{http.MethodGet, "/v1/library/duplicates", true, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		id := tracks[0].ID
+		if strings.HasPrefix(test.path, "/v1/album/") {
+			id = tracks[0].AlbumID
+		}
+
+		path := test.path
+		if strings.Contains(path, "%d") {
+			path = fmt.Sprintf(path, id)
+		}
+
+		req, _ := http.NewRequest(
+			test.method,
+			testURL()+strings.TrimPrefix(path, "/"),
+			strings.NewReader(`{"title": "Overridden"}`),
+		)
+		if test.admin {
+			req.SetBasicAuth(wsCfg.Authenticate.User, wsCfg.Authenticate.Password)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+string(deviceToken))
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != test.expectedCode {
+			t.Errorf("%s %s (admin: %t): expected %d but got %d", test.method,
+				test.path, test.admin, test.expectedCode, resp.StatusCode)
+		}
+	}
+}
+
 func TestSearchUrl(t *testing.T) {
 	projRoot, _ := getProjectRoot()
 
@@ -0,0 +1,314 @@
+// Package ziparchive reads files stored in ZIP archives as if the archives were
+// directories. A file in an archive is addressed by the path of the archive
+// followed by the name of the file in it. For example "/music/album.zip/01.flac"
+// is the file "01.flac" in the archive "/music/album.zip".
+//
+// Files in archives could be read from any position even when they are
+// compressed. Files which are only stored in the archive are read directly from
+// it. Compressed ones are decompressed from their beginning when reading goes
+// backwards.
+package ziparchive
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Extension is the extension of the archive files.
+const Extension = ".zip"
+
+// IsArchive returns true when path has the extension of archive files. It does
+// not check whether there is such a file.
+func IsArchive(filePath string) bool {
+	base := filepath.Base(filePath)
+	ext := filepath.Ext(base)
+	return base != ext && strings.EqualFold(ext, Extension)
+}
+
+// Split splits the path of a file in an archive into the path of the archive and
+// the name of the file in it. The name uses forward slashes as in the archive.
+// ok is false when there is no archive file in path.
+func Split(filePath string) (archivePath, name string, ok bool) {
+	filePath = filepath.Clean(filePath)
+
+	for ind := 0; ind < len(filePath); ind++ {
+		if !os.IsPathSeparator(filePath[ind]) || ind == 0 {
+			continue
+		}
+
+		prefix := filePath[:ind]
+		if !IsArchive(prefix) {
+			continue
+		}
+
+		st, err := os.Stat(prefix)
+		if err != nil || !st.Mode().IsRegular() {
+			continue
+		}
+
+		return prefix, filepath.ToSlash(filePath[ind+1:]), true
+	}
+
+	return "", "", false
+}
+
+// Join returns the path of the file `name` in the archive at archivePath.
+func Join(archivePath, name string) string {
+	return filepath.Join(archivePath, filepath.FromSlash(name))
+}
+
+// Open opens the file or directory `name` in the archive at archivePath.
+// Directories could only be read with ReadDir.
+func Open(archivePath, name string) (fs.File, error) {
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := openInArchive(archive, name)
+	if err != nil {
+		archive.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// openInArchive opens `name` in the already opened archive. The archive is
+// closed together with the returned file.
+func openInArchive(archive *os.File, name string) (fs.File, error) {
+	st, err := archive.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := zip.NewReader(archive, st.Size())
+	if err != nil {
+		return nil, fmt.Errorf("reading archive %s: %w", archive.Name(), err)
+	}
+
+	name = path.Clean(name)
+	for _, zipFile := range reader.File {
+		if zipFile.FileInfo().IsDir() || path.Clean(zipFile.Name) != name {
+			continue
+		}
+
+		return newFile(archive, zipFile)
+	}
+
+	dir, err := reader.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &archivedDir{File: dir, archive: archive}, nil
+}
+
+// Stat returns information about the file or directory `name` in the archive at
+// archivePath.
+func Stat(archivePath, name string) (fs.FileInfo, error) {
+	file, err := Open(archivePath, name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return file.Stat()
+}
+
+// Extract copies the file `name` from the archive at archivePath into a new
+// temporary file with the same extension. The caller must remove it when done.
+// It returns the path of the temporary file.
+func Extract(archivePath, name string) (string, error) {
+	file, err := Open(archivePath, name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	tmp, err := os.CreateTemp("", "euterpe-*"+path.Ext(name))
+	if err != nil {
+		return "", err
+	}
+
+	_, err = io.Copy(tmp, file)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("extracting %s: %w", name, err)
+	}
+
+	return tmp.Name(), nil
+}
+
+// File is a file in an archive. It could be read from any position.
+type File struct {
+	archive *os.File
+	zipFile *zip.File
+	size    int64
+
+	// stored is used for reading files which are not compressed.
+	stored *io.SectionReader
+
+	mu sync.Mutex
+
+	// offset is the position for Read and Seek.
+	offset int64
+
+	// decompressed reads compressed files and decompressedPos is how much was
+	// read from it.
+	decompressed    io.ReadCloser
+	decompressedPos int64
+}
+
+var (
+	_ io.ReadSeeker = (*File)(nil)
+	_ io.ReaderAt   = (*File)(nil)
+	_ fs.File       = (*File)(nil)
+)
+
+func newFile(archive *os.File, zipFile *zip.File) (*File, error) {
+	file := &File{
+		archive: archive,
+		zipFile: zipFile,
+		size:    int64(zipFile.UncompressedSize64),
+	}
+
+	if zipFile.Method == zip.Store && zipFile.Flags&0x1 == 0 {
+		offset, err := zipFile.DataOffset()
+		if err != nil {
+			return nil, err
+		}
+		file.stored = io.NewSectionReader(archive, offset, file.size)
+	}
+
+	return file, nil
+}
+
+// Stat implements fs.File.
+func (f *File) Stat() (fs.FileInfo, error) {
+	return f.zipFile.FileInfo(), nil
+}
+
+// Read implements io.Reader.
+func (f *File) Read(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n, err := f.readAt(p, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+// ReadAt implements io.ReaderAt.
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.readAt(p, off)
+}
+
+// Seek implements io.Seeker.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	f.offset = offset
+	return offset, nil
+}
+
+// Close closes the file and its archive.
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.decompressed != nil {
+		f.decompressed.Close()
+		f.decompressed = nil
+	}
+	return f.archive.Close()
+}
+
+// readAt reads len(p) bytes at off. It must be called with the file locked.
+func (f *File) readAt(p []byte, off int64) (int, error) {
+	if off >= f.size {
+		return 0, io.EOF
+	}
+
+	if f.stored != nil {
+		return f.stored.ReadAt(p, off)
+	}
+
+	if f.decompressed == nil || f.decompressedPos > off {
+		if f.decompressed != nil {
+			f.decompressed.Close()
+		}
+
+		decompressed, err := f.zipFile.Open()
+		if err != nil {
+			f.decompressed = nil
+			return 0, err
+		}
+		f.decompressed = decompressed
+		f.decompressedPos = 0
+	}
+
+	if skip := off - f.decompressedPos; skip > 0 {
+		skipped, err := io.CopyN(io.Discard, f.decompressed, skip)
+		f.decompressedPos += skipped
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := io.ReadFull(f.decompressed, p)
+	f.decompressedPos += int64(n)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// archivedDir is a directory in an archive which closes the archive as well.
+type archivedDir struct {
+	fs.File
+
+	archive *os.File
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *archivedDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rd, ok := d.File.(fs.ReadDirFile)
+	if !ok {
+		return nil, errors.New("not a directory")
+	}
+	return rd.ReadDir(n)
+}
+
+// Close closes the directory and its archive.
+func (d *archivedDir) Close() error {
+	d.File.Close()
+	return d.archive.Close()
+}
@@ -0,0 +1,127 @@
+package ziparchive
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestReadingArchivedFiles checks finding, reading and seeking in files in
+// archives, both compressed and stored.
+func TestReadingArchivedFiles(t *testing.T) {
+	content := make([]byte, 100000)
+	for ind := range content {
+		content[ind] = byte(ind % 251)
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "Artist - Album.zip")
+	writeArchive(t, archivePath, map[string]uint16{
+		"01 Stored.flac":     zip.Store,
+		"CD2/02 Packed.flac": zip.Deflate,
+	}, content)
+
+	archived := filepath.Join(archivePath, "CD2", "02 Packed.flac")
+	foundArchive, name, ok := Split(archived)
+	if !ok || foundArchive != archivePath || name != "CD2/02 Packed.flac" {
+		t.Fatalf("unexpected split of %s: %s %s %t", archived, foundArchive, name, ok)
+	}
+	if Join(foundArchive, name) != archived {
+		t.Errorf("joining the split path did not return %s", archived)
+	}
+
+	if _, _, ok := Split(filepath.Join(filepath.Dir(archivePath), "missing.zip", "a.mp3")); ok {
+		t.Errorf("expected no archive for a path with a missing archive")
+	}
+	if !IsArchive("/music/album.ZIP") || IsArchive("/music/.zip") {
+		t.Errorf("archives are not recognized by their extension")
+	}
+
+	for _, name := range []string{"01 Stored.flac", "CD2/02 Packed.flac"} {
+		file, err := Open(archivePath, name)
+		if err != nil {
+			t.Fatalf("opening %s: %s", name, err)
+		}
+
+		st, err := file.Stat()
+		if err != nil || st.Size() != int64(len(content)) {
+			t.Errorf("%s: unexpected stat %v: %v", name, st, err)
+		}
+
+		rs := file.(io.ReadSeeker)
+		if _, err := rs.Seek(60000, io.SeekStart); err != nil {
+			t.Fatalf("%s: seeking: %s", name, err)
+		}
+		tail, err := io.ReadAll(rs)
+		if err != nil || !bytes.Equal(tail, content[60000:]) {
+			t.Errorf("%s: reading after seek returned wrong content: %v", name, err)
+		}
+
+		// Reading backwards after reading to the end.
+		buf := make([]byte, 100)
+		n, err := file.(io.ReaderAt).ReadAt(buf, 10)
+		if err != nil || n != len(buf) || !bytes.Equal(buf, content[10:110]) {
+			t.Errorf("%s: ReadAt returned wrong content: %d %v", name, n, err)
+		}
+
+		file.Close()
+	}
+
+	dirInfo, err := Stat(archivePath, "CD2")
+	if err != nil || !dirInfo.IsDir() {
+		t.Errorf("expected CD2 to be a directory: %v", err)
+	}
+
+	if _, err := Open(archivePath, "03 Missing.flac"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist for a missing file but got %v", err)
+	}
+
+	extracted, err := Extract(archivePath, "CD2/02 Packed.flac")
+	if err != nil {
+		t.Fatalf("extracting: %s", err)
+	}
+	defer os.Remove(extracted)
+
+	if filepath.Ext(extracted) != ".flac" {
+		t.Errorf("expected the extracted file to keep its extension: %s", extracted)
+	}
+	extractedContent, err := os.ReadFile(extracted)
+	if err != nil || !bytes.Equal(extractedContent, content) {
+		t.Errorf("the extracted file has wrong content: %v", err)
+	}
+}
+
+// writeArchive writes an archive at archivePath with files with the same content
+// and compression methods.
+func writeArchive(
+	t *testing.T,
+	archivePath string,
+	files map[string]uint16,
+	content []byte,
+) {
+	t.Helper()
+
+	out, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	zw := zip.NewWriter(out)
+	for name, method := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}